@@ -0,0 +1,48 @@
+// fakewallet 本地模拟的平台单一钱包，用于离线联调 seamless 钱包模式
+//
+// 用法：
+//
+//	go run ./cmd/fakewallet -addr :9300 -app-key web_platform_001 -app-secret sk_web_1a2b3c4d5e6f7g8h9i0j
+//	go run ./cmd/fakewallet -fail-rate 0.1 -lost-rate 0.1 -delay 500ms   # 注入故障，验证重试与冲正
+//
+// 平台配置 wallet_mode=seamless、wallet_url=http://127.0.0.1:9300/wallet 后即可下注；
+// 余额查询：curl 'http://127.0.0.1:9300/wallet/balance?user_id=u1&currency=CNY'
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"dt-server/internal/seamless"
+
+	decimal "github.com/shopspring/decimal"
+)
+
+func main() {
+	addr := flag.String("addr", ":9300", "监听地址")
+	appKey := flag.String("app-key", "web_platform_001", "平台 app_key（与游戏服务平台配置一致）")
+	appSecret := flag.String("app-secret", "sk_web_1a2b3c4d5e6f7g8h9i0j", "平台 app_secret，为空时不校验签名")
+	balance := flag.String("balance", "10000", "新用户初始余额")
+	failRate := flag.Float64("fail-rate", 0, "处理前返回 500 的比例（0~1）")
+	lostRate := flag.Float64("lost-rate", 0, "处理后返回 500 的比例（0~1），模拟响应丢失")
+	delay := flag.Duration("delay", 0, "每个请求的处理延迟")
+	flag.Parse()
+
+	initial, err := decimal.NewFromString(*balance)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -balance: %v\n", err)
+		os.Exit(2)
+	}
+	w := seamless.NewFakeWallet(*appKey, *appSecret, initial)
+	w.FailRate = *failRate
+	w.LostRate = *lostRate
+	w.Delay = *delay
+
+	log.Printf("fake wallet listening on %s (app_key=%s, initial_balance=%s)", *addr, *appKey, initial.StringFixed(2))
+	if err := http.ListenAndServe(*addr, w); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,30 @@
+// statediagram 导出牌局状态机图
+//
+// 用法：
+//
+//	go run ./cmd/statediagram -format mermaid > docs/state.mmd
+//	go run ./cmd/statediagram -format dot | dot -Tpng -o state.png
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"dt-server/internal/state"
+)
+
+func main() {
+	format := flag.String("format", "mermaid", "输出格式: mermaid | dot")
+	flag.Parse()
+
+	switch *format {
+	case "mermaid":
+		fmt.Print(state.Default.Mermaid())
+	case "dot":
+		fmt.Print(state.Default.DOT())
+	default:
+		fmt.Fprintf(os.Stderr, "unknown format %q (want mermaid|dot)\n", *format)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,15 @@
+-- ============================================
+-- 批量投注（POST /api/bets/batch）
+-- 创建时间: 2025-11-07
+-- 说明: 一次请求包含同一局的多个玩法，共用一个幂等键：
+--       idempotency_keys 按该键只占一行（purpose=bet_batch，ref 为第一笔注单号），
+--       本批次的每张注单 orders.idempotency_key 均为该键；重复请求按幂等键回查全部注单
+-- ============================================
+
+ALTER TABLE orders
+ADD INDEX idx_idempotency_key (idempotency_key);
+
+-- ============================================
+-- 回滚脚本（如需回滚，请执行以下语句）
+-- ============================================
+-- ALTER TABLE orders DROP INDEX idx_idempotency_key;
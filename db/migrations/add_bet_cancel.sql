@@ -0,0 +1,23 @@
+-- ============================================
+-- 下注中撤单（POST /api/bets/cancel）
+-- 创建时间: 2025-11-08
+-- 说明: 房间开启 cancel_allowed 后，玩家可在牌局 betting 状态、且早于 bet_stop_time - cancel_cutoff_sec
+--       时撤销本人的待结算注单：注单置为 bill_status=3，全额退回本金（wallet_ledger.biz_type=6 bet_cancel），
+--       写入 bet_cancelled Outbox 消息，并删除原幂等键的 Redis 结果缓存
+-- ============================================
+
+-- 1. 房间撤单配置
+ALTER TABLE rooms
+ADD COLUMN cancel_allowed TINYINT NOT NULL DEFAULT 0 COMMENT '撤单: 0=不允许 1=下注中允许撤单' AFTER draw_approval,
+ADD COLUMN cancel_cutoff_sec INT NOT NULL DEFAULT 0 COMMENT '封盘前停止撤单的秒数（0..bet_window_sec）' AFTER cancel_allowed;
+
+-- 2. 账本业务类型增加 6=bet_cancel
+ALTER TABLE wallet_ledger
+MODIFY COLUMN biz_type TINYINT NOT NULL COMMENT '业务类型: 1=bet 下注 2=settle 结算 3=refund 退款 4=adjust 后台调整 5=settle_reverse 结算冲正 6=bet_cancel 撤单退款';
+
+-- ============================================
+-- 回滚脚本（如需回滚，请执行以下语句）
+-- ============================================
+-- ALTER TABLE wallet_ledger
+-- MODIFY COLUMN biz_type TINYINT NOT NULL COMMENT '业务类型: 1=bet 下注 2=settle 结算 3=refund 退款 4=adjust 后台调整 5=settle_reverse 结算冲正';
+-- ALTER TABLE rooms DROP COLUMN cancel_cutoff_sec, DROP COLUMN cancel_allowed;
@@ -0,0 +1,42 @@
+-- ============================================
+-- 开奖结果复核（maker-checker）
+-- 创建时间: 2025-11-06
+-- 说明: 房间开启 draw_approval 后，POST /api/drawresult（及 MQ draw_result 命令）不再立即结算，
+--       开奖结果写入 pending_draw_results 待复核：
+--       - 另一名管理员复核通过（POST /api/admin/drawresult/pending/:id/approve）后结算，复核人不能是提交人
+--       - 同一局两路不同扫描源（source）的牌面一致时自动结算；不一致时双方置为 mismatch，
+--         写入 draw_result_mismatch 告警事件并阻断结算，驳回后才能重新提交
+--       管理员身份取自 auth.admin.users 的具名 Token，共享 Token 的身份统一为 admin
+-- ============================================
+
+-- 1. 房间开奖复核开关
+ALTER TABLE rooms
+ADD COLUMN draw_approval TINYINT NOT NULL DEFAULT 0 COMMENT '开奖复核: 0=直接结算 1=需另一名管理员复核或双扫描一致' AFTER is_vip;
+
+-- 2. 待复核开奖结果
+CREATE TABLE IF NOT EXISTS `pending_draw_results` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '自增ID',
+  `game_id` VARCHAR(32) NOT NULL COMMENT '游戏ID',
+  `room_id` VARCHAR(32) NOT NULL COMMENT '房间ID',
+  `game_round_id` VARCHAR(64) NOT NULL COMMENT '游戏回合ID',
+  `source` VARCHAR(32) NOT NULL DEFAULT 'manual' COMMENT '结果来源: manual 人工录入 | 扫描设备标识',
+  `mode` VARCHAR(32) NOT NULL COMMENT '牌面来源: server | manual | manual_override',
+  `card_list` VARCHAR(256) NOT NULL COMMENT '牌面',
+  `result` VARCHAR(32) NOT NULL COMMENT '按牌面计算的结果',
+  `maker` VARCHAR(64) NOT NULL COMMENT '提交人',
+  `checker` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '复核人（双扫描一致时为 scan:<source>）',
+  `status` TINYINT NOT NULL DEFAULT 1 COMMENT '状态: 1=pending 待复核 2=approved 已通过 3=rejected 已驳回 4=mismatch 扫描不一致',
+  `reason` VARCHAR(512) NOT NULL DEFAULT '' COMMENT '驳回原因 / 不一致说明',
+  `trace_id` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '链路追踪ID',
+  `created_at` BIGINT UNSIGNED NOT NULL COMMENT '提交时间(13位毫秒时间戳)',
+  `decided_at` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '结案时间(13位毫秒时间戳，未结案为 0)',
+  PRIMARY KEY (`id`),
+  INDEX `idx_round_status` (`game_round_id`, `status`),
+  INDEX `idx_status` (`status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='待复核开奖结果表';
+
+-- ============================================
+-- 回滚脚本（如需回滚，请执行以下语句）
+-- ============================================
+-- DROP TABLE IF EXISTS `pending_draw_results`;
+-- ALTER TABLE rooms DROP COLUMN draw_approval;
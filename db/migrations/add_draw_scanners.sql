@@ -0,0 +1,16 @@
+-- ============================================
+-- 开奖复核：扫描设备白名单
+-- 创建时间: 2025-11-17
+-- 说明: 房间开启 draw_approval 后，仅 draw_scanners 中的扫描设备之间自动比对（一致结算、不一致阻断），
+--       其余提交（人工录入、未登记的扫描设备、MQ 命令）须另一名管理员复核。
+--       扫描设备以 auth.admin.scanners 中的专用 Token 认证，source 与提交人身份（scan:<id>）均取自认证结果，
+--       请求体中的 source / operator 不再作为身份依据。
+-- ============================================
+
+ALTER TABLE rooms
+ADD COLUMN draw_scanners VARCHAR(512) NOT NULL DEFAULT '' COMMENT '可自动比对的扫描设备标识(JSON 数组)，空表示不自动比对' AFTER draw_approval;
+
+-- ============================================
+-- 回滚脚本（如需回滚，请执行以下语句）
+-- ============================================
+-- ALTER TABLE rooms DROP COLUMN draw_scanners;
@@ -0,0 +1,24 @@
+-- ============================================
+-- 游戏事件幂等与顺序
+-- 创建时间: 2025-11-03
+-- 说明: 事件发送方（荷官端/MQ）可为每个事件携带 event_id 与局内序号 seq（从 1 开始连续递增）
+--       - 重复投递（event_id 或 seq 已入库且为同一事件）按成功处理，不再报非法跳转
+--       - 序号超前（前序事件尚未到达）的事件被拒绝，发送方补发前序事件后重试
+--       - event_id / seq 被其他事件占用视为冲突
+--       未携带时入库为 NULL，不参与唯一约束（定时任务、巡检等内部事件）
+-- ============================================
+
+ALTER TABLE game_event_audit
+ADD COLUMN event_id VARCHAR(64) NULL DEFAULT NULL COMMENT '事件ID（发送方生成，全局唯一）' AFTER event_type,
+ADD COLUMN seq BIGINT UNSIGNED NULL DEFAULT NULL COMMENT '局内事件序号（从 1 开始连续递增）' AFTER event_id,
+ADD UNIQUE KEY uk_event_id (event_id),
+ADD UNIQUE KEY uk_round_seq (game_round_id, seq);
+
+-- ============================================
+-- 回滚脚本（如需回滚，请执行以下语句）
+-- ============================================
+-- ALTER TABLE game_event_audit
+-- DROP INDEX uk_round_seq,
+-- DROP INDEX uk_event_id,
+-- DROP COLUMN seq,
+-- DROP COLUMN event_id;
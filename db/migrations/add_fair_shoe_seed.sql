@@ -0,0 +1,24 @@
+-- ============================================
+-- 可验证公平改为牌靴级种子
+-- 创建时间: 2025-11-16
+-- 说明: 原按局种子模式每局从独立的虚拟 8 副牌派生牌面，绕过了房间牌靴（无烧牌/切牌、shoe_no 为空，
+--       按靴路单不会重置）。改为：
+--         1) 开靴时生成 server_seed，由 (server_seed, shoe_no) 派生整靴牌序，烧牌、切牌照常进行，公布 seed_hash；
+--         2) game_start 时本局承诺所用牌靴的 seed_hash（seed_nonce 记靴号），new_card 从牌靴发牌，
+--            game_round_info.shoe_position 记录本局第一张牌在靴中的下标；
+--         3) 换靴时在 shoe_changed 中公布旧靴 server_seed，按各局起始下标即可复算牌面。
+--       已有按局种子的历史局（game_round_info.server_seed 非空）仍按原规则核对。
+-- ============================================
+
+ALTER TABLE shoes
+ADD COLUMN `server_seed` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '靴种子(hex; 换靴后公布, 非公平模式为空)' AFTER burn_cards,
+ADD COLUMN `seed_hash` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '靴种子承诺: hex(SHA256(server_seed))' AFTER server_seed;
+
+ALTER TABLE game_round_info
+ADD COLUMN `shoe_position` INT NOT NULL DEFAULT 0 COMMENT '本局第一张牌在牌靴中的下标' AFTER shoe_no;
+
+-- ============================================
+-- 回滚脚本（如需回滚，请执行以下语句）
+-- ============================================
+-- ALTER TABLE game_round_info DROP COLUMN shoe_position;
+-- ALTER TABLE shoes DROP COLUMN seed_hash, DROP COLUMN server_seed;
@@ -0,0 +1,34 @@
+-- ============================================
+-- 可插拔游戏引擎（按 game_id 选择规则），新增百家乐
+-- 创建时间: 2025-10-30
+-- 说明: 规则由 internal/engine 按 game_id 注册（dt/dt_game/dragon_tiger -> 龙虎，bac/baccarat -> 百家乐）；
+--       玩法与结果共用一套编码，百家乐新增 17=闲 18=庄，和局沿用 3=和
+--       百家乐 card_list 按发牌顺序 P/B 交替，如 P1h,B13s,P9c,B2d,B7h，人工录入按补牌规则校验
+--       新增百家乐演示房间 B001
+-- ============================================
+
+ALTER TABLE orders
+MODIFY COLUMN `play_type` TINYINT NOT NULL COMMENT '下注类型: 1=龙 2=虎 3=和 4=同花和 5~10=龙大/小/单/双/红/黑 11~16=虎大/小/单/双/红/黑 17=闲 18=庄',
+MODIFY COLUMN `game_result` TINYINT NOT NULL DEFAULT 0 COMMENT '游戏结果: 0=未开奖 1=dragon 2=tiger 3=tie 17=player 18=banker';
+
+ALTER TABLE game_round_info
+MODIFY COLUMN `game_result` TINYINT NOT NULL DEFAULT 0 COMMENT '本局结果: 未设置=0; 1=dragon 2=tiger 3=tie 17=player 18=banker',
+MODIFY COLUMN `game_result_str` VARCHAR(20) NOT NULL DEFAULT '' COMMENT '本局结果(冗余字符串: dragon|tiger|tie|player|banker)';
+
+INSERT IGNORE INTO `rooms` (`room_id`, `game_id`, `room_name`, `bet_window_sec`, `bet_limits`, `odds`, `currency`, `status`, `is_vip`, `created_at`, `updated_at`)
+VALUES
+  ('B001', 'baccarat', '百家乐 1 号厅', 45,
+   '{"player":{"min":"0.01","max":"1000000"},"banker":{"min":"0.01","max":"1000000"},"tie":{"min":"0.01","max":"100000"}}',
+   '{"player":"1","banker":"0.95","tie":"8"}',
+   'CNY', 1, 0, UNIX_TIMESTAMP() * 1000, UNIX_TIMESTAMP() * 1000);
+
+-- ============================================
+-- 回滚脚本（如需回滚，请执行以下语句）
+-- ============================================
+-- DELETE FROM rooms WHERE room_id = 'B001';
+-- ALTER TABLE game_round_info
+-- MODIFY COLUMN `game_result` TINYINT NOT NULL DEFAULT 0 COMMENT '本局结果: 未设置=0; 1=dragon 2=tiger 3=tie',
+-- MODIFY COLUMN `game_result_str` VARCHAR(20) NOT NULL DEFAULT '' COMMENT '本局结果(冗余字符串: dragon|tiger|tie)';
+-- ALTER TABLE orders
+-- MODIFY COLUMN `play_type` TINYINT NOT NULL COMMENT '下注类型: 1=龙 2=虎 3=和 4=同花和 5~10=龙大/小/单/双/红/黑 11~16=虎大/小/单/双/红/黑',
+-- MODIFY COLUMN `game_result` TINYINT NOT NULL DEFAULT 0 COMMENT '游戏结果: 0=未开奖 1=dragon 2=tiger 3=tie';
@@ -0,0 +1,16 @@
+-- ============================================
+-- 单局赔付上限（庄家风险敞口）
+-- 创建时间: 2025-11-09
+-- 说明: 每局按玩法累计下注额与潜在派彩、按主结果累计庄家净赔付（Redis Hash game:exposure:{round_id}，单位分），
+--       下注时原子校验并累加；Hash 缺失时由 orders 中待结算注单重建，Redis 不可用时直接按 orders 校验。
+--       任一结果开出时的净赔付将超过房间 liability_cap 的下注被拒绝（错误码 2024）；边注按最坏情况计入每个结果。
+--       运营查询/对账：GET|POST /api/admin/exposure/:round_id
+-- ============================================
+
+ALTER TABLE rooms
+ADD COLUMN liability_cap DECIMAL(18,2) NOT NULL DEFAULT 0.00 COMMENT '单局任一结果净赔付上限，0=不限' AFTER cancel_cutoff_sec;
+
+-- ============================================
+-- 回滚脚本（如需回滚，请执行以下语句）
+-- ============================================
+-- ALTER TABLE rooms DROP COLUMN liability_cap;
@@ -0,0 +1,19 @@
+-- ============================================
+-- RocketMQ 入站命令
+-- 创建时间: 2025-11-04
+-- 说明: 荷官端/现场系统可向命令 topic（rocketmq_command_topic）发布 game_event 与 draw_result 命令
+--       - 按 command_id（缺省为 MQ 消息ID）写入 inbox 去重，业务事务提交后才写 processed_at
+--       - 处理失败的命令延时投递到重试 topic，超过最大次数或不可重试的错误投递到死信 topic
+--       inbox 新增 attempts / last_error 记录处理次数与最近一次失败原因
+-- ============================================
+
+ALTER TABLE inbox
+ADD COLUMN attempts INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '处理次数（入站命令）' AFTER processed_at,
+ADD COLUMN last_error VARCHAR(255) NOT NULL DEFAULT '' COMMENT '最近一次处理失败原因' AFTER attempts;
+
+-- ============================================
+-- 回滚脚本（如需回滚，请执行以下语句）
+-- ============================================
+-- ALTER TABLE inbox
+-- DROP COLUMN last_error,
+-- DROP COLUMN attempts;
@@ -0,0 +1,36 @@
+-- ============================================
+-- 多币种钱包
+-- 创建时间: 2025-11-11
+-- 说明: 每个用户每个币种一个钱包（wallets），下注、结算、退款、撤单与开奖更正均按注单币种记入对应钱包；
+--       注单按房间币种计价（orders.currency），账本记录同一币种（wallet_ledger.currency）。
+--       币种规则（精度、单注限额）与平台可用币种在配置文件 currencies / auth.platforms[].currencies 中设置。
+--       customers.balance 不再更新，现有余额按 CNY 迁入 wallets。
+--       settlement_log.currency_stats 记录每次结算按币种分组的注单数、下注额与派彩。
+--       运营接口：GET /api/admin/reports/currency
+-- ============================================
+
+CREATE TABLE IF NOT EXISTS `wallets` (
+  `user_id` BIGINT NOT NULL COMMENT '用户ID（customers.user_id）',
+  `currency` VARCHAR(8) NOT NULL COMMENT '币种',
+  `balance` DECIMAL(18,2) NOT NULL DEFAULT 0.00 COMMENT '当前可用余额（开奖更正 allow 策略下可为负）',
+  `created_at` BIGINT UNSIGNED NOT NULL COMMENT '创建时间(13位毫秒时间戳)',
+  `updated_at` BIGINT UNSIGNED NOT NULL COMMENT '更新时间(13位毫秒时间戳)',
+  PRIMARY KEY (`user_id`, `currency`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='用户多币种钱包';
+
+-- 迁移现有余额（历史注单均为 CNY）
+INSERT IGNORE INTO `wallets` (`user_id`, `currency`, `balance`, `created_at`, `updated_at`)
+SELECT `user_id`, 'CNY', `balance`, `created_at`, `updated_at` FROM `customers`;
+
+ALTER TABLE settlement_log
+ADD COLUMN currency_stats JSON NULL COMMENT '按币种分组的结算统计，如 {"CNY":{"orders":3,"bet_amount":300,"payout":194}}';
+
+ALTER TABLE orders
+ADD INDEX idx_bet_time_currency (bet_time, currency);
+
+-- ============================================
+-- 回滚脚本（如需回滚，请执行以下语句）
+-- ============================================
+-- ALTER TABLE orders DROP INDEX idx_bet_time_currency;
+-- ALTER TABLE settlement_log DROP COLUMN currency_stats;
+-- DROP TABLE IF EXISTS `wallets`;
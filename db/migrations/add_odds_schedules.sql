@@ -0,0 +1,36 @@
+-- ============================================
+-- 版本化赔率表
+-- 创建时间: 2025-11-10
+-- 说明: 按 game_id + room_id + platform_id 配置赔率并指定生效时间，覆盖房间默认赔率（rooms.odds）。
+--       room_id 为空表示该游戏全部房间，platform_id=-1 表示全部平台；同一玩法取作用范围最精确、生效时间最晚的版本。
+--       已有版本不可修改，调整赔率即新增版本，停用则撤销（status=2，保留行）。
+--       下注时将所用版本写入 orders.odds_version（0 表示房间默认赔率），争议时可追溯到具体赔率表。
+--       运营接口：GET|POST /api/admin/odds，POST /api/admin/odds/:version/revoke
+-- ============================================
+
+CREATE TABLE IF NOT EXISTS `odds_schedules` (
+  `version` BIGINT NOT NULL AUTO_INCREMENT COMMENT '赔率版本号（全局唯一）',
+  `game_id` VARCHAR(32) NOT NULL COMMENT '游戏ID',
+  `room_id` VARCHAR(32) NOT NULL DEFAULT '' COMMENT '房间ID，空=该游戏全部房间',
+  `platform_id` TINYINT NOT NULL DEFAULT -1 COMMENT '平台ID，-1=全部平台',
+  `odds` JSON NOT NULL COMMENT '各玩法赔率，如 {"dragon":"0.97","tie":"8"}',
+  `effective_from` BIGINT UNSIGNED NOT NULL COMMENT '生效时间(13位毫秒时间戳)',
+  `status` TINYINT NOT NULL DEFAULT 1 COMMENT '状态: 1=有效 2=已撤销',
+  `operator` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '操作人（新增/撤销）',
+  `remark` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '备注',
+  `trace_id` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '链路追踪ID',
+  `created_at` BIGINT UNSIGNED NOT NULL COMMENT '创建时间(13位毫秒时间戳)',
+  `updated_at` BIGINT UNSIGNED NOT NULL COMMENT '更新时间(13位毫秒时间戳)',
+  PRIMARY KEY (`version`),
+  INDEX `idx_scope` (`game_id`, `room_id`, `platform_id`, `effective_from`),
+  INDEX `idx_status` (`status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='版本化赔率表';
+
+ALTER TABLE orders
+ADD COLUMN odds_version BIGINT NOT NULL DEFAULT 0 COMMENT '下注所用赔率版本（odds_schedules.version），0=房间默认赔率' AFTER bet_odds;
+
+-- ============================================
+-- 回滚脚本（如需回滚，请执行以下语句）
+-- ============================================
+-- ALTER TABLE orders DROP COLUMN odds_version;
+-- DROP TABLE IF EXISTS `odds_schedules`;
@@ -0,0 +1,17 @@
+-- ============================================
+-- 可验证公平（commit/reveal）
+-- 创建时间: 2025-10-27
+-- 说明: game_start 时生成 server_seed 并公布 seed_hash = SHA256(server_seed)，
+--       new_card 时由 (server_seed, seed_nonce) 派生牌面，结算后在 game_drawn 中公布 server_seed；
+--       历史局可通过 GET /api/fair/verify/:round_id 复算核对
+-- ============================================
+
+ALTER TABLE game_round_info
+ADD COLUMN `server_seed` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '服务端种子(hex; 结算后公布)' AFTER shoe_no,
+ADD COLUMN `seed_hash` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '种子承诺: hex(SHA256(server_seed))' AFTER server_seed,
+ADD COLUMN `seed_nonce` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '派生牌面使用的 nonce(默认局号)' AFTER seed_hash;
+
+-- ============================================
+-- 回滚脚本（如需回滚，请执行以下语句）
+-- ============================================
+-- ALTER TABLE game_round_info DROP COLUMN seed_nonce, DROP COLUMN seed_hash, DROP COLUMN server_seed;
@@ -0,0 +1,41 @@
+-- ============================================
+-- 开奖结果更正与重新结算
+-- 创建时间: 2025-11-05
+-- 说明: 管理员可更正已结算牌局的牌面（POST /api/admin/drawresult/correct）：
+--       扣回每笔注单的原派彩（wallet_ledger biz_type=5 settle_reverse），按新牌面重新派彩，
+--       写入新版本的 settlement_log，并发出 order_resettled / round_resettled 事件
+--       审计 game_event_audit.event_type=7 表示开奖结果更正（不改变牌局状态）
+-- 1. settlement_log 按 game_round_id + version 唯一，最新版本为当前有效结算
+-- 2. 负余额策略 correction.negative_balance=allow 时余额可能为负，余额与账本快照改为有符号
+-- ============================================
+
+-- 1. 结算日志版本化
+ALTER TABLE settlement_log
+ADD COLUMN version INT UNSIGNED NOT NULL DEFAULT 1 COMMENT '结算版本（1=首次结算，更正后递增）' AFTER game_round_id,
+ADD COLUMN reason VARCHAR(255) NOT NULL DEFAULT '' COMMENT '更正原因' AFTER operator,
+DROP INDEX uk_round,
+ADD UNIQUE KEY uk_round_version (game_round_id, version) COMMENT '防止同一版本重复结算';
+
+-- 2. 余额与账本快照允许为负
+ALTER TABLE customers
+MODIFY COLUMN balance DECIMAL(18,2) NOT NULL DEFAULT 0.00 COMMENT '当前可用余额（开奖更正且允许负余额时可能为负）';
+
+ALTER TABLE wallet_ledger
+MODIFY COLUMN biz_type TINYINT NOT NULL COMMENT '业务类型: 1=bet 下注 2=settle 结算 3=refund 退款 4=adjust 后台调整 5=settle_reverse 结算冲正',
+MODIFY COLUMN before_amount DECIMAL(18,2) NOT NULL COMMENT '变动前余额快照',
+MODIFY COLUMN after_amount DECIMAL(18,2) NOT NULL COMMENT '变动后余额快照';
+
+-- ============================================
+-- 回滚脚本（如需回滚，请执行以下语句；回滚前需确认没有多版本结算与负余额数据）
+-- ============================================
+-- ALTER TABLE wallet_ledger
+-- MODIFY COLUMN biz_type TINYINT NOT NULL COMMENT '业务类型: 1=bet 下注 2=settle 结算 3=refund 退款 4=adjust 后台调整',
+-- MODIFY COLUMN before_amount DECIMAL(18,2) UNSIGNED NOT NULL COMMENT '变动前余额快照(非负数)',
+-- MODIFY COLUMN after_amount DECIMAL(18,2) UNSIGNED NOT NULL COMMENT '变动后余额快照(非负数)';
+-- ALTER TABLE customers
+-- MODIFY COLUMN balance DECIMAL(18,2) UNSIGNED NOT NULL DEFAULT 0.00 COMMENT '当前可用余额';
+-- ALTER TABLE settlement_log
+-- DROP INDEX uk_round_version,
+-- ADD UNIQUE KEY uk_round (game_round_id) COMMENT '防止重复结算',
+-- DROP COLUMN reason,
+-- DROP COLUMN version;
@@ -0,0 +1,44 @@
+-- ============================================
+-- 房间配置（rooms）
+-- 创建时间: 2025-10-28
+-- 说明: 房间维度的游戏、下注窗口、各玩法限红与赔率、币种、状态与 VIP 标记；
+--       服务端以内存注册表缓存，按 (COUNT(*), MAX(updated_at)) 指纹检测变更后重载；
+--       下注、game_start 与结算均读取房间配置，未登记的 room_id 不再接受下注与开局
+-- ============================================
+
+CREATE TABLE IF NOT EXISTS `rooms` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '自增ID',
+  `room_id` VARCHAR(32) NOT NULL COMMENT '房间ID',
+  `game_id` VARCHAR(32) NOT NULL COMMENT '游戏ID',
+  `room_name` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '房间名称',
+  `bet_window_sec` INT NOT NULL DEFAULT 45 COMMENT '下注窗口(秒)',
+  `bet_limits` JSON NOT NULL COMMENT '各玩法限红: {"dragon":{"min":"0.01","max":"1000000"},...}',
+  `odds` JSON NOT NULL COMMENT '各玩法赔率: {"dragon":"0.97","tiger":"0.97","tie":"8"}',
+  `currency` VARCHAR(8) NOT NULL DEFAULT 'CNY' COMMENT '币种',
+  `status` TINYINT NOT NULL DEFAULT 1 COMMENT '状态: 1=open 开放 2=maintenance 维护 3=closed 关闭',
+  `is_vip` TINYINT NOT NULL DEFAULT 0 COMMENT 'VIP 房间: 0=否 1=是',
+  `trace_id` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '最后一次变更的链路追踪ID',
+  `created_at` BIGINT UNSIGNED NOT NULL COMMENT '创建时间(13位毫秒时间戳)',
+  `updated_at` BIGINT UNSIGNED NOT NULL COMMENT '更新时间(13位毫秒时间戳)',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_room_id` (`room_id`),
+  INDEX `idx_updated_at` (`updated_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='房间配置表';
+
+-- 初始房间：沿用原硬编码配置（45s 下注窗口、0.01~1,000,000 限红、龙虎 0.97 / 和 8）
+-- R001 为自动开局调度默认房间，room_001 为调试页面使用的房间
+INSERT IGNORE INTO `rooms` (`room_id`, `game_id`, `room_name`, `bet_window_sec`, `bet_limits`, `odds`, `currency`, `status`, `is_vip`, `created_at`, `updated_at`)
+VALUES
+  ('R001', 'dt', '龙虎 1 号厅', 45,
+   '{"dragon":{"min":"0.01","max":"1000000"},"tiger":{"min":"0.01","max":"1000000"},"tie":{"min":"0.01","max":"1000000"}}',
+   '{"dragon":"0.97","tiger":"0.97","tie":"8"}',
+   'CNY', 1, 0, UNIX_TIMESTAMP() * 1000, UNIX_TIMESTAMP() * 1000),
+  ('room_001', 'dt_game', '调试房间', 45,
+   '{"dragon":{"min":"0.01","max":"1000000"},"tiger":{"min":"0.01","max":"1000000"},"tie":{"min":"0.01","max":"1000000"}}',
+   '{"dragon":"0.97","tiger":"0.97","tie":"8"}',
+   'CNY', 1, 0, UNIX_TIMESTAMP() * 1000, UNIX_TIMESTAMP() * 1000);
+
+-- ============================================
+-- 回滚脚本（如需回滚，请执行以下语句）
+-- ============================================
+-- DROP TABLE IF EXISTS `rooms`;
@@ -0,0 +1,20 @@
+-- ============================================
+-- 牌局作废（game_cancel）
+-- 创建时间: 2025-10-25
+-- 说明: 新增事件类型 6=game_cancel 与回合状态 8=已取消；
+--       作废时本局待结算注单置为 bill_status=3 并全额退款（wallet_ledger.biz_type=3 refund）
+-- ============================================
+
+ALTER TABLE game_round_info
+MODIFY COLUMN `game_status` TINYINT NOT NULL DEFAULT 1 COMMENT '回合状态: 1=初始 2=下注中 3=封盘 4=已发牌 5=已开奖 6=已结算 7=已结束 8=已取消';
+
+ALTER TABLE game_event_audit
+MODIFY COLUMN `event_type` TINYINT NOT NULL DEFAULT 0 COMMENT '事件类型: 1=game_start 2=game_stop 3=new_card 4=game_draw 5=game_end 6=game_cancel';
+
+-- ============================================
+-- 回滚脚本（如需回滚，请执行以下语句）
+-- ============================================
+-- ALTER TABLE game_round_info
+-- MODIFY COLUMN `game_status` TINYINT NOT NULL DEFAULT 1 COMMENT '回合状态: 1=初始 2=下注中 3=封盘 4=已发牌 5=已开奖 6=已结算 7=已结束';
+-- ALTER TABLE game_event_audit
+-- MODIFY COLUMN `event_type` TINYINT NOT NULL DEFAULT 0 COMMENT '事件类型: 1=game_start 2=game_stop 3=new_card 4=game_draw 5=game_end';
@@ -0,0 +1,13 @@
+-- ============================================
+-- 自动开局调度
+-- 创建时间: 2025-10-24
+-- 说明: 调度器按房间查询最近一局以恢复进度（ORDER BY id DESC LIMIT 1）
+-- ============================================
+
+ALTER TABLE game_round_info
+ADD INDEX idx_room_id (room_id, id);
+
+-- ============================================
+-- 回滚脚本（如需回滚，请执行以下语句）
+-- ============================================
+-- ALTER TABLE game_round_info DROP INDEX idx_room_id;
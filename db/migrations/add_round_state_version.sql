@@ -0,0 +1,18 @@
+-- ============================================
+-- 下注去除回合行排他锁
+-- 创建时间: 2025-11-14
+-- 说明: 下注/撤单不再对 game_round_info 加 FOR UPDATE，改为：
+--       1) 先读 Redis 回合状态缓存（game:state:{round_id}），已封盘/未开盘直接拒绝，不开事务；
+--       2) 事务内以共享锁（LOCK IN SHARE MODE）读取回合状态并校验，同一局的下注互不阻塞；
+--          game_stop 等状态推进仍取排他锁，须等进行中的下注提交，封盘提交后不会再有下注提交。
+--       state_version 每次状态变更 +1，作为缓存的防护令牌：缓存只接受更高版本的写入，
+--       延迟到达的旧状态不会覆盖新状态。
+-- ============================================
+
+ALTER TABLE game_round_info
+ADD COLUMN state_version INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '状态版本（每次状态变更 +1）' AFTER game_status;
+
+-- ============================================
+-- 回滚脚本（如需回滚，请执行以下语句）
+-- ============================================
+-- ALTER TABLE game_round_info DROP COLUMN state_version;
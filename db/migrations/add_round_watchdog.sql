@@ -0,0 +1,15 @@
+-- ============================================
+-- 卡局巡检
+-- 创建时间: 2025-10-31
+-- 说明: 巡检按 game_status + updated_at 扫描停留过久的牌局（betting/sealed/dealt/drawn），
+--       发现后写入 round_stuck Outbox 告警，并可按配置自动封盘或作废
+--       各状态阈值为动态阈值 round_stuck_{betting,sealed,dealt,drawn}_sec
+-- ============================================
+
+ALTER TABLE game_round_info
+ADD INDEX idx_status_updated (game_status, updated_at);
+
+-- ============================================
+-- 回滚脚本（如需回滚，请执行以下语句）
+-- ============================================
+-- ALTER TABLE game_round_info DROP INDEX idx_status_updated;
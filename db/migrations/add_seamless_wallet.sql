@@ -0,0 +1,48 @@
+-- ============================================
+-- 单一钱包（seamless）模式
+-- 创建时间: 2025-11-12
+-- 说明: 平台配置 auth.platforms[].wallet_mode=seamless 时，余额由平台钱包维护，本服务调用平台 debit/credit/rollback 接口：
+--       下注同步扣款，注单先以 bet_status=1 落库，扣款成功后置 2，被拒或冲正后置 3（bill_status=3）；
+--       结算、退款、撤单与开奖更正登记为异步交易，由投递任务按退避重试；
+--       超过 30 秒仍未确认的下注扣款由对账任务置失败并登记 rollback。
+--       wallet_transactions 记录每一次平台钱包调用，txn_id 为发给平台的幂等键。
+--       本地联调可运行 go run ./cmd/fakewallet 模拟平台钱包。
+-- ============================================
+
+CREATE TABLE IF NOT EXISTS `wallet_transactions` (
+  `txn_id` VARCHAR(80) NOT NULL COMMENT '交易号（平台幂等键）',
+  `platform_id` TINYINT NOT NULL COMMENT '平台ID',
+  `platform_user_id` VARCHAR(64) NOT NULL COMMENT '平台的用户ID',
+  `user_id` BIGINT NOT NULL COMMENT '内部用户ID',
+  `op` TINYINT NOT NULL COMMENT '操作: 1=debit 2=credit 3=rollback',
+  `biz_type` VARCHAR(16) NOT NULL COMMENT '业务类型: bet|settle|refund|bet_cancel|resettle|rollback',
+  `amount` DECIMAL(18,2) NOT NULL COMMENT '金额（rollback 为原扣款金额）',
+  `currency` VARCHAR(8) NOT NULL COMMENT '币种',
+  `ref_txn_id` VARCHAR(80) NOT NULL DEFAULT '' COMMENT '关联的下注扣款交易号',
+  `game_round_id` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '局ID',
+  `bill_nos` TEXT NOT NULL COMMENT '关联注单号（逗号分隔）',
+  `status` TINYINT NOT NULL DEFAULT 1 COMMENT '状态: 1=待调用 2=成功 3=失败 4=结果未知',
+  `attempts` INT NOT NULL DEFAULT 0 COMMENT '已调用次数',
+  `next_retry_at` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '下次调用时间(13位毫秒时间戳)',
+  `last_error` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '最后一次错误',
+  `balance` DECIMAL(18,2) NOT NULL DEFAULT 0.00 COMMENT '平台返回的处理后余额',
+  `trace_id` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '链路追踪ID',
+  `created_at` BIGINT UNSIGNED NOT NULL COMMENT '创建时间(13位毫秒时间戳)',
+  `updated_at` BIGINT UNSIGNED NOT NULL COMMENT '更新时间(13位毫秒时间戳)',
+  PRIMARY KEY (`txn_id`),
+  INDEX `idx_due` (`status`, `next_retry_at`),
+  INDEX `idx_op_status` (`op`, `biz_type`, `status`, `created_at`),
+  INDEX `idx_round` (`game_round_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='平台钱包交易（seamless 模式）';
+
+ALTER TABLE orders
+ADD COLUMN wallet_txn_id VARCHAR(80) NOT NULL DEFAULT '' COMMENT 'seamless 模式下注扣款交易号（transfer 模式为空）' AFTER currency,
+MODIFY COLUMN bet_status TINYINT NOT NULL DEFAULT 1 COMMENT '下注状态: 1=创建（待平台扣款确认） 2=成功 3=失败',
+ADD INDEX idx_wallet_txn (wallet_txn_id);
+
+-- ============================================
+-- 回滚脚本（如需回滚，请执行以下语句）
+-- ============================================
+-- ALTER TABLE orders DROP INDEX idx_wallet_txn, DROP COLUMN wallet_txn_id,
+--   MODIFY COLUMN bet_status TINYINT NOT NULL DEFAULT 1 COMMENT '下注状态: 1=创建 2=成功 3=失败';
+-- DROP TABLE IF EXISTS `wallet_transactions`;
@@ -0,0 +1,37 @@
+-- ============================================
+-- 服务端牌靴发牌
+-- 创建时间: 2025-10-26
+-- 说明: 每个房间一个使用中的牌靴（8 副牌，crypto/rand 洗牌），new_card 时由服务端发牌；
+--       game_round_info.shoe_no 记录本局牌面所属靴号（人工录入为空）
+-- ============================================
+
+CREATE TABLE IF NOT EXISTS `shoes` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `shoe_no` VARCHAR(64) NOT NULL COMMENT '靴号(唯一)',
+  `game_id` VARCHAR(32) NOT NULL DEFAULT '' COMMENT '游戏ID',
+  `room_id` VARCHAR(32) NOT NULL COMMENT '房间ID',
+  `decks` TINYINT NOT NULL DEFAULT 8 COMMENT '副数',
+  `cards` TEXT NOT NULL COMMENT '整靴牌序(逗号分隔, <点数><花色>, 如 13S,1H)',
+  `total_cards` INT NOT NULL DEFAULT 0 COMMENT '总张数',
+  `position` INT NOT NULL DEFAULT 0 COMMENT '下一张待发牌下标',
+  `cut_position` INT NOT NULL DEFAULT 0 COMMENT '切牌位置(到达后本局结束即换靴)',
+  `burn_cards` VARCHAR(128) NOT NULL DEFAULT '' COMMENT '开靴烧牌',
+  `rounds_dealt` INT NOT NULL DEFAULT 0 COMMENT '已发局数',
+  `status` TINYINT NOT NULL DEFAULT 1 COMMENT '状态: 1=使用中 2=已换靴',
+  `trace_id` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '链路追踪ID',
+  `created_at` BIGINT UNSIGNED NOT NULL COMMENT '创建时间(13位毫秒时间戳)',
+  `updated_at` BIGINT UNSIGNED NOT NULL COMMENT '更新时间(13位毫秒时间戳)',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_shoe_no` (`shoe_no`),
+  INDEX `idx_room_status` (`room_id`, `status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='牌靴表';
+
+ALTER TABLE game_round_info
+ADD COLUMN `shoe_no` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '服务端发牌靴号(人工录入为空)'
+AFTER card_list;
+
+-- ============================================
+-- 回滚脚本（如需回滚，请执行以下语句）
+-- ============================================
+-- ALTER TABLE game_round_info DROP COLUMN shoe_no;
+-- DROP TABLE IF EXISTS shoes;
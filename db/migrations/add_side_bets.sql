@@ -0,0 +1,31 @@
+-- ============================================
+-- 龙虎边注（同花和、大小、单双、红黑）
+-- 创建时间: 2025-10-29
+-- 说明: orders.play_type 新增边注编码；card_list 支持带花色的牌面（如 D13h,T13h）；
+--       边注赔率与限红按房间配置，为已有房间补充默认边注配置
+--       play_type: 1=龙 2=虎 3=和 4=同花和
+--                  5=龙大 6=龙小 7=龙单 8=龙双 9=龙红 10=龙黑
+--                  11=虎大 12=虎小 13=虎单 14=虎双 15=虎红 16=虎黑
+--       大小/单双遇 7 点均输；红黑与同花和依赖花色，牌面未带花色时拒绝结算
+-- ============================================
+
+ALTER TABLE orders
+MODIFY COLUMN `play_type` TINYINT NOT NULL COMMENT '下注类型: 1=龙 2=虎 3=和 4=同花和 5~10=龙大/小/单/双/红/黑 11~16=虎大/小/单/双/红/黑';
+
+UPDATE rooms SET
+  odds = JSON_MERGE_PATCH(odds, '{"suited_tie":"50","dragon_big":"1","dragon_small":"1","dragon_odd":"0.75","dragon_even":"1.05","dragon_red":"0.9","dragon_black":"0.9","tiger_big":"1","tiger_small":"1","tiger_odd":"0.75","tiger_even":"1.05","tiger_red":"0.9","tiger_black":"0.9"}'),
+  bet_limits = JSON_MERGE_PATCH(bet_limits, '{"suited_tie":{"min":"0.01","max":"10000"},"dragon_big":{"min":"0.01","max":"100000"},"dragon_small":{"min":"0.01","max":"100000"},"dragon_odd":{"min":"0.01","max":"100000"},"dragon_even":{"min":"0.01","max":"100000"},"dragon_red":{"min":"0.01","max":"100000"},"dragon_black":{"min":"0.01","max":"100000"},"tiger_big":{"min":"0.01","max":"100000"},"tiger_small":{"min":"0.01","max":"100000"},"tiger_odd":{"min":"0.01","max":"100000"},"tiger_even":{"min":"0.01","max":"100000"},"tiger_red":{"min":"0.01","max":"100000"},"tiger_black":{"min":"0.01","max":"100000"}}'),
+  updated_at = UNIX_TIMESTAMP() * 1000
+WHERE room_id IN ('R001', 'room_001');
+
+-- ============================================
+-- 回滚脚本（如需回滚，请执行以下语句）
+-- ============================================
+-- UPDATE rooms SET
+--   odds = JSON_REMOVE(odds, '$.suited_tie', '$.dragon_big', '$.dragon_small', '$.dragon_odd', '$.dragon_even', '$.dragon_red', '$.dragon_black',
+--     '$.tiger_big', '$.tiger_small', '$.tiger_odd', '$.tiger_even', '$.tiger_red', '$.tiger_black'),
+--   bet_limits = JSON_REMOVE(bet_limits, '$.suited_tie', '$.dragon_big', '$.dragon_small', '$.dragon_odd', '$.dragon_even', '$.dragon_red', '$.dragon_black',
+--     '$.tiger_big', '$.tiger_small', '$.tiger_odd', '$.tiger_even', '$.tiger_red', '$.tiger_black'),
+--   updated_at = UNIX_TIMESTAMP() * 1000
+-- WHERE room_id IN ('R001', 'room_001');
+-- ALTER TABLE orders MODIFY COLUMN `play_type` TINYINT NOT NULL COMMENT '下注类型: 1=龙 2=虎 3=和';
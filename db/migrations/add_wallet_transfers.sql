@@ -0,0 +1,43 @@
+-- ============================================
+-- 平台上分/下分（transfer 钱包模式）
+-- 创建时间: 2025-11-13
+-- 说明: 平台通过签名接口为玩家转入/转出资金（按币种记入 wallets）：
+--       POST /api/wallet/deposit、POST /api/wallet/withdraw、GET /api/wallet/transfers/:transfer_id
+--       transfer_id 为平台侧转账单号，同一平台内唯一；以同一单号重试时返回首次结果，参数不一致时拒绝。
+--       下分在余额不足或用户禁止提款（customers.status=3，见 common/constant.UserNotAllowWithdraw）时拒绝，
+--       被拒绝的转账同样记录（status=2），便于平台按单号对账。
+--       账本新增 biz_type 7=deposit 上分、8=withdraw 下分。
+-- ============================================
+
+CREATE TABLE IF NOT EXISTS `transfers` (
+  `transfer_id` VARCHAR(64) NOT NULL COMMENT '平台转账单号（幂等键）',
+  `platform_id` TINYINT NOT NULL COMMENT '平台ID',
+  `platform_user_id` VARCHAR(64) NOT NULL COMMENT '平台的用户ID',
+  `user_id` BIGINT NOT NULL COMMENT '内部用户ID',
+  `direction` TINYINT NOT NULL COMMENT '方向: 1=deposit 上分 2=withdraw 下分',
+  `amount` DECIMAL(18,2) UNSIGNED NOT NULL COMMENT '金额',
+  `currency` VARCHAR(8) NOT NULL COMMENT '币种',
+  `before_amount` DECIMAL(18,2) NOT NULL COMMENT '转账前余额',
+  `after_amount` DECIMAL(18,2) NOT NULL COMMENT '转账后余额（失败时与转账前相同）',
+  `status` TINYINT NOT NULL COMMENT '状态: 1=成功 2=失败',
+  `reason` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '失败原因',
+  `remark` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '平台备注',
+  `trace_id` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '链路追踪ID',
+  `created_at` BIGINT UNSIGNED NOT NULL COMMENT '创建时间(13位毫秒时间戳)',
+  PRIMARY KEY (`platform_id`, `transfer_id`),
+  INDEX `idx_user_time` (`user_id`, `created_at`),
+  INDEX `idx_platform_time` (`platform_id`, `created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='平台上分/下分记录';
+
+ALTER TABLE customers
+MODIFY COLUMN status TINYINT NOT NULL DEFAULT 1 COMMENT '状态: 1=启用 2=禁用 3=禁止提款';
+
+ALTER TABLE wallet_ledger
+MODIFY COLUMN biz_type TINYINT NOT NULL COMMENT '业务类型: 1=bet 下注 2=settle 结算 3=refund 退款 4=adjust 后台调整 5=settle_reverse 结算冲正 6=bet_cancel 撤单 7=deposit 上分 8=withdraw 下分';
+
+-- ============================================
+-- 回滚脚本（如需回滚，请执行以下语句）
+-- ============================================
+-- ALTER TABLE wallet_ledger MODIFY COLUMN biz_type TINYINT NOT NULL COMMENT '业务类型: 1=bet 下注 2=settle 结算 3=refund 退款 4=adjust 后台调整';
+-- ALTER TABLE customers MODIFY COLUMN status TINYINT NOT NULL DEFAULT 1 COMMENT '状态: 1=启用 2=禁用';
+-- DROP TABLE IF EXISTS `transfers`;
@@ -0,0 +1,19 @@
+-- ============================================
+-- 钱包余额原子条件更新
+-- 创建时间: 2025-11-15
+-- 说明: 下注、结算、退款、撤单、开奖更正与上分/下分不再 SELECT ... FOR UPDATE 锁定钱包后在程序中计算余额写回，
+--       改为在业务事务内以单条条件语句变更：
+--         UPDATE wallets SET balance = balance + ?, version = version + 1
+--         WHERE user_id = ? AND currency = ? [AND balance >= ?]
+--       命中后在同一事务内读回变更后余额；未命中时加锁读取（钱包不存在时创建）区分余额不足。
+--       version 为余额变更计数，不作为写入条件；并发变更由行锁排队，无需重试。
+--       结算、作废退款与开奖更正不再锁定 customers 行。
+-- ============================================
+
+ALTER TABLE wallets
+ADD COLUMN version BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '余额版本（每次余额变更 +1）' AFTER balance;
+
+-- ============================================
+-- 回滚脚本（如需回滚，请执行以下语句）
+-- ============================================
+-- ALTER TABLE wallets DROP COLUMN version;
@@ -182,6 +182,12 @@ func checkAndSetNonce(ctx context.Context, appKey, nonce string) error {
 	return nil
 }
 
+// SignRequest 计算平台接口签名（与 VerifyPlatformSignature 校验的算法一致），
+// 供本服务调用平台接口（如单一钱包）时签名请求
+func SignRequest(appKey, timestamp, nonce, body, secret string) string {
+	return generateSignature(appKey, timestamp, nonce, body, secret)
+}
+
 // generateSignature 生成签名
 // 签名算法：HMAC-SHA256(app_key + timestamp + nonce + body, app_secret)
 func generateSignature(appKey, timestamp, nonce, body, secret string) string {
@@ -9,6 +9,8 @@ import (
 	"strings"
 	"time"
 
+	"dt-server/internal/model"
+
 	beegocontext "github.com/beego/beego/v2/server/web/context"
 )
 
@@ -64,6 +66,14 @@ func GetTraceID(ctx *beegocontext.Context) string {
 	return ""
 }
 
+// GetAdmin 管理员认证中间件注入的管理员身份；未启用管理员认证时为空
+func GetAdmin(ctx *beegocontext.Context) string {
+	if v, ok := ctx.Input.GetData("admin").(string); ok {
+		return v
+	}
+	return ""
+}
+
 // parseByContentType 按 Content-Type 选择解析函数，减少重复 if/else 分支
 func parseByContentType[T any](ctx *beegocontext.Context,
 	jsonParser func(io.Reader) (T, bool, string),
@@ -85,6 +95,7 @@ type BetParsed struct {
 	BetAmount      string `json:"bet_amount"`
 	PlayType       int    `json:"play_type"`
 	Platform       int    `json:"platform"`
+	Currency       string `json:"currency"` // 可选：平台指定的玩家币种，须与房间币种一致
 	IdempotencyKey string `json:"idempotency_key"`
 }
 
@@ -127,8 +138,8 @@ func ParseBetFromForm(ctx *beegocontext.Context) (BetParsed, bool, string) {
 		return BetParsed{}, false, "play_type required"
 	}
 	pi, err := strconv.Atoi(ptStr)
-	if err != nil || (pi != 1 && pi != 2 && pi != 3) {
-		return BetParsed{}, false, "play_type must be 1|2|3"
+	if err != nil || !IsValidPlayType(pi) {
+		return BetParsed{}, false, playTypeMsg
 	}
 	out.PlayType = pi
 
@@ -145,6 +156,8 @@ func ParseBetFromForm(ctx *beegocontext.Context) (BetParsed, bool, string) {
 		}
 	}
 
+	out.Currency = strings.ToUpper(strings.TrimSpace(ctx.Input.Query("currency")))
+
 	out.IdempotencyKey = strings.TrimSpace(ctx.Input.Query("idempotency_key"))
 	if out.IdempotencyKey == "" {
 		return BetParsed{}, false, "idempotency_key required"
@@ -164,16 +177,29 @@ func ValidateBet(in *BetParsed) (bool, string) {
 		return false, "invalid request"
 	}
 	// 玩法校验
-	if in.PlayType != 1 && in.PlayType != 2 && in.PlayType != 3 {
-		return false, "play_type must be 1|2|3"
+	if !IsValidPlayType(in.PlayType) {
+		return false, playTypeMsg
 	}
 	// 金额校验
 	if !IsMoneyFormat(in.BetAmount) {
 		return false, "bet_amount must be numeric with up to 2 decimals"
 	}
+	in.Currency = strings.ToUpper(strings.TrimSpace(in.Currency))
+	if in.Currency != "" && !currencyRe.MatchString(in.Currency) {
+		return false, "invalid currency"
+	}
 	return true, ""
 }
 
+// 玩法：龙虎 1=dragon 2=tiger 3=tie 4=suited_tie 5~10 龙大/小/单/双/红/黑 11~16 虎大/小/单/双/红/黑；
+// 百家乐 17=player 18=banker 3=tie。玩法是否属于房间的游戏由 service 层校验
+const playTypeMsg = "play_type must be 1..18"
+
+// IsValidPlayType 校验玩法编码（编码表见 model.PlayTypeName）
+func IsValidPlayType(pt int) bool {
+	return pt >= 1 && pt <= 127 && model.PlayTypeName(int8(pt)) != ""
+}
+
 // ParseAndValidateBet 按 Content-Type 自动解析并做统一校验
 func ParseAndValidateBet(ctx *beegocontext.Context) (BetParsed, bool, string) {
 	out, ok, msg := parseByContentType(ctx, ParseBetFromJSON, ParseBetFromForm)
@@ -186,17 +212,224 @@ func ParseAndValidateBet(ctx *beegocontext.Context) (BetParsed, bool, string) {
 	return out, true, ""
 }
 
+// -------- BatchBet helpers --------
+
+// maxBatchBets 单次批量投注最多包含的玩法数
+const maxBatchBets = 16
+
+type BatchBetItemParsed struct {
+	PlayType  int    `json:"play_type"`
+	BetAmount string `json:"bet_amount"`
+}
+
+// BatchBetParsed 批量投注入参：同一局的多个玩法共用一个幂等键
+type BatchBetParsed struct {
+	GameId         string               `json:"game_id"`
+	RoomId         string               `json:"room_id"`
+	GameRoundId    string               `json:"game_round_id"`
+	UserId         int64                `json:"user_id"`
+	Bets           []BatchBetItemParsed `json:"bets"`
+	Currency       string               `json:"currency"` // 可选：平台指定的玩家币种，须与房间币种一致
+	IdempotencyKey string               `json:"idempotency_key"`
+}
+
+func ParseBatchBetFromJSON(r io.Reader) (BatchBetParsed, bool, string) {
+	var out BatchBetParsed
+	if err := json.NewDecoder(r).Decode(&out); err != nil {
+		return BatchBetParsed{}, false, "invalid json body"
+	}
+	return out, true, ""
+}
+
+// ParseBatchBetFromForm 批量投注包含数组，仅支持 JSON
+func ParseBatchBetFromForm(ctx *beegocontext.Context) (BatchBetParsed, bool, string) {
+	return BatchBetParsed{}, false, "content-type must be application/json"
+}
+
+func ValidateBatchBet(in *BatchBetParsed) (bool, string) {
+	if in.GameId == "" || in.RoomId == "" || in.GameRoundId == "" || in.IdempotencyKey == "" {
+		return false, "missing or invalid fields"
+	}
+	if len(in.GameId) > 64 || len(in.RoomId) > 64 || len(in.GameRoundId) > 64 || len(in.IdempotencyKey) > 64 {
+		return false, "invalid request"
+	}
+	in.Currency = strings.ToUpper(strings.TrimSpace(in.Currency))
+	if in.Currency != "" && !currencyRe.MatchString(in.Currency) {
+		return false, "invalid currency"
+	}
+	if len(in.Bets) == 0 || len(in.Bets) > maxBatchBets {
+		return false, fmt.Sprintf("bets must contain 1..%d items", maxBatchBets)
+	}
+	seen := make(map[int]bool, len(in.Bets))
+	for _, b := range in.Bets {
+		if !IsValidPlayType(b.PlayType) {
+			return false, playTypeMsg
+		}
+		if seen[b.PlayType] {
+			return false, "duplicate play_type in bets"
+		}
+		seen[b.PlayType] = true
+		if len(b.BetAmount) > 32 || !IsMoneyFormat(b.BetAmount) {
+			return false, "bet_amount must be numeric with up to 2 decimals"
+		}
+	}
+	return true, ""
+}
+
+// ParseAndValidateBatchBet 解析并校验批量投注
+func ParseAndValidateBatchBet(ctx *beegocontext.Context) (BatchBetParsed, bool, string) {
+	out, ok, msg := parseByContentType(ctx, ParseBatchBetFromJSON, ParseBatchBetFromForm)
+	if !ok {
+		return BatchBetParsed{}, false, msg
+	}
+	if ok, msg := ValidateBatchBet(&out); !ok {
+		return BatchBetParsed{}, false, msg
+	}
+	return out, true, ""
+}
+
+// CancelBetParsed 撤单入参
+type CancelBetParsed struct {
+	BillNo string `json:"bill_no"`
+	UserId int64  `json:"user_id"` // 演示模式下无认证信息时使用
+}
+
+func ParseCancelBetFromJSON(r io.Reader) (CancelBetParsed, bool, string) {
+	var out CancelBetParsed
+	if err := json.NewDecoder(r).Decode(&out); err != nil {
+		return CancelBetParsed{}, false, "invalid json body"
+	}
+	return out, true, ""
+}
+
+func ParseCancelBetFromForm(ctx *beegocontext.Context) (CancelBetParsed, bool, string) {
+	var out CancelBetParsed
+	out.BillNo = ctx.Input.Query("bill_no")
+	if s := ctx.Input.Query("user_id"); s != "" {
+		uid, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return CancelBetParsed{}, false, "user_id must be integer"
+		}
+		out.UserId = uid
+	}
+	return out, true, ""
+}
+
+func ValidateCancelBet(in *CancelBetParsed) (bool, string) {
+	in.BillNo = strings.TrimSpace(in.BillNo)
+	if in.BillNo == "" || len(in.BillNo) > 64 {
+		return false, "missing or invalid bill_no"
+	}
+	return true, ""
+}
+
+// ParseAndValidateCancelBet 解析并校验撤单请求
+func ParseAndValidateCancelBet(ctx *beegocontext.Context) (CancelBetParsed, bool, string) {
+	out, ok, msg := parseByContentType(ctx, ParseCancelBetFromJSON, ParseCancelBetFromForm)
+	if !ok {
+		return CancelBetParsed{}, false, msg
+	}
+	if ok, msg := ValidateCancelBet(&out); !ok {
+		return CancelBetParsed{}, false, msg
+	}
+	return out, true, ""
+}
+
+// -------- Transfer helpers --------
+
+// 平台转账单号：字母、数字、下划线、连字符
+var transferIDRe = regexp.MustCompile(`^[A-Za-z0-9_\-]{1,64}$`)
+
+// TransferParsed 平台上分/下分入参
+type TransferParsed struct {
+	TransferId string `json:"transfer_id"` // 平台转账单号（同一平台内唯一，幂等键）
+	Amount     string `json:"amount"`
+	Currency   string `json:"currency"` // 可选，默认 CNY
+	Remark     string `json:"remark"`
+	UserId     int64  `json:"user_id"` // 演示模式下无认证信息时使用
+}
+
+func ParseTransferFromJSON(r io.Reader) (TransferParsed, bool, string) {
+	var out TransferParsed
+	if err := json.NewDecoder(r).Decode(&out); err != nil {
+		return TransferParsed{}, false, "invalid json body"
+	}
+	return out, true, ""
+}
+
+func ParseTransferFromForm(ctx *beegocontext.Context) (TransferParsed, bool, string) {
+	var out TransferParsed
+	out.TransferId = ctx.Input.Query("transfer_id")
+	out.Amount = ctx.Input.Query("amount")
+	out.Currency = ctx.Input.Query("currency")
+	out.Remark = ctx.Input.Query("remark")
+	if s := ctx.Input.Query("user_id"); s != "" {
+		uid, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return TransferParsed{}, false, "user_id must be integer"
+		}
+		out.UserId = uid
+	}
+	return out, true, ""
+}
+
+func ValidateTransfer(in *TransferParsed) (bool, string) {
+	in.TransferId = strings.TrimSpace(in.TransferId)
+	if !IsValidTransferID(in.TransferId) {
+		return false, "missing or invalid transfer_id"
+	}
+	in.Amount = strings.TrimSpace(in.Amount)
+	if len(in.Amount) > 32 || !IsMoneyFormat(in.Amount) {
+		return false, "amount must be numeric with up to 2 decimals"
+	}
+	in.Currency = strings.ToUpper(strings.TrimSpace(in.Currency))
+	if in.Currency == "" {
+		in.Currency = "CNY"
+	}
+	if !currencyRe.MatchString(in.Currency) {
+		return false, "invalid currency"
+	}
+	if len(in.Remark) > 255 {
+		return false, "remark too long"
+	}
+	return true, ""
+}
+
+// IsValidTransferID 校验平台转账单号格式
+func IsValidTransferID(s string) bool {
+	return transferIDRe.MatchString(s)
+}
+
+// ParseAndValidateTransfer 解析并校验上分/下分请求
+func ParseAndValidateTransfer(ctx *beegocontext.Context) (TransferParsed, bool, string) {
+	out, ok, msg := parseByContentType(ctx, ParseTransferFromJSON, ParseTransferFromForm)
+	if !ok {
+		return TransferParsed{}, false, msg
+	}
+	if ok, msg := ValidateTransfer(&out); !ok {
+		return TransferParsed{}, false, msg
+	}
+	return out, true, ""
+}
+
 // -------- DrawResult helpers --------
 
-// IsValidCardList 校验 card_list 参数格式：
-// - 简化演示：允许 "dragon"|"tiger"|"tie"
-// - 兼容牌面格式：包含 D/T/R 字符（不做更细颗粒解析）
+// card_list 单个 token：<位置字母><点数>[花色] 或 R<结果>
+var cardTokenRe = regexp.MustCompile(`^(?i:[a-qs-z]\d{1,2}[shdc]?|r-?[a-z]+)$`)
+
+// IsValidCardList 校验 card_list 参数格式：逗号分隔的牌（D13h、P9c 等）与可选的 R 结果标记
+// 牌的位置前缀、张数与补牌规则由各游戏引擎在解析时校验
 func IsValidCardList(s string) bool {
-	ls := strings.ToLower(strings.TrimSpace(s))
-	if ls == "dragon" || ls == "tiger" || ls == "tie" {
-		return true
+	tokens := strings.Split(strings.TrimSpace(s), ",")
+	if len(tokens) < 2 {
+		return false
+	}
+	for _, tok := range tokens {
+		if !cardTokenRe.MatchString(strings.TrimSpace(tok)) {
+			return false
+		}
 	}
-	return strings.Contains(ls, "d") && strings.Contains(ls, "t") && strings.Contains(ls, "r")
+	return true
 }
 
 type DrawResultParsed struct {
@@ -205,6 +438,9 @@ type DrawResultParsed struct {
 	GameRoundId string `json:"game_round_id"`
 	CardList    string `json:"card_list"`
 	DrawTime    int64  `json:"draw_time"`
+	Override    bool   `json:"override"` // 人工覆盖服务端牌面
+	Operator    string `json:"operator"` // 操作人（override 时必填）
+	Source      string `json:"source"`   // 结果来源：manual（默认）或牌面扫描设备标识
 }
 
 func ParseDrawResultFromJSON(r io.Reader) (DrawResultParsed, bool, string) {
@@ -221,6 +457,11 @@ func ParseDrawResultFromForm(ctx *beegocontext.Context) (DrawResultParsed, bool,
 	out.RoomId = ctx.Input.Query("room_id")
 	out.GameRoundId = ctx.Input.Query("game_round_id")
 	out.CardList = ctx.Input.Query("card_list")
+	out.Operator = ctx.Input.Query("operator")
+	out.Source = ctx.Input.Query("source")
+	if v := strings.TrimSpace(ctx.Input.Query("override")); v != "" {
+		out.Override, _ = strconv.ParseBool(v)
+	}
 	if ts := strings.TrimSpace(ctx.Input.Query("draw_time")); ts != "" {
 		if v, err := strconv.ParseInt(ts, 10, 64); err == nil {
 			out.DrawTime = v
@@ -229,13 +470,26 @@ func ParseDrawResultFromForm(ctx *beegocontext.Context) (DrawResultParsed, bool,
 	return out, true, ""
 }
 
+// ValidateDrawResult 服务端牌靴模式下 card_list 可为空；override=true 时 card_list 与 operator 必填
 func ValidateDrawResult(in *DrawResultParsed) (bool, string) {
-	if in.GameRoundId == "" || len(in.CardList) == 0 {
+	if in.GameRoundId == "" {
 		return false, "invalid request"
 	}
 	if len(in.GameRoundId) > 64 {
 		return false, "invalid request"
 	}
+	if in.Override && (len(in.CardList) == 0 || strings.TrimSpace(in.Operator) == "") {
+		return false, "override requires card_list and operator"
+	}
+	if len(in.Operator) > 64 {
+		return false, "invalid operator"
+	}
+	if in.Source != "" && !drawSourceRe.MatchString(in.Source) {
+		return false, "invalid source"
+	}
+	if len(in.CardList) == 0 {
+		return true, ""
+	}
 	if len(in.CardList) > 256 {
 		return false, "invalid card_list"
 	}
@@ -245,16 +499,131 @@ func ValidateDrawResult(in *DrawResultParsed) (bool, string) {
 	return true, ""
 }
 
+// 开奖结果来源：manual 或扫描设备标识
+var drawSourceRe = regexp.MustCompile(`^[A-Za-z0-9_\-]{1,32}$`)
+
+// -------- DrawReview helpers --------
+
+// DrawReviewParsed 开奖复核（通过/驳回）参数；复核人优先取管理员认证身份
+type DrawReviewParsed struct {
+	PendingId int64  `json:"-"`
+	Operator  string `json:"operator"`
+	Reason    string `json:"reason"`
+}
+
+func ParseDrawReviewFromJSON(r io.Reader) (DrawReviewParsed, bool, string) {
+	var out DrawReviewParsed
+	// 请求体可为空（复核人取管理员认证身份）
+	if err := json.NewDecoder(r).Decode(&out); err != nil && err != io.EOF {
+		return DrawReviewParsed{}, false, "invalid request"
+	}
+	return out, true, ""
+}
+
+func ParseDrawReviewFromForm(ctx *beegocontext.Context) (DrawReviewParsed, bool, string) {
+	var out DrawReviewParsed
+	out.Operator = ctx.Input.Query("operator")
+	out.Reason = ctx.Input.Query("reason")
+	return out, true, ""
+}
+
+// ValidateDrawReview reject=true 时要求填写驳回原因
+func ValidateDrawReview(in *DrawReviewParsed, reject bool) (bool, string) {
+	if in.PendingId <= 0 {
+		return false, "invalid pending id"
+	}
+	if strings.TrimSpace(in.Operator) == "" || len(in.Operator) > 64 {
+		return false, "operator required"
+	}
+	if len(in.Reason) > 255 || (reject && strings.TrimSpace(in.Reason) == "") {
+		return false, "reason required (at most 255 bytes)"
+	}
+	return true, ""
+}
+
+// ParseAndValidateDrawReview 解析路径中的待复核记录ID与请求体；已认证的管理员身份覆盖请求中的 operator
+func ParseAndValidateDrawReview(ctx *beegocontext.Context, reject bool) (DrawReviewParsed, bool, string) {
+	out, ok, msg := parseByContentType(ctx, ParseDrawReviewFromJSON, ParseDrawReviewFromForm)
+	if !ok {
+		return DrawReviewParsed{}, false, msg
+	}
+	out.PendingId, _ = strconv.ParseInt(ctx.Input.Param(":id"), 10, 64)
+	if admin := GetAdmin(ctx); admin != "" {
+		out.Operator = admin
+	}
+	if ok, msg := ValidateDrawReview(&out, reject); !ok {
+		return DrawReviewParsed{}, false, msg
+	}
+	return out, true, ""
+}
+
+// -------- DrawCorrection helpers --------
+
+type DrawCorrectionParsed struct {
+	GameRoundId string `json:"game_round_id"`
+	CardList    string `json:"card_list"`
+	Operator    string `json:"operator"`
+	Reason      string `json:"reason"`
+}
+
+func ParseDrawCorrectionFromJSON(r io.Reader) (DrawCorrectionParsed, bool, string) {
+	var out DrawCorrectionParsed
+	if err := json.NewDecoder(r).Decode(&out); err != nil {
+		return DrawCorrectionParsed{}, false, "invalid request"
+	}
+	return out, true, ""
+}
+
+func ParseDrawCorrectionFromForm(ctx *beegocontext.Context) (DrawCorrectionParsed, bool, string) {
+	var out DrawCorrectionParsed
+	out.GameRoundId = ctx.Input.Query("game_round_id")
+	out.CardList = ctx.Input.Query("card_list")
+	out.Operator = ctx.Input.Query("operator")
+	out.Reason = ctx.Input.Query("reason")
+	return out, true, ""
+}
+
+func ValidateDrawCorrection(in *DrawCorrectionParsed) (bool, string) {
+	if in.GameRoundId == "" || len(in.GameRoundId) > 64 {
+		return false, "invalid request"
+	}
+	if strings.TrimSpace(in.Operator) == "" || len(in.Operator) > 64 {
+		return false, "operator required"
+	}
+	if strings.TrimSpace(in.Reason) == "" || len(in.Reason) > 255 {
+		return false, "reason required (at most 255 bytes)"
+	}
+	if len(in.CardList) == 0 || len(in.CardList) > 256 || !IsValidCardList(in.CardList) {
+		return false, "invalid card_list"
+	}
+	return true, ""
+}
+
+// ParseAndValidateDrawCorrection 按 Content-Type 自动解析并校验
+func ParseAndValidateDrawCorrection(ctx *beegocontext.Context) (DrawCorrectionParsed, bool, string) {
+	out, ok, msg := parseByContentType(ctx, ParseDrawCorrectionFromJSON, ParseDrawCorrectionFromForm)
+	if !ok {
+		return DrawCorrectionParsed{}, false, msg
+	}
+	if ok, msg := ValidateDrawCorrection(&out); !ok {
+		return DrawCorrectionParsed{}, false, msg
+	}
+	return out, true, ""
+}
+
 // -------- GameEvent helpers --------
 
 type GameEventParsed struct {
 	GameId      string `json:"game_id"`
 	RoomId      string `json:"room_id"`
 	GameRoundId string `json:"game_round_id"`
-	EventType   int    `json:"event_type"` // 仅支持数值：1=game_start 2=game_stop 3=new_card 4=game_draw 5=game_end
+	EventType   int    `json:"event_type"` // 仅支持数值：1=game_start 2=game_stop 3=new_card 4=game_draw 5=game_end 6=game_cancel
+	Reason      string `json:"reason"`     // 作废原因（仅 game_cancel 使用，可选）
+	EventId     string `json:"event_id"`   // 事件ID（可选，用于重复投递识别）
+	Seq         int64  `json:"seq"`        // 局内事件序号（可选，从 1 开始）
 }
 
-// ParseGameEventFromJSON 仅接受数值型 event_type（1..5）
+// ParseGameEventFromJSON 仅接受数值型 event_type（1..6）
 func ParseGameEventFromJSON(r io.Reader) (GameEventParsed, bool, string) {
 	var raw map[string]any
 	if err := json.NewDecoder(r).Decode(&raw); err != nil {
@@ -270,6 +639,15 @@ func ParseGameEventFromJSON(r io.Reader) (GameEventParsed, bool, string) {
 	if v, ok := raw["game_round_id"].(string); ok {
 		out.GameRoundId = v
 	}
+	if v, ok := raw["reason"].(string); ok {
+		out.Reason = v
+	}
+	if v, ok := raw["event_id"].(string); ok {
+		out.EventId = v
+	}
+	if v, ok := raw["seq"].(float64); ok {
+		out.Seq = int64(v)
+	}
 	// 仅当 event_type 为 JSON 数字时赋值
 	if v, ok := raw["event_type"].(float64); ok {
 		out.EventType = int(v)
@@ -305,6 +683,13 @@ func ParseGameEventFromForm(ctx *beegocontext.Context) (GameEventParsed, bool, s
 	out.GameId = ctx.Input.Query("game_id")
 	out.RoomId = ctx.Input.Query("room_id")
 	out.GameRoundId = ctx.Input.Query("game_round_id")
+	out.Reason = ctx.Input.Query("reason")
+	out.EventId = ctx.Input.Query("event_id")
+	if v := strings.TrimSpace(ctx.Input.Query("seq")); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			out.Seq = n
+		}
+	}
 	et := strings.TrimSpace(ctx.Input.Query("event_type"))
 	if et != "" {
 		if n, err := strconv.Atoi(et); err == nil {
@@ -321,8 +706,326 @@ func ValidateGameEvent(in *GameEventParsed) (bool, string) {
 	if len(in.GameRoundId) > 64 {
 		return false, "invalid request"
 	}
-	if in.EventType < 1 || in.EventType > 5 {
-		return false, "event_type must be one of: 1|2|3|4|5"
+	if in.EventType < 1 || in.EventType > 6 {
+		return false, "event_type must be one of: 1|2|3|4|5|6"
+	}
+	if len(in.Reason) > 255 {
+		return false, "reason too long"
+	}
+	if len(in.EventId) > 64 {
+		return false, "event_id too long"
+	}
+	if in.Seq < 0 {
+		return false, "seq must be positive"
+	}
+	return true, ""
+}
+
+// -------- ShoeChange helpers --------
+
+type ShoeChangeParsed struct {
+	GameId string `json:"game_id"`
+	RoomId string `json:"room_id"`
+	Reason string `json:"reason"`
+}
+
+func ParseShoeChangeFromJSON(r io.Reader) (ShoeChangeParsed, bool, string) {
+	var out ShoeChangeParsed
+	if err := json.NewDecoder(r).Decode(&out); err != nil {
+		return ShoeChangeParsed{}, false, "invalid request"
+	}
+	return out, true, ""
+}
+
+func ParseShoeChangeFromForm(ctx *beegocontext.Context) (ShoeChangeParsed, bool, string) {
+	var out ShoeChangeParsed
+	out.GameId = ctx.Input.Query("game_id")
+	out.RoomId = ctx.Input.Query("room_id")
+	out.Reason = ctx.Input.Query("reason")
+	return out, true, ""
+}
+
+func ValidateShoeChange(in *ShoeChangeParsed) (bool, string) {
+	if strings.TrimSpace(in.RoomId) == "" || len(in.RoomId) > 32 || len(in.GameId) > 32 {
+		return false, "invalid request"
+	}
+	if len(in.Reason) > 255 {
+		return false, "reason too long"
+	}
+	return true, ""
+}
+
+// ParseAndValidateShoeChange 按 Content-Type 自动解析并校验
+func ParseAndValidateShoeChange(ctx *beegocontext.Context) (ShoeChangeParsed, bool, string) {
+	out, ok, msg := parseByContentType(ctx, ParseShoeChangeFromJSON, ParseShoeChangeFromForm)
+	if !ok {
+		return ShoeChangeParsed{}, false, msg
+	}
+	if ok, msg := ValidateShoeChange(&out); !ok {
+		return ShoeChangeParsed{}, false, msg
+	}
+	return out, true, ""
+}
+
+// -------- Replay helpers --------
+
+// 按时间范围回放的最大跨度：7 天
+const maxReplayRangeMs = 7 * 24 * 3600 * 1000
+
+type ReplayParsed struct {
+	GameRoundId string `json:"game_round_id"`
+	From        int64  `json:"from"` // 毫秒时间戳
+	To          int64  `json:"to"`
+	Rebuild     bool   `json:"rebuild"`
+	Operator    string `json:"operator"`
+}
+
+func ParseReplayFromJSON(r io.Reader) (ReplayParsed, bool, string) {
+	var out ReplayParsed
+	if err := json.NewDecoder(r).Decode(&out); err != nil {
+		return ReplayParsed{}, false, "invalid request"
+	}
+	return out, true, ""
+}
+
+func ParseReplayFromForm(ctx *beegocontext.Context) (ReplayParsed, bool, string) {
+	var out ReplayParsed
+	out.GameRoundId = ctx.Input.Query("game_round_id")
+	out.From, _ = strconv.ParseInt(ctx.Input.Query("from"), 10, 64)
+	out.To, _ = strconv.ParseInt(ctx.Input.Query("to"), 10, 64)
+	out.Rebuild, _ = strconv.ParseBool(ctx.Input.Query("rebuild"))
+	out.Operator = ctx.Input.Query("operator")
+	return out, true, ""
+}
+
+func ValidateReplay(in *ReplayParsed) (bool, string) {
+	if len(in.GameRoundId) > 64 || len(in.Operator) > 64 {
+		return false, "invalid request"
+	}
+	if in.GameRoundId == "" {
+		if in.From <= 0 || in.To < in.From || in.To-in.From > maxReplayRangeMs {
+			return false, "game_round_id or from/to (at most 7 days) required"
+		}
+	}
+	if in.Rebuild && strings.TrimSpace(in.Operator) == "" {
+		return false, "operator required for rebuild"
+	}
+	return true, ""
+}
+
+// ParseAndValidateReplay 按 Content-Type 自动解析并校验
+func ParseAndValidateReplay(ctx *beegocontext.Context) (ReplayParsed, bool, string) {
+	out, ok, msg := parseByContentType(ctx, ParseReplayFromJSON, ParseReplayFromForm)
+	if !ok {
+		return ReplayParsed{}, false, msg
+	}
+	if ok, msg := ValidateReplay(&out); !ok {
+		return ReplayParsed{}, false, msg
+	}
+	return out, true, ""
+}
+
+// -------- Room helpers --------
+
+// 赔率格式校验：正数，最多四位小数
+var oddsRe = regexp.MustCompile(`^(?:0|[1-9]\d*)(?:\.\d{1,4})?$`)
+
+// 币种：3~8 位大写字母
+var currencyRe = regexp.MustCompile(`^[A-Z]{3,8}$`)
+
+type RoomLimitParsed struct {
+	Min string `json:"min"`
+	Max string `json:"max"`
+}
+
+type RoomParsed struct {
+	RoomId       string                     `json:"room_id"`
+	GameId       string                     `json:"game_id"`
+	RoomName     string                     `json:"room_name"`
+	BetWindowSec int                        `json:"bet_window_sec"`
+	BetLimits    map[string]RoomLimitParsed `json:"bet_limits"`
+	Odds         map[string]string          `json:"odds"`
+	Currency     string                     `json:"currency"`
+	Status       int                        `json:"status"` // 1=open 2=maintenance 3=closed
+	IsVip        bool                       `json:"is_vip"`
+	DrawApproval bool                       `json:"draw_approval"` // 开奖结果需复核
+	DrawScanners []string                   `json:"draw_scanners"` // 可自动比对的扫描设备标识
+	// 撤单：是否允许下注中撤单，以及封盘前多少秒停止撤单
+	CancelAllowed   bool `json:"cancel_allowed"`
+	CancelCutoffSec int  `json:"cancel_cutoff_sec"`
+	// 单局净赔付上限，空或 0 表示不限
+	LiabilityCap string `json:"liability_cap"`
+}
+
+func ParseRoomFromJSON(r io.Reader) (RoomParsed, bool, string) {
+	var out RoomParsed
+	if err := json.NewDecoder(r).Decode(&out); err != nil {
+		return RoomParsed{}, false, "invalid request"
+	}
+	return out, true, ""
+}
+
+// ParseRoomFromForm 房间配置包含嵌套的限红与赔率，仅支持 JSON
+func ParseRoomFromForm(ctx *beegocontext.Context) (RoomParsed, bool, string) {
+	return RoomParsed{}, false, "content-type must be application/json"
+}
+
+func ValidateRoom(in *RoomParsed) (bool, string) {
+	if strings.TrimSpace(in.RoomId) == "" || strings.TrimSpace(in.GameId) == "" {
+		return false, "room_id and game_id required"
+	}
+	if len(in.RoomId) > 32 || len(in.GameId) > 32 || len(in.RoomName) > 64 {
+		return false, "invalid request"
+	}
+	if in.BetWindowSec <= 0 || in.BetWindowSec > 600 {
+		return false, "bet_window_sec must be in 1..600"
+	}
+	if in.Status < 1 || in.Status > 3 {
+		return false, "status must be 1|2|3"
+	}
+	if in.CancelCutoffSec < 0 || in.CancelCutoffSec > in.BetWindowSec {
+		return false, "cancel_cutoff_sec must be in 0..bet_window_sec"
+	}
+	if in.LiabilityCap != "" && !IsMoneyFormat(in.LiabilityCap) {
+		return false, "liability_cap must be numeric with up to 2 decimals"
+	}
+	// 扫描设备标识与 source 规则一致，统一转小写并去重
+	if len(in.DrawScanners) > 8 {
+		return false, "at most 8 draw_scanners"
+	}
+	scanners := make([]string, 0, len(in.DrawScanners))
+	seen := make(map[string]bool, len(in.DrawScanners))
+	for _, id := range in.DrawScanners {
+		id = strings.ToLower(strings.TrimSpace(id))
+		if !drawSourceRe.MatchString(id) || id == model.PendingDrawSourceManual {
+			return false, "invalid draw_scanners entry: " + id
+		}
+		if !seen[id] {
+			seen[id] = true
+			scanners = append(scanners, id)
+		}
+	}
+	in.DrawScanners = scanners
+	if in.Currency == "" {
+		in.Currency = "CNY"
+	}
+	if !currencyRe.MatchString(in.Currency) {
+		return false, "invalid currency"
+	}
+	if len(in.Odds) == 0 {
+		return false, "odds required"
+	}
+	for pt, o := range in.Odds {
+		if model.PlayTypeCode(pt) == 0 {
+			return false, "unknown play type: " + pt
+		}
+		if !oddsRe.MatchString(o) {
+			return false, "odds must be numeric with up to 4 decimals"
+		}
+		l, ok := in.BetLimits[pt]
+		if !ok {
+			return false, "bet_limits of " + pt + " required"
+		}
+		if !IsMoneyFormat(l.Min) || !IsMoneyFormat(l.Max) {
+			return false, "bet_limits must be numeric with up to 2 decimals"
+		}
+	}
+	for pt := range in.BetLimits {
+		if _, ok := in.Odds[pt]; !ok {
+			return false, "odds of " + pt + " required"
+		}
 	}
 	return true, ""
 }
+
+// ParseAndValidateRoom 解析并校验房间配置；pathRoomID 非空时（修改接口）以路径参数为准，请求体中的 room_id 必须为空或一致
+func ParseAndValidateRoom(ctx *beegocontext.Context, pathRoomID string) (RoomParsed, bool, string) {
+	out, ok, msg := parseByContentType(ctx, ParseRoomFromJSON, ParseRoomFromForm)
+	if !ok {
+		return RoomParsed{}, false, msg
+	}
+	if pathRoomID != "" {
+		if out.RoomId != "" && out.RoomId != pathRoomID {
+			return RoomParsed{}, false, "room_id mismatch"
+		}
+		out.RoomId = pathRoomID
+	}
+	if ok, msg := ValidateRoom(&out); !ok {
+		return RoomParsed{}, false, msg
+	}
+	return out, true, ""
+}
+
+// -------- OddsSchedule helpers --------
+
+type OddsScheduleParsed struct {
+	GameId        string            `json:"game_id"`
+	RoomId        string            `json:"room_id"`     // 可选，空表示该游戏全部房间
+	PlatformId    *int              `json:"platform_id"` // 可选，不传表示全部平台
+	Odds          map[string]string `json:"odds"`
+	EffectiveFrom int64             `json:"effective_from"` // 毫秒时间戳，0 表示立即生效
+	Operator      string            `json:"operator"`
+	Remark        string            `json:"remark"`
+}
+
+func ParseOddsScheduleFromJSON(r io.Reader) (OddsScheduleParsed, bool, string) {
+	var out OddsScheduleParsed
+	if err := json.NewDecoder(r).Decode(&out); err != nil {
+		return OddsScheduleParsed{}, false, "invalid request"
+	}
+	return out, true, ""
+}
+
+// ParseOddsScheduleFromForm 赔率表包含嵌套的玩法赔率，仅支持 JSON
+func ParseOddsScheduleFromForm(ctx *beegocontext.Context) (OddsScheduleParsed, bool, string) {
+	return OddsScheduleParsed{}, false, "content-type must be application/json"
+}
+
+func ValidateOddsSchedule(in *OddsScheduleParsed) (bool, string) {
+	if strings.TrimSpace(in.GameId) == "" || len(in.GameId) > 32 || len(in.RoomId) > 32 {
+		return false, "game_id required"
+	}
+	if in.PlatformId != nil && (*in.PlatformId < 1 || *in.PlatformId > 127) {
+		return false, "platform_id must be in 1..127"
+	}
+	if in.EffectiveFrom < 0 {
+		return false, "invalid effective_from"
+	}
+	if strings.TrimSpace(in.Operator) == "" || len(in.Operator) > 64 {
+		return false, "operator required"
+	}
+	if len(in.Remark) > 255 {
+		return false, "remark too long (at most 255 bytes)"
+	}
+	if len(in.Odds) == 0 {
+		return false, "odds required"
+	}
+	odds := make(map[string]string, len(in.Odds))
+	for pt, o := range in.Odds {
+		pt = strings.ToLower(strings.TrimSpace(pt))
+		if model.PlayTypeCode(pt) == 0 {
+			return false, "unknown play type: " + pt
+		}
+		if !oddsRe.MatchString(o) {
+			return false, "odds must be numeric with up to 4 decimals"
+		}
+		odds[pt] = o
+	}
+	in.Odds = odds
+	return true, ""
+}
+
+// ParseAndValidateOddsSchedule 解析并校验赔率版本；已认证的管理员身份覆盖请求中的 operator
+func ParseAndValidateOddsSchedule(ctx *beegocontext.Context) (OddsScheduleParsed, bool, string) {
+	out, ok, msg := parseByContentType(ctx, ParseOddsScheduleFromJSON, ParseOddsScheduleFromForm)
+	if !ok {
+		return OddsScheduleParsed{}, false, msg
+	}
+	if admin := GetAdmin(ctx); admin != "" {
+		out.Operator = admin
+	}
+	if ok, msg := ValidateOddsSchedule(&out); !ok {
+		return OddsScheduleParsed{}, false, msg
+	}
+	return out, true, ""
+}
@@ -30,6 +30,28 @@ const (
 	CodeInsufficientBalance = 2007 // 余额不足
 	CodeInvalidStateDraw    = 2008 // 开奖状态不允许
 	CodeInvalidStateGameEnd = 2009 // 游戏结束状态不允许
+	CodeRoundAlreadySettled = 2010 // 牌局已结算，不能作废
+	CodeManualCardsDenied   = 2011 // 服务端发牌模式下不允许直接录入牌面
+	CodeShoeChangeInRound   = 2012 // 牌局进行中不能换靴
+	CodeRoomNotOpen         = 2013 // 房间维护中或已关闭
+	CodeEventOutOfOrder     = 2014 // 事件序号超前（前序事件未到达）
+	CodeEventConflict       = 2015 // 事件ID或序号已被其他事件占用
+	CodeCorrectionDenied    = 2016 // 牌局未结算或已作废，不能更正开奖结果
+	CodeNegativeBalance     = 2017 // 更正将导致用户余额为负
+	CodeDrawMismatch        = 2018 // 两路扫描牌面不一致，已阻断结算
+	CodeSameApprover        = 2019 // 复核人与提交人相同
+	CodePendingDrawDecided  = 2020 // 开奖结果已复核或已驳回
+	CodeBetCancelDisabled   = 2021 // 房间不允许撤单
+	CodeBetCancelClosed     = 2022 // 已过撤单截止时间
+	CodeBetNotCancellable   = 2023 // 注单已结算或已取消
+	CodeExposureLimit       = 2024 // 超出单局赔付上限
+	CodeCurrencyNotAllowed  = 2025 // 币种不可用或与房间币种不一致
+	CodeWalletUnavailable   = 2026 // 平台钱包暂不可用（seamless 模式扣款结果未知）
+	CodeWalletRejected      = 2027 // 平台钱包拒绝扣款
+	CodeWithdrawNotAllowed  = 2028 // 用户禁止提款
+	CodeTransferConflict    = 2029 // 转账单号已使用且参数不一致
+	CodeTransferNotAllowed  = 2030 // 单一钱包模式不支持转账
+	CodeExposureUnavailable = 2031 // 赔付敞口暂无法校验（Redis 不可用）
 	CodeUnauthorized        = 3000 // 未授权
 	CodeInvalidToken        = 3001 // Token 无效
 	CodeTokenExpired        = 3002 // Token 过期
@@ -56,10 +78,32 @@ var ErrorMessages = map[int]string{
 	CodeInvalidState:        "当前状态不允许此操作",
 	CodeBetWindowNotStart:   "投注窗口未开始",
 	CodeBetWindowClosed:     "投注窗口已关闭",
-	CodeConflictingBet:      "不能同时投注龙和虎",
+	CodeConflictingBet:      "不能同时投注龙和虎（闲和庄）",
 	CodeInsufficientBalance: "余额不足",
 	CodeInvalidStateDraw:    "当前状态不允许开奖",
 	CodeInvalidStateGameEnd: "游戏尚未开奖，不能结束",
+	CodeRoundAlreadySettled: "牌局已结算，不能作废",
+	CodeManualCardsDenied:   "本局由服务端发牌，人工录入牌面需使用覆盖模式",
+	CodeShoeChangeInRound:   "牌局进行中，不能换靴",
+	CodeRoomNotOpen:         "房间维护中或已关闭",
+	CodeEventOutOfOrder:     "事件序号超前，请先补发前序事件",
+	CodeEventConflict:       "事件ID或序号已被其他事件使用",
+	CodeCorrectionDenied:    "牌局未结算或已作废，不能更正开奖结果",
+	CodeNegativeBalance:     "扣回原派彩后用户余额将为负，已拒绝更正",
+	CodeDrawMismatch:        "两路扫描牌面不一致，已阻断结算，请驳回后重新提交",
+	CodeSameApprover:        "开奖结果须由另一名管理员复核",
+	CodePendingDrawDecided:  "该开奖结果已复核或已驳回",
+	CodeBetCancelDisabled:   "本房间不允许撤单",
+	CodeBetCancelClosed:     "已过撤单截止时间",
+	CodeBetNotCancellable:   "注单已结算或已取消，不能撤单",
+	CodeExposureLimit:       "超出本桌单局赔付上限，请调整投注",
+	CodeCurrencyNotAllowed:  "币种不可用或与房间币种不一致",
+	CodeWalletUnavailable:   "平台钱包暂不可用，注单未确认，请稍后查询",
+	CodeWalletRejected:      "平台钱包拒绝扣款",
+	CodeWithdrawNotAllowed:  "该用户禁止提款",
+	CodeTransferConflict:    "转账单号已使用且参数不一致",
+	CodeTransferNotAllowed:  "平台为单一钱包模式，不支持转账",
+	CodeExposureUnavailable: "赔付上限暂无法校验，请稍后重试",
 	CodeNotFound:            "资源不存在",
 	CodeSystemError:         "系统繁忙，请稍后重试",
 }
@@ -67,8 +67,11 @@ type Config struct {
 			Issuer          string `yaml:"issuer" json:"issuer"`
 		} `yaml:"jwt" json:"jwt"`
 		Admin struct {
-			Enabled bool   `yaml:"enabled" json:"enabled"`
-			Token   string `yaml:"token" json:"token"`
+			Enabled bool        `yaml:"enabled" json:"enabled"`
+			Token   string      `yaml:"token" json:"token"` // 共享 Token，认证后的管理员身份为 "admin"
+			Users   []AdminUser `yaml:"users" json:"users"` // 具名管理员（开奖复核要求提交人与复核人为不同管理员）
+			// 牌面扫描设备，认证后的身份为 scan:<id>，只能提交开奖结果（POST /api/drawresult）
+			Scanners []AdminScanner `yaml:"scanners" json:"scanners"`
 		} `yaml:"admin" json:"admin"`
 		DemoPlatform struct {
 			PlatformID int8   `yaml:"platform_id" json:"platform_id"`
@@ -115,6 +118,89 @@ type Config struct {
 	// 第一步动态配置：功能开关与业务阈值
 	FeatureFlags map[string]bool  `yaml:"feature_flags" json:"feature_flags"`
 	Thresholds   map[string]int64 `yaml:"thresholds" json:"thresholds"`
+
+	// 自动开局调度（按房间驱动游戏事件）
+	Scheduler SchedulerConfig `yaml:"scheduler" json:"scheduler"`
+
+	// 服务端牌靴发牌
+	Shoe ShoeConfig `yaml:"shoe" json:"shoe"`
+
+	// 卡局巡检
+	Watchdog WatchdogConfig `yaml:"watchdog" json:"watchdog"`
+
+	// 开奖结果更正
+	Correction CorrectionConfig `yaml:"correction" json:"correction"`
+
+	// 币种：金额精度与单注限额；未配置时不限制币种，金额精度 2 位
+	Currencies []CurrencyConfig `yaml:"currencies" json:"currencies"`
+}
+
+// CurrencyConfig 币种配置
+// 单注限额对该币种的所有下注生效（与房间玩法限红同时校验），0 表示不限
+type CurrencyConfig struct {
+	Code      string  `yaml:"code" json:"code"`           // 币种代码，如 CNY、USD、JPY
+	Precision int     `yaml:"precision" json:"precision"` // 金额小数位数 0~2（金额按 DECIMAL(18,2) 存储）
+	MinBet    float64 `yaml:"min_bet" json:"min_bet"`     // 单注最小金额
+	MaxBet    float64 `yaml:"max_bet" json:"max_bet"`     // 单注最大金额
+}
+
+// 开奖结果更正时的负余额策略
+const (
+	NegativeBalanceReject = "reject" // 扣回原派彩后余额为负的用户存在时拒绝更正（默认）
+	NegativeBalanceAllow  = "allow"  // 允许余额为负，由运营线下追缴
+)
+
+// AdminUser 具名管理员，认证通过后以 Name 作为操作人身份
+type AdminUser struct {
+	Name  string `yaml:"name" json:"name"`
+	Token string `yaml:"token" json:"token"`
+}
+
+// AdminScanner 牌面扫描设备凭据；ID 即开奖结果的 source，房间 draw_scanners 中的设备之间自动比对
+type AdminScanner struct {
+	ID    string `yaml:"id" json:"id"`
+	Token string `yaml:"token" json:"token"`
+}
+
+// CorrectionConfig 开奖结果更正配置
+type CorrectionConfig struct {
+	NegativeBalance string `yaml:"negative_balance" json:"negative_balance"` // reject | allow，默认 reject
+}
+
+// WatchdogConfig 卡局巡检配置
+// 各状态的卡住阈值（秒）为动态阈值，见 config.GetThreshold：
+// round_stuck_betting_sec（超过 bet_stop_time）、round_stuck_sealed_sec、round_stuck_dealt_sec、round_stuck_drawn_sec
+type WatchdogConfig struct {
+	Enabled     bool `yaml:"enabled" json:"enabled"`
+	IntervalSec int  `yaml:"interval_sec" json:"interval_sec"`   // 扫描间隔（秒），默认 10
+	AutoSeal    bool `yaml:"auto_seal" json:"auto_seal"`         // 下注中超时后自动封盘（game_stop）
+	AutoVoidSec int  `yaml:"auto_void_sec" json:"auto_void_sec"` // 卡住超过该秒数后自动作废并退款（0=不作废）
+}
+
+// ShoeConfig 服务端牌靴配置
+// 启用后 new_card 由服务端从房间牌靴发牌，开奖只能使用服务端牌面；人工录入需显式 override
+// ProvablyFair 开启后每局牌面由 game_start 时承诺的 server_seed 派生（每局独立的虚拟整靴），不再使用房间物理牌靴
+type ShoeConfig struct {
+	Enabled      bool `yaml:"enabled" json:"enabled"`
+	Decks        int  `yaml:"decks" json:"decks"` // 副数，默认 8
+	ProvablyFair bool `yaml:"provably_fair" json:"provably_fair"`
+}
+
+// SchedulerConfig 自动开局调度配置
+type SchedulerConfig struct {
+	Enabled bool                  `yaml:"enabled" json:"enabled"`
+	TickMs  int                   `yaml:"tick_ms" json:"tick_ms"` // 调度节拍（毫秒），默认 500
+	Rooms   []SchedulerRoomConfig `yaml:"rooms" json:"rooms"`
+}
+
+// SchedulerRoomConfig 单个房间的调度节奏（单位：秒，0 表示使用默认值）
+type SchedulerRoomConfig struct {
+	GameID            string `yaml:"game_id" json:"game_id"`
+	RoomID            string `yaml:"room_id" json:"room_id"`
+	DealDelaySec      int    `yaml:"deal_delay_sec" json:"deal_delay_sec"`             // 封盘后多久触发 new_card
+	DrawDelaySec      int    `yaml:"draw_delay_sec" json:"draw_delay_sec"`             // 发牌后多久触发 game_draw
+	EndDelaySec       int    `yaml:"end_delay_sec" json:"end_delay_sec"`               // 结算后多久触发 game_end
+	NextRoundDelaySec int    `yaml:"next_round_delay_sec" json:"next_round_delay_sec"` // 结束后多久开下一局
 }
 
 // PlatformConfig 平台配置
@@ -126,8 +212,21 @@ type PlatformConfig struct {
 	Status     int8     `yaml:"status" json:"status"`
 	RateLimit  int      `yaml:"rate_limit" json:"rate_limit"`
 	AllowedIPs []string `yaml:"allowed_ips" json:"allowed_ips"`
+	Currencies []string `yaml:"currencies" json:"currencies"` // 平台玩家可使用的币种，空表示不限制
+
+	// 钱包模式：transfer（缺省）由本服务维护余额，平台通过转账充值；
+	// seamless 下注时调用平台钱包扣款，结算/退款/撤单调用平台入账，本服务不维护余额
+	WalletMode      string `yaml:"wallet_mode" json:"wallet_mode"`
+	WalletURL       string `yaml:"wallet_url" json:"wallet_url"`               // 平台钱包接口地址（seamless 必填），如 http://127.0.0.1:9300/wallet
+	WalletTimeoutMs int    `yaml:"wallet_timeout_ms" json:"wallet_timeout_ms"` // 单次调用超时（毫秒），缺省 3000
 }
 
+// 平台钱包模式
+const (
+	WalletModeTransfer = "transfer"
+	WalletModeSeamless = "seamless"
+)
+
 // Load 优先从 Nacos 配置中心读取配置，如果失败则从本地文件读取（兜底）
 // 支持以下环境变量：
 //   - NACOS_SERVER_ADDR: Nacos 服务器地址（如 "127.0.0.1:8848"，如果设置则优先从 Nacos 加载）
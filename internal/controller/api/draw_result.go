@@ -24,9 +24,16 @@ type DrawResultRequestParam struct {
 	CardList string `json:"card_list"`
 	// 扩展一个游戏结果生成的时间戳
 	DrawTime int64 `json:"draw_time"`
+	// 服务端牌靴模式下 card_list 可不传；人工覆盖服务端牌面需 override=true 并填写 operator（会记录审计）
+	Override bool   `json:"override"`
+	Operator string `json:"operator"`
+	// 结果来源：manual（默认）或牌面扫描设备标识。扫描设备以专用 Token 认证，source 取自认证身份（此处须为空或一致）；
+	// 房间开启开奖复核时，draw_scanners 白名单内两台不同设备的结果自动比对
+	Source string `json:"source"`
 }
 
 // Drawresult 人工开奖接口：POST /api/drawresult
+// 房间开启开奖复核（draw_approval）时不立即结算，返回 status=pending 与待复核记录ID
 func (c *DrawResultController) Drawresult() {
 	dp, ok, msg := helper.ParseAndValidateDrawResult(c.Ctx)
 	if !ok {
@@ -36,13 +43,26 @@ func (c *DrawResultController) Drawresult() {
 
 	svc := newDrawService()
 	traceID := helper.GetTraceID(c.Ctx)
-	if err := svc.SubmitDrawResult(c.Ctx.Request.Context(), service.DrawInput{
+	sub, err := svc.SubmitDrawResult(c.Ctx.Request.Context(), service.DrawInput{
 		GameID:      dp.GameId,
 		RoomID:      dp.RoomId,
 		GameRoundID: dp.GameRoundId,
 		CardList:    dp.CardList,
+		Override:    dp.Override,
+		Operator:    dp.Operator,
+		Source:      dp.Source,
+		Submitter:   helper.GetAdmin(c.Ctx),
 		TraceID:     traceID,
-	}); err != nil {
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrDrawResultMismatch) {
+			response.Conflict(&c.Controller, response.CodeDrawMismatch, traceID)
+			return
+		}
+		if errors.Is(err, service.ErrPendingDrawExists) {
+			response.Conflict(&c.Controller, response.CodeDuplicateKey, traceID)
+			return
+		}
 		if errors.Is(err, service.ErrInvalidStateDraw) {
 			response.Conflict(&c.Controller, response.CodeInvalidStateDraw, traceID)
 			return
@@ -51,8 +71,17 @@ func (c *DrawResultController) Drawresult() {
 			response.NotFound(&c.Controller, "游戏回合不存在", traceID)
 			return
 		}
+		if errors.Is(err, service.ErrManualCardsNotAllowed) {
+			response.Conflict(&c.Controller, response.CodeManualCardsDenied, traceID)
+			return
+		}
 		if errors.Is(err, service.ErrBadRequest) {
-			response.BadRequest(&c.Controller, "invalid request", traceID)
+			response.BadRequest(&c.Controller, err.Error(), traceID)
+			return
+		}
+		// 存在红黑/同花和注单但牌面未带花色
+		if errors.Is(err, service.ErrCardSuitRequired) {
+			response.BadRequest(&c.Controller, err.Error(), traceID)
 			return
 		}
 		// 处理卡牌格式和结果验证错误（新增）
@@ -65,5 +94,128 @@ func (c *DrawResultController) Drawresult() {
 		response.InternalError(&c.Controller, traceID)
 		return
 	}
+	response.Success(&c.Controller, sub, traceID)
+}
+
+// Correct 开奖结果更正接口：POST /api/admin/drawresult/correct
+// 冲正原派彩并按新牌面重新结算，返回新的结算版本与派彩汇总
+func (c *DrawResultController) Correct() {
+	traceID := helper.GetTraceID(c.Ctx)
+	req, ok, msg := helper.ParseAndValidateDrawCorrection(c.Ctx)
+	if !ok {
+		response.BadRequest(&c.Controller, msg, traceID)
+		return
+	}
+	res, err := newDrawService().CorrectDrawResult(c.Ctx.Request.Context(), service.CorrectionInput{
+		GameRoundID: req.GameRoundId,
+		CardList:    req.CardList,
+		Operator:    req.Operator,
+		Reason:      req.Reason,
+		TraceID:     traceID,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrGameRoundNotFound):
+			response.NotFound(&c.Controller, "游戏回合不存在", traceID)
+		case errors.Is(err, service.ErrCorrectionNotAllowed):
+			response.Conflict(&c.Controller, response.CodeCorrectionDenied, traceID)
+		case errors.Is(err, service.ErrCorrectionNegativeBalance):
+			response.Conflict(&c.Controller, response.CodeNegativeBalance, traceID)
+		case errors.Is(err, service.ErrCorrectionUnchanged), errors.Is(err, service.ErrBadRequest):
+			response.BadRequest(&c.Controller, err.Error(), traceID)
+		case errors.Is(err, service.ErrCardSuitRequired),
+			strings.Contains(err.Error(), "invalid card list format"):
+			response.BadRequest(&c.Controller, err.Error(), traceID)
+		default:
+			response.InternalError(&c.Controller, traceID)
+		}
+		return
+	}
+	response.Success(&c.Controller, res, traceID)
+}
+
+// Pending 开奖复核列表：GET /api/admin/drawresult/pending?round_id=
+// 指定 round_id 时返回该局全部提交（含已结案），否则返回全部待复核与扫描不一致的提交
+func (c *DrawResultController) Pending() {
+	traceID := helper.GetTraceID(c.Ctx)
+	roundID := strings.TrimSpace(c.Ctx.Input.Query("round_id"))
+	if len(roundID) > 64 {
+		response.BadRequest(&c.Controller, "invalid round_id", traceID)
+		return
+	}
+	list, err := newDrawService().ListPendingDraws(c.Ctx.Request.Context(), roundID)
+	if err != nil {
+		response.InternalError(&c.Controller, traceID)
+		return
+	}
+	response.Success(&c.Controller, list, traceID)
+}
+
+// Approve 复核通过并结算：POST /api/admin/drawresult/pending/:id/approve
+// 复核人取管理员认证身份，须与提交人不同（共享管理员 Token 的身份均为 admin，需配置具名管理员）
+func (c *DrawResultController) Approve() {
+	traceID := helper.GetTraceID(c.Ctx)
+	req, ok, msg := helper.ParseAndValidateDrawReview(c.Ctx, false)
+	if !ok {
+		response.BadRequest(&c.Controller, msg, traceID)
+		return
+	}
+	sub, err := newDrawService().ApproveDrawResult(c.Ctx.Request.Context(), service.DrawReviewInput{
+		PendingID: req.PendingId,
+		Checker:   req.Operator,
+		TraceID:   traceID,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrCardSuitRequired),
+			strings.Contains(err.Error(), "invalid card list format"):
+			response.BadRequest(&c.Controller, err.Error(), traceID)
+		default:
+			c.handleReviewError(err, traceID)
+		}
+		return
+	}
+	response.Success(&c.Controller, sub, traceID)
+}
+
+// Reject 驳回开奖结果：POST /api/admin/drawresult/pending/:id/reject
+// 扫描不一致的记录须驳回后才能重新提交
+func (c *DrawResultController) Reject() {
+	traceID := helper.GetTraceID(c.Ctx)
+	req, ok, msg := helper.ParseAndValidateDrawReview(c.Ctx, true)
+	if !ok {
+		response.BadRequest(&c.Controller, msg, traceID)
+		return
+	}
+	if err := newDrawService().RejectDrawResult(c.Ctx.Request.Context(), service.DrawReviewInput{
+		PendingID: req.PendingId,
+		Checker:   req.Operator,
+		Reason:    req.Reason,
+		TraceID:   traceID,
+	}); err != nil {
+		c.handleReviewError(err, traceID)
+		return
+	}
 	response.Success(&c.Controller, nil, traceID)
 }
+
+func (c *DrawResultController) handleReviewError(err error, traceID string) {
+	switch {
+	case errors.Is(err, service.ErrPendingDrawNotFound):
+		response.NotFound(&c.Controller, "待复核记录不存在", traceID)
+	case errors.Is(err, service.ErrPendingDrawDecided):
+		response.Conflict(&c.Controller, response.CodePendingDrawDecided, traceID)
+	case errors.Is(err, service.ErrSameApprover):
+		response.Conflict(&c.Controller, response.CodeSameApprover, traceID)
+	case errors.Is(err, service.ErrDrawResultMismatch):
+		response.Conflict(&c.Controller, response.CodeDrawMismatch, traceID)
+	case errors.Is(err, service.ErrInvalidStateDraw):
+		response.Conflict(&c.Controller, response.CodeInvalidStateDraw, traceID)
+	case errors.Is(err, service.ErrGameRoundNotFound):
+		response.NotFound(&c.Controller, "游戏回合不存在", traceID)
+	case errors.Is(err, service.ErrBadRequest):
+		response.BadRequest(&c.Controller, err.Error(), traceID)
+	default:
+		response.InternalError(&c.Controller, traceID)
+	}
+}
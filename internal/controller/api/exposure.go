@@ -0,0 +1,46 @@
+package api
+
+import (
+	"errors"
+
+	helper "dt-server/internal/common/helper"
+	"dt-server/internal/common/response"
+	"dt-server/internal/service"
+
+	beego "github.com/beego/beego/v2/server/web"
+)
+
+var newExposureService = service.NewExposureService
+
+// ExposureController 单局赔付敞口接口（管理员认证）
+//
+//	GET  /api/admin/exposure/:round_id  查询各玩法下注额、潜在派彩与各结果净赔付
+//	POST /api/admin/exposure/:round_id  按 orders 重算并覆盖 Redis 中的敞口（对账）
+type ExposureController struct{ beego.Controller }
+
+func (c *ExposureController) Get() {
+	c.serve(false)
+}
+
+func (c *ExposureController) Reconcile() {
+	c.serve(true)
+}
+
+func (c *ExposureController) serve(reconcile bool) {
+	traceID := helper.GetTraceID(c.Ctx)
+	roundID := c.Ctx.Input.Param(":round_id")
+	if roundID == "" || len(roundID) > 64 {
+		response.BadRequest(&c.Controller, "round_id is required", traceID)
+		return
+	}
+	out, err := newExposureService().GetRoundExposure(c.Ctx.Request.Context(), roundID, reconcile, traceID)
+	if err != nil {
+		if errors.Is(err, service.ErrGameRoundNotFound) {
+			response.NotFound(&c.Controller, err.Error(), traceID)
+			return
+		}
+		response.InternalError(&c.Controller, traceID)
+		return
+	}
+	response.Success(&c.Controller, out, traceID)
+}
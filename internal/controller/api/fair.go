@@ -0,0 +1,38 @@
+package api
+
+import (
+	"errors"
+
+	helper "dt-server/internal/common/helper"
+	"dt-server/internal/common/response"
+	"dt-server/internal/service"
+
+	beego "github.com/beego/beego/v2/server/web"
+)
+
+var newFairService = service.NewFairService
+
+// FairController 可验证公平核对接口（公开，无需认证）
+// GET /api/fair/verify/:round_id
+// 种子公布前仅返回 seed_hash；牌靴换下后（旧版按局种子为结算/结束/作废后）返回 server_seed 以及由种子复算的牌面
+type FairController struct{ beego.Controller }
+
+func (c *FairController) Verify() {
+	traceID := helper.GetTraceID(c.Ctx)
+	roundID := c.Ctx.Input.Param(":round_id")
+	if roundID == "" || len(roundID) > 64 {
+		response.BadRequest(&c.Controller, "round_id is required", traceID)
+		return
+	}
+
+	res, err := newFairService().Verify(c.Ctx.Request.Context(), roundID)
+	if err != nil {
+		if errors.Is(err, service.ErrGameRoundNotFound) || errors.Is(err, service.ErrRoundNotProvablyFair) {
+			response.NotFound(&c.Controller, err.Error(), traceID)
+			return
+		}
+		response.InternalError(&c.Controller, traceID)
+		return
+	}
+	response.Success(&c.Controller, res, traceID)
+}
@@ -10,6 +10,7 @@ import (
 	"dt-server/internal/service"
 
 	beego "github.com/beego/beego/v2/server/web"
+	beegocontext "github.com/beego/beego/v2/server/web/context"
 
 	mysqlerr "github.com/go-sql-driver/mysql"
 )
@@ -25,7 +26,7 @@ type BetRequestParam struct {
 	GameRoundId string `json:"game_round_id"` // 局ID
 	UserId      int64  `json:"user_id"`       // 用户ID
 	BetAmount   string `json:"bet_amount"`    // 投注金额
-	PlayType    int    `json:"play_type"`     // 下注玩法 1=dragon2=tiger3=tie
+	PlayType    int    `json:"play_type"`     // 下注玩法 1=dragon2=tiger3=tie，4~16 为边注，17=player18=banker
 	// Platform    int    `json:"platform"`      // 平台 1:测试演示 2.
 	/*
 		幂等键：客户端生成并随请求传入，用于在网络重试/超时重发/服务端重试时保证“同一业务请求只生效一次”。
@@ -57,33 +58,7 @@ func (c *BetController) Bet() {
 
 	svc := newBetService()
 	traceID := helper.GetTraceID(c.Ctx)
-
-	// 从 context 提取平台信息（由认证中间件注入）
-	platformID := int8(0)
-	platformUserID := ""
-	platformUserName := ""
-
-	if v := c.Ctx.Input.GetData("platform_id"); v != nil {
-		if pid, ok := v.(int8); ok {
-			platformID = pid
-		}
-	}
-	if v := c.Ctx.Input.GetData("platform_user_id"); v != nil {
-		if puid, ok := v.(string); ok {
-			platformUserID = puid
-		}
-	}
-	if v := c.Ctx.Input.GetData("platform_user_name"); v != nil {
-		if pname, ok := v.(string); ok {
-			platformUserName = pname
-		}
-	}
-
-	// 如果中间件未注入平台信息，使用请求中的 user_id
-	if platformUserID == "" && bp.UserId != 0 {
-		platformID = 0 // 系统默认平台
-		platformUserID = strconv.FormatInt(bp.UserId, 10)
-	}
+	platformID, platformUserID, platformUserName := c.platformUser(bp.UserId)
 
 	// 进行投注业务逻辑处理
 	out, err := svc.PlaceBet(c.Ctx.Request.Context(), service.BetInput{
@@ -95,61 +70,12 @@ func (c *BetController) Bet() {
 		PlatformUserName: platformUserName,
 		BetAmount:        bp.BetAmount,
 		PlayType:         bp.PlayType,
+		Currency:         bp.Currency,
 		IdempotencyKey:   bp.IdempotencyKey,
 		TraceID:          traceID,
 	})
 	if err != nil {
-		// MySQL 唯一键冲突
-		if me, ok := err.(*mysqlerr.MySQLError); ok && me.Number == 1062 {
-			response.Conflict(&c.Controller, response.CodeDuplicateKey, traceID)
-			return
-		}
-		// 重复请求进行中
-		if errors.Is(err, service.ErrDuplicateInFlight) {
-			response.Accepted(&c.Controller, "重复请求进行中，请稍后重试", traceID)
-			return
-		}
-		// 状态不允许投注
-		if errors.Is(err, service.ErrInvalidStateBet) {
-			response.Conflict(&c.Controller, response.CodeInvalidState, traceID)
-			return
-		}
-		// 投注窗口未开始
-		if errors.Is(err, service.ErrBetWindowNotStart) {
-			response.Conflict(&c.Controller, response.CodeBetWindowNotStart, traceID)
-			return
-		}
-		// 投注窗口已关闭
-		if errors.Is(err, service.ErrBetWindowClosed) {
-			response.Conflict(&c.Controller, response.CodeBetWindowClosed, traceID)
-			return
-		}
-		// 冲突投注（同时投注龙和虎）
-		if errors.Is(err, service.ErrConflictingPlayTypes) {
-			response.Conflict(&c.Controller, response.CodeConflictingBet, traceID)
-			return
-		}
-		// 投注金额验证失败
-		errMsg := err.Error()
-		if strings.Contains(errMsg, "invalid bet amount") ||
-			strings.Contains(errMsg, "bet amount must be positive") ||
-			strings.Contains(errMsg, "below minimum limit") ||
-			strings.Contains(errMsg, "exceeds maximum limit") {
-			response.BadRequest(&c.Controller, errMsg, traceID)
-			return
-		}
-		// 余额不足
-		if strings.Contains(errMsg, "insufficient balance") {
-			response.BadRequest(&c.Controller, "余额不足", traceID)
-			return
-		}
-		// 用户状态异常
-		if strings.Contains(errMsg, "user disabled") {
-			response.BadRequest(&c.Controller, "用户状态异常", traceID)
-			return
-		}
-		// 系统错误
-		response.InternalError(&c.Controller, traceID)
+		c.handleBetError(err, traceID)
 		return
 	}
 
@@ -157,5 +83,207 @@ func (c *BetController) Bet() {
 	response.Success(&c.Controller, map[string]interface{}{
 		"bill_no":       out.BillNo,
 		"remain_amount": out.RemainAmount,
+		"currency":      out.Currency,
 	}, traceID)
 }
+
+// Batch 批量投注接口：POST /api/bets/batch
+// 同一局的多个玩法（如龙+和）共用一个幂等键，在一个事务内整体成功或失败，返回全部注单号
+func (c *BetController) Batch() {
+	bp, ok, msg := helper.ParseAndValidateBatchBet(c.Ctx)
+	if !ok {
+		response.BadRequest(&c.Controller, msg, helper.GetTraceID(c.Ctx))
+		return
+	}
+	traceID := helper.GetTraceID(c.Ctx)
+	platformID, platformUserID, platformUserName := c.platformUser(bp.UserId)
+
+	items := make([]service.BetItem, 0, len(bp.Bets))
+	for _, b := range bp.Bets {
+		items = append(items, service.BetItem{PlayType: b.PlayType, BetAmount: b.BetAmount})
+	}
+	out, err := newBetService().PlaceBets(c.Ctx.Request.Context(), service.BatchBetInput{
+		GameID:           bp.GameId,
+		RoomID:           bp.RoomId,
+		GameRoundID:      bp.GameRoundId,
+		PlatformID:       platformID,
+		PlatformUserID:   platformUserID,
+		PlatformUserName: platformUserName,
+		Items:            items,
+		Currency:         bp.Currency,
+		IdempotencyKey:   bp.IdempotencyKey,
+		TraceID:          traceID,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrDuplicatePlayType) || errors.Is(err, service.ErrBadRequest) {
+			response.BadRequest(&c.Controller, err.Error(), traceID)
+			return
+		}
+		c.handleBetError(err, traceID)
+		return
+	}
+	response.Success(&c.Controller, out, traceID)
+}
+
+// Cancel 撤单接口：POST /api/bets/cancel
+// 仅在牌局下注中、且未过房间撤单截止时间时可撤销本人的待结算注单，全额退回本金
+func (c *BetController) Cancel() {
+	cp, ok, msg := helper.ParseAndValidateCancelBet(c.Ctx)
+	if !ok {
+		response.BadRequest(&c.Controller, msg, helper.GetTraceID(c.Ctx))
+		return
+	}
+	traceID := helper.GetTraceID(c.Ctx)
+	platformID, platformUserID, _ := c.platformUser(cp.UserId)
+	if platformUserID == "" {
+		response.BadRequest(&c.Controller, "missing user", traceID)
+		return
+	}
+
+	out, err := newBetService().CancelBet(c.Ctx.Request.Context(), service.CancelBetInput{
+		BillNo:         cp.BillNo,
+		PlatformID:     platformID,
+		PlatformUserID: platformUserID,
+		TraceID:        traceID,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrBetNotFound):
+			response.NotFound(&c.Controller, err.Error(), traceID)
+		case errors.Is(err, service.ErrBetCancelDisabled):
+			response.Conflict(&c.Controller, response.CodeBetCancelDisabled, traceID)
+		case errors.Is(err, service.ErrBetCancelClosed):
+			response.Conflict(&c.Controller, response.CodeBetCancelClosed, traceID)
+		case errors.Is(err, service.ErrBetNotCancellable):
+			response.Conflict(&c.Controller, response.CodeBetNotCancellable, traceID)
+		default:
+			c.handleBetError(err, traceID)
+		}
+		return
+	}
+	response.Success(&c.Controller, out, traceID)
+}
+
+// platformUser 从 context 提取平台信息（由认证中间件注入）；中间件未注入时使用请求中的 user_id
+func (c *BetController) platformUser(userID int64) (platformID int8, platformUserID, platformUserName string) {
+	return platformUserOf(c.Ctx, userID)
+}
+
+// platformUserOf 从 context 提取平台信息（下注与转账接口共用）
+func platformUserOf(ctx *beegocontext.Context, userID int64) (platformID int8, platformUserID, platformUserName string) {
+	if v := ctx.Input.GetData("platform_id"); v != nil {
+		if pid, ok := v.(int8); ok {
+			platformID = pid
+		}
+	}
+	if v := ctx.Input.GetData("platform_user_id"); v != nil {
+		if puid, ok := v.(string); ok {
+			platformUserID = puid
+		}
+	}
+	if v := ctx.Input.GetData("platform_user_name"); v != nil {
+		if pname, ok := v.(string); ok {
+			platformUserName = pname
+		}
+	}
+	if platformUserID == "" && userID != 0 {
+		platformID = 0 // 系统默认平台
+		platformUserID = strconv.FormatInt(userID, 10)
+	}
+	return platformID, platformUserID, platformUserName
+}
+
+// handleBetError 投注业务错误到响应码的映射（单笔与批量投注共用）
+func (c *BetController) handleBetError(err error, traceID string) {
+	// MySQL 唯一键冲突
+	if me, ok := err.(*mysqlerr.MySQLError); ok && me.Number == 1062 {
+		response.Conflict(&c.Controller, response.CodeDuplicateKey, traceID)
+		return
+	}
+	// 重复请求进行中
+	if errors.Is(err, service.ErrDuplicateInFlight) {
+		response.Accepted(&c.Controller, "重复请求进行中，请稍后重试", traceID)
+		return
+	}
+	// 状态不允许投注
+	if errors.Is(err, service.ErrInvalidStateBet) {
+		response.Conflict(&c.Controller, response.CodeInvalidState, traceID)
+		return
+	}
+	// 投注窗口未开始
+	if errors.Is(err, service.ErrBetWindowNotStart) {
+		response.Conflict(&c.Controller, response.CodeBetWindowNotStart, traceID)
+		return
+	}
+	// 投注窗口已关闭
+	if errors.Is(err, service.ErrBetWindowClosed) {
+		response.Conflict(&c.Controller, response.CodeBetWindowClosed, traceID)
+		return
+	}
+	// 冲突投注（同时投注龙和虎）
+	if errors.Is(err, service.ErrConflictingPlayTypes) {
+		response.Conflict(&c.Controller, response.CodeConflictingBet, traceID)
+		return
+	}
+	// 超出单局赔付上限
+	if errors.Is(err, service.ErrExposureLimit) {
+		response.Conflict(&c.Controller, response.CodeExposureLimit, traceID)
+		return
+	}
+	if errors.Is(err, service.ErrExposureUnavailable) {
+		response.Error(&c.Controller, 503, response.CodeExposureUnavailable, traceID)
+		return
+	}
+	// 币种未配置、平台不允许或与房间币种不一致
+	if errors.Is(err, service.ErrCurrencyNotSupported) || errors.Is(err, service.ErrCurrencyNotAllowed) ||
+		errors.Is(err, service.ErrCurrencyMismatch) {
+		response.Conflict(&c.Controller, response.CodeCurrencyNotAllowed, traceID)
+		return
+	}
+	// seamless 钱包：扣款结果未知（注单待确认，由对账任务冲正）/ 平台拒绝扣款
+	if errors.Is(err, service.ErrWalletUnavailable) {
+		response.Error(&c.Controller, 503, response.CodeWalletUnavailable, traceID)
+		return
+	}
+	if errors.Is(err, service.ErrWalletRejected) {
+		response.Conflict(&c.Controller, response.CodeWalletRejected, traceID)
+		return
+	}
+	// 房间未登记
+	if errors.Is(err, service.ErrRoomNotFound) {
+		response.NotFound(&c.Controller, err.Error(), traceID)
+		return
+	}
+	// 房间维护中或已关闭
+	if errors.Is(err, service.ErrRoomNotOpen) {
+		response.Conflict(&c.Controller, response.CodeRoomNotOpen, traceID)
+		return
+	}
+	// 房间与游戏不一致 / 回合不属于该房间 / 房间未开放该玩法
+	if errors.Is(err, service.ErrRoomGameMismatch) || errors.Is(err, service.ErrRoundRoomMismatch) ||
+		errors.Is(err, service.ErrPlayTypeNotOffered) {
+		response.BadRequest(&c.Controller, err.Error(), traceID)
+		return
+	}
+	// 投注金额验证失败
+	errMsg := err.Error()
+	if strings.Contains(errMsg, "invalid bet amount") ||
+		strings.Contains(errMsg, "bet amount must be positive") ||
+		strings.Contains(errMsg, "below minimum limit") ||
+		strings.Contains(errMsg, "exceeds maximum limit") {
+		response.BadRequest(&c.Controller, errMsg, traceID)
+		return
+	}
+	// 余额不足
+	if strings.Contains(errMsg, "insufficient balance") {
+		response.BadRequest(&c.Controller, "余额不足", traceID)
+		return
+	}
+	// 用户状态异常
+	if strings.Contains(errMsg, "user disabled") {
+		response.BadRequest(&c.Controller, "用户状态异常", traceID)
+		return
+	}
+	// 系统错误
+	response.InternalError(&c.Controller, traceID)
+}
@@ -24,14 +24,17 @@ type GameEventRequestParam struct {
 	GameId      string `json:"game_id"`
 	RoomId      string `json:"room_id"`
 	GameRoundId string `json:"game_round_id"`
-	EventType   int    `json:"event_type"` // 1=game_start 2=game_stop 3=new_card 4=game_draw 5=game_end
+	EventType   int    `json:"event_type"` // 1=game_start 2=game_stop 3=new_card 4=game_draw 5=game_end 6=game_cancel
+	Reason      string `json:"reason"`     // 作废原因（仅 game_cancel）
+	EventId     string `json:"event_id"`   // 事件ID（可选）：重复投递直接返回成功
+	Seq         int64  `json:"seq"`        // 局内事件序号（可选，从 1 开始连续递增）：超前返回 409
 }
 
 // Post 接收并处理事件
 // 步骤：
 // 1) 解析入参与基本校验
 // 2) 调用 Service 层执行业务与状态检查
-// 3) 按错误类型映射 HTTP 状态码：400 参数错误；409 非法状态跳转
+// 3) 按错误类型映射 HTTP 状态码：400 参数错误；409 非法状态跳转、序号超前或事件ID冲突
 func (c *GameEventController) GameEvent() {
 	gp, ok, msg := helper.ParseAndValidateGameEvent(c.Ctx)
 	if !ok {
@@ -45,12 +48,39 @@ func (c *GameEventController) GameEvent() {
 		RoomID:      gp.RoomId,
 		GameRoundID: gp.GameRoundId,
 		EventType:   int8(gp.EventType),
+		Reason:      gp.Reason,
+		EventID:     gp.EventId,
+		Seq:         gp.Seq,
 		TraceID:     traceID,
 	}); err != nil {
 		if errors.Is(err, service.ErrBadRequest) {
 			response.BadRequest(&c.Controller, "invalid request", traceID)
 			return
 		}
+		if errors.Is(err, service.ErrRoomGameMismatch) {
+			response.BadRequest(&c.Controller, err.Error(), traceID)
+			return
+		}
+		if errors.Is(err, service.ErrRoomNotFound) {
+			response.NotFound(&c.Controller, err.Error(), traceID)
+			return
+		}
+		if errors.Is(err, service.ErrRoomNotOpen) {
+			response.Conflict(&c.Controller, response.CodeRoomNotOpen, traceID)
+			return
+		}
+		if errors.Is(err, service.ErrCancelSettledRound) {
+			response.Conflict(&c.Controller, response.CodeRoundAlreadySettled, traceID)
+			return
+		}
+		if errors.Is(err, service.ErrEventOutOfOrder) {
+			response.Conflict(&c.Controller, response.CodeEventOutOfOrder, traceID)
+			return
+		}
+		if errors.Is(err, service.ErrEventConflict) {
+			response.Conflict(&c.Controller, response.CodeEventConflict, traceID)
+			return
+		}
 		response.Conflict(&c.Controller, response.CodeInvalidState, traceID)
 		return
 	}
@@ -64,9 +94,9 @@ func (c *GameEventController) GameEvent() {
 			response.Success(&c.Controller, map[string]interface{}{
 				"bet_start_time":   betStart,
 				"bet_stop_time":    betStop,
-				"countdown_second": 45, // 倒计时秒数（方便测试）
+				"countdown_second": (betStop - betStart) / 1000, // 倒计时秒数（房间下注窗口）
 				"game_round_id":    gp.GameRoundId,
-				"game_id":          gp.GameId,
+				"game_id":          rs.GameID,
 				"room_id":          gp.RoomId,
 			}, traceID)
 			return
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+
+	helper "dt-server/internal/common/helper"
+	"dt-server/internal/common/response"
+	"dt-server/internal/model"
+	"dt-server/internal/service"
+
+	beego "github.com/beego/beego/v2/server/web"
+	decimal "github.com/shopspring/decimal"
+)
+
+var newOddsService = service.NewOddsService
+
+// OddsController 赔率表管理接口（管理员认证）
+//
+//	GET  /api/admin/odds?game_id=&room_id=&limit=  赔率版本列表（含已撤销）
+//	POST /api/admin/odds                           新增赔率版本（可指定未来生效时间）
+//	POST /api/admin/odds/:version/revoke           撤销赔率版本
+//
+// 已有版本不可修改，调整赔率即新增版本；注单记录下注时所用版本（orders.odds_version）
+type OddsController struct{ beego.Controller }
+
+func (c *OddsController) List() {
+	traceID := helper.GetTraceID(c.Ctx)
+	gameID := c.Ctx.Input.Query("game_id")
+	if gameID == "" || len(gameID) > 32 {
+		response.BadRequest(&c.Controller, "game_id is required", traceID)
+		return
+	}
+	limit, _ := strconv.Atoi(c.Ctx.Input.Query("limit"))
+	list, err := newOddsService().ListSchedules(c.Ctx.Request.Context(), gameID, c.Ctx.Input.Query("room_id"), limit)
+	if err != nil {
+		response.InternalError(&c.Controller, traceID)
+		return
+	}
+	response.Success(&c.Controller, list, traceID)
+}
+
+func (c *OddsController) Create() {
+	traceID := helper.GetTraceID(c.Ctx)
+	req, ok, msg := helper.ParseAndValidateOddsSchedule(c.Ctx)
+	if !ok {
+		response.BadRequest(&c.Controller, msg, traceID)
+		return
+	}
+	in := service.OddsScheduleInput{
+		GameID:        req.GameId,
+		RoomID:        req.RoomId,
+		PlatformID:    model.OddsAnyPlatform,
+		Odds:          make(map[string]decimal.Decimal, len(req.Odds)),
+		EffectiveFrom: req.EffectiveFrom,
+		Remark:        req.Remark,
+		Operator:      req.Operator,
+		TraceID:       traceID,
+	}
+	if req.PlatformId != nil {
+		in.PlatformID = int8(*req.PlatformId)
+	}
+	for pt, o := range req.Odds {
+		v, err := decimal.NewFromString(o)
+		if err != nil {
+			response.BadRequest(&c.Controller, "invalid odds of "+pt, traceID)
+			return
+		}
+		in.Odds[pt] = v
+	}
+
+	out, err := newOddsService().CreateSchedule(c.Ctx.Request.Context(), in)
+	if err != nil {
+		c.handleError(err, traceID)
+		return
+	}
+	response.Success(&c.Controller, out, traceID)
+}
+
+func (c *OddsController) Revoke() {
+	traceID := helper.GetTraceID(c.Ctx)
+	version, err := strconv.ParseInt(c.Ctx.Input.Param(":version"), 10, 64)
+	if err != nil || version <= 0 {
+		response.BadRequest(&c.Controller, "invalid version", traceID)
+		return
+	}
+	operator := helper.GetAdmin(c.Ctx)
+	if operator == "" {
+		operator = c.Ctx.Input.Query("operator")
+	}
+	if operator == "" || len(operator) > 64 {
+		response.BadRequest(&c.Controller, "operator required", traceID)
+		return
+	}
+	out, err := newOddsService().RevokeSchedule(c.Ctx.Request.Context(), version, operator, traceID)
+	if err != nil {
+		c.handleError(err, traceID)
+		return
+	}
+	response.Success(&c.Controller, out, traceID)
+}
+
+func (c *OddsController) handleError(err error, traceID string) {
+	switch {
+	case errors.Is(err, service.ErrOddsScheduleNotFound), errors.Is(err, service.ErrRoomNotFound):
+		response.NotFound(&c.Controller, err.Error(), traceID)
+	case errors.Is(err, service.ErrBadRequest), errors.Is(err, service.ErrRoomGameMismatch):
+		response.BadRequest(&c.Controller, err.Error(), traceID)
+	default:
+		response.InternalError(&c.Controller, traceID)
+	}
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"errors"
+
+	helper "dt-server/internal/common/helper"
+	"dt-server/internal/common/response"
+	"dt-server/internal/service"
+
+	beego "github.com/beego/beego/v2/server/web"
+)
+
+var newReplayService = service.NewReplayService
+
+// ReplayController 牌局回放接口（管理员认证）
+//
+//	GET  /api/admin/replay/:round_id  回放单局，返回期望状态与差异（只读）
+//	POST /api/admin/replay            回放单局或时间范围，rebuild=true 时按审计重写有差异的牌局
+type ReplayController struct{ beego.Controller }
+
+func (c *ReplayController) Inspect() {
+	traceID := helper.GetTraceID(c.Ctx)
+	roundID := c.Ctx.Input.Param(":round_id")
+	if roundID == "" || len(roundID) > 64 {
+		response.BadRequest(&c.Controller, "round_id is required", traceID)
+		return
+	}
+	reports, err := newReplayService().Replay(c.Ctx.Request.Context(), service.ReplayInput{
+		GameRoundID: roundID,
+		TraceID:     traceID,
+	})
+	if err != nil {
+		c.handleError(err, traceID)
+		return
+	}
+	response.Success(&c.Controller, reports[0], traceID)
+}
+
+func (c *ReplayController) Replay() {
+	traceID := helper.GetTraceID(c.Ctx)
+	req, ok, msg := helper.ParseAndValidateReplay(c.Ctx)
+	if !ok {
+		response.BadRequest(&c.Controller, msg, traceID)
+		return
+	}
+	reports, err := newReplayService().Replay(c.Ctx.Request.Context(), service.ReplayInput{
+		GameRoundID: req.GameRoundId,
+		From:        req.From,
+		To:          req.To,
+		Rebuild:     req.Rebuild,
+		Operator:    req.Operator,
+		TraceID:     traceID,
+	})
+	if err != nil {
+		c.handleError(err, traceID)
+		return
+	}
+	response.Success(&c.Controller, reports, traceID)
+}
+
+func (c *ReplayController) handleError(err error, traceID string) {
+	switch {
+	case errors.Is(err, service.ErrGameRoundNotFound):
+		response.NotFound(&c.Controller, err.Error(), traceID)
+	case errors.Is(err, service.ErrBadRequest):
+		response.BadRequest(&c.Controller, "invalid request", traceID)
+	default:
+		response.InternalError(&c.Controller, traceID)
+	}
+}
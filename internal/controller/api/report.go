@@ -0,0 +1,65 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+
+	helper "dt-server/internal/common/helper"
+	"dt-server/internal/common/response"
+	"dt-server/internal/model"
+	"dt-server/internal/service"
+
+	beego "github.com/beego/beego/v2/server/web"
+)
+
+var newReportService = service.NewReportService
+
+// ReportController 报表接口（管理员认证）
+//
+//	GET /api/admin/reports/currency?from=&to=&game_id=&room_id=&platform_id=  按币种汇总下注额与派彩
+//
+// from/to 为下注时间（13位毫秒时间戳，[from, to)），缺省为最近 24 小时，跨度不超过 31 天
+type ReportController struct{ beego.Controller }
+
+func (c *ReportController) Currency() {
+	traceID := helper.GetTraceID(c.Ctx)
+	var f model.CurrencySummaryFilter
+	var err error
+	if v := c.Ctx.Input.Query("from"); v != "" {
+		if f.From, err = strconv.ParseInt(v, 10, 64); err != nil || f.From < 0 {
+			response.BadRequest(&c.Controller, "invalid from", traceID)
+			return
+		}
+	}
+	if v := c.Ctx.Input.Query("to"); v != "" {
+		if f.To, err = strconv.ParseInt(v, 10, 64); err != nil || f.To < 0 {
+			response.BadRequest(&c.Controller, "invalid to", traceID)
+			return
+		}
+	}
+	if v := c.Ctx.Input.Query("platform_id"); v != "" {
+		pid, err := strconv.ParseInt(v, 10, 8)
+		if err != nil || pid <= 0 {
+			response.BadRequest(&c.Controller, "invalid platform_id", traceID)
+			return
+		}
+		f.PlatformID = int8(pid)
+	}
+	f.GameID = c.Ctx.Input.Query("game_id")
+	f.RoomID = c.Ctx.Input.Query("room_id")
+	if len(f.GameID) > 32 || len(f.RoomID) > 64 {
+		response.BadRequest(&c.Controller, "invalid request", traceID)
+		return
+	}
+
+	out, err := newReportService().CurrencyReport(c.Ctx.Request.Context(), f)
+	if err != nil {
+		if errors.Is(err, service.ErrBadRequest) {
+			response.BadRequest(&c.Controller, err.Error(), traceID)
+			return
+		}
+		response.InternalError(&c.Controller, traceID)
+		return
+	}
+	response.Success(&c.Controller, out, traceID)
+}
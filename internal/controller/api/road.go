@@ -0,0 +1,38 @@
+package api
+
+import (
+	"errors"
+
+	helper "dt-server/internal/common/helper"
+	"dt-server/internal/common/response"
+	"dt-server/internal/service"
+
+	beego "github.com/beego/beego/v2/server/web"
+)
+
+var newRoadService = service.NewRoadService
+
+// RoadController 路单查询接口（公开，无需认证）
+// GET /api/roads/:room_id
+// 返回房间当前牌靴的珠盘路、大路、大眼仔、小路、曱甴路；与 game_drawn 消息中的 roads 字段结构一致
+type RoadController struct{ beego.Controller }
+
+func (c *RoadController) Get() {
+	traceID := helper.GetTraceID(c.Ctx)
+	roomID := c.Ctx.Input.Param(":room_id")
+	if roomID == "" || len(roomID) > 32 {
+		response.BadRequest(&c.Controller, "room_id is required", traceID)
+		return
+	}
+
+	res, err := newRoadService().GetRoads(c.Ctx.Request.Context(), roomID)
+	if err != nil {
+		if errors.Is(err, service.ErrRoomNotFound) {
+			response.NotFound(&c.Controller, err.Error(), traceID)
+			return
+		}
+		response.InternalError(&c.Controller, traceID)
+		return
+	}
+	response.Success(&c.Controller, res, traceID)
+}
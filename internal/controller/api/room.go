@@ -0,0 +1,149 @@
+package api
+
+import (
+	"errors"
+
+	helper "dt-server/internal/common/helper"
+	"dt-server/internal/common/response"
+	"dt-server/internal/service"
+
+	beego "github.com/beego/beego/v2/server/web"
+	decimal "github.com/shopspring/decimal"
+)
+
+var newRoomService = service.NewRoomService
+
+// RoomController 房间配置管理接口（管理员认证）
+//
+//	GET  /api/admin/rooms           房间列表
+//	GET  /api/admin/rooms/:room_id  房间详情
+//	POST /api/admin/rooms           新建房间
+//	PUT  /api/admin/rooms/:room_id  修改房间（整行覆盖，含开放/维护/关闭）
+//
+// 修改立即在本实例生效，其他实例在注册表检测间隔（5s）内生效
+type RoomController struct{ beego.Controller }
+
+func (c *RoomController) List() {
+	traceID := helper.GetTraceID(c.Ctx)
+	list, err := newRoomService().ListRooms(c.Ctx.Request.Context())
+	if err != nil {
+		response.InternalError(&c.Controller, traceID)
+		return
+	}
+	response.Success(&c.Controller, list, traceID)
+}
+
+func (c *RoomController) Get() {
+	traceID := helper.GetTraceID(c.Ctx)
+	roomID := c.Ctx.Input.Param(":room_id")
+	if roomID == "" || len(roomID) > 32 {
+		response.BadRequest(&c.Controller, "room_id is required", traceID)
+		return
+	}
+	room, err := newRoomService().GetRoom(c.Ctx.Request.Context(), roomID)
+	if err != nil {
+		c.handleError(err, traceID)
+		return
+	}
+	response.Success(&c.Controller, room, traceID)
+}
+
+func (c *RoomController) Create() {
+	c.save("")
+}
+
+func (c *RoomController) Update() {
+	roomID := c.Ctx.Input.Param(":room_id")
+	if roomID == "" || len(roomID) > 32 {
+		response.BadRequest(&c.Controller, "room_id is required", helper.GetTraceID(c.Ctx))
+		return
+	}
+	c.save(roomID)
+}
+
+// save 新建（pathRoomID 为空）或修改房间
+func (c *RoomController) save(pathRoomID string) {
+	traceID := helper.GetTraceID(c.Ctx)
+	req, ok, msg := helper.ParseAndValidateRoom(c.Ctx, pathRoomID)
+	if !ok {
+		response.BadRequest(&c.Controller, msg, traceID)
+		return
+	}
+	cfg, err := roomConfigFromRequest(&req)
+	if err != nil {
+		response.BadRequest(&c.Controller, err.Error(), traceID)
+		return
+	}
+
+	svc := newRoomService()
+	in := service.RoomInput{Config: *cfg, TraceID: traceID}
+	var room *service.RoomConfig
+	if pathRoomID == "" {
+		room, err = svc.CreateRoom(c.Ctx.Request.Context(), in)
+	} else {
+		room, err = svc.UpdateRoom(c.Ctx.Request.Context(), in)
+	}
+	if err != nil {
+		c.handleError(err, traceID)
+		return
+	}
+	response.Success(&c.Controller, room, traceID)
+}
+
+func (c *RoomController) handleError(err error, traceID string) {
+	switch {
+	case errors.Is(err, service.ErrRoomNotFound):
+		response.NotFound(&c.Controller, err.Error(), traceID)
+	case errors.Is(err, service.ErrRoomExists):
+		response.Conflict(&c.Controller, response.CodeDuplicateKey, traceID)
+	case errors.Is(err, service.ErrBadRequest):
+		response.BadRequest(&c.Controller, err.Error(), traceID)
+	default:
+		response.InternalError(&c.Controller, traceID)
+	}
+}
+
+// roomConfigFromRequest 将已校验的请求参数转换为房间配置（金额与赔率转为 decimal）
+func roomConfigFromRequest(req *helper.RoomParsed) (*service.RoomConfig, error) {
+	cfg := &service.RoomConfig{
+		RoomID:          req.RoomId,
+		GameID:          req.GameId,
+		RoomName:        req.RoomName,
+		BetWindowSec:    req.BetWindowSec,
+		BetLimits:       make(map[string]service.BetLimit, len(req.BetLimits)),
+		Odds:            make(map[string]decimal.Decimal, len(req.Odds)),
+		Currency:        req.Currency,
+		Status:          int8(req.Status),
+		IsVIP:           req.IsVip,
+		DrawApproval:    req.DrawApproval,
+		DrawScanners:    req.DrawScanners,
+		CancelAllowed:   req.CancelAllowed,
+		CancelCutoffSec: req.CancelCutoffSec,
+	}
+	for pt, l := range req.BetLimits {
+		min, err := decimal.NewFromString(l.Min)
+		if err != nil {
+			return nil, errors.New("invalid bet_limits of " + pt)
+		}
+		max, err := decimal.NewFromString(l.Max)
+		if err != nil {
+			return nil, errors.New("invalid bet_limits of " + pt)
+		}
+		cfg.BetLimits[pt] = service.BetLimit{Min: min, Max: max}
+	}
+	for pt, o := range req.Odds {
+		v, err := decimal.NewFromString(o)
+		if err != nil {
+			return nil, errors.New("invalid odds of " + pt)
+		}
+		cfg.Odds[pt] = v
+	}
+	if req.LiabilityCap != "" {
+		v, err := decimal.NewFromString(req.LiabilityCap)
+		if err != nil {
+			return nil, errors.New("invalid liability_cap")
+		}
+		cfg.LiabilityCap = v
+	}
+	return cfg, nil
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"errors"
+
+	helper "dt-server/internal/common/helper"
+	"dt-server/internal/common/response"
+	"dt-server/internal/service"
+
+	beego "github.com/beego/beego/v2/server/web"
+)
+
+var newShoeService = service.NewShoeService
+
+type ShoeController struct{ beego.Controller }
+
+// 换靴请求参数
+type ShoeChangeRequestParam struct {
+	GameId string `json:"game_id"`
+	RoomId string `json:"room_id"`
+	Reason string `json:"reason"` // 换靴原因（可选）
+}
+
+// ChangeShoe 人工换靴接口：POST /api/shoe_change
+// 仅允许在两局之间调用（房间最近一局已结束/已取消或尚无牌局）
+func (c *ShoeController) ChangeShoe() {
+	req, ok, msg := helper.ParseAndValidateShoeChange(c.Ctx)
+	if !ok {
+		response.BadRequest(&c.Controller, msg, helper.GetTraceID(c.Ctx))
+		return
+	}
+
+	traceID := helper.GetTraceID(c.Ctx)
+	sh, err := newShoeService().ChangeShoe(c.Ctx.Request.Context(), service.ShoeChangeInput{
+		GameID:  req.GameId,
+		RoomID:  req.RoomId,
+		Reason:  req.Reason,
+		TraceID: traceID,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrShoeChangeInRound) {
+			response.Conflict(&c.Controller, response.CodeShoeChangeInRound, traceID)
+			return
+		}
+		if errors.Is(err, service.ErrBadRequest) {
+			response.BadRequest(&c.Controller, "invalid request", traceID)
+			return
+		}
+		response.InternalError(&c.Controller, traceID)
+		return
+	}
+	response.Success(&c.Controller, map[string]interface{}{
+		"shoe_no":      sh.ShoeNo,
+		"room_id":      sh.RoomID,
+		"decks":        sh.Decks,
+		"total_cards":  sh.TotalCards,
+		"cut_position": sh.CutPosition,
+		"burn_cards":   sh.BurnCards,
+	}, traceID)
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"errors"
+	"strings"
+
+	helper "dt-server/internal/common/helper"
+	"dt-server/internal/common/response"
+	"dt-server/internal/service"
+
+	beego "github.com/beego/beego/v2/server/web"
+)
+
+var newTransferService = service.NewTransferService
+
+// TransferController 平台上分/下分接口（平台签名认证，transfer 钱包模式）
+//
+//	POST /api/wallet/deposit                    上分：转入玩家钱包
+//	POST /api/wallet/withdraw                   下分：从玩家钱包转出
+//	GET  /api/wallet/transfers/:transfer_id     查询转账状态（对账）
+//
+// transfer_id 为平台侧转账单号，同一平台内唯一；以同一单号重试时返回首次的结果
+type TransferController struct{ beego.Controller }
+
+// Deposit 上分
+func (c *TransferController) Deposit() {
+	c.transfer(false)
+}
+
+// Withdraw 下分
+func (c *TransferController) Withdraw() {
+	c.transfer(true)
+}
+
+func (c *TransferController) transfer(withdraw bool) {
+	tp, ok, msg := helper.ParseAndValidateTransfer(c.Ctx)
+	if !ok {
+		response.BadRequest(&c.Controller, msg, helper.GetTraceID(c.Ctx))
+		return
+	}
+	traceID := helper.GetTraceID(c.Ctx)
+	platformID, platformUserID, platformUserName := platformUserOf(c.Ctx, tp.UserId)
+	if platformUserID == "" {
+		response.BadRequest(&c.Controller, "missing user", traceID)
+		return
+	}
+
+	in := service.TransferInput{
+		TransferID:       tp.TransferId,
+		PlatformID:       platformID,
+		PlatformUserID:   platformUserID,
+		PlatformUserName: platformUserName,
+		Amount:           tp.Amount,
+		Currency:         tp.Currency,
+		Remark:           tp.Remark,
+		TraceID:          traceID,
+	}
+	svc := newTransferService()
+	var out *service.TransferOutput
+	var err error
+	if withdraw {
+		out, err = svc.Withdraw(c.Ctx.Request.Context(), in)
+	} else {
+		out, err = svc.Deposit(c.Ctx.Request.Context(), in)
+	}
+	if err != nil {
+		c.handleTransferError(err, traceID)
+		return
+	}
+	response.Success(&c.Controller, out, traceID)
+}
+
+// Status 查询转账状态：只能查询本平台的转账（含被拒绝的下分）
+func (c *TransferController) Status() {
+	traceID := helper.GetTraceID(c.Ctx)
+	transferID := c.Ctx.Input.Param(":transfer_id")
+	if !helper.IsValidTransferID(transferID) {
+		response.BadRequest(&c.Controller, "invalid transfer_id", traceID)
+		return
+	}
+	platformID, _, _ := platformUserOf(c.Ctx, 0)
+
+	t, err := newTransferService().GetTransfer(c.Ctx.Request.Context(), platformID, transferID)
+	if err != nil {
+		if errors.Is(err, service.ErrTransferNotFound) {
+			response.NotFound(&c.Controller, err.Error(), traceID)
+			return
+		}
+		response.InternalError(&c.Controller, traceID)
+		return
+	}
+	response.Success(&c.Controller, t, traceID)
+}
+
+// handleTransferError 转账业务错误到响应码的映射
+func (c *TransferController) handleTransferError(err error, traceID string) {
+	switch {
+	case errors.Is(err, service.ErrWithdrawNotAllowed):
+		response.Conflict(&c.Controller, response.CodeWithdrawNotAllowed, traceID)
+	case errors.Is(err, service.ErrTransferConflict):
+		response.Conflict(&c.Controller, response.CodeTransferConflict, traceID)
+	case errors.Is(err, service.ErrTransferNotSupported):
+		response.Conflict(&c.Controller, response.CodeTransferNotAllowed, traceID)
+	case errors.Is(err, service.ErrCurrencyNotSupported), errors.Is(err, service.ErrCurrencyNotAllowed):
+		response.Conflict(&c.Controller, response.CodeCurrencyNotAllowed, traceID)
+	case errors.Is(err, service.ErrBadRequest):
+		response.BadRequest(&c.Controller, err.Error(), traceID)
+	case strings.Contains(err.Error(), "insufficient balance"):
+		response.BadRequest(&c.Controller, "余额不足", traceID)
+	default:
+		response.InternalError(&c.Controller, traceID)
+	}
+}
@@ -0,0 +1,206 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"dt-server/internal/shoe"
+
+	decimal "github.com/shopspring/decimal"
+)
+
+// baccarat 百家乐（闲/庄/和）
+//
+// card_list：按发牌顺序 P<点数>[花色],B<点数>[花色],P..,B..[,P..][,B..][,R<结果>]，如 P1h,B13s,P9c,B2d,B7h
+// 点数：A=1，2~9 按面值，10/J/Q/K=0，手牌点数取和的个位
+// 补牌规则：
+//   - 任一方前两张为 8/9（天牌）双方均不补牌
+//   - 闲 0~5 补第三张，6~7 停牌
+//   - 闲停牌时庄 0~5 补牌、6~7 停牌
+//   - 闲补牌时按闲第三张 p3 决定庄是否补牌：庄 0~2 补；3 点 p3!=8 补；4 点 p3∈2~7 补；5 点 p3∈4~7 补；6 点 p3∈6~7 补；7 点停
+//
+// 人工录入的牌面会按补牌规则校验（带 R 结果标记的人工录入除外）。
+// 主注：闲/庄/和，押闲/庄遇和退还本金；赔率由房间配置（通常闲 1、庄 0.95、和 8）
+type baccarat struct{}
+
+var bacPlayTypes = map[string]bool{"player": true, "banker": true, "tie": true}
+
+func (baccarat) Name() string { return "baccarat" }
+
+func (baccarat) MaxCards() int { return 6 }
+
+func (baccarat) ValidPlayType(pt string) bool { return bacPlayTypes[pt] }
+
+func (baccarat) Results() []string { return []string{"player", "banker", "tie"} }
+
+func (baccarat) ParseCards(cardList string) (*Hand, error) {
+	seq, forced, err := splitCardList(cardList, "pb")
+	if err != nil {
+		return nil, err
+	}
+	h := &Hand{Cards: map[string][]shoe.Card{}}
+	for _, pc := range seq {
+		pos := "player"
+		if pc.Prefix == 'b' {
+			pos = "banker"
+		}
+		h.Cards[pos] = append(h.Cards[pos], pc.Card)
+	}
+	np, nb := len(h.Cards["player"]), len(h.Cards["banker"])
+	if np < 2 || np > 3 || nb < 2 || nb > 3 {
+		return nil, fmt.Errorf("%w: baccarat needs 2~3 P cards and 2~3 B cards", ErrInvalidCardList)
+	}
+	switch forced {
+	case "":
+	case "p", "player":
+		h.Forced = "player"
+	case "b", "banker":
+		h.Forced = "banker"
+	case "t", "tie":
+		h.Forced = "tie"
+	default:
+		return nil, fmt.Errorf("%w: unknown result token %q", ErrInvalidCardList, "R"+forced)
+	}
+	return h, nil
+}
+
+func (baccarat) DecideResult(h *Hand) (*Outcome, error) {
+	p, b := h.Cards["player"], h.Cards["banker"]
+	if h.Forced == "" {
+		if err := checkThirdCards(p, b); err != nil {
+			return nil, err
+		}
+	}
+	pp, bp := bacPoints(p...), bacPoints(b...)
+	out := &Outcome{Hand: *h, Points: map[string]int{"player": pp, "banker": bp}}
+	switch {
+	case h.Forced != "":
+		out.Result = h.Forced
+	case pp > bp:
+		out.Result = "player"
+	case pp < bp:
+		out.Result = "banker"
+	default:
+		out.Result = "tie"
+	}
+	return out, nil
+}
+
+// Payout 押闲/庄/和结果匹配 -> 本金 × (1 + 赔率)；押闲/庄遇和退还本金；其他 -> 0
+func (baccarat) Payout(pt string, amount, odds decimal.Decimal, out *Outcome) (decimal.Decimal, error) {
+	pt = strings.ToLower(pt)
+	if !bacPlayTypes[pt] {
+		return decimal.Zero, fmt.Errorf("%w: %s", ErrPlayTypeNotInGame, pt)
+	}
+	if pt == out.Result {
+		return winPayout(amount, odds), nil
+	}
+	if pt != "tie" && out.Result == "tie" {
+		return amount, nil
+	}
+	return decimal.Zero, nil
+}
+
+func (baccarat) Deal(next func() (shoe.Card, error)) (string, error) {
+	var p, b []shoe.Card
+	var order []string
+	take := func(side string) error {
+		c, err := next()
+		if err != nil {
+			return err
+		}
+		if side == "P" {
+			p = append(p, c)
+		} else {
+			b = append(b, c)
+		}
+		order = append(order, side+c.String())
+		return nil
+	}
+	for _, side := range []string{"P", "B", "P", "B"} {
+		if err := take(side); err != nil {
+			return "", err
+		}
+	}
+	if playerDraws(p, b) {
+		if err := take("P"); err != nil {
+			return "", err
+		}
+	}
+	if bankerDraws(p, b) {
+		if err := take("B"); err != nil {
+			return "", err
+		}
+	}
+	return strings.Join(order, ","), nil
+}
+
+func (baccarat) Details(out *Outcome) map[string]any {
+	return map[string]any{
+		"player_points": out.Points["player"],
+		"banker_points": out.Points["banker"],
+	}
+}
+
+// bacValue 单张牌点数：10/J/Q/K 为 0
+func bacValue(c shoe.Card) int {
+	if c.Rank >= 10 {
+		return 0
+	}
+	return c.Rank
+}
+
+// bacPoints 手牌点数（个位）
+func bacPoints(cards ...shoe.Card) int {
+	sum := 0
+	for _, c := range cards {
+		sum += bacValue(c)
+	}
+	return sum % 10
+}
+
+// natural 任一方前两张为 8/9
+func natural(p, b []shoe.Card) bool {
+	return bacPoints(p[:2]...) >= 8 || bacPoints(b[:2]...) >= 8
+}
+
+// playerDraws 闲是否补第三张（仅看前两张）
+func playerDraws(p, b []shoe.Card) bool {
+	return !natural(p, b) && bacPoints(p[:2]...) <= 5
+}
+
+// bankerDraws 庄是否补第三张；p 可能已含闲第三张
+func bankerDraws(p, b []shoe.Card) bool {
+	if natural(p, b) {
+		return false
+	}
+	bp := bacPoints(b[:2]...)
+	if len(p) < 3 {
+		return bp <= 5
+	}
+	p3 := bacValue(p[2])
+	switch bp {
+	case 0, 1, 2:
+		return true
+	case 3:
+		return p3 != 8
+	case 4:
+		return p3 >= 2 && p3 <= 7
+	case 5:
+		return p3 >= 4 && p3 <= 7
+	case 6:
+		return p3 == 6 || p3 == 7
+	}
+	return false
+}
+
+// checkThirdCards 校验人工录入的补牌是否符合规则
+func checkThirdCards(p, b []shoe.Card) error {
+	if want := playerDraws(p, b); want != (len(p) == 3) {
+		return fmt.Errorf("%w: player third card does not follow drawing rules", ErrInvalidCardList)
+	}
+	if want := bankerDraws(p, b); want != (len(b) == 3) {
+		return fmt.Errorf("%w: banker third card does not follow drawing rules", ErrInvalidCardList)
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+
+	"dt-server/internal/shoe"
+
+	decimal "github.com/shopspring/decimal"
+)
+
+// card 点数 v（0~9）对应的一张牌，0 点用 K
+func card(v int) shoe.Card {
+	if v == 0 {
+		return shoe.Card{Rank: 13, Suit: 'S'}
+	}
+	return shoe.Card{Rank: v, Suit: 'H'}
+}
+
+// twoCards 两张牌合计 total 点（0~9）
+func twoCards(total int) []shoe.Card {
+	return []shoe.Card{card(0), card(total)}
+}
+
+// 标准补牌表：闲补第三张时，庄前两张 0~7 点 × 闲第三张点数 0~9 -> 庄是否补牌（D=补 S=停）
+var bankerChart = map[int]string{
+	0: "DDDDDDDDDD",
+	1: "DDDDDDDDDD",
+	2: "DDDDDDDDDD",
+	3: "DDDDDDDDSD",
+	4: "SSDDDDDDSS",
+	5: "SSSSDDDDSS",
+	6: "SSSSSSDDSS",
+	7: "SSSSSSSSSS",
+}
+
+func TestBankerDrawsChart(t *testing.T) {
+	for bp, row := range bankerChart {
+		for p3 := 0; p3 <= 9; p3++ {
+			p := append(twoCards(3), card(p3))
+			b := twoCards(bp)
+			want := row[p3] == 'D'
+			if got := bankerDraws(p, b); got != want {
+				t.Fatalf("banker %d, player third %d: draws=%v, want %v", bp, p3, got, want)
+			}
+		}
+	}
+	// 闲停牌：庄 0~5 补、6~7 停
+	for bp := 0; bp <= 7; bp++ {
+		for _, pp := range []int{6, 7} {
+			if got, want := bankerDraws(twoCards(pp), twoCards(bp)), bp <= 5; got != want {
+				t.Fatalf("player stands on %d, banker %d: draws=%v, want %v", pp, bp, got, want)
+			}
+		}
+	}
+}
+
+func TestPlayerDrawsAndNaturals(t *testing.T) {
+	for pp := 0; pp <= 9; pp++ {
+		for bp := 0; bp <= 9; bp++ {
+			p, b := twoCards(pp), twoCards(bp)
+			isNatural := pp >= 8 || bp >= 8
+			if got, want := playerDraws(p, b), !isNatural && pp <= 5; got != want {
+				t.Fatalf("player %d, banker %d: player draws=%v, want %v", pp, bp, got, want)
+			}
+			if isNatural && bankerDraws(p, b) {
+				t.Fatalf("player %d, banker %d: banker draws on a natural", pp, bp)
+			}
+		}
+	}
+}
+
+func TestCheckThirdCards(t *testing.T) {
+	cases := []struct {
+		cardList string
+		valid    bool
+		result   string
+	}{
+		{"P9,B1,P9,B2", true, "player"},          // 闲 8 点天牌，均不补
+		{"P9,B1,P9,B2,B5", false, ""},            // 天牌后庄补牌
+		{"P4s,B3h,P10c,B3d", false, ""},          // 闲 4 点未补牌
+		{"P3,B5,P3,B2,P8", false, ""},            // 闲 6 点补牌
+		{"P3,B5,P3,B1", true, "tie"},             // 闲 6 庄 6 均停
+		{"P3,B5,P3,B1,B1", false, ""},            // 闲停牌时庄 6 点补牌
+		{"P3,B2,P4,B1", false, ""},               // 闲 7 停，庄 3 点未补牌
+		{"P2,B3,P1,B10,P9,B3", true, "banker"},   // 庄 3 点、闲第三张 9 -> 补
+		{"P2,B3,P1,B10,P8", true, "banker"},      // 庄 3 点、闲第三张 8 -> 停
+		{"P2,B3,P1,B10,P8,B1", false, ""},        // 庄 3 点、闲第三张 8 仍补牌
+		{"P2,B4,P1,B10,P1", true, "tie"},         // 庄 4 点、闲第三张 1 -> 停
+		{"P2,B6,P1,B10,P6,B1", true, "player"},   // 庄 6 点、闲第三张 6 -> 补
+		{"P2,B6,P1,B10,P5", true, "player"},      // 庄 6 点、闲第三张 5 -> 停
+		{"P9,B1,P9,B2,B5,Rb", true, "banker"},    // 带结果标记的人工录入不校验补牌
+		{"P2,B3,P1,B10,P8,B1,Rtie", true, "tie"}, // 同上
+	}
+
+	for _, c := range cases {
+		h, err := Baccarat.ParseCards(c.cardList)
+		if err != nil {
+			t.Fatalf("%s: parse: %v", c.cardList, err)
+		}
+		out, err := Baccarat.DecideResult(h)
+		if !c.valid {
+			if !errors.Is(err, ErrInvalidCardList) {
+				t.Fatalf("%s: want ErrInvalidCardList, got %v", c.cardList, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: decide: %v", c.cardList, err)
+		}
+		if out.Result != c.result {
+			t.Fatalf("%s: result=%s, want %s", c.cardList, out.Result, c.result)
+		}
+	}
+}
+
+func TestBaccaratPayout(t *testing.T) {
+	odds := map[string]decimal.Decimal{
+		"player": decimal.NewFromInt(1),
+		"banker": decimal.RequireFromString("0.95"),
+		"tie":    decimal.NewFromInt(8),
+	}
+	want := map[[2]string]string{
+		{"player", "player"}: "200", {"player", "banker"}: "0", {"player", "tie"}: "100",
+		{"banker", "player"}: "0", {"banker", "banker"}: "195", {"banker", "tie"}: "100",
+		{"tie", "player"}: "0", {"tie", "banker"}: "0", {"tie", "tie"}: "900",
+	}
+	amount := decimal.NewFromInt(100)
+	for k, w := range want {
+		pt, result := k[0], k[1]
+		got, err := Baccarat.Payout(pt, amount, odds[pt], &Outcome{Result: result})
+		if err != nil {
+			t.Fatalf("bet %s, result %s: %v", pt, result, err)
+		}
+		if !got.Equal(decimal.RequireFromString(w)) {
+			t.Fatalf("bet %s, result %s: payout=%s, want %s", pt, result, got, w)
+		}
+	}
+	if _, err := Baccarat.Payout("dragon", amount, odds["player"], &Outcome{Result: "player"}); !errors.Is(err, ErrPlayTypeNotInGame) {
+		t.Fatalf("dragon on baccarat: want ErrPlayTypeNotInGame, got %v", err)
+	}
+	// 0.95 赔率的派彩保留两位小数
+	got, _ := Baccarat.Payout("banker", decimal.RequireFromString("3.33"), odds["banker"], &Outcome{Result: "banker"})
+	if !got.Equal(decimal.RequireFromString("6.49")) {
+		t.Fatalf("banker 3.33 @0.95: payout=%s, want 6.49", got)
+	}
+}
+
+func TestResultTokens(t *testing.T) {
+	cases := []struct {
+		eng      GameEngine
+		cardList string
+		forced   string
+		valid    bool
+	}{
+		{DragonTiger, "D9,T8", "", true},
+		{DragonTiger, "D9,T8,Rd", "dragon", true},
+		{DragonTiger, "D5,T3,RDragon", "dragon", true},
+		{DragonTiger, "D9,T9,RTie", "tie", true},
+		{DragonTiger, "D9,T8,R-tiger", "tiger", true},
+		{DragonTiger, "D9,T8,Rx", "", false},
+		{DragonTiger, "D9,T8,Rbanker", "", false},
+		{DragonTiger, "D9,T8,R", "", false},
+		{DragonTiger, "D9,T8,Rd,Rt", "", false},
+		{Baccarat, "P9,B1,P9,B2,Rp", "player", true},
+		{Baccarat, "P9,B1,P9,B2,RBanker", "banker", true},
+		{Baccarat, "P9,B1,P9,B2,Rdragon", "", false},
+		{Baccarat, "P9,B1,P9,B2,R", "", false},
+	}
+	for _, c := range cases {
+		h, err := c.eng.ParseCards(c.cardList)
+		if !c.valid {
+			if !errors.Is(err, ErrInvalidCardList) {
+				t.Fatalf("%s %s: want ErrInvalidCardList, got %v", c.eng.Name(), c.cardList, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s %s: %v", c.eng.Name(), c.cardList, err)
+		}
+		if h.Forced != c.forced {
+			t.Fatalf("%s %s: forced=%q, want %q", c.eng.Name(), c.cardList, h.Forced, c.forced)
+		}
+	}
+}
+
+func TestDealFollowsDrawingRules(t *testing.T) {
+	// 服务端发牌的牌面总能通过补牌校验
+	deck := shoe.NewDeck(1)
+	for i := 0; i < len(deck); i++ {
+		for j := 0; j < len(deck); j += 7 {
+			seq := []shoe.Card{deck[i], deck[j], deck[(i+j)%52], deck[(i*3+j)%52], deck[(i+5)%52], deck[(j+11)%52]}
+			pos := 0
+			cardList, err := Baccarat.Deal(func() (shoe.Card, error) { c := seq[pos]; pos++; return c, nil })
+			if err != nil {
+				t.Fatalf("deal: %v", err)
+			}
+			h, err := Baccarat.ParseCards(cardList)
+			if err != nil {
+				t.Fatalf("%s: parse: %v", cardList, err)
+			}
+			if _, err := Baccarat.DecideResult(h); err != nil {
+				t.Fatalf("%s: %v", cardList, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"dt-server/internal/shoe"
+
+	decimal "github.com/shopspring/decimal"
+)
+
+// dragonTiger 龙虎
+//
+// card_list：D<点数>[花色],T<点数>[花色][,R<结果>]，如 D13h,T10s、D9,T8,Rd
+// 主注：龙/虎比点数（A 最小、K 最大），点数相同为和；押龙/虎遇和退还本金
+// 边注（与主结果无关，只看牌面）：
+//   - suited_tie 同花和：龙虎点数相同且花色相同
+//   - {side}_big / {side}_small 大/小：8~13 为大，1~6 为小，7 点大小均输
+//   - {side}_odd / {side}_even 单/双：按点数奇偶，7 点单双均输
+//   - {side}_red / {side}_black 红/黑：红心、方块为红；黑桃、梅花为黑
+//
+// 红黑与同花和依赖花色，牌面缺少花色时无法结算。
+type dragonTiger struct{}
+
+var dtPlayTypes = map[string]bool{
+	"dragon": true, "tiger": true, "tie": true, "suited_tie": true,
+	"dragon_big": true, "dragon_small": true, "dragon_odd": true, "dragon_even": true, "dragon_red": true, "dragon_black": true,
+	"tiger_big": true, "tiger_small": true, "tiger_odd": true, "tiger_even": true, "tiger_red": true, "tiger_black": true,
+}
+
+// dtSideBets 边注（按展示顺序）
+var dtSideBets = []string{"suited_tie",
+	"dragon_big", "dragon_small", "dragon_odd", "dragon_even", "dragon_red", "dragon_black",
+	"tiger_big", "tiger_small", "tiger_odd", "tiger_even", "tiger_red", "tiger_black"}
+
+func (dragonTiger) Name() string { return "dragon_tiger" }
+
+func (dragonTiger) MaxCards() int { return 2 }
+
+func (dragonTiger) ValidPlayType(pt string) bool { return dtPlayTypes[pt] }
+
+func (dragonTiger) Results() []string { return []string{"dragon", "tiger", "tie"} }
+
+func (dragonTiger) ParseCards(cardList string) (*Hand, error) {
+	seq, forced, err := splitCardList(cardList, "dt")
+	if err != nil {
+		return nil, err
+	}
+	h := &Hand{Cards: map[string][]shoe.Card{}}
+	for _, pc := range seq {
+		pos := "dragon"
+		if pc.Prefix == 't' {
+			pos = "tiger"
+		}
+		h.Cards[pos] = append(h.Cards[pos], pc.Card)
+	}
+	if len(h.Cards["dragon"]) != 1 || len(h.Cards["tiger"]) != 1 {
+		return nil, fmt.Errorf("%w: dragon tiger needs exactly one D and one T card", ErrInvalidCardList)
+	}
+	if h.Forced, err = dtResultToken(forced); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// dtResultToken 解析结果标记：d, dragon, -dragon, t, tiger, -tiger, tie；无标记返回空
+// 无法识别的标记视为牌面错误，避免录错的结果被静默忽略而按牌面结算
+func dtResultToken(tok string) (string, error) {
+	switch tok {
+	case "":
+		return "", nil
+	case "d", "dragon", "-dragon":
+		return "dragon", nil
+	case "t", "tiger", "-tiger":
+		return "tiger", nil
+	case "tie":
+		return "tie", nil
+	}
+	return "", fmt.Errorf("%w: unknown result token %q", ErrInvalidCardList, "R"+tok)
+}
+
+func (dragonTiger) DecideResult(h *Hand) (*Outcome, error) {
+	d, t := h.Cards["dragon"][0], h.Cards["tiger"][0]
+	out := &Outcome{Hand: *h, Points: map[string]int{"dragon": d.Rank, "tiger": t.Rank}}
+	switch {
+	case h.Forced != "":
+		out.Result = h.Forced
+	case d.Rank > t.Rank:
+		out.Result = "dragon"
+	case d.Rank < t.Rank:
+		out.Result = "tiger"
+	default:
+		out.Result = "tie"
+	}
+	return out, nil
+}
+
+// Payout 计算派彩（下注时已扣款，派彩为用户最终收到的金额）
+//   - 投注龙/虎/和，结果匹配 -> 本金 × (1 + 赔率)
+//   - 投注龙/虎，结果是和 -> 退还本金
+//   - 边注中奖 -> 本金 × (1 + 赔率)
+//   - 其他 -> 0
+func (dragonTiger) Payout(pt string, amount, odds decimal.Decimal, out *Outcome) (decimal.Decimal, error) {
+	pt = strings.ToLower(pt)
+	switch pt {
+	case "dragon", "tiger", "tie":
+		if pt == out.Result {
+			return winPayout(amount, odds), nil
+		}
+		if pt != "tie" && out.Result == "tie" {
+			return amount, nil
+		}
+		return decimal.Zero, nil
+	}
+	if !dtPlayTypes[pt] {
+		return decimal.Zero, fmt.Errorf("%w: %s", ErrPlayTypeNotInGame, pt)
+	}
+	if dtNeedsSuit(pt) && !allSuited(out.Cards["dragon"], out.Cards["tiger"]) {
+		return decimal.Zero, ErrCardSuitRequired
+	}
+	if dtSideBetWins(pt, out) {
+		return winPayout(amount, odds), nil
+	}
+	return decimal.Zero, nil
+}
+
+func (dragonTiger) Deal(next func() (shoe.Card, error)) (string, error) {
+	d, err := next()
+	if err != nil {
+		return "", err
+	}
+	t, err := next()
+	if err != nil {
+		return "", err
+	}
+	return "D" + d.String() + ",T" + t.String(), nil
+}
+
+func (dragonTiger) Details(out *Outcome) map[string]any {
+	suited := allSuited(out.Cards["dragon"], out.Cards["tiger"])
+	wins := make([]string, 0, 4)
+	for _, pt := range dtSideBets {
+		if dtNeedsSuit(pt) && !suited {
+			continue
+		}
+		if dtSideBetWins(pt, out) {
+			wins = append(wins, pt)
+		}
+	}
+	return map[string]any{"side_wins": wins}
+}
+
+// dtNeedsSuit 需要花色才能结算的边注
+func dtNeedsSuit(pt string) bool {
+	return pt == "suited_tie" || strings.HasSuffix(pt, "_red") || strings.HasSuffix(pt, "_black")
+}
+
+// dtSideBetWins 判断边注是否中奖
+func dtSideBetWins(pt string, out *Outcome) bool {
+	d, t := out.Cards["dragon"][0], out.Cards["tiger"][0]
+	if pt == "suited_tie" {
+		return d.Suit != 0 && d.Rank == t.Rank && d.Suit == t.Suit
+	}
+	side, kind, ok := strings.Cut(pt, "_")
+	if !ok {
+		return false
+	}
+	c := d
+	if side == "tiger" {
+		c = t
+	}
+	switch kind {
+	case "big":
+		return c.Rank >= 8
+	case "small":
+		return c.Rank <= 6
+	case "odd":
+		return c.Rank != 7 && c.Rank%2 == 1
+	case "even":
+		return c.Rank%2 == 0
+	case "red":
+		return c.Suit == 'H' || c.Suit == 'D'
+	case "black":
+		return c.Suit == 'S' || c.Suit == 'C'
+	}
+	return false
+}
@@ -0,0 +1,203 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	decimal "github.com/shopspring/decimal"
+)
+
+// dtOutcome 解析并判定龙虎牌面
+func dtOutcome(t *testing.T, cardList string) *Outcome {
+	t.Helper()
+	h, err := DragonTiger.ParseCards(cardList)
+	if err != nil {
+		t.Fatalf("%s: parse: %v", cardList, err)
+	}
+	out, err := DragonTiger.DecideResult(h)
+	if err != nil {
+		t.Fatalf("%s: decide: %v", cardList, err)
+	}
+	return out
+}
+
+func TestDTRankSideBets(t *testing.T) {
+	// 大小单双只看点数：8 点起为大，6 点及以下为小，7 点大小单双均输
+	cases := []struct {
+		rank                  int
+		big, small, odd, even bool
+	}{
+		{1, false, true, true, false},
+		{2, false, true, false, true},
+		{6, false, true, false, true},
+		{7, false, false, false, false},
+		{8, true, false, false, true},
+		{9, true, false, true, false},
+		{13, true, false, true, false},
+	}
+	for _, c := range cases {
+		want := map[string]bool{"big": c.big, "small": c.small, "odd": c.odd, "even": c.even}
+		for _, side := range []string{"dragon", "tiger"} {
+			// 另一边固定为 7 点，避免干扰本边的判定
+			cardList := fmt.Sprintf("D%ds,T7h", c.rank)
+			if side == "tiger" {
+				cardList = fmt.Sprintf("D7h,T%ds", c.rank)
+			}
+			out := dtOutcome(t, cardList)
+			for kind, w := range want {
+				if got := dtSideBetWins(side+"_"+kind, out); got != w {
+					t.Fatalf("%s: %s_%s wins=%v, want %v", cardList, side, kind, got, w)
+				}
+			}
+		}
+	}
+}
+
+func TestDTColorSideBets(t *testing.T) {
+	cases := []struct {
+		cardList string
+		wins     []string
+	}{
+		{"D1h,T1s", []string{"dragon_red", "tiger_black"}},
+		{"D2d,T2c", []string{"dragon_red", "tiger_black"}},
+		{"D3s,T3h", []string{"dragon_black", "tiger_red"}},
+		{"D4c,T4d", []string{"dragon_black", "tiger_red"}},
+		{"D7H,T7D", []string{"dragon_red", "tiger_red"}}, // 大写花色同样识别；7 点不影响红黑
+	}
+	colors := []string{"dragon_red", "dragon_black", "tiger_red", "tiger_black"}
+	for _, c := range cases {
+		out := dtOutcome(t, c.cardList)
+		for _, pt := range colors {
+			want := false
+			for _, w := range c.wins {
+				want = want || w == pt
+			}
+			if got := dtSideBetWins(pt, out); got != want {
+				t.Fatalf("%s: %s wins=%v, want %v", c.cardList, pt, got, want)
+			}
+		}
+	}
+}
+
+func TestDTSuitParsing(t *testing.T) {
+	h, err := DragonTiger.ParseCards("D13h,T13h")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	d, tg := h.Cards["dragon"][0], h.Cards["tiger"][0]
+	if d.Rank != 13 || d.Suit != 'H' || tg.Rank != 13 || tg.Suit != 'H' {
+		t.Fatalf("cards=%v/%v, want 13H/13H", d, tg)
+	}
+	// 无花色的牌 Suit 为 0
+	h, err = DragonTiger.ParseCards("D13,T1")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if d, tg := h.Cards["dragon"][0], h.Cards["tiger"][0]; d.Suit != 0 || tg.Suit != 0 || tg.Rank != 1 {
+		t.Fatalf("cards=%v/%v, want suitless 13/1", d, tg)
+	}
+	for _, bad := range []string{"D13h", "D13h,T13h,T1s", "D14h,T1s", "D13x,T1s", "P13h,T1s"} {
+		if _, err := DragonTiger.ParseCards(bad); !errors.Is(err, ErrInvalidCardList) {
+			t.Fatalf("%s: want ErrInvalidCardList, got %v", bad, err)
+		}
+	}
+}
+
+func TestDTTiePayout(t *testing.T) {
+	odds := map[string]decimal.Decimal{
+		"dragon":     decimal.NewFromInt(1),
+		"tiger":      decimal.NewFromInt(1),
+		"tie":        decimal.NewFromInt(8),
+		"suited_tie": decimal.NewFromInt(50),
+	}
+	cases := []struct {
+		cardList string
+		result   string
+		want     map[string]string
+	}{
+		// 同花和：和与同花和都中，押龙虎退本金
+		{"D13h,T13h", "tie", map[string]string{"dragon": "100", "tiger": "100", "tie": "900", "suited_tie": "5100"}},
+		// 普通和：点数相同花色不同，同花和不中
+		{"D13h,T13s", "tie", map[string]string{"dragon": "100", "tiger": "100", "tie": "900", "suited_tie": "0"}},
+		// 同花色但点数不同不是同花和
+		{"D12h,T13h", "tiger", map[string]string{"dragon": "0", "tiger": "200", "tie": "0", "suited_tie": "0"}},
+		{"D2s,T1s", "dragon", map[string]string{"dragon": "200", "tiger": "0", "tie": "0", "suited_tie": "0"}},
+	}
+	amount := decimal.NewFromInt(100)
+	for _, c := range cases {
+		out := dtOutcome(t, c.cardList)
+		if out.Result != c.result {
+			t.Fatalf("%s: result=%s, want %s", c.cardList, out.Result, c.result)
+		}
+		for pt, w := range c.want {
+			got, err := DragonTiger.Payout(pt, amount, odds[pt], out)
+			if err != nil {
+				t.Fatalf("%s: bet %s: %v", c.cardList, pt, err)
+			}
+			if !got.Equal(decimal.RequireFromString(w)) {
+				t.Fatalf("%s: bet %s payout=%s, want %s", c.cardList, pt, got, w)
+			}
+		}
+	}
+	if _, err := DragonTiger.Payout("player", amount, odds["dragon"], dtOutcome(t, "D1h,T2h")); !errors.Is(err, ErrPlayTypeNotInGame) {
+		t.Fatalf("player on dragon tiger: want ErrPlayTypeNotInGame, got %v", err)
+	}
+}
+
+func TestDTSuitRequired(t *testing.T) {
+	amount, odds := decimal.NewFromInt(100), decimal.NewFromInt(1)
+	cases := []struct {
+		cardList string
+		pt       string
+		want     string // 空表示 ErrCardSuitRequired
+	}{
+		{"D13,T13", "suited_tie", ""},
+		{"D13,T13", "dragon_red", ""},
+		{"D13,T13", "tiger_black", ""},
+		{"D13h,T13", "suited_tie", ""}, // 只缺一边的花色也无法结算
+		{"D13h,T13", "dragon_red", ""},
+		// 不依赖花色的注照常结算
+		{"D13,T13", "tie", "200"},
+		{"D13,T13", "dragon", "100"},
+		{"D13,T13", "dragon_big", "200"},
+		{"D13,T12", "tiger_even", "200"},
+		{"D7,T12", "dragon_odd", "0"},
+	}
+	for _, c := range cases {
+		out := dtOutcome(t, c.cardList)
+		got, err := DragonTiger.Payout(c.pt, amount, odds, out)
+		if c.want == "" {
+			if !errors.Is(err, ErrCardSuitRequired) {
+				t.Fatalf("%s: bet %s: want ErrCardSuitRequired, got %v", c.cardList, c.pt, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: bet %s: %v", c.cardList, c.pt, err)
+		}
+		if !got.Equal(decimal.RequireFromString(c.want)) {
+			t.Fatalf("%s: bet %s payout=%s, want %s", c.cardList, c.pt, got, c.want)
+		}
+	}
+}
+
+func TestDTDetails(t *testing.T) {
+	cases := []struct {
+		cardList string
+		wins     []string
+	}{
+		{"D13h,T13h", []string{"suited_tie", "dragon_big", "dragon_odd", "dragon_red", "tiger_big", "tiger_odd", "tiger_red"}},
+		{"D7s,T2d", []string{"dragon_black", "tiger_small", "tiger_even", "tiger_red"}},
+		// 缺花色时跳过同花和与红黑
+		{"D13,T13", []string{"dragon_big", "dragon_odd", "tiger_big", "tiger_odd"}},
+		{"D7,T7", []string{}},
+	}
+	for _, c := range cases {
+		got := DragonTiger.Details(dtOutcome(t, c.cardList))["side_wins"]
+		if !reflect.DeepEqual(got, c.wins) {
+			t.Fatalf("%s: side_wins=%v, want %v", c.cardList, got, c.wins)
+		}
+	}
+}
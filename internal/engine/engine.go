@@ -0,0 +1,165 @@
+// Package engine 按 game_id 可插拔的游戏引擎
+//
+// 每个引擎负责一种牌桌游戏的规则：解析 card_list、判定结果、校验玩法、计算派彩以及服务端发牌顺序。
+// 注单、账本、结算流程与状态机与游戏无关，由 service 层统一处理。
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"dt-server/internal/shoe"
+
+	decimal "github.com/shopspring/decimal"
+)
+
+var (
+	ErrUnknownGame       = errors.New("unknown game_id")
+	ErrInvalidCardList   = errors.New("invalid card list")
+	ErrCardSuitRequired  = errors.New("card list must carry suits to settle red/black or suited tie bets")
+	ErrPlayTypeNotInGame = errors.New("play type not supported by game")
+)
+
+// Hand 解析后的牌面
+type Hand struct {
+	Cards  map[string][]shoe.Card // 位置 -> 牌（按发牌顺序），如 dragon/tiger、player/banker
+	Forced string                 // 显式结果标记（card_list 中的 R<结果>，仅人工录入使用），空表示按牌面计算
+}
+
+// Outcome 一局的开奖结果
+type Outcome struct {
+	Hand
+	Result string         // 主结果，取值为本游戏的主注玩法名（如 dragon|tiger|tie、player|banker|tie）
+	Points map[string]int // 各位置点数
+}
+
+// GameEngine 游戏引擎
+type GameEngine interface {
+	// Name 引擎名（dragon_tiger / baccarat）
+	Name() string
+	// ParseCards 解析 card_list，格式错误返回包装了 ErrInvalidCardList 的错误
+	ParseCards(cardList string) (*Hand, error)
+	// DecideResult 按牌面判定结果；hand.Forced 非空时以显式标记为准
+	DecideResult(h *Hand) (*Outcome, error)
+	// ValidPlayType 玩法是否属于本游戏
+	ValidPlayType(playType string) bool
+	// Results 全部可能的主结果（与 Outcome.Result 取值一致），用于按结果计算庄家赔付敞口
+	Results() []string
+	// Payout 单注派彩（含本金，输为 0）；牌面信息不足以结算该玩法时返回错误（如 ErrCardSuitRequired）
+	Payout(playType string, amount, odds decimal.Decimal, out *Outcome) (decimal.Decimal, error)
+	// Deal 服务端发牌：按游戏规则从 next 依次取牌，返回 card_list
+	Deal(next func() (shoe.Card, error)) (string, error)
+	// MaxCards 单局最多用牌数（用于判断牌靴剩余是否足够、种子发牌张数）
+	MaxCards() int
+	// Details 附加到 game_drawn 消息的游戏相关信息（点数、中奖边注等）
+	Details(out *Outcome) map[string]any
+}
+
+var (
+	mu       sync.RWMutex
+	registry = make(map[string]GameEngine)
+)
+
+// Register 按 game_id 注册引擎，重复注册直接 panic（仅在包初始化阶段调用）
+func Register(gameID string, e GameEngine) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, dup := registry[gameID]; dup {
+		panic("engine: duplicate game_id " + gameID)
+	}
+	registry[gameID] = e
+}
+
+// For 按 game_id 查找引擎；game_id 为空（房间注册表上线前的历史牌局）按龙虎处理
+func For(gameID string) (GameEngine, error) {
+	if gameID == "" {
+		return DragonTiger, nil
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	if e, ok := registry[gameID]; ok {
+		return e, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownGame, gameID)
+}
+
+// 内置引擎
+var (
+	DragonTiger GameEngine = dragonTiger{}
+	Baccarat    GameEngine = baccarat{}
+)
+
+func init() {
+	for _, id := range []string{"dt", "dt_game", "dragon_tiger"} {
+		Register(id, DragonTiger)
+	}
+	for _, id := range []string{"bac", "baccarat"} {
+		Register(id, Baccarat)
+	}
+}
+
+// splitCardList 按逗号切分 card_list，返回 (位置前缀, 牌) 序列与显式结果标记
+// 牌：<前缀字母><点数>[花色]，花色字母 s/h/d/c 大小写均可，未带花色时 Suit 为 0；结果标记：R<结果>
+func splitCardList(input string, prefixes string) (seq []prefixedCard, forced string, err error) {
+	for _, token := range strings.Split(strings.TrimSpace(input), ",") {
+		tok := strings.ToLower(strings.TrimSpace(token))
+		if tok == "" {
+			continue
+		}
+		if tok[0] == 'r' {
+			if len(tok) == 1 || forced != "" {
+				return nil, "", fmt.Errorf("%w: bad result token %q", ErrInvalidCardList, token)
+			}
+			forced = tok[1:]
+			continue
+		}
+		if !strings.ContainsRune(prefixes, rune(tok[0])) {
+			return nil, "", fmt.Errorf("%w: unexpected token %q", ErrInvalidCardList, token)
+		}
+		c, ok := parseCardToken(tok[1:])
+		if !ok {
+			return nil, "", fmt.Errorf("%w: bad card %q", ErrInvalidCardList, token)
+		}
+		seq = append(seq, prefixedCard{Prefix: tok[0], Card: c})
+	}
+	return seq, forced, nil
+}
+
+type prefixedCard struct {
+	Prefix byte
+	Card   shoe.Card
+}
+
+// parseCardToken 解析 <点数>[花色]（已转小写），花色转为大写字母
+func parseCardToken(tok string) (shoe.Card, bool) {
+	var suit byte
+	if n := len(tok); n > 1 && strings.ContainsRune("shdc", rune(tok[n-1])) {
+		suit = tok[n-1] - 'a' + 'A'
+		tok = tok[:n-1]
+	}
+	val, err := strconv.Atoi(tok)
+	if err != nil || val < 1 || val > 13 {
+		return shoe.Card{}, false
+	}
+	return shoe.Card{Rank: val, Suit: suit}, true
+}
+
+// allSuited 所有牌是否都带花色
+func allSuited(cards ...[]shoe.Card) bool {
+	for _, cs := range cards {
+		for _, c := range cs {
+			if c.Suit == 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// winPayout 中奖派彩 = 本金 × (1 + 赔率)
+func winPayout(amount, odds decimal.Decimal) decimal.Decimal {
+	return amount.Mul(decimal.NewFromInt(1).Add(odds)).Round(2)
+}
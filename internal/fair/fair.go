@@ -0,0 +1,99 @@
+// Package fair 实现牌靴级可验证公平（commit/reveal）
+//
+// 流程：
+//  1. 开靴时生成随机 server_seed（32 字节，hex 编码），以 (server_seed, 靴号) 对未洗的 decks 副整靴做确定性
+//     Fisher-Yates 得到整靴牌序，公布 seed_hash = hex(SHA256(server_seed))；烧牌、切牌照常在该牌序上进行
+//  2. game_start 时本局承诺所用牌靴的 seed_hash，new_card 时从牌靴当前下标依次发牌并记录起始下标
+//  3. 换靴时公布旧靴的 server_seed，任何人都可以重新计算 seed_hash 与整靴牌序，按各局起始下标核对牌面
+//
+// 旧版按局种子（每局独立 server_seed，nonce 为局号，取派生牌序的前 n 张）仍可用 DealCards 复算。
+//
+// 随机数流：HMAC-SHA256(key=server_seed, msg="{nonce}:{counter}")，每块 32 字节按大端 uint64 切分，
+// 取模前做拒绝采样以消除偏差。
+package fair
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"strconv"
+
+	"dt-server/internal/shoe"
+)
+
+// NewServerSeed 生成 32 字节随机种子（hex 编码）
+func NewServerSeed() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Commit 计算种子承诺：hex(SHA256(server_seed))，对 hex 字符串本身做哈希
+func Commit(serverSeed string) string {
+	sum := sha256.Sum256([]byte(serverSeed))
+	return hex.EncodeToString(sum[:])
+}
+
+// Verify 校验公布的种子是否与承诺一致
+func Verify(serverSeed, seedHash string) bool {
+	return hmac.Equal([]byte(Commit(serverSeed)), []byte(seedHash))
+}
+
+// DealCards 由种子与 nonce 确定性地派生 n 张牌
+func DealCards(serverSeed, nonce string, decks, n int) []shoe.Card {
+	cards := shoe.NewDeck(decks)
+	if n > len(cards) {
+		n = len(cards)
+	}
+	s := newStream(serverSeed, nonce)
+	for i := 0; i < n; i++ {
+		j := i + s.intn(len(cards)-i)
+		cards[i], cards[j] = cards[j], cards[i]
+	}
+	return cards[:n]
+}
+
+// ShuffleShoe 由种子与 nonce（靴号）确定性地派生 decks 副整靴牌序
+func ShuffleShoe(serverSeed, nonce string, decks int) []shoe.Card {
+	return DealCards(serverSeed, nonce, decks, decks*52)
+}
+
+// stream 基于 HMAC-SHA256 的确定性随机数流
+type stream struct {
+	key     []byte
+	nonce   string
+	counter int
+	buf     []byte
+}
+
+func newStream(serverSeed, nonce string) *stream {
+	return &stream{key: []byte(serverSeed), nonce: nonce}
+}
+
+func (s *stream) uint64() uint64 {
+	if len(s.buf) < 8 {
+		mac := hmac.New(sha256.New, s.key)
+		mac.Write([]byte(s.nonce + ":" + strconv.Itoa(s.counter)))
+		s.buf = mac.Sum(nil)
+		s.counter++
+	}
+	v := binary.BigEndian.Uint64(s.buf[:8])
+	s.buf = s.buf[8:]
+	return v
+}
+
+// intn 返回 [0, n) 的均匀整数（拒绝采样）
+func (s *stream) intn(n int) int {
+	max := uint64(n)
+	limit := ^uint64(0) - (^uint64(0) % max)
+	for {
+		v := s.uint64()
+		if v < limit {
+			return int(v % max)
+		}
+	}
+}
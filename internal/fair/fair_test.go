@@ -0,0 +1,112 @@
+package fair
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"dt-server/internal/shoe"
+)
+
+const testSeed = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+// dealVector DealCards(testSeed, "R1", 8, 6) 的结果
+const dealVector = "10C,6S,6S,3C,12C,7S"
+
+func TestCommitAndVerify(t *testing.T) {
+	// SHA256("abc")
+	if got := Commit("abc"); got != "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad" {
+		t.Fatalf("commit mismatch: %s", got)
+	}
+	seed, err := NewServerSeed()
+	if err != nil {
+		t.Fatalf("new seed: %v", err)
+	}
+	if b, err := hex.DecodeString(seed); err != nil || len(b) != 32 {
+		t.Fatalf("seed is not 32-byte hex: %s", seed)
+	}
+	hash := Commit(seed)
+	if !Verify(seed, hash) {
+		t.Fatalf("verify failed: seed=%s hash=%s", seed, hash)
+	}
+	if Verify(seed+"0", hash) || Verify(testSeed, hash) {
+		t.Fatalf("verify accepted a different seed")
+	}
+	if Verify(seed, hash[:63]+"x") {
+		t.Fatalf("verify accepted a different hash")
+	}
+}
+
+func TestDealCardsDeterministic(t *testing.T) {
+	a := shoe.Encode(DealCards(testSeed, "R1", 8, 6))
+	b := shoe.Encode(DealCards(testSeed, "R1", 8, 6))
+	if a != b {
+		t.Fatalf("same seed and nonce dealt differently: %s vs %s", a, b)
+	}
+	if a == shoe.Encode(DealCards(testSeed, "R2", 8, 6)) {
+		t.Fatalf("different nonce dealt the same cards: %s", a)
+	}
+	if a == shoe.Encode(DealCards(testSeed[1:]+"0", "R1", 8, 6)) {
+		t.Fatalf("different seed dealt the same cards: %s", a)
+	}
+	// 已公布的算法不得改变：固定种子的牌面须与历史结果一致
+	if a != dealVector {
+		t.Fatalf("deal vector changed: got %s, want %s", a, dealVector)
+	}
+}
+
+func TestDealCardsPrefix(t *testing.T) {
+	// 前 n 张只取决于种子与 nonce，与取多少张无关
+	full := DealCards(testSeed, "R1", 8, 416)
+	for _, n := range []int{1, 2, 6, 100} {
+		got := DealCards(testSeed, "R1", 8, n)
+		if shoe.Encode(got) != shoe.Encode(full[:n]) {
+			t.Fatalf("first %d cards differ from full shoe", n)
+		}
+	}
+	if n := len(DealCards(testSeed, "R1", 1, 60)); n != 52 {
+		t.Fatalf("n larger than the deck: got %d cards, want 52", n)
+	}
+}
+
+func TestShuffleShoeIsPermutation(t *testing.T) {
+	for _, decks := range []int{1, 6, 8} {
+		cards := ShuffleShoe(testSeed, "S1", decks)
+		if len(cards) != decks*52 {
+			t.Fatalf("decks=%d: got %d cards", decks, len(cards))
+		}
+		count := map[shoe.Card]int{}
+		for _, c := range cards {
+			count[c]++
+		}
+		for _, c := range shoe.NewDeck(1) {
+			if count[c] != decks {
+				t.Fatalf("decks=%d: card %s appears %d times", decks, c, count[c])
+			}
+		}
+		if shoe.Encode(cards) == shoe.Encode(shoe.NewDeck(decks)) {
+			t.Fatalf("decks=%d: shoe was not shuffled", decks)
+		}
+	}
+	if shoe.Encode(ShuffleShoe(testSeed, "S1", 8)) != shoe.Encode(DealCards(testSeed, "S1", 8, 416)) {
+		t.Fatalf("shuffle shoe differs from dealing the whole shoe")
+	}
+}
+
+func TestIntnUniform(t *testing.T) {
+	s := newStream(testSeed, "uniform")
+	const n, draws = 13, 13000
+	var hist [n]int
+	for i := 0; i < draws; i++ {
+		v := s.intn(n)
+		if v < 0 || v >= n {
+			t.Fatalf("intn out of range: %d", v)
+		}
+		hist[v]++
+	}
+	for v, c := range hist {
+		// 期望 1000，允许 ±20%
+		if c < 800 || c > 1200 {
+			t.Fatalf("value %d drawn %d times out of %d", v, c, draws)
+		}
+	}
+}
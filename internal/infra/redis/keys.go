@@ -10,11 +10,27 @@ const (
 	// PrefixBetIdemLock：投注幂等“进行中锁”Key 的前缀。
 	// 作用：使用 SETNX + TTL 标记 idempotency key 正在处理，吸收瞬时重复请求，减轻数据库压力。
 	PrefixBetIdemLock = "bet:idem:lock:"
+	// PrefixBetBatchIdemResult / PrefixBetBatchIdemLock：批量投注的幂等结果缓存与进行中锁（结果为 BatchBetOutput JSON）
+	PrefixBetBatchIdemResult = "bet:batch:idem:result:"
+	PrefixBetBatchIdemLock   = "bet:batch:idem:lock:"
 
 	// PrefixRoundInfo：开局信息缓存（例如下注窗口），用于前端倒计时等快速查询
 	PrefixRoundInfo = "game:round:"
+	// PrefixRoundState：回合下注状态缓存（状态、下注窗口、状态版本），下注前据此快速拒绝已封盘的请求
+	PrefixRoundState = "game:state:"
 	// PrefixRoundResult：开奖结果缓存
 	PrefixRoundResult = "game:result:"
+	// PrefixRoomRoads：房间当前牌靴的路单缓存（结算时增量更新，缺失时由 game_round_info 重建）
+	PrefixRoomRoads = "game:roads:"
+	// PrefixRoundExposure：单局赔付敞口 Hash（各玩法下注额/潜在派彩、各结果净赔付，单位分），缺失时由 orders 重建
+	PrefixRoundExposure = "game:exposure:"
+
+	// PrefixSchedulerLease：自动开局调度的房间租约，保证多实例部署时同一房间只有一个调度者
+	PrefixSchedulerLease = "scheduler:room:"
+	// WatchdogLeaseKey：卡局巡检租约，多实例部署时只有一个实例执行巡检与自动处置
+	WatchdogLeaseKey = "watchdog:rounds"
+	// SeamlessWalletLeaseKey：seamless 钱包投递与对账租约，多实例部署时只有一个实例调用平台钱包
+	SeamlessWalletLeaseKey = "seamless:wallet"
 )
 
 // IdemResultKey：构造幂等“结果缓存”的完整 Key。
@@ -25,8 +41,24 @@ func IdemResultKey(k string) string { return PrefixBetIdemResult + k }
 // 形如：bet:idem:lock:{idempotency_key}
 func IdemLockKey(k string) string { return PrefixBetIdemLock + k }
 
+// BatchIdemResultKey / BatchIdemLockKey：批量投注幂等 Key。形如：bet:batch:idem:result:{idempotency_key}
+func BatchIdemResultKey(k string) string { return PrefixBetBatchIdemResult + k }
+func BatchIdemLockKey(k string) string   { return PrefixBetBatchIdemLock + k }
+
 // RoundInfoKey：构造游戏局信息缓存 Key。形如：game:round:{round_id}
 func RoundInfoKey(roundID string) string { return PrefixRoundInfo + roundID }
 
+// RoundStateKey：构造回合下注状态缓存 Key。形如：game:state:{round_id}
+func RoundStateKey(roundID string) string { return PrefixRoundState + roundID }
+
 // RoundResultKey：构造开奖结果缓存 Key。形如：game:result:{round_id}
 func RoundResultKey(roundID string) string { return PrefixRoundResult + roundID }
+
+// RoomRoadsKey：构造房间路单缓存 Key。形如：game:roads:{room_id}
+func RoomRoadsKey(roomID string) string { return PrefixRoomRoads + roomID }
+
+// RoundExposureKey：构造单局赔付敞口 Key。形如：game:exposure:{round_id}
+func RoundExposureKey(roundID string) string { return PrefixRoundExposure + roundID }
+
+// SchedulerLeaseKey：构造房间调度租约 Key。形如：scheduler:room:{room_id}
+func SchedulerLeaseKey(roomID string) string { return PrefixSchedulerLease + roomID }
@@ -18,6 +18,8 @@ import (
 // Publisher is a minimal facade for sending messages.
 type Publisher interface {
 	Publish(topic string, body []byte) error
+	// PublishDelayed sends a message that becomes visible to consumers after delay (broker timed message).
+	PublishDelayed(topic string, body []byte, delay time.Duration) error
 }
 
 // Consumer placeholder retained for future extension.
@@ -49,10 +51,17 @@ func PublisherInstance() Publisher {
 type rmqPublisher struct{ p rmq.Producer }
 
 func (r *rmqPublisher) Publish(topic string, body []byte) error {
+	return r.PublishDelayed(topic, body, 0)
+}
+
+func (r *rmqPublisher) PublishDelayed(topic string, body []byte, delay time.Duration) error {
 	if r.p == nil {
 		return nil
 	}
 	msg := &rmq.Message{Topic: topic, Body: body}
+	if delay > 0 {
+		msg.SetDelayTimestamp(time.Now().Add(delay))
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	_, err := r.p.Send(ctx, msg)
@@ -67,6 +76,10 @@ func (s *stubPublisher) Publish(topic string, body []byte) error {
 	return nil
 }
 
+func (s *stubPublisher) PublishDelayed(topic string, body []byte, delay time.Duration) error {
+	return s.Publish(topic, body)
+}
+
 func initMQ() {
 	// Use SDK's ResetLogger to avoid default file-based logging under /logs
 	rmq.ResetLogger()
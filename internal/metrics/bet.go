@@ -38,3 +38,17 @@ func RecordBet(result, playType string, started time.Time) {
 	betDuration.WithLabelValues(res, pt).Observe(durMs)
 }
 
+
+var betCancelTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "bet_cancels_total",
+		Help: "Total bet cancel requests by result",
+	},
+	[]string{"result"},
+)
+
+// RecordBetCancel 记录撤单结果
+// result: "success" | "fail"
+func RecordBetCancel(result string) {
+	betCancelTotal.WithLabelValues(result).Inc()
+}
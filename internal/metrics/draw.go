@@ -40,3 +40,17 @@ func RecordDraw(result, outcome string, started time.Time) {
 	drawDuration.WithLabelValues(res, oc).Observe(durMs)
 }
 
+
+var drawApprovalTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "draw_approvals_total",
+		Help: "Draw result maker-checker actions",
+	},
+	[]string{"action"},
+)
+
+// RecordDrawApproval 记录开奖复核动作
+// action: "submitted" | "approved" | "auto_approved" | "rejected" | "mismatch"
+func RecordDrawApproval(action string) {
+	drawApprovalTotal.WithLabelValues(action).Inc()
+}
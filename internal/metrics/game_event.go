@@ -28,11 +28,11 @@ var (
 )
 
 // RecordGameEvent 记录 GameEvent 的业务指标
-// result: "success" | "fail"
+// result: "success" | "duplicate"（重复投递，按成功处理）| "fail"
 // eventType: 事件类型（小写）
 func RecordGameEvent(result, eventType string, started time.Time) {
 	res := result
-	if res != "success" { res = "fail" }
+	if res != "success" && res != "duplicate" { res = "fail" }
 	et := strings.ToLower(strings.TrimSpace(eventType))
 	if et == "" { et = "unknown" }
 	gameEventTotal.WithLabelValues(res, et).Inc()
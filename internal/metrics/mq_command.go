@@ -0,0 +1,23 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var mqCommandTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "mq_command_total",
+		Help: "Inbound MQ commands handled, by command and result",
+	},
+	[]string{"command", "result"},
+)
+
+// RecordMQCommand 记录一次入站命令处理
+// result: "success" | "duplicate" | "retry" | "dead_letter"
+func RecordMQCommand(command, result string) {
+	if command == "" {
+		command = "unknown"
+	}
+	mqCommandTotal.WithLabelValues(command, result).Inc()
+}
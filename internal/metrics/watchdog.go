@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	roundStuckTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "round_stuck_total",
+			Help: "Total rounds detected stuck by the watchdog, by state",
+		},
+		[]string{"state"},
+	)
+
+	roundsStuck = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "rounds_stuck",
+			Help: "Rounds currently stuck beyond the threshold of their state",
+		},
+		[]string{"state"},
+	)
+
+	watchdogRemediationTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "round_watchdog_remediation_total",
+			Help: "Automatic remediations run by the round watchdog, by action and result",
+		},
+		[]string{"action", "result"},
+	)
+)
+
+// RecordRoundStuck 新发现一局卡住（同一局同一状态只计一次）
+func RecordRoundStuck(state string) {
+	roundStuckTotal.WithLabelValues(state).Inc()
+}
+
+// SetRoundsStuck 本轮扫描时各状态卡住的局数
+func SetRoundsStuck(state string, n int) {
+	roundsStuck.WithLabelValues(state).Set(float64(n))
+}
+
+// RecordWatchdogRemediation 记录自动处置
+// action: "seal" | "void"；result: "success" | "fail"
+func RecordWatchdogRemediation(action, result string) {
+	if result != "success" {
+		result = "fail"
+	}
+	watchdogRemediationTotal.WithLabelValues(action, result).Inc()
+}
@@ -8,6 +8,7 @@ import (
 	"dt-server/internal/common/helper"
 	"dt-server/internal/common/response"
 	"dt-server/internal/config"
+	"dt-server/internal/model"
 
 	beegocontext "github.com/beego/beego/v2/server/web/context"
 	"go.uber.org/zap"
@@ -55,8 +56,9 @@ func AdminAuthFilter(ctx *beegocontext.Context) {
 
 	token := parts[1]
 
-	// 验证 Token
-	if token != cfg.Auth.Admin.Token {
+	// 验证 Token：具名管理员优先，共享 Token 的身份统一为 "admin"
+	name := adminName(cfg, token)
+	if name == "" {
 		logger.Warn("invalid admin token",
 			zap.String("trace_id", traceID),
 			zap.String("token_prefix", token[:min(len(token), 8)]+"..."))
@@ -64,10 +66,40 @@ func AdminAuthFilter(ctx *beegocontext.Context) {
 		return
 	}
 
-	// 标记为管理员请求
+	// 扫描设备只能提交开奖结果，不能调用其他管理接口（复核、更正等）
+	if strings.HasPrefix(name, model.ScannerMakerPrefix) && ctx.Input.URL() != "/api/drawresult" {
+		logger.Warn("scanner token used outside drawresult",
+			zap.String("admin", name), zap.String("path", ctx.Input.URL()), zap.String("trace_id", traceID))
+		returnAuthError(403, "扫描设备无权访问该接口")
+		return
+	}
+
+	// 标记为管理员请求，并记录管理员身份（开奖复核据此区分提交人与复核人）
 	ctx.Input.SetData("is_admin", true)
+	ctx.Input.SetData("admin", name)
+
+	logger.Debug("admin authentication successful", zap.String("admin", name), zap.String("trace_id", traceID))
+}
 
-	logger.Debug("admin authentication successful", zap.String("trace_id", traceID))
+// adminName Token 对应的管理员身份，无效 Token 返回空串；扫描设备的身份为 scan:<id>
+func adminName(cfg *config.Config, token string) string {
+	if token == "" {
+		return ""
+	}
+	for _, u := range cfg.Auth.Admin.Users {
+		if u.Token != "" && u.Name != "" && token == u.Token {
+			return u.Name
+		}
+	}
+	for _, sc := range cfg.Auth.Admin.Scanners {
+		if sc.Token != "" && sc.ID != "" && token == sc.Token {
+			return model.ScannerMakerPrefix + strings.ToLower(sc.ID)
+		}
+	}
+	if token == cfg.Auth.Admin.Token {
+		return "admin"
+	}
+	return ""
 }
 
 func min(a, b int) int {
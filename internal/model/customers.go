@@ -8,20 +8,21 @@ import (
 	"dt-server/common/logger"
 
 	"github.com/jmoiron/sqlx"
+	decimal "github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
 // Customers 用户表
 // 用户唯一标识 = platform_id + platform_user_id
 type Customers struct {
-	ID             int64   `db:"user_id"`          // 自增ID（内部使用）
-	PlatformID     int8    `db:"platform_id"`      // 平台ID
-	PlatformUserID string  `db:"platform_user_id"` // 平台用户ID
-	Username       string  `db:"username"`         // 用户名（可选）
-	Balance        float64 `db:"balance"`          // 余额
-	Status         int8    `db:"status"`           // 状态: 1=正常 0=禁用
-	CreatedAt      int64   `db:"created_at"`       // 创建时间（13位毫秒时间戳）
-	UpdatedAt      int64   `db:"updated_at"`       // 更新时间（13位毫秒时间戳）
+	ID             int64           `db:"user_id"`          // 自增ID（内部使用）
+	PlatformID     int8            `db:"platform_id"`      // 平台ID
+	PlatformUserID string          `db:"platform_user_id"` // 平台用户ID
+	Username       string          `db:"username"`         // 用户名（可选）
+	Balance        decimal.Decimal `db:"balance"`          // 余额（已废弃：余额按币种记在 wallets，见 Wallet）
+	Status         int8            `db:"status"`           // 状态: 1=正常 2=禁用 3=禁止提款（见 constant.UserNotAllowWithdraw）
+	CreatedAt      int64           `db:"created_at"`       // 创建时间（13位毫秒时间戳）
+	UpdatedAt      int64           `db:"updated_at"`       // 更新时间（13位毫秒时间戳）
 }
 
 // GetUserByPlatformUser 根据平台ID和平台用户ID查询用户
@@ -71,6 +72,30 @@ func GetUserByPlatformUserForUpdate(ctx context.Context, exec sqlx.ExtContext, p
 	return &user, nil
 }
 
+// GetUserByPlatformUserShared 根据平台ID和平台用户ID查询用户（共享锁，LOCK IN SHARE MODE）
+// 必须在事务中调用；同一用户的并发事务互不阻塞，且与加锁读取一样不建立一致性读快照
+func GetUserByPlatformUserShared(ctx context.Context, exec sqlx.ExtContext, platformID int8, platformUserID string) (*Customers, error) {
+	query := `SELECT user_id, platform_id, platform_user_id, username, balance, status, created_at, updated_at
+	          FROM customers
+	          WHERE platform_id = ? AND platform_user_id = ?
+	          LOCK IN SHARE MODE`
+
+	var user Customers
+	err := sqlx.GetContext(ctx, exec, &user, query, platformID, platformUserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		logger.Error("get user by platform user shared failed",
+			zap.Int8("platform_id", platformID),
+			zap.String("platform_user_id", platformUserID),
+			zap.Error(err))
+		return nil, err
+	}
+
+	return &user, nil
+}
+
 // GetUserByID 根据内部ID查询用户
 func GetUserByID(ctx context.Context, db *sqlx.DB, userID int64) (*Customers, error) {
 	query := `SELECT user_id, platform_id, platform_user_id, username, balance, status, created_at, updated_at
@@ -148,7 +173,8 @@ func (u *Customers) Insert(ctx context.Context, db *sqlx.DB) error {
 }
 
 // UpdateBalance 更新用户余额
-func UpdateUserBalance(ctx context.Context, exec sqlx.ExtContext, userID int64, newBalance float64) error {
+// Deprecated: 余额按币种记在 wallets，使用 UpdateWalletBalance
+func UpdateUserBalance(ctx context.Context, exec sqlx.ExtContext, userID int64, newBalance decimal.Decimal) error {
 	now := getCurrentMillis() // 13位毫秒时间戳
 	query := `UPDATE customers SET balance = ?, updated_at = ? WHERE user_id = ?`
 
@@ -156,7 +182,7 @@ func UpdateUserBalance(ctx context.Context, exec sqlx.ExtContext, userID int64,
 	if err != nil {
 		logger.Error("update user balance failed",
 			zap.Int64("user_id", userID),
-			zap.Float64("new_balance", newBalance),
+			zap.String("new_balance", newBalance.String()),
 			zap.Error(err))
 		return err
 	}
@@ -179,8 +205,8 @@ func GetOrCreateUser(ctx context.Context, db *sqlx.DB, platformID int8, platform
 			PlatformID:     platformID,
 			PlatformUserID: platformUserID,
 			Username:       username,
-			Balance:        0.00, // 初始余额
-			Status:         1,    // 正常状态
+			Balance:        decimal.Zero, // 初始余额
+			Status:         1,            // 正常状态
 		}
 
 		if err := newUser.Insert(ctx, db); err != nil {
@@ -213,17 +239,17 @@ func isMySQLDuplicateKeyError(err error) bool {
 }
 
 // GetUserBalance 获取用户余额（非锁查询）
-func GetUserBalance(ctx context.Context, db *sqlx.DB, platformID int8, platformUserID string) (float64, error) {
+func GetUserBalance(ctx context.Context, db *sqlx.DB, platformID int8, platformUserID string) (decimal.Decimal, error) {
 	query := `SELECT balance FROM customers WHERE platform_id = ? AND platform_user_id = ? LIMIT 1`
 
-	var balance float64
+	var balance decimal.Decimal
 	err := db.GetContext(ctx, &balance, query, platformID, platformUserID)
 	if err != nil {
 		logger.Error("get user balance failed",
 			zap.Int8("platform_id", platformID),
 			zap.String("platform_user_id", platformUserID),
 			zap.Error(err))
-		return 0, err
+		return decimal.Zero, err
 	}
 
 	return balance, nil
@@ -8,8 +8,13 @@ import (
 )
 
 // GameEventAudit 对应 game_event_audit 表（状态机审计）
-// event_type 采用数值枚举（1=game_start 2=game_stop 3=new_card 4=game_draw 5=game_end）
+// event_type 采用数值枚举（1=game_start 2=game_stop 3=new_card 4=game_draw 5=game_end 6=game_cancel）
 // prev_state/next_state 使用字符串快照，便于直观查询
+// 开奖结果更正不是状态转换，审计使用独立的 event_type=7（AuditEventResultCorrection），prev_state 与 next_state 相同
+// event_id/seq 由事件发送方提供（可选），分别全局唯一与按局唯一，用于识别重复投递与乱序事件；未提供时入库为 NULL
+// AuditEventResultCorrection 开奖结果更正的审计事件类型（状态机之外，不能通过 /api/game_event 触发）
+const AuditEventResultCorrection int8 = 7
+
 type GameEventAudit struct {
 	ID int64 `db:"id"`
 	// 游戏ID
@@ -18,8 +23,10 @@ type GameEventAudit struct {
 	RoomID string `db:"room_id"`
 	// 局ID
 	GameRoundID string `db:"game_round_id"`
-	// 事件类型（数值：1=game_start 2=game_stop 3=new_card 4=game_draw 5=game_end）
+	// 事件类型（数值：1=game_start 2=game_stop 3=new_card 4=game_draw 5=game_end 6=game_cancel）
 	EventType int8   `db:"event_type"`
+	EventID   string `db:"event_id"` // 事件ID（发送方生成）
+	Seq       int64  `db:"seq"`      // 局内事件序号，从 1 开始连续递增
 	PrevState string `db:"prev_state"`
 	NextState string `db:"next_state"`
 	Operator  string `db:"operator"`
@@ -33,9 +40,84 @@ type GameEventAudit struct {
 func (e *GameEventAudit) Insert(ctx context.Context, exec sqlx.ExtContext) error {
 	now := time.Now().UnixMilli()
 
-	sqlStr := "INSERT INTO game_event_audit (game_id, room_id, game_round_id, event_type, prev_state, next_state, operator, source, payload, trace_id, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
-	args := []interface{}{e.GameID, e.RoomID, e.GameRoundID, e.EventType, e.PrevState, e.NextState, e.Operator, e.Source, e.Payload, e.TraceID, now}
+	sqlStr := "INSERT INTO game_event_audit (game_id, room_id, game_round_id, event_type, event_id, seq, prev_state, next_state, operator, source, payload, trace_id, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	args := []interface{}{e.GameID, e.RoomID, e.GameRoundID, e.EventType, nullString(e.EventID), nullInt64(e.Seq), e.PrevState, e.NextState, e.Operator, e.Source, e.Payload, e.TraceID, now}
 
 	_, err := exec.ExecContext(ctx, sqlStr, args...)
 	return err
 }
+
+const auditColumns = `id, game_id, room_id, game_round_id, event_type, COALESCE(event_id, '') AS event_id,
+	COALESCE(seq, 0) AS seq, prev_state, next_state, operator, source, payload, trace_id, created_at`
+
+// GetAuditByEventID 按事件ID查询已入库的事件，不存在返回 sql.ErrNoRows
+func GetAuditByEventID(ctx context.Context, exec sqlx.QueryerContext, eventID string) (*GameEventAudit, error) {
+	var e GameEventAudit
+	if err := sqlx.GetContext(ctx, exec, &e, `SELECT `+auditColumns+` FROM game_event_audit WHERE event_id = ?`, eventID); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// GetAuditBySeq 按局内序号查询已入库的事件，不存在返回 sql.ErrNoRows
+func GetAuditBySeq(ctx context.Context, exec sqlx.QueryerContext, roundID string, seq int64) (*GameEventAudit, error) {
+	var e GameEventAudit
+	if err := sqlx.GetContext(ctx, exec, &e, `SELECT `+auditColumns+` FROM game_event_audit WHERE game_round_id = ? AND seq = ?`, roundID, seq); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// MaxAuditSeq 一局已入库的最大事件序号，没有带序号的事件时返回 0
+func MaxAuditSeq(ctx context.Context, exec sqlx.QueryerContext, roundID string) (int64, error) {
+	var seq int64
+	err := sqlx.GetContext(ctx, exec, &seq, `SELECT COALESCE(MAX(seq), 0) FROM game_event_audit WHERE game_round_id = ?`, roundID)
+	return seq, err
+}
+
+// GetLastAudit 一局最近写入的审计事件，没有时返回 sql.ErrNoRows
+func GetLastAudit(ctx context.Context, exec sqlx.QueryerContext, roundID string) (*GameEventAudit, error) {
+	var e GameEventAudit
+	if err := sqlx.GetContext(ctx, exec, &e, `SELECT `+auditColumns+` FROM game_event_audit WHERE game_round_id = ? ORDER BY id DESC LIMIT 1`, roundID); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// ListAuditsByRound 按写入顺序查询一局的全部审计事件（回放）
+func ListAuditsByRound(ctx context.Context, exec sqlx.ExtContext, roundID string) ([]GameEventAudit, error) {
+	sqlStr := `SELECT ` + auditColumns + ` FROM game_event_audit WHERE game_round_id = ? ORDER BY id`
+	var rows []GameEventAudit
+	if err := sqlx.SelectContext(ctx, exec, &rows, sqlStr, roundID); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// ListAuditRoundIDs 查询 [from, to]（毫秒）内有审计事件的局ID，按首个事件先后排序，最多 limit 个
+func ListAuditRoundIDs(ctx context.Context, exec sqlx.ExtContext, from, to int64, limit int) ([]string, error) {
+	sqlStr := `SELECT game_round_id FROM game_event_audit
+		WHERE created_at BETWEEN ? AND ? AND game_round_id <> ''
+		GROUP BY game_round_id ORDER BY MIN(id) LIMIT ?`
+	var ids []string
+	if err := sqlx.SelectContext(ctx, exec, &ids, sqlStr, from, to, limit); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// nullString 空串入库为 NULL（唯一键列允许多个 NULL）
+func nullString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// nullInt64 0 入库为 NULL
+func nullInt64(v int64) any {
+	if v == 0 {
+		return nil
+	}
+	return v
+}
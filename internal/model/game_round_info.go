@@ -9,9 +9,10 @@ import (
 
 // GameRoundInfo 对应 game_round_info 表
 // 说明：时间为毫秒时间戳在 Repo 层转换；结果采用“数值码+冗余字符串”双写
-// game_status: 1=初始 2=下注中 3=封盘 4=已发牌 5=已开奖 6=已结算 7=已结束
-// game_result: 0=未设置 1=dragon 2=tiger 3=tie
+// game_status: 1=初始 2=下注中 3=封盘 4=已发牌 5=已开奖 6=已结算 7=已结束 8=已取消
+// game_result: 0=未设置，其余同 orders.play_type 编码（1=dragon 2=tiger 3=tie 17=player 18=banker）
 // is_settled: 0=未结算 1=已结算（防止重复结算）
+// state_version: 状态版本，每次状态变更 +1（下注侧回合状态缓存以此判断新旧）
 type GameRoundInfo struct {
 	ID            int64  `db:"id"`
 	GameRoundID   string `db:"game_round_id"`
@@ -21,10 +22,16 @@ type GameRoundInfo struct {
 	BetStopTime   int64  `db:"bet_stop_time"`
 	GameDrawTime  int64  `db:"game_draw_time"`
 	CardList      string `db:"card_list"`
+	ShoeNo        string `db:"shoe_no"`       // 服务端发牌时的靴号（人工录入为空）
+	ShoePosition  int    `db:"shoe_position"` // 本局第一张牌在牌靴中的下标
+	ServerSeed    string `db:"server_seed"`   // 旧版按局种子（结算/作废后公布）；靴种子模式为空，种子记在 shoes
+	SeedHash      string `db:"seed_hash"`     // 种子承诺 SHA256(server_seed)，game_start 时公布
+	SeedNonce     string `db:"seed_nonce"`    // 派生牌面使用的 nonce（靴种子模式为靴号，旧版为局号）
 	GameResult    int8   `db:"game_result"`
 	GameResultStr string `db:"game_result_str"`
 	GameStatus    int8   `db:"game_status"`
-	IsSettled     int8   `db:"is_settled"` // 是否已结算: 0=未结算 1=已结算
+	StateVersion  int64  `db:"state_version"` // 状态版本
+	IsSettled     int8   `db:"is_settled"`    // 是否已结算: 0=未结算 1=已结算
 	TraceID       string `db:"trace_id"`
 	CreatedAt     int64  `db:"created_at"`
 	UpdatedAt     int64  `db:"updated_at"`
@@ -53,17 +60,40 @@ func EnsureOnStart(ctx context.Context, exec sqlx.ExtContext, roundID, gameID, r
 
 // UpdateDraw
 func UpdateDraw(ctx context.Context, exec sqlx.ExtContext, roundID, cardListJSON, resultStr string, status int8) error {
-	resCode := toPlayTypeCode(resultStr) // 1=dragon 2=tiger 3=tie
+	resCode := toPlayTypeCode(resultStr) // 结果编码同 orders.play_type
 	now := time.Now().UnixMilli()
 
 	// 使用原生 SQL 以避免 goqu 在某些 MySQL 版本上的兼容性问题
-	sqlStr := "UPDATE game_round_info SET card_list = ?, game_result = ?, game_result_str = ?, game_status = ?, game_draw_time = ?, updated_at = ? WHERE game_round_id = ?"
+	sqlStr := "UPDATE game_round_info SET card_list = ?, game_result = ?, game_result_str = ?, game_status = ?, state_version = state_version + 1, game_draw_time = ?, updated_at = ? WHERE game_round_id = ?"
 	args := []interface{}{cardListJSON, resCode, resultStr, status, now, now, roundID}
 
 	_, err := exec.ExecContext(ctx, sqlStr, args...)
 	return err
 }
 
+// UpdateResult 开奖结果更正：只改牌面与结果，保留状态与开奖时间
+func UpdateResult(ctx context.Context, exec sqlx.ExtContext, roundID, cardList, resultStr string) error {
+	sqlStr := "UPDATE game_round_info SET card_list = ?, game_result = ?, game_result_str = ?, updated_at = ? WHERE game_round_id = ?"
+	_, err := exec.ExecContext(ctx, sqlStr, cardList, toPlayTypeCode(resultStr), resultStr, time.Now().UnixMilli(), roundID)
+	return err
+}
+
+// SetSeedCommit 写入本局可验证公平种子与承诺（game_start 时调用）
+func SetSeedCommit(ctx context.Context, exec sqlx.ExtContext, roundID, serverSeed, seedHash, nonce string) error {
+	now := time.Now().UnixMilli()
+	sqlStr := "UPDATE game_round_info SET server_seed = ?, seed_hash = ?, seed_nonce = ?, updated_at = ? WHERE game_round_id = ?"
+	_, err := exec.ExecContext(ctx, sqlStr, serverSeed, seedHash, nonce, now, roundID)
+	return err
+}
+
+// SetDealtCards 记录服务端发出的牌面、靴号与本局在牌靴中的起始下标（new_card 时调用）
+func SetDealtCards(ctx context.Context, exec sqlx.ExtContext, roundID, shoeNo, cardList string, position int) error {
+	now := time.Now().UnixMilli()
+	sqlStr := "UPDATE game_round_info SET card_list = ?, shoe_no = ?, shoe_position = ?, updated_at = ? WHERE game_round_id = ?"
+	_, err := exec.ExecContext(ctx, sqlStr, cardList, shoeNo, position, now, roundID)
+	return err
+}
+
 // GetStatusForUpdate 在事务中按回合ID加锁并返回当前状态码
 func GetStatusForUpdate(ctx context.Context, exec sqlx.ExtContext, roundID string) (int8, error) {
 	// 使用原生 SQL 以避免 goqu 在某些 MySQL 版本上的兼容性问题
@@ -92,11 +122,11 @@ func GetSettlementStatusForUpdate(ctx context.Context, exec sqlx.ExtContext, rou
 	return r.GameStatus, r.IsSettled, nil
 }
 
-// MarkAsSettled 标记回合为已结算
-func MarkAsSettled(ctx context.Context, exec sqlx.ExtContext, roundID string) error {
+// MarkAsSettled 标记回合为已结算，status 为状态机给出的目标状态码（settled=6）
+func MarkAsSettled(ctx context.Context, exec sqlx.ExtContext, roundID string, status int8) error {
 	now := time.Now().UnixMilli()
-	sqlStr := "UPDATE game_round_info SET is_settled = 1, game_status = 6, updated_at = ? WHERE game_round_id = ?"
-	_, err := exec.ExecContext(ctx, sqlStr, now, roundID)
+	sqlStr := "UPDATE game_round_info SET is_settled = 1, game_status = ?, state_version = state_version + 1, updated_at = ? WHERE game_round_id = ?"
+	_, err := exec.ExecContext(ctx, sqlStr, status, now, roundID)
 	return err
 }
 
@@ -104,7 +134,8 @@ func MarkAsSettled(ctx context.Context, exec sqlx.ExtContext, roundID string) er
 func GetRoundInfo(ctx context.Context, exec sqlx.ExtContext, roundID string) (*GameRoundInfo, error) {
 	// 使用原生 SQL 以避免 goqu 在某些 MySQL 版本上的兼容性问题
 	sqlStr := `SELECT id, game_round_id, game_id, room_id, bet_start_time, bet_stop_time,
-		game_draw_time, card_list, game_result, game_result_str, game_status,
+		game_draw_time, card_list, shoe_no, shoe_position, server_seed, seed_hash, seed_nonce,
+		game_result, game_result_str, game_status, state_version, is_settled,
 		trace_id, created_at, updated_at
 		FROM game_round_info WHERE game_round_id = ?`
 	var round GameRoundInfo
@@ -114,11 +145,12 @@ func GetRoundInfo(ctx context.Context, exec sqlx.ExtContext, roundID string) (*G
 	return &round, nil
 }
 
-// GetRoundForUpdate 获取回合信息并加锁（用于投注时校验时间窗口）
+// GetRoundForUpdate 获取回合信息并加排他锁（状态推进、开奖、结算使用）
 func GetRoundForUpdate(ctx context.Context, exec sqlx.ExtContext, roundID string) (*GameRoundInfo, error) {
 	// 使用原生 SQL 以避免 goqu 在某些 MySQL 版本上的兼容性问题
 	sqlStr := `SELECT id, game_round_id, game_id, room_id, bet_start_time, bet_stop_time,
-		game_draw_time, card_list, game_result, game_result_str, game_status, is_settled,
+		game_draw_time, card_list, shoe_no, shoe_position, server_seed, seed_hash, seed_nonce,
+		game_result, game_result_str, game_status, state_version, is_settled,
 		trace_id, created_at, updated_at
 		FROM game_round_info WHERE game_round_id = ? FOR UPDATE`
 	var round GameRoundInfo
@@ -128,12 +160,49 @@ func GetRoundForUpdate(ctx context.Context, exec sqlx.ExtContext, roundID string
 	return &round, nil
 }
 
-// UpdateState 更新回合状态
+// RoundBetState 下注/撤单校验所需的回合状态
+// RoomID/GameID 仅由数据库读取填充（回合状态缓存不含），用于校验下注请求的房间与回合一致
+type RoundBetState struct {
+	RoomID       string `db:"room_id" json:"-"`
+	GameID       string `db:"game_id" json:"-"`
+	GameStatus   int8   `db:"game_status" json:"game_status"`
+	BetStartTime int64  `db:"bet_start_time" json:"bet_start_time"`
+	BetStopTime  int64  `db:"bet_stop_time" json:"bet_stop_time"`
+	StateVersion int64  `db:"state_version" json:"state_version"`
+}
+
+// GetRoundBetStateShared 以共享锁读取回合的下注状态（LOCK IN SHARE MODE）
+// 并发下注互不阻塞；状态推进（FOR UPDATE）须等持有共享锁的下注事务结束，封盘提交后不会再有下注提交
+func GetRoundBetStateShared(ctx context.Context, exec sqlx.ExtContext, roundID string) (*RoundBetState, error) {
+	sqlStr := `SELECT room_id, game_id, game_status, bet_start_time, bet_stop_time, state_version
+		FROM game_round_info WHERE game_round_id = ? LOCK IN SHARE MODE`
+	var st RoundBetState
+	if err := sqlx.GetContext(ctx, exec, &st, sqlStr, roundID); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// GetLatestRoundByRoom 查询房间最近一局（不加锁，供调度器恢复进度）
+// 房间内尚无任何回合时返回 sql.ErrNoRows
+func GetLatestRoundByRoom(ctx context.Context, exec sqlx.ExtContext, roomID string) (*GameRoundInfo, error) {
+	sqlStr := `SELECT id, game_round_id, game_id, room_id, bet_start_time, bet_stop_time,
+		game_draw_time, card_list, shoe_no, game_result, game_result_str, game_status, is_settled,
+		trace_id, created_at, updated_at
+		FROM game_round_info WHERE room_id = ? ORDER BY id DESC LIMIT 1`
+	var round GameRoundInfo
+	if err := sqlx.GetContext(ctx, exec, &round, sqlStr, roomID); err != nil {
+		return nil, err
+	}
+	return &round, nil
+}
+
+// UpdateState 更新回合状态（状态版本 +1）
 func UpdateState(ctx context.Context, exec sqlx.ExtContext, roundID string, newStatus int8) error {
 	now := time.Now().UnixMilli()
 
 	// 使用原生 SQL 以避免 goqu 在某些 MySQL 版本上的兼容性问题
-	sqlStr := "UPDATE game_round_info SET game_status = ?, updated_at = ? WHERE game_round_id = ?"
+	sqlStr := "UPDATE game_round_info SET game_status = ?, state_version = state_version + 1, updated_at = ? WHERE game_round_id = ?"
 	args := []interface{}{newStatus, now, roundID}
 
 	_, err := exec.ExecContext(ctx, sqlStr, args...)
@@ -164,13 +233,13 @@ func SetBetTimes(ctx context.Context, exec sqlx.ExtContext, roundID string, betS
 	return err
 }
 
-// SetBetStopNow 将 bet_stop_time 设置为当前时间（毫秒）
-func SetBetStopNow(ctx context.Context, exec sqlx.ExtContext, roundID string) error {
+// SetBetStop 封盘时将 bet_stop_time 设置为封盘时间（毫秒）
+func SetBetStop(ctx context.Context, exec sqlx.ExtContext, roundID string, betStopMs int64) error {
 	now := time.Now().UnixMilli()
 
 	// 使用原生 SQL 以避免 goqu 在某些 MySQL 版本上的兼容性问题
 	sqlStr := "UPDATE game_round_info SET bet_stop_time = ?, updated_at = ? WHERE game_round_id = ?"
-	args := []interface{}{now, now, roundID}
+	args := []interface{}{betStopMs, now, roundID}
 
 	_, err := exec.ExecContext(ctx, sqlStr, args...)
 	return err
@@ -201,3 +270,65 @@ func GetRoundSnapshot(ctx context.Context, exec sqlx.ExtContext, roundID string)
 	}
 	return &rs, nil
 }
+
+// RoundResult 路单所需的单局结果
+type RoundResult struct {
+	GameRoundID   string `db:"game_round_id"`
+	GameResultStr string `db:"game_result_str"`
+	GameDrawTime  int64  `db:"game_draw_time"`
+}
+
+// ListSettledResultsByRoom 查询房间在 since（毫秒）之后开奖且已结算的牌局结果
+// 只取最近的 limit 局，按开奖先后正序返回
+func ListSettledResultsByRoom(ctx context.Context, exec sqlx.ExtContext, roomID string, since int64, limit int) ([]RoundResult, error) {
+	sqlStr := `SELECT game_round_id, game_result_str, game_draw_time
+		FROM game_round_info
+		WHERE room_id = ? AND is_settled = 1 AND game_draw_time >= ?
+		ORDER BY id DESC LIMIT ?`
+	var rows []RoundResult
+	if err := sqlx.SelectContext(ctx, exec, &rows, sqlStr, roomID, since, limit); err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+		rows[i], rows[j] = rows[j], rows[i]
+	}
+	return rows, nil
+}
+
+// ListStaleRounds 查询处于 status 且 updated_at 早于 before（毫秒）的牌局，按 id 正序最多 limit 条（卡局巡检）
+func ListStaleRounds(ctx context.Context, exec sqlx.ExtContext, status int8, before int64, limit int) ([]GameRoundInfo, error) {
+	sqlStr := `SELECT id, game_round_id, game_id, room_id, bet_start_time, bet_stop_time,
+		game_draw_time, card_list, shoe_no, game_result, game_result_str, game_status, is_settled,
+		trace_id, created_at, updated_at
+		FROM game_round_info WHERE game_status = ? AND updated_at < ?
+		ORDER BY id LIMIT ?`
+	var rows []GameRoundInfo
+	if err := sqlx.SelectContext(ctx, exec, &rows, sqlStr, status, before, limit); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// UpsertRoundProjection 用回放结果重写牌局行（行不存在时插入）；server_seed 不在审计中，保留原值
+func UpsertRoundProjection(ctx context.Context, exec sqlx.ExtContext, r *GameRoundInfo) error {
+	now := time.Now().UnixMilli()
+	sqlStr := `INSERT INTO game_round_info (game_round_id, game_id, room_id, bet_start_time, bet_stop_time,
+		game_draw_time, card_list, shoe_no, seed_hash, seed_nonce, game_result, game_result_str,
+		game_status, is_settled, trace_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE game_id = VALUES(game_id), room_id = VALUES(room_id),
+		bet_start_time = VALUES(bet_start_time), bet_stop_time = VALUES(bet_stop_time),
+		game_draw_time = VALUES(game_draw_time), card_list = VALUES(card_list), shoe_no = VALUES(shoe_no),
+		seed_hash = VALUES(seed_hash), seed_nonce = VALUES(seed_nonce),
+		game_result = VALUES(game_result), game_result_str = VALUES(game_result_str),
+		game_status = VALUES(game_status), state_version = state_version + 1, is_settled = VALUES(is_settled),
+		updated_at = VALUES(updated_at)`
+	createdAt := r.CreatedAt
+	if createdAt == 0 {
+		createdAt = now
+	}
+	_, err := exec.ExecContext(ctx, sqlStr, r.GameRoundID, r.GameID, r.RoomID, r.BetStartTime, r.BetStopTime,
+		r.GameDrawTime, r.CardList, r.ShoeNo, r.SeedHash, r.SeedNonce, r.GameResult, r.GameResultStr,
+		r.GameStatus, r.IsSettled, r.TraceID, createdAt, now)
+	return err
+}
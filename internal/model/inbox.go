@@ -36,3 +36,34 @@ args := []interface{}{messageID, topic, payload, processedAtMs, now}
 _, err := exec.ExecContext(ctx, sqlStr, args...)
 return err
 }
+
+// ClaimInbox 入站命令按 message_id 去重入库（未处理成功时累加 attempts），返回 processed_at（0=尚未处理成功）与已尝试次数
+func ClaimInbox(ctx context.Context, exec sqlx.ExtContext, messageID, topic, payload string) (int64, int, error) {
+	now := time.Now().UnixMilli()
+	sqlStr := `INSERT INTO inbox (message_id, topic, payload, processed_at, attempts, created_at) VALUES (?, ?, ?, 0, 1, ?)
+		ON DUPLICATE KEY UPDATE attempts = IF(processed_at = 0, attempts + 1, attempts)`
+	if _, err := exec.ExecContext(ctx, sqlStr, messageID, topic, payload, now); err != nil {
+		return 0, 0, err
+	}
+	var row struct {
+		ProcessedAt int64 `db:"processed_at"`
+		Attempts    int   `db:"attempts"`
+	}
+	if err := sqlx.GetContext(ctx, exec, &row, "SELECT processed_at, attempts FROM inbox WHERE message_id = ?", messageID); err != nil {
+		return 0, 0, err
+	}
+	return row.ProcessedAt, row.Attempts, nil
+}
+
+// MarkInboxProcessed 业务事务提交后标记命令已处理
+func MarkInboxProcessed(ctx context.Context, exec sqlx.ExtContext, messageID string) error {
+	_, err := exec.ExecContext(ctx, "UPDATE inbox SET processed_at = ?, last_error = '' WHERE message_id = ? AND processed_at = 0",
+		time.Now().UnixMilli(), messageID)
+	return err
+}
+
+// MarkInboxError 记录命令最近一次处理失败的原因（processed_at 保持 0，便于从死信重新投递）
+func MarkInboxError(ctx context.Context, exec sqlx.ExtContext, messageID, lastError string) error {
+	_, err := exec.ExecContext(ctx, "UPDATE inbox SET last_error = ? WHERE message_id = ?", lastError, messageID)
+	return err
+}
@@ -0,0 +1,113 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// 赔率表状态（odds_schedules.status）
+const (
+	OddsScheduleActive  int8 = 1 // 有效
+	OddsScheduleRevoked int8 = 2 // 已撤销（保留行，已下注单仍可按版本追溯）
+)
+
+// OddsAnyPlatform platform_id 取该值表示对全部平台生效
+const OddsAnyPlatform int8 = -1
+
+// OddsSchedule 对应 odds_schedules 表（版本化赔率表）
+// 按 game_id + room_id + platform_id 划分作用范围：room_id 为空表示该游戏的全部房间，platform_id=-1 表示全部平台；
+// effective_from 起生效。version 为自增主键（全局唯一），下注时写入 orders.odds_version
+type OddsSchedule struct {
+	Version       int64  `db:"version" json:"version"`               // 版本号
+	GameID        string `db:"game_id" json:"game_id"`               // 游戏ID
+	RoomID        string `db:"room_id" json:"room_id"`               // 房间ID（空=全部房间）
+	PlatformID    int8   `db:"platform_id" json:"platform_id"`       // 平台ID（-1=全部平台）
+	Odds          string `db:"odds" json:"odds"`                     // 各玩法赔率(JSON)
+	EffectiveFrom int64  `db:"effective_from" json:"effective_from"` // 生效时间（13位毫秒时间戳）
+	Status        int8   `db:"status" json:"status"`                 // 1=有效 2=已撤销
+	Operator      string `db:"operator" json:"operator"`             // 操作人
+	Remark        string `db:"remark" json:"remark"`                 // 备注
+	TraceID       string `db:"trace_id" json:"trace_id"`             // 链路追踪ID
+	CreatedAt     int64  `db:"created_at" json:"created_at"`         // 创建时间
+	UpdatedAt     int64  `db:"updated_at" json:"updated_at"`         // 更新时间
+}
+
+const oddsScheduleColumns = `version, game_id, room_id, platform_id, odds, effective_from, status, operator, remark,
+	trace_id, created_at, updated_at`
+
+// Insert 新增一个赔率版本
+func (s *OddsSchedule) Insert(ctx context.Context, exec sqlx.ExtContext) error {
+	now := time.Now().UnixMilli()
+	s.CreatedAt = now
+	s.UpdatedAt = now
+	if s.Status == 0 {
+		s.Status = OddsScheduleActive
+	}
+	sqlStr := `INSERT INTO odds_schedules (game_id, room_id, platform_id, odds, effective_from, status, operator, remark,
+		trace_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	res, err := exec.ExecContext(ctx, sqlStr,
+		s.GameID, s.RoomID, s.PlatformID, s.Odds, s.EffectiveFrom, s.Status, s.Operator, s.Remark,
+		s.TraceID, now, now)
+	if err != nil {
+		return err
+	}
+	id, _ := res.LastInsertId()
+	s.Version = id
+	return nil
+}
+
+// GetOddsSchedule 按版本号查询，不存在时返回 sql.ErrNoRows
+func GetOddsSchedule(ctx context.Context, exec sqlx.QueryerContext, version int64) (*OddsSchedule, error) {
+	var s OddsSchedule
+	if err := sqlx.GetContext(ctx, exec, &s, "SELECT "+oddsScheduleColumns+" FROM odds_schedules WHERE version = ?", version); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// ListActiveOddsSchedules 查询全部有效的赔率版本（含尚未生效的），用于加载进程内缓存
+func ListActiveOddsSchedules(ctx context.Context, exec sqlx.QueryerContext) ([]OddsSchedule, error) {
+	var out []OddsSchedule
+	err := sqlx.SelectContext(ctx, exec, &out,
+		"SELECT "+oddsScheduleColumns+" FROM odds_schedules WHERE status = ? ORDER BY version", OddsScheduleActive)
+	return out, err
+}
+
+// ListOddsSchedules 管理查询：按游戏（可选房间）列出赔率版本（含已撤销），新版本在前
+func ListOddsSchedules(ctx context.Context, exec sqlx.QueryerContext, gameID, roomID string, limit int) ([]OddsSchedule, error) {
+	out := []OddsSchedule{}
+	sqlStr := "SELECT " + oddsScheduleColumns + " FROM odds_schedules WHERE game_id = ?"
+	args := []interface{}{gameID}
+	if roomID != "" {
+		sqlStr += " AND room_id IN ('', ?)"
+		args = append(args, roomID)
+	}
+	sqlStr += " ORDER BY version DESC LIMIT ?"
+	args = append(args, limit)
+	err := sqlx.SelectContext(ctx, exec, &out, sqlStr, args...)
+	return out, err
+}
+
+// RevokeOddsSchedule 撤销一个有效的赔率版本，返回影响行数
+func RevokeOddsSchedule(ctx context.Context, exec sqlx.ExtContext, version int64, operator, traceID string) (int64, error) {
+	sqlStr := `UPDATE odds_schedules SET status = ?, operator = ?, trace_id = ?, updated_at = GREATEST(?, updated_at + 1)
+		WHERE version = ? AND status = ?`
+	res, err := exec.ExecContext(ctx, sqlStr, OddsScheduleRevoked, operator, traceID, time.Now().UnixMilli(), version, OddsScheduleActive)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// OddsSchedulesFingerprint 赔率表指纹：行数与最大更新时间，任一变化即说明有新增或撤销
+func OddsSchedulesFingerprint(ctx context.Context, exec sqlx.QueryerContext) (count int64, maxUpdatedAt int64, err error) {
+	var row struct {
+		Count        int64 `db:"cnt"`
+		MaxUpdatedAt int64 `db:"max_updated_at"`
+	}
+	err = sqlx.GetContext(ctx, exec, &row, "SELECT COUNT(*) AS cnt, COALESCE(MAX(updated_at), 0) AS max_updated_at FROM odds_schedules")
+	return row.Count, row.MaxUpdatedAt, err
+}
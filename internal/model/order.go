@@ -5,35 +5,38 @@ import (
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	decimal "github.com/shopspring/decimal"
 )
 
 // Order 对应 orders 表
 // 说明：金额为非负；下注/结算状态采用数值枚举（从1开始）
-// bet_status: 1=创建 2=成功 3=失败
+// bet_status: 1=创建 2=成功 3=失败（seamless 钱包模式下注单先以 1 落库，平台扣款成功后置 2，扣款被拒或冲正后置 3）
 // bill_status: 1=待结算 2=已结算 3=已取消
-// game_result: 0=未开奖 1=dragon 2=tiger 3=tie
+// game_result: 0=未开奖 1=dragon 2=tiger 3=tie 17=player 18=banker
 type Order struct {
-	BillNo         string  `db:"bill_no"`          // 注单号(主键)
-	RoomID         string  `db:"room_id"`          // 房间ID
-	GameRoundID    string  `db:"game_round_id"`    // 局号ID
-	GameID         string  `db:"game_id"`          // 游戏ID
-	UserID         int64   `db:"user_id"`          // 用户ID（内部ID）
-	PlatformID     int8    `db:"platform_id"`      // 平台ID
-	PlatformUserID string  `db:"platform_user_id"` // 平台用户ID
-	UserName       string  `db:"user_name"`        // 用户名
-	BetAmount      float64 `db:"bet_amount"`       // 下注金额(非负)
-	PlayType       string  `db:"play_type"`        // 玩法: dragon|tiger|tie（入库为数值枚举，模型用字符串）
-	BetStatus      int8    `db:"bet_status"`       // 下注状态
-	BetTime        int64   `db:"bet_time"`         // 下注时间（毫秒戳由调用方维护）
-	BillStatus     int8    `db:"bill_status"`      // 结算状态
-	GameResult     int8    `db:"game_result"`      // 游戏结果: 0=未开奖 1=dragon 2=tiger 3=tie
-	WinAmount      float64 `db:"win_amount"`       // 派彩金额
-	BetOdds        float64 `db:"bet_odds"`         // 赔率
-	Currency       string  `db:"currency"`         // 币种
-	IdempotencyKey string  `db:"idempotency_key"`  // 幂等键
-	TraceID        string  `db:"trace_id"`         // 链路追踪ID
-	CreatedAt      int64   `db:"created_at"`       // 创建时间
-	UpdatedAt      int64   `db:"updated_at"`       // 更新时间
+	BillNo         string          `db:"bill_no"`          // 注单号(主键)
+	RoomID         string          `db:"room_id"`          // 房间ID
+	GameRoundID    string          `db:"game_round_id"`    // 局号ID
+	GameID         string          `db:"game_id"`          // 游戏ID
+	UserID         int64           `db:"user_id"`          // 用户ID（内部ID）
+	PlatformID     int8            `db:"platform_id"`      // 平台ID
+	PlatformUserID string          `db:"platform_user_id"` // 平台用户ID
+	UserName       string          `db:"user_name"`        // 用户名
+	BetAmount      decimal.Decimal `db:"bet_amount"`       // 下注金额(非负)
+	PlayType       string          `db:"play_type"`        // 玩法: dragon|tiger|tie|边注（入库为数值枚举，模型用字符串）
+	BetStatus      int8            `db:"bet_status"`       // 下注状态
+	BetTime        int64           `db:"bet_time"`         // 下注时间（毫秒戳由调用方维护）
+	BillStatus     int8            `db:"bill_status"`      // 结算状态
+	GameResult     int8            `db:"game_result"`      // 游戏结果: 0=未开奖 1=dragon 2=tiger 3=tie 17=player 18=banker
+	WinAmount      decimal.Decimal `db:"win_amount"`       // 派彩金额
+	BetOdds        decimal.Decimal `db:"bet_odds"`         // 赔率
+	OddsVersion    int64           `db:"odds_version"`     // 赔率版本（odds_schedules.version，0=房间默认赔率）
+	Currency       string          `db:"currency"`         // 币种
+	WalletTxnID    string          `db:"wallet_txn_id"`    // seamless 钱包模式下注扣款的交易号（wallet_transactions.txn_id），transfer 模式为空
+	IdempotencyKey string          `db:"idempotency_key"`  // 幂等键
+	TraceID        string          `db:"trace_id"`         // 链路追踪ID
+	CreatedAt      int64           `db:"created_at"`       // 创建时间
+	UpdatedAt      int64           `db:"updated_at"`       // 更新时间
 }
 
 // Insert 插入一条 Order 记录
@@ -47,11 +50,11 @@ func (o *Order) Insert(ctx context.Context, exec sqlx.ExtContext) error {
 
 	// 使用原生 SQL 以避免 goqu 在某些 MySQL 版本上的兼容性问题
 	sqlStr := `INSERT INTO orders (bill_no, room_id, game_round_id, game_id, user_id, platform_id, platform_user_id, user_name,
-		bet_amount, play_type, bet_status, bet_time, bill_status, win_amount, bet_odds, currency,
+		bet_amount, play_type, bet_status, bet_time, bill_status, win_amount, bet_odds, odds_version, currency, wallet_txn_id,
 		idempotency_key, trace_id, created_at, updated_at, game_result)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 	_, err := exec.ExecContext(ctx, sqlStr, o.BillNo, o.RoomID, o.GameRoundID, o.GameID, o.UserID, o.PlatformID, o.PlatformUserID, o.UserName,
-		o.BetAmount, playCode, o.BetStatus, bt, o.BillStatus, o.WinAmount, o.BetOdds, o.Currency,
+		o.BetAmount, playCode, o.BetStatus, bt, o.BillStatus, o.WinAmount, o.BetOdds, o.OddsVersion, o.Currency, o.WalletTxnID,
 		o.IdempotencyKey, o.TraceID, now, now, o.GameResult)
 	return err
 }
@@ -59,18 +62,21 @@ func (o *Order) Insert(ctx context.Context, exec sqlx.ExtContext) error {
 // ListByRoundForUpdate 按局号查询需结算的订单（FOR UPDATE），需要在事务中调用
 func ListByRoundForUpdate(ctx context.Context, exec sqlx.ExtContext, roundID string) ([]Order, error) {
 	// 使用原生 SQL 以避免 goqu 在某些 MySQL 版本上的兼容性问题
-	sqlStr := `SELECT bill_no, user_id, user_name, bet_amount, play_type, bet_odds, currency
-		FROM orders WHERE game_round_id = ? AND bill_status = 1 AND bet_status = 2 FOR UPDATE`
+	sqlStr := `SELECT bill_no, user_id, platform_id, platform_user_id, user_name, bet_amount, play_type, bet_odds, currency, wallet_txn_id
+		FROM orders WHERE game_round_id = ? AND bill_status = 1 AND bet_status = 2 ORDER BY user_id, currency, bill_no FOR UPDATE`
 
 	// 使用中间投影结构接收数值型 play_type，然后映射回字符串
 	type row struct {
-		BillNo    string  `db:"bill_no"`
-		UserID    int64   `db:"user_id"`
-		UserName  string  `db:"user_name"`
-		BetAmount float64 `db:"bet_amount"`
-		PlayCode  int8    `db:"play_type"`
-		BetOdds   float64 `db:"bet_odds"`
-		Currency  string  `db:"currency"`
+		BillNo         string          `db:"bill_no"`
+		UserID         int64           `db:"user_id"`
+		PlatformID     int8            `db:"platform_id"`
+		PlatformUserID string          `db:"platform_user_id"`
+		UserName       string          `db:"user_name"`
+		BetAmount      decimal.Decimal `db:"bet_amount"`
+		PlayCode       int8            `db:"play_type"`
+		BetOdds        decimal.Decimal `db:"bet_odds"`
+		Currency       string          `db:"currency"`
+		WalletTxnID    string          `db:"wallet_txn_id"`
 	}
 	var rs []row
 	if err := sqlx.SelectContext(ctx, exec, &rs, sqlStr, roundID); err != nil {
@@ -79,47 +85,153 @@ func ListByRoundForUpdate(ctx context.Context, exec sqlx.ExtContext, roundID str
 	out := make([]Order, 0, len(rs))
 	for _, r := range rs {
 		out = append(out, Order{
-			BillNo:    r.BillNo,
-			UserID:    r.UserID,
-			UserName:  r.UserName,
-			BetAmount: r.BetAmount,
-			PlayType:  fromPlayTypeCode(r.PlayCode),
-			BetOdds:   r.BetOdds,
-			Currency:  r.Currency,
+			BillNo:         r.BillNo,
+			GameRoundID:    roundID,
+			UserID:         r.UserID,
+			PlatformID:     r.PlatformID,
+			PlatformUserID: r.PlatformUserID,
+			UserName:       r.UserName,
+			BetAmount:      r.BetAmount,
+			PlayType:       fromPlayTypeCode(r.PlayCode),
+			BetOdds:        r.BetOdds,
+			Currency:       r.Currency,
+			WalletTxnID:    r.WalletTxnID,
 		})
 	}
 	return out, nil
 }
 
-// 简易的玩法映射（与仓储层保持一致）
-func toPlayTypeCode(s string) int8 {
-	switch s {
-	case "dragon":
-		return 1
-	case "tiger":
-		return 2
-	case "tie":
-		return 3
-	default:
-		return 0
+// ListOpenByRound 按局号查询待结算注单的玩法、金额与赔率（不加锁），用于重建单局赔付敞口
+// 含 seamless 模式下平台扣款尚未确认的注单（bet_status=1），扣款失败或冲正后由调用方扣减敞口
+func ListOpenByRound(ctx context.Context, exec sqlx.QueryerContext, roundID string) ([]Order, error) {
+	sqlStr := `SELECT play_type, bet_amount, bet_odds
+		FROM orders WHERE game_round_id = ? AND bill_status = 1 AND bet_status IN (1, 2)`
+
+	type row struct {
+		PlayCode  int8            `db:"play_type"`
+		BetAmount decimal.Decimal `db:"bet_amount"`
+		BetOdds   decimal.Decimal `db:"bet_odds"`
+	}
+	var rs []row
+	if err := sqlx.SelectContext(ctx, exec, &rs, sqlStr, roundID); err != nil {
+		return nil, err
+	}
+	out := make([]Order, 0, len(rs))
+	for _, r := range rs {
+		out = append(out, Order{GameRoundID: roundID, PlayType: fromPlayTypeCode(r.PlayCode), BetAmount: r.BetAmount, BetOdds: r.BetOdds})
+	}
+	return out, nil
+}
+
+// ListByIdemKey 按幂等键查询某用户的注单（按下单顺序），用于批量投注重复请求时返回首次结果
+func ListByIdemKey(ctx context.Context, exec sqlx.QueryerContext, idemKey string, platformID int8, platformUserID string) ([]Order, error) {
+	sqlStr := `SELECT bill_no, play_type, bet_amount, currency
+		FROM orders WHERE idempotency_key = ? AND platform_id = ? AND platform_user_id = ? ORDER BY bet_time, bill_no`
+
+	type row struct {
+		BillNo    string          `db:"bill_no"`
+		PlayCode  int8            `db:"play_type"`
+		BetAmount decimal.Decimal `db:"bet_amount"`
+		Currency  string          `db:"currency"`
 	}
+	var rs []row
+	if err := sqlx.SelectContext(ctx, exec, &rs, sqlStr, idemKey, platformID, platformUserID); err != nil {
+		return nil, err
+	}
+	out := make([]Order, 0, len(rs))
+	for _, r := range rs {
+		out = append(out, Order{BillNo: r.BillNo, PlayType: fromPlayTypeCode(r.PlayCode), BetAmount: r.BetAmount, Currency: r.Currency})
+	}
+	return out, nil
 }
 
-func fromPlayTypeCode(c int8) string {
-	switch c {
-	case 1:
-		return "dragon"
-	case 2:
-		return "tiger"
-	case 3:
-		return "tie"
-	default:
-		return ""
+// ListSettledByRoundForUpdate 按局号查询已结算的订单（含已派彩金额，FOR UPDATE），用于开奖结果更正后重新结算
+func ListSettledByRoundForUpdate(ctx context.Context, exec sqlx.ExtContext, roundID string) ([]Order, error) {
+	sqlStr := `SELECT bill_no, user_id, platform_id, platform_user_id, user_name, bet_amount, play_type, bet_odds, win_amount, currency, wallet_txn_id
+		FROM orders WHERE game_round_id = ? AND bill_status = 2 AND bet_status = 2 ORDER BY user_id, currency, bill_no FOR UPDATE`
+
+	type row struct {
+		BillNo         string          `db:"bill_no"`
+		UserID         int64           `db:"user_id"`
+		PlatformID     int8            `db:"platform_id"`
+		PlatformUserID string          `db:"platform_user_id"`
+		UserName       string          `db:"user_name"`
+		BetAmount      decimal.Decimal `db:"bet_amount"`
+		PlayCode       int8            `db:"play_type"`
+		BetOdds        decimal.Decimal `db:"bet_odds"`
+		WinAmount      decimal.Decimal `db:"win_amount"`
+		Currency       string          `db:"currency"`
+		WalletTxnID    string          `db:"wallet_txn_id"`
+	}
+	var rs []row
+	if err := sqlx.SelectContext(ctx, exec, &rs, sqlStr, roundID); err != nil {
+		return nil, err
+	}
+	out := make([]Order, 0, len(rs))
+	for _, r := range rs {
+		out = append(out, Order{
+			BillNo:         r.BillNo,
+			GameRoundID:    roundID,
+			UserID:         r.UserID,
+			PlatformID:     r.PlatformID,
+			PlatformUserID: r.PlatformUserID,
+			UserName:       r.UserName,
+			BetAmount:      r.BetAmount,
+			PlayType:       fromPlayTypeCode(r.PlayCode),
+			BetOdds:        r.BetOdds,
+			WinAmount:      r.WinAmount,
+			Currency:       r.Currency,
+			WalletTxnID:    r.WalletTxnID,
+		})
 	}
+	return out, nil
+}
+
+// 玩法编码（orders.play_type，同时用作 game_result 结果编码），各游戏共用一套编码
+// 龙虎主注：1=dragon 2=tiger 3=tie
+// 龙虎边注：4=suited_tie 同花和；5~10 龙的大/小/单/双/红/黑；11~16 虎的大/小/单/双/红/黑
+// 百家乐：17=player 18=banker（和局与龙虎共用 3=tie）
+var playTypeNames = map[int8]string{
+	1:  "dragon",
+	2:  "tiger",
+	3:  "tie",
+	4:  "suited_tie",
+	5:  "dragon_big",
+	6:  "dragon_small",
+	7:  "dragon_odd",
+	8:  "dragon_even",
+	9:  "dragon_red",
+	10: "dragon_black",
+	11: "tiger_big",
+	12: "tiger_small",
+	13: "tiger_odd",
+	14: "tiger_even",
+	15: "tiger_red",
+	16: "tiger_black",
+	17: "player",
+	18: "banker",
 }
 
+var playTypeCodes = func() map[string]int8 {
+	m := make(map[string]int8, len(playTypeNames))
+	for c, n := range playTypeNames {
+		m[n] = c
+	}
+	return m
+}()
+
+// PlayTypeName 玩法编码 -> 名称，未知编码返回空串
+func PlayTypeName(c int8) string { return playTypeNames[c] }
+
+// PlayTypeCode 玩法名称 -> 编码，未知名称返回 0
+func PlayTypeCode(s string) int8 { return playTypeCodes[s] }
+
+func toPlayTypeCode(s string) int8 { return PlayTypeCode(s) }
+
+func fromPlayTypeCode(c int8) string { return PlayTypeName(c) }
+
 // UpdateSettlement 更新订单的派彩、结算状态和游戏结果
-func UpdateSettlement(ctx context.Context, exec sqlx.ExtContext, billNo string, winAmount float64, billStatus int8, gameResult int8) error {
+func UpdateSettlement(ctx context.Context, exec sqlx.ExtContext, billNo string, winAmount decimal.Decimal, billStatus int8, gameResult int8) error {
 	now := time.Now().UnixMilli()
 
 	sqlStr := "UPDATE orders SET win_amount = ?, bill_status = ?, game_result = ?, updated_at = ? WHERE bill_no = ?"
@@ -129,20 +241,102 @@ func UpdateSettlement(ctx context.Context, exec sqlx.ExtContext, billNo string,
 	return err
 }
 
+// CancelOrder 将待结算注单置为已取消（bill_status=3），仅影响 bill_status=1 的注单
+func CancelOrder(ctx context.Context, exec sqlx.ExtContext, billNo string) error {
+	now := time.Now().UnixMilli()
+
+	sqlStr := "UPDATE orders SET bill_status = 3, updated_at = ? WHERE bill_no = ? AND bill_status = 1"
+	args := []interface{}{now, billNo}
+
+	_, err := exec.ExecContext(ctx, sqlStr, args...)
+	return err
+}
+
+// ConfirmWalletTxnOrders seamless 模式平台扣款成功后确认注单（bet_status 1 -> 2），返回确认的注单数
+// 返回 0 表示注单已被对账任务置为失败（扣款已冲正）
+func ConfirmWalletTxnOrders(ctx context.Context, exec sqlx.ExtContext, walletTxnID string) (int64, error) {
+	res, err := exec.ExecContext(ctx, "UPDATE orders SET bet_status = 2, updated_at = ? WHERE wallet_txn_id = ? AND bet_status = 1",
+		time.Now().UnixMilli(), walletTxnID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// FailWalletTxnOrders seamless 模式平台扣款被拒或冲正后将注单置为失败并取消（bet_status=3, bill_status=3），返回影响的注单数
+func FailWalletTxnOrders(ctx context.Context, exec sqlx.ExtContext, walletTxnID string) (int64, error) {
+	res, err := exec.ExecContext(ctx, "UPDATE orders SET bet_status = 3, bill_status = 3, updated_at = ? WHERE wallet_txn_id = ? AND bet_status = 1",
+		time.Now().UnixMilli(), walletTxnID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ListByWalletTxn 查询同一笔下注扣款的注单（按注单号）
+func ListByWalletTxn(ctx context.Context, exec sqlx.QueryerContext, walletTxnID string) ([]Order, error) {
+	type row struct {
+		Order
+		PlayCode int8 `db:"play_code"`
+	}
+	var rs []row
+	if err := sqlx.SelectContext(ctx, exec, &rs, "SELECT "+orderColumns+" FROM orders WHERE wallet_txn_id = ? ORDER BY bill_no", walletTxnID); err != nil {
+		return nil, err
+	}
+	out := make([]Order, 0, len(rs))
+	for _, r := range rs {
+		o := r.Order
+		o.PlayType = fromPlayTypeCode(r.PlayCode)
+		out = append(out, o)
+	}
+	return out, nil
+}
+
+// orderColumns 单笔注单查询的列（play_type 入库为数值枚举，以别名 play_code 接收后映射回字符串）
+const orderColumns = `bill_no, room_id, game_round_id, game_id, user_id, platform_id, platform_user_id, user_name,
+	bet_amount, play_type AS play_code, bet_status, bet_time, bill_status, game_result, win_amount, bet_odds, odds_version, currency,
+	wallet_txn_id, idempotency_key, trace_id, created_at, updated_at`
+
+// GetOrder 按注单号查询注单，不存在时返回 sql.ErrNoRows
+func GetOrder(ctx context.Context, exec sqlx.QueryerContext, billNo string) (*Order, error) {
+	return getOrder(ctx, exec, "SELECT "+orderColumns+" FROM orders WHERE bill_no = ?", billNo)
+}
+
+// GetOrderForUpdate 按注单号查询并锁定注单（FOR UPDATE），需要在事务中调用
+func GetOrderForUpdate(ctx context.Context, exec sqlx.QueryerContext, billNo string) (*Order, error) {
+	return getOrder(ctx, exec, "SELECT "+orderColumns+" FROM orders WHERE bill_no = ? FOR UPDATE", billNo)
+}
+
+func getOrder(ctx context.Context, exec sqlx.QueryerContext, sqlStr, billNo string) (*Order, error) {
+	type row struct {
+		Order
+		PlayCode int8 `db:"play_code"`
+	}
+	var r row
+	if err := sqlx.GetContext(ctx, exec, &r, sqlStr, billNo); err != nil {
+		return nil, err
+	}
+	o := r.Order
+	o.PlayType = fromPlayTypeCode(r.PlayCode)
+	return &o, nil
+}
+
 // BetRecord 投注记录（用于查询接口）
 type BetRecord struct {
-	BillNo      string  `db:"bill_no" json:"bill_no"`             // 订单号
-	GameRoundID string  `db:"game_round_id" json:"game_round_id"` // 游戏回合ID
-	PlayType    int8    `db:"play_type" json:"play_type"`         // 投注类型：1=Dragon, 2=Tiger, 3=Tie
-	BetAmount   float64 `db:"bet_amount" json:"bet_amount"`       // 投注金额
-	BetStatus   int8    `db:"bet_status" json:"bet_status"`       // 下注状态：1=创建, 2=成功, 3=失败
-	BillStatus  int8    `db:"bill_status" json:"bill_status"`     // 订单状态：1=待结算, 2=已结算, 3=已取消
-	GameResult  int8    `db:"game_result" json:"game_result"`     // 游戏结果：0=未开奖, 1=Dragon, 2=Tiger, 3=Tie
-	WinAmount   float64 `db:"win_amount" json:"win_amount"`       // 盈亏金额
-	BetOdds     float64 `db:"bet_odds" json:"bet_odds"`           // 赔率
-	BetTime     int64   `db:"bet_time" json:"bet_time"`           // 投注时间（毫秒时间戳）
-	CreatedAt   int64   `db:"created_at" json:"created_at"`       // 创建时间（毫秒时间戳）
-	UpdatedAt   int64   `db:"updated_at" json:"updated_at"`       // 更新时间（毫秒时间戳）
+	BillNo      string          `db:"bill_no" json:"bill_no"`             // 订单号
+	GameRoundID string          `db:"game_round_id" json:"game_round_id"` // 游戏回合ID
+	PlayType    int8            `db:"play_type" json:"play_type"`         // 投注类型：1=Dragon, 2=Tiger, 3=Tie
+	BetAmount   decimal.Decimal `db:"bet_amount" json:"bet_amount"`       // 投注金额
+	BetStatus   int8            `db:"bet_status" json:"bet_status"`       // 下注状态：1=创建, 2=成功, 3=失败
+	BillStatus  int8            `db:"bill_status" json:"bill_status"`     // 订单状态：1=待结算, 2=已结算, 3=已取消
+	GameResult  int8            `db:"game_result" json:"game_result"`     // 游戏结果：0=未开奖, 1=Dragon, 2=Tiger, 3=Tie
+	WinAmount   decimal.Decimal `db:"win_amount" json:"win_amount"`       // 盈亏金额
+	BetOdds     decimal.Decimal `db:"bet_odds" json:"bet_odds"`           // 赔率
+	OddsVersion int64           `db:"odds_version" json:"odds_version"`   // 赔率版本（0=房间默认赔率）
+	Currency    string          `db:"currency" json:"currency"`           // 币种
+	BetTime     int64           `db:"bet_time" json:"bet_time"`           // 投注时间（毫秒时间戳）
+	CreatedAt   int64           `db:"created_at" json:"created_at"`       // 创建时间（毫秒时间戳）
+	UpdatedAt   int64           `db:"updated_at" json:"updated_at"`       // 更新时间（毫秒时间戳）
 }
 
 // ListUserBets 查询用户的投注记录（按平台用户ID查询）
@@ -165,7 +359,7 @@ func ListUserBets(ctx context.Context, db *sqlx.DB, platformID int8, platformUse
 	if gameRoundID != "" {
 		// 查询指定回合的投注记录
 		sqlStr = `SELECT bill_no, game_round_id, play_type, bet_amount, bet_status, bill_status,
-			game_result, win_amount, bet_odds, bet_time, created_at, updated_at
+			game_result, win_amount, bet_odds, odds_version, currency, bet_time, created_at, updated_at
 			FROM orders
 			WHERE platform_id = ? AND platform_user_id = ? AND game_round_id = ?
 			ORDER BY bet_time DESC
@@ -174,7 +368,7 @@ func ListUserBets(ctx context.Context, db *sqlx.DB, platformID int8, platformUse
 	} else {
 		// 查询所有投注记录
 		sqlStr = `SELECT bill_no, game_round_id, play_type, bet_amount, bet_status, bill_status,
-			game_result, win_amount, bet_odds, bet_time, created_at, updated_at
+			game_result, win_amount, bet_odds, odds_version, currency, bet_time, created_at, updated_at
 			FROM orders
 			WHERE platform_id = ? AND platform_user_id = ?
 			ORDER BY bet_time DESC
@@ -189,3 +383,51 @@ func ListUserBets(ctx context.Context, db *sqlx.DB, platformID int8, platformUse
 
 	return records, nil
 }
+
+// CurrencySummary 按币种汇总的注单统计（仅下注成功的注单；不同币种金额不可直接相加）
+type CurrencySummary struct {
+	Currency      string          `db:"currency" json:"currency"`             // 币种
+	Orders        int64           `db:"orders" json:"orders"`                 // 注单数（不含已取消）
+	BetAmount     decimal.Decimal `db:"bet_amount" json:"bet_amount"`         // 下注总额（不含已取消）
+	SettledOrders int64           `db:"settled_orders" json:"settled_orders"` // 已结算注单数
+	SettledBet    decimal.Decimal `db:"settled_bet" json:"settled_bet"`       // 已结算注单下注额
+	Payout        decimal.Decimal `db:"payout" json:"payout"`                 // 已结算注单派彩（含本金）
+}
+
+// CurrencySummaryFilter 币种汇总的筛选条件；时间为下注时间（13位毫秒时间戳，[From, To)）
+type CurrencySummaryFilter struct {
+	From       int64
+	To         int64
+	GameID     string // 可选
+	RoomID     string // 可选
+	PlatformID int8   // 可选，0 表示全部平台
+}
+
+// SumOrdersByCurrency 按币种汇总下注额与派彩
+func SumOrdersByCurrency(ctx context.Context, exec sqlx.QueryerContext, f CurrencySummaryFilter) ([]CurrencySummary, error) {
+	sqlStr := `SELECT currency,
+		SUM(CASE WHEN bill_status <> 3 THEN 1 ELSE 0 END) AS orders,
+		COALESCE(SUM(CASE WHEN bill_status <> 3 THEN bet_amount ELSE 0 END), 0) AS bet_amount,
+		SUM(CASE WHEN bill_status = 2 THEN 1 ELSE 0 END) AS settled_orders,
+		COALESCE(SUM(CASE WHEN bill_status = 2 THEN bet_amount ELSE 0 END), 0) AS settled_bet,
+		COALESCE(SUM(CASE WHEN bill_status = 2 THEN win_amount ELSE 0 END), 0) AS payout
+		FROM orders WHERE bet_status = 2 AND bet_time >= ? AND bet_time < ?`
+	args := []interface{}{f.From, f.To}
+	if f.GameID != "" {
+		sqlStr += " AND game_id = ?"
+		args = append(args, f.GameID)
+	}
+	if f.RoomID != "" {
+		sqlStr += " AND room_id = ?"
+		args = append(args, f.RoomID)
+	}
+	if f.PlatformID != 0 {
+		sqlStr += " AND platform_id = ?"
+		args = append(args, f.PlatformID)
+	}
+	sqlStr += " GROUP BY currency ORDER BY currency"
+
+	out := []CurrencySummary{}
+	err := sqlx.SelectContext(ctx, exec, &out, sqlStr, args...)
+	return out, err
+}
@@ -0,0 +1,120 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// 待复核开奖结果状态（pending_draw_results.status）
+const (
+	PendingDrawPending  int8 = 1 // 待复核
+	PendingDrawApproved int8 = 2 // 已通过（已结算）
+	PendingDrawRejected int8 = 3 // 已驳回
+	PendingDrawMismatch int8 = 4 // 双扫描不一致，阻断结算，需驳回后重新提交
+)
+
+// PendingDrawSourceManual 人工录入；其余 source 为牌面扫描设备标识，房间 draw_scanners 中不同扫描源的结果自动比对
+const PendingDrawSourceManual = "manual"
+
+// ScannerMakerPrefix 扫描设备的认证身份前缀（scan:<设备标识>），由管理员认证按 auth.admin.scanners 的 Token 确定
+const ScannerMakerPrefix = "scan:"
+
+// PendingDrawResult 对应 pending_draw_results 表（开奖结果复核）
+// 房间开启 draw_approval 后，开奖结果先写入本表，经另一名管理员复核或两路扫描一致后才结算
+type PendingDrawResult struct {
+	ID          int64  `db:"id" json:"id"`
+	GameID      string `db:"game_id" json:"game_id"`             // 游戏ID
+	RoomID      string `db:"room_id" json:"room_id"`             // 房间ID
+	GameRoundID string `db:"game_round_id" json:"game_round_id"` // 游戏回合ID
+	Source      string `db:"source" json:"source"`               // 来源: manual 人工录入 | 扫描设备标识
+	Mode        string `db:"mode" json:"mode"`                   // 牌面来源: server | manual | manual_override
+	CardList    string `db:"card_list" json:"card_list"`         // 牌面
+	Result      string `db:"result" json:"result"`               // 按牌面计算的结果
+	Maker       string `db:"maker" json:"maker"`                 // 提交人
+	Checker     string `db:"checker" json:"checker"`             // 复核人（双扫描一致时为 scan:<source>）
+	Status      int8   `db:"status" json:"status"`               // 1=待复核 2=已通过 3=已驳回 4=不一致
+	Reason      string `db:"reason" json:"reason"`               // 驳回原因 / 不一致说明
+	TraceID     string `db:"trace_id" json:"trace_id"`           // 链路追踪ID
+	CreatedAt   int64  `db:"created_at" json:"created_at"`       // 提交时间
+	DecidedAt   int64  `db:"decided_at" json:"decided_at"`       // 复核时间（未复核为 0）
+}
+
+const pendingDrawColumns = `id, game_id, room_id, game_round_id, source, mode, card_list, result, maker, checker,
+	status, reason, trace_id, created_at, decided_at`
+
+// Insert 写入待复核开奖结果
+func (p *PendingDrawResult) Insert(ctx context.Context, exec sqlx.ExtContext) error {
+	p.CreatedAt = time.Now().UnixMilli()
+	if p.Status == 0 {
+		p.Status = PendingDrawPending
+	}
+	sqlStr := `INSERT INTO pending_draw_results (game_id, room_id, game_round_id, source, mode, card_list, result, maker,
+		checker, status, reason, trace_id, created_at, decided_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 0)`
+	res, err := exec.ExecContext(ctx, sqlStr,
+		p.GameID, p.RoomID, p.GameRoundID, p.Source, p.Mode, p.CardList, p.Result, p.Maker,
+		p.Checker, p.Status, p.Reason, p.TraceID, p.CreatedAt)
+	if err != nil {
+		return err
+	}
+	id, _ := res.LastInsertId()
+	p.ID = id
+	return nil
+}
+
+// GetPendingDraw 按 id 查询，不存在时返回 sql.ErrNoRows
+func GetPendingDraw(ctx context.Context, exec sqlx.QueryerContext, id int64) (*PendingDrawResult, error) {
+	var p PendingDrawResult
+	if err := sqlx.GetContext(ctx, exec, &p, "SELECT "+pendingDrawColumns+" FROM pending_draw_results WHERE id = ?", id); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ListOpenPendingDraws 查询一局尚未结案（待复核或不一致）的提交，按提交顺序
+func ListOpenPendingDraws(ctx context.Context, exec sqlx.QueryerContext, roundID string) ([]PendingDrawResult, error) {
+	var out []PendingDrawResult
+	err := sqlx.SelectContext(ctx, exec, &out,
+		"SELECT "+pendingDrawColumns+" FROM pending_draw_results WHERE game_round_id = ? AND status IN (?, ?) ORDER BY id",
+		roundID, PendingDrawPending, PendingDrawMismatch)
+	return out, err
+}
+
+// ListPendingDraws 查询复核列表：roundID 非空时按局查询（含已结案），否则查询全部未结案的提交
+func ListPendingDraws(ctx context.Context, exec sqlx.QueryerContext, roundID string, limit int) ([]PendingDrawResult, error) {
+	out := []PendingDrawResult{}
+	var err error
+	if roundID != "" {
+		err = sqlx.SelectContext(ctx, exec, &out,
+			"SELECT "+pendingDrawColumns+" FROM pending_draw_results WHERE game_round_id = ? ORDER BY id LIMIT ?", roundID, limit)
+	} else {
+		err = sqlx.SelectContext(ctx, exec, &out,
+			"SELECT "+pendingDrawColumns+" FROM pending_draw_results WHERE status IN (?, ?) ORDER BY id LIMIT ?",
+			PendingDrawPending, PendingDrawMismatch, limit)
+	}
+	return out, err
+}
+
+// DecidePendingDraw 结案（通过/驳回/标记不一致）；只更新仍处于 from 状态的行，返回影响行数
+func DecidePendingDraw(ctx context.Context, exec sqlx.ExtContext, id int64, from, to int8, checker, reason string) (int64, error) {
+	sqlStr := `UPDATE pending_draw_results SET status = ?, checker = ?, reason = ?, decided_at = ?
+		WHERE id = ? AND status = ?`
+	res, err := exec.ExecContext(ctx, sqlStr, to, checker, reason, time.Now().UnixMilli(), id, from)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// SupersedePendingDraws 驳回一局其余仍待复核的提交（该局已按另一提交结算），返回影响行数
+func SupersedePendingDraws(ctx context.Context, exec sqlx.ExtContext, roundID, checker, reason string) (int64, error) {
+	sqlStr := `UPDATE pending_draw_results SET status = ?, checker = ?, reason = ?, decided_at = ?
+		WHERE game_round_id = ? AND status = ?`
+	res, err := exec.ExecContext(ctx, sqlStr, PendingDrawRejected, checker, reason, time.Now().UnixMilli(), roundID, PendingDrawPending)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,106 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	decimal "github.com/shopspring/decimal"
+)
+
+// Room 对应 rooms 表（房间配置）
+// bet_limits / odds 为 JSON 文本，由 service 层解析为按玩法索引的限红与赔率
+// status: 1=开放 2=维护 3=关闭
+// draw_approval: 1=开奖结果需复核（maker-checker），提交后进入 pending_draw_results，复核通过或双扫描一致后才结算
+// draw_scanners: 允许自动比对的扫描设备标识（JSON 数组），不在其中的扫描结果仍需管理员复核
+// cancel_allowed: 1=下注中允许玩家撤单；cancel_cutoff_sec 为 bet_stop_time 前多少秒停止撤单
+// liability_cap: 单局任一结果开出时庄家净赔付上限，0 表示不限
+type Room struct {
+	ID              int64           `db:"id"`
+	RoomID          string          `db:"room_id"`           // 房间ID
+	GameID          string          `db:"game_id"`           // 游戏ID
+	RoomName        string          `db:"room_name"`         // 房间名称
+	BetWindowSec    int             `db:"bet_window_sec"`    // 下注窗口(秒)
+	BetLimits       string          `db:"bet_limits"`        // 各玩法限红(JSON)
+	Odds            string          `db:"odds"`              // 各玩法赔率(JSON)
+	Currency        string          `db:"currency"`          // 币种
+	Status          int8            `db:"status"`            // 1=开放 2=维护 3=关闭
+	IsVIP           int8            `db:"is_vip"`            // 0=否 1=是
+	DrawApproval    int8            `db:"draw_approval"`     // 0=直接结算 1=开奖需复核
+	DrawScanners    string          `db:"draw_scanners"`     // 可自动比对的扫描设备(JSON)
+	CancelAllowed   int8            `db:"cancel_allowed"`    // 0=不允许撤单 1=允许撤单
+	CancelCutoffSec int             `db:"cancel_cutoff_sec"` // 封盘前停止撤单的秒数
+	LiabilityCap    decimal.Decimal `db:"liability_cap"`     // 单局净赔付上限（0=不限）
+	TraceID         string          `db:"trace_id"`          // 链路追踪ID
+	CreatedAt       int64           `db:"created_at"`        // 创建时间
+	UpdatedAt       int64           `db:"updated_at"`        // 更新时间
+}
+
+const roomColumns = `id, room_id, game_id, room_name, bet_window_sec, bet_limits, odds, currency,
+	status, is_vip, draw_approval, draw_scanners, cancel_allowed, cancel_cutoff_sec, liability_cap, trace_id, created_at, updated_at`
+
+// Insert 新建房间，room_id 重复时返回 MySQL 1062
+func (r *Room) Insert(ctx context.Context, exec sqlx.ExtContext) error {
+	now := time.Now().UnixMilli()
+	r.CreatedAt = now
+	r.UpdatedAt = now
+
+	sqlStr := `INSERT INTO rooms (room_id, game_id, room_name, bet_window_sec, bet_limits, odds, currency,
+		status, is_vip, draw_approval, draw_scanners, cancel_allowed, cancel_cutoff_sec, liability_cap, trace_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	res, err := exec.ExecContext(ctx, sqlStr,
+		r.RoomID, r.GameID, r.RoomName, r.BetWindowSec, r.BetLimits, r.Odds, r.Currency,
+		r.Status, r.IsVIP, r.DrawApproval, r.DrawScanners, r.CancelAllowed, r.CancelCutoffSec, r.LiabilityCap, r.TraceID, now, now)
+	if err != nil {
+		return err
+	}
+	id, _ := res.LastInsertId()
+	r.ID = id
+	return nil
+}
+
+// UpdateRoom 按 room_id 整行更新房间配置，返回影响行数（0 表示房间不存在）
+// updated_at 取 max(now, 原值+1)，保证同一毫秒内的连续修改也能改变注册表指纹
+func UpdateRoom(ctx context.Context, exec sqlx.ExtContext, r *Room) (int64, error) {
+	now := time.Now().UnixMilli()
+	sqlStr := `UPDATE rooms SET game_id = ?, room_name = ?, bet_window_sec = ?, bet_limits = ?, odds = ?,
+		currency = ?, status = ?, is_vip = ?, draw_approval = ?, draw_scanners = ?, cancel_allowed = ?, cancel_cutoff_sec = ?, liability_cap = ?,
+		trace_id = ?,
+		updated_at = GREATEST(?, updated_at + 1)
+		WHERE room_id = ?`
+	res, err := exec.ExecContext(ctx, sqlStr,
+		r.GameID, r.RoomName, r.BetWindowSec, r.BetLimits, r.Odds,
+		r.Currency, r.Status, r.IsVIP, r.DrawApproval, r.DrawScanners, r.CancelAllowed, r.CancelCutoffSec, r.LiabilityCap, r.TraceID, now, r.RoomID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// GetRoom 按 room_id 查询房间，不存在时返回 sql.ErrNoRows
+func GetRoom(ctx context.Context, exec sqlx.QueryerContext, roomID string) (*Room, error) {
+	var r Room
+	if err := sqlx.GetContext(ctx, exec, &r, "SELECT "+roomColumns+" FROM rooms WHERE room_id = ?", roomID); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// ListRooms 查询全部房间（按 room_id 排序）
+func ListRooms(ctx context.Context, exec sqlx.QueryerContext) ([]Room, error) {
+	var out []Room
+	if err := sqlx.SelectContext(ctx, exec, &out, "SELECT "+roomColumns+" FROM rooms ORDER BY room_id"); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RoomsFingerprint 房间表指纹：行数与最大更新时间，任一变化即说明配置有增删改
+func RoomsFingerprint(ctx context.Context, exec sqlx.QueryerContext) (count int64, maxUpdatedAt int64, err error) {
+	var row struct {
+		Count        int64 `db:"cnt"`
+		MaxUpdatedAt int64 `db:"max_updated_at"`
+	}
+	err = sqlx.GetContext(ctx, exec, &row, "SELECT COUNT(*) AS cnt, COALESCE(MAX(updated_at), 0) AS max_updated_at FROM rooms")
+	return row.Count, row.MaxUpdatedAt, err
+}
@@ -5,32 +5,44 @@ import (
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	decimal "github.com/shopspring/decimal"
 )
 
 // SettlementLog 结算日志表（防止重复结算）
+// 每局首次结算为 version=1；开奖结果更正后重新结算写入新版本（version 递增），最新版本为当前有效结算
 type SettlementLog struct {
-	ID          int64   `db:"id"`            // 自增ID
-	GameRoundID string  `db:"game_round_id"` // 游戏回合ID
-	CardList    string  `db:"card_list"`     // 牌面信息
-	Result      string  `db:"result"`        // 游戏结果: dragon|tiger|tie
-	TotalOrders int     `db:"total_orders"`  // 结算订单总数
-	TotalPayout float64 `db:"total_payout"`  // 总派彩金额
-	Operator    string  `db:"operator"`      // 操作人
-	TraceID     string  `db:"trace_id"`      // 链路追踪ID
-	CreatedAt   int64   `db:"created_at"`    // 创建时间（13位毫秒时间戳）
+	ID            int64           `db:"id"`             // 自增ID
+	GameRoundID   string          `db:"game_round_id"`  // 游戏回合ID
+	Version       int             `db:"version"`        // 结算版本，从 1 开始
+	CardList      string          `db:"card_list"`      // 牌面信息
+	Result        string          `db:"result"`         // 游戏结果: dragon|tiger|tie
+	TotalOrders   int             `db:"total_orders"`   // 结算订单总数
+	TotalPayout   decimal.Decimal `db:"total_payout"`   // 总派彩金额（各币种名义金额之和，按币种统计见 CurrencyStats）
+	CurrencyStats string          `db:"currency_stats"` // 按币种统计(JSON)：{"CNY":{"orders":3,"bet_amount":"300","payout":"194"}}
+	Operator      string          `db:"operator"`       // 操作人
+	Reason        string          `db:"reason"`         // 更正原因（version>1 时填写）
+	TraceID       string          `db:"trace_id"`       // 链路追踪ID
+	CreatedAt     int64           `db:"created_at"`     // 创建时间（13位毫秒时间戳）
 }
 
-// CreateSettlementLog 创建结算日志（利用唯一索引防止重复结算）
-// 如果返回唯一键冲突错误，说明该回合已经结算过
+// CreateSettlementLog 创建结算日志（利用 game_round_id+version 唯一索引防止重复结算）
+// 如果返回唯一键冲突错误，说明该回合该版本已经结算过；Version 为 0 时按 1 写入
 func CreateSettlementLog(ctx context.Context, exec sqlx.ExtContext, log *SettlementLog) error {
 	now := time.Now().UnixMilli()
 	log.CreatedAt = now
+	if log.Version == 0 {
+		log.Version = 1
+	}
+
+	if log.CurrencyStats == "" {
+		log.CurrencyStats = "{}"
+	}
 
-	sqlStr := `INSERT INTO settlement_log (game_round_id, card_list, result, total_orders, total_payout, operator, trace_id, created_at)
-	           VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	sqlStr := `INSERT INTO settlement_log (game_round_id, version, card_list, result, total_orders, total_payout, currency_stats, operator, reason, trace_id, created_at)
+	           VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
 	result, err := exec.ExecContext(ctx, sqlStr,
-		log.GameRoundID, log.CardList, log.Result, log.TotalOrders, log.TotalPayout, log.Operator, log.TraceID, log.CreatedAt)
+		log.GameRoundID, log.Version, log.CardList, log.Result, log.TotalOrders, log.TotalPayout, log.CurrencyStats, log.Operator, log.Reason, log.TraceID, log.CreatedAt)
 
 	if err != nil {
 		return err
@@ -42,22 +54,22 @@ func CreateSettlementLog(ctx context.Context, exec sqlx.ExtContext, log *Settlem
 	return nil
 }
 
-// GetSettlementLog 查询结算日志
-func GetSettlementLog(ctx context.Context, db *sqlx.DB, gameRoundID string) (*SettlementLog, error) {
-	sqlStr := `SELECT id, game_round_id, card_list, result, total_orders, total_payout, operator, trace_id, created_at
-	           FROM settlement_log WHERE game_round_id = ? LIMIT 1`
+// GetSettlementLog 查询当前有效（最新版本）的结算日志
+func GetSettlementLog(ctx context.Context, db sqlx.QueryerContext, gameRoundID string) (*SettlementLog, error) {
+	sqlStr := `SELECT id, game_round_id, version, card_list, result, total_orders, total_payout, COALESCE(currency_stats, JSON_OBJECT()) AS currency_stats, operator, reason, trace_id, created_at
+	           FROM settlement_log WHERE game_round_id = ? ORDER BY version DESC LIMIT 1`
 
 	var log SettlementLog
-	if err := db.GetContext(ctx, &log, sqlStr, gameRoundID); err != nil {
+	if err := sqlx.GetContext(ctx, db, &log, sqlStr, gameRoundID); err != nil {
 		return nil, err
 	}
 
 	return &log, nil
 }
 
-// UpdateSettlementStats 更新结算日志的统计信息（订单数和派彩金额）
-func UpdateSettlementStats(ctx context.Context, exec sqlx.ExtContext, gameRoundID string, totalOrders int, totalPayout float64) error {
-	sqlStr := `UPDATE settlement_log SET total_orders = ?, total_payout = ? WHERE game_round_id = ?`
-	_, err := exec.ExecContext(ctx, sqlStr, totalOrders, totalPayout, gameRoundID)
+// UpdateSettlementStats 更新指定版本结算日志的统计信息（订单数、派彩金额与按币种统计）
+func UpdateSettlementStats(ctx context.Context, exec sqlx.ExtContext, gameRoundID string, version int, totalOrders int, totalPayout decimal.Decimal, currencyStats string) error {
+	sqlStr := `UPDATE settlement_log SET total_orders = ?, total_payout = ?, currency_stats = ? WHERE game_round_id = ? AND version = ?`
+	_, err := exec.ExecContext(ctx, sqlStr, totalOrders, totalPayout, currencyStats, gameRoundID, version)
 	return err
 }
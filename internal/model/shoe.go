@@ -0,0 +1,105 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Shoe 对应 shoes 表（服务端牌靴）
+// 每个房间同一时间只有一个使用中的牌靴（status=1）
+// cards 为整靴洗好的牌序（逗号分隔，"<点数><花色>"），position 为下一张待发牌的下标
+// 可验证公平模式下牌序由 (server_seed, shoe_no) 派生，开靴公布 seed_hash，换靴后公布 server_seed
+// status: 1=使用中 2=已换靴
+type Shoe struct {
+	ID          int64  `db:"id"`
+	ShoeNo      string `db:"shoe_no"`      // 靴号
+	GameID      string `db:"game_id"`      // 游戏ID
+	RoomID      string `db:"room_id"`      // 房间ID
+	Decks       int    `db:"decks"`        // 副数
+	Cards       string `db:"cards"`        // 整靴牌序
+	TotalCards  int    `db:"total_cards"`  // 总张数
+	Position    int    `db:"position"`     // 下一张待发牌下标
+	CutPosition int    `db:"cut_position"` // 切牌位置（到达后本局结束即换靴）
+	BurnCards   string `db:"burn_cards"`   // 开靴烧牌
+	ServerSeed  string `db:"server_seed"`  // 可验证公平靴种子（换靴后才对外公布，非公平模式为空）
+	SeedHash    string `db:"seed_hash"`    // 靴种子承诺 SHA256(server_seed)
+	RoundsDealt int    `db:"rounds_dealt"` // 已发局数
+	Status      int8   `db:"status"`       // 1=使用中 2=已换靴
+	TraceID     string `db:"trace_id"`     // 链路追踪ID
+	CreatedAt   int64  `db:"created_at"`   // 创建时间
+	UpdatedAt   int64  `db:"updated_at"`   // 更新时间
+}
+
+// Insert 新建牌靴
+func (s *Shoe) Insert(ctx context.Context, exec sqlx.ExtContext) error {
+	now := time.Now().UnixMilli()
+	s.CreatedAt = now
+	s.UpdatedAt = now
+
+	// 使用原生 SQL 以避免 goqu 在某些 MySQL 版本上的兼容性问题
+	sqlStr := `INSERT INTO shoes (shoe_no, game_id, room_id, decks, cards, total_cards, position, cut_position,
+		burn_cards, server_seed, seed_hash, rounds_dealt, status, trace_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	res, err := exec.ExecContext(ctx, sqlStr,
+		s.ShoeNo, s.GameID, s.RoomID, s.Decks, s.Cards, s.TotalCards, s.Position, s.CutPosition,
+		s.BurnCards, s.ServerSeed, s.SeedHash, s.RoundsDealt, s.Status, s.TraceID, now, now)
+	if err != nil {
+		return err
+	}
+	id, _ := res.LastInsertId()
+	s.ID = id
+	return nil
+}
+
+// GetActiveShoeForUpdate 查询房间使用中的牌靴并加锁，房间尚无牌靴时返回 sql.ErrNoRows
+func GetActiveShoeForUpdate(ctx context.Context, exec sqlx.ExtContext, roomID string) (*Shoe, error) {
+	sqlStr := `SELECT id, shoe_no, game_id, room_id, decks, cards, total_cards, position, cut_position,
+		burn_cards, server_seed, seed_hash, rounds_dealt, status, trace_id, created_at, updated_at
+		FROM shoes WHERE room_id = ? AND status = 1 ORDER BY id DESC LIMIT 1 FOR UPDATE`
+	var s Shoe
+	if err := sqlx.GetContext(ctx, exec, &s, sqlStr, roomID); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// GetShoeByNo 按靴号查询牌靴（不含整靴牌序），不存在时返回 sql.ErrNoRows
+func GetShoeByNo(ctx context.Context, exec sqlx.ExtContext, shoeNo string) (*Shoe, error) {
+	sqlStr := `SELECT id, shoe_no, game_id, room_id, decks, total_cards, position, cut_position,
+		burn_cards, server_seed, seed_hash, rounds_dealt, status, trace_id, created_at, updated_at
+		FROM shoes WHERE shoe_no = ?`
+	var s Shoe
+	if err := sqlx.GetContext(ctx, exec, &s, sqlStr, shoeNo); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// GetActiveShoe 查询房间使用中牌靴的靴号与开靴时间（无锁读取），房间尚无牌靴时返回 sql.ErrNoRows
+func GetActiveShoe(ctx context.Context, exec sqlx.ExtContext, roomID string) (*Shoe, error) {
+	sqlStr := `SELECT id, shoe_no, game_id, room_id, status, created_at
+		FROM shoes WHERE room_id = ? AND status = 1 ORDER BY id DESC LIMIT 1`
+	var s Shoe
+	if err := sqlx.GetContext(ctx, exec, &s, sqlStr, roomID); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// UpdateShoePosition 发牌后推进牌靴下标并累加局数
+func UpdateShoePosition(ctx context.Context, exec sqlx.ExtContext, id int64, position int) error {
+	now := time.Now().UnixMilli()
+	sqlStr := "UPDATE shoes SET position = ?, rounds_dealt = rounds_dealt + 1, updated_at = ? WHERE id = ? AND status = 1"
+	_, err := exec.ExecContext(ctx, sqlStr, position, now, id)
+	return err
+}
+
+// RetireShoe 将牌靴置为已换靴
+func RetireShoe(ctx context.Context, exec sqlx.ExtContext, id int64) error {
+	now := time.Now().UnixMilli()
+	sqlStr := "UPDATE shoes SET status = 2, updated_at = ? WHERE id = ? AND status = 1"
+	_, err := exec.ExecContext(ctx, sqlStr, now, id)
+	return err
+}
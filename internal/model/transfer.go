@@ -0,0 +1,60 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	decimal "github.com/shopspring/decimal"
+)
+
+// Transfer 对应 transfers 表：transfer 钱包模式下平台的上分（转入）与下分（转出）
+// 同一平台的 transfer_id 唯一（平台侧转账单号，幂等键）；被拒绝的转账同样落库（status=2），便于平台对账
+// direction: 1=deposit 转入 2=withdraw 转出
+// status: 1=成功 2=失败
+type Transfer struct {
+	TransferID     string          `db:"transfer_id" json:"transfer_id"`           // 平台转账单号
+	PlatformID     int8            `db:"platform_id" json:"platform_id"`           // 平台ID
+	PlatformUserID string          `db:"platform_user_id" json:"platform_user_id"` // 平台用户ID
+	UserID         int64           `db:"user_id" json:"-"`                         // 内部用户ID
+	Direction      int8            `db:"direction" json:"direction"`               // 方向
+	Amount         decimal.Decimal `db:"amount" json:"amount"`                     // 金额
+	Currency       string          `db:"currency" json:"currency"`                 // 币种
+	BeforeAmount   decimal.Decimal `db:"before_amount" json:"before_amount"`       // 转账前余额
+	AfterAmount    decimal.Decimal `db:"after_amount" json:"after_amount"`         // 转账后余额（失败时与转账前相同）
+	Status         int8            `db:"status" json:"status"`                     // 状态
+	Reason         string          `db:"reason" json:"reason"`                     // 失败原因
+	Remark         string          `db:"remark" json:"remark"`                     // 平台备注
+	TraceID        string          `db:"trace_id" json:"trace_id"`                 // 链路追踪ID
+	CreatedAt      int64           `db:"created_at" json:"created_at"`             // 创建时间（13位毫秒时间戳）
+}
+
+const (
+	TransferDeposit  int8 = 1
+	TransferWithdraw int8 = 2
+
+	TransferSuccess int8 = 1
+	TransferFailed  int8 = 2
+)
+
+const transferColumns = `transfer_id, platform_id, platform_user_id, user_id, direction, amount, currency,
+	before_amount, after_amount, status, reason, remark, trace_id, created_at`
+
+// Insert 写入转账记录（(platform_id, transfer_id) 唯一，重复时返回 MySQL 1062）
+func (t *Transfer) Insert(ctx context.Context, exec sqlx.ExtContext) error {
+	t.CreatedAt = time.Now().UnixMilli()
+	sqlStr := `INSERT INTO transfers (` + transferColumns + `) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := exec.ExecContext(ctx, sqlStr, t.TransferID, t.PlatformID, t.PlatformUserID, t.UserID, t.Direction, t.Amount, t.Currency,
+		t.BeforeAmount, t.AfterAmount, t.Status, t.Reason, t.Remark, t.TraceID, t.CreatedAt)
+	return err
+}
+
+// GetTransfer 查询平台的转账记录，不存在时返回 sql.ErrNoRows
+func GetTransfer(ctx context.Context, exec sqlx.QueryerContext, platformID int8, transferID string) (*Transfer, error) {
+	var t Transfer
+	if err := sqlx.GetContext(ctx, exec, &t, "SELECT "+transferColumns+" FROM transfers WHERE platform_id = ? AND transfer_id = ?",
+		platformID, transferID); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	decimal "github.com/shopspring/decimal"
+)
+
+// Wallet 对应 wallets 表：每个用户每个币种一个钱包
+// 余额按 DECIMAL(18,2) 存储（有符号：开奖更正在 allow 策略下允许为负），Go 层以 decimal.Decimal 表示（精确小数）
+// version 每次余额变更 +1（变更计数），余额变更以单条条件语句写入
+type Wallet struct {
+	UserID    int64           `db:"user_id" json:"-"`             // 用户ID（customers.user_id）
+	Currency  string          `db:"currency" json:"currency"`     // 币种
+	Balance   decimal.Decimal `db:"balance" json:"balance"`       // 余额
+	Version   int64           `db:"version" json:"-"`             // 余额版本
+	CreatedAt int64           `db:"created_at" json:"created_at"` // 创建时间（13位毫秒时间戳）
+	UpdatedAt int64           `db:"updated_at" json:"updated_at"` // 更新时间（13位毫秒时间戳）
+}
+
+const walletColumns = "user_id, currency, balance, version, created_at, updated_at"
+
+// GetWalletForUpdate 锁定用户指定币种的钱包，不存在时先创建余额为 0 的钱包
+// 必须在事务中调用；并发创建由主键去重（INSERT IGNORE）。余额变更直接以条件语句写入，见 AddWalletBalance
+func GetWalletForUpdate(ctx context.Context, exec sqlx.ExtContext, userID int64, currency string) (*Wallet, error) {
+	now := time.Now().UnixMilli()
+	if _, err := exec.ExecContext(ctx,
+		`INSERT IGNORE INTO wallets (user_id, currency, balance, created_at, updated_at) VALUES (?, ?, 0, ?, ?)`,
+		userID, currency, now, now); err != nil {
+		return nil, err
+	}
+	var w Wallet
+	if err := sqlx.GetContext(ctx, exec, &w,
+		"SELECT "+walletColumns+" FROM wallets WHERE user_id = ? AND currency = ? FOR UPDATE", userID, currency); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// AddWalletBalance 单条条件语句变更余额：balance += delta、version +1（版本仅用于变更计数与对账，不作为写入条件）
+// requireCover 时要求变更后余额不为负（WHERE balance >= -delta）；须在事务中调用，命中后行锁持有到事务结束
+// 返回是否生效：false 表示钱包不存在或余额不足，由调用方区分
+func AddWalletBalance(ctx context.Context, exec sqlx.ExtContext, userID int64, currency string, delta decimal.Decimal, requireCover bool) (bool, error) {
+	sqlStr := "UPDATE wallets SET balance = balance + ?, version = version + 1, updated_at = ? WHERE user_id = ? AND currency = ?"
+	args := []interface{}{delta, time.Now().UnixMilli(), userID, currency}
+	if requireCover {
+		sqlStr += " AND balance >= ?"
+		args = append(args, delta.Neg())
+	}
+	res, err := exec.ExecContext(ctx, sqlStr, args...)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// GetWalletBalanceForUpdate 加锁读取钱包余额（本事务已修改该行时读到的是本事务写入后的值），不存在时返回 sql.ErrNoRows
+func GetWalletBalanceForUpdate(ctx context.Context, exec sqlx.QueryerContext, userID int64, currency string) (decimal.Decimal, error) {
+	var balance decimal.Decimal
+	err := sqlx.GetContext(ctx, exec, &balance,
+		"SELECT balance FROM wallets WHERE user_id = ? AND currency = ? FOR UPDATE", userID, currency)
+	return balance, err
+}
+
+// GetWallet 非锁查询钱包，不存在时返回 sql.ErrNoRows
+func GetWallet(ctx context.Context, exec sqlx.QueryerContext, userID int64, currency string) (*Wallet, error) {
+	var w Wallet
+	if err := sqlx.GetContext(ctx, exec, &w,
+		"SELECT "+walletColumns+" FROM wallets WHERE user_id = ? AND currency = ?", userID, currency); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// ListWallets 查询用户全部币种的钱包
+func ListWallets(ctx context.Context, exec sqlx.QueryerContext, userID int64) ([]Wallet, error) {
+	out := []Wallet{}
+	err := sqlx.SelectContext(ctx, exec, &out,
+		"SELECT "+walletColumns+" FROM wallets WHERE user_id = ? ORDER BY currency", userID)
+	return out, err
+}
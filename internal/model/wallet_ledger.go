@@ -6,28 +6,30 @@ import (
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	decimal "github.com/shopspring/decimal"
 )
 
 // WalletLedger 对应 wallet_ledger 表（追加式账本）
 // 说明：金额为非负；方向由 before_amount/after_amount 与 biz_type 推导
-// biz_type: 1=bet 下注 2=settle 结算 3=refund 退款 4=adjust 后台调整
+// biz_type: 1=bet 下注 2=settle 结算 3=refund 退款 4=adjust 后台调整 5=settle_reverse 结算冲正（开奖结果更正时扣回原派彩）
+// 6=bet_cancel 撤单退款 7=deposit 平台上分 8=withdraw 平台下分
 // 同时冗余 biz_type_str 便于查询
 type WalletLedger struct {
-	ID           int64   `db:"id"`
-	UserID       int64   `db:"user_id"`
-	BizType      int     `db:"biz_type"`
-	BizTypeStr   string  `db:"biz_type_str"`
-	Amount       float64 `db:"amount"`
-	BeforeAmount float64 `db:"before_amount"`
-	AfterAmount  float64 `db:"after_amount"`
-	Currency     string  `db:"currency"`
-	BillNo       string  `db:"bill_no"`
-	GameRoundID  string  `db:"game_round_id"`
-	GameID       string  `db:"game_id"`
-	RoomID       string  `db:"room_id"`
-	Remark       string  `db:"remark"`
-	TraceID      string  `db:"trace_id"`
-	CreatedAt    int64   `db:"created_at"`
+	ID           int64           `db:"id"`
+	UserID       int64           `db:"user_id"`
+	BizType      int             `db:"biz_type"`
+	BizTypeStr   string          `db:"biz_type_str"`
+	Amount       decimal.Decimal `db:"amount"`
+	BeforeAmount decimal.Decimal `db:"before_amount"`
+	AfterAmount  decimal.Decimal `db:"after_amount"`
+	Currency     string          `db:"currency"`
+	BillNo       string          `db:"bill_no"`
+	GameRoundID  string          `db:"game_round_id"`
+	GameID       string          `db:"game_id"`
+	RoomID       string          `db:"room_id"`
+	Remark       string          `db:"remark"`
+	TraceID      string          `db:"trace_id"`
+	CreatedAt    int64           `db:"created_at"`
 }
 
 // Insert 新增一条账本记录（biz_type 数值码与字符串双写）
@@ -45,6 +47,14 @@ func (l *WalletLedger) Insert(ctx context.Context, exec sqlx.ExtContext) error {
 			code = 3
 		case "adjust":
 			code = 4
+		case "settle_reverse":
+			code = 5
+		case "bet_cancel":
+			code = 6
+		case "deposit":
+			code = 7
+		case "withdraw":
+			code = 8
 		}
 	}
 	if str == "" && code != 0 {
@@ -57,6 +67,14 @@ func (l *WalletLedger) Insert(ctx context.Context, exec sqlx.ExtContext) error {
 			str = "refund"
 		case 4:
 			str = "adjust"
+		case 5:
+			str = "settle_reverse"
+		case 6:
+			str = "bet_cancel"
+		case 7:
+			str = "deposit"
+		case 8:
+			str = "withdraw"
 		}
 	}
 	// 使用原生 SQL 以避免 goqu 在某些 MySQL 版本上的兼容性问题
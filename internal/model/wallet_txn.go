@@ -0,0 +1,131 @@
+package model
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	decimal "github.com/shopspring/decimal"
+)
+
+// WalletTxn 对应 wallet_transactions 表：seamless 钱包模式下对平台钱包的每一次调用
+// op: 1=debit 扣款 2=credit 入账 3=rollback 撤销扣款
+// status: 1=待调用 2=成功 3=失败（平台明确拒绝） 4=结果未知（待重试或冲正）
+// 下注扣款（op=1, biz_type=bet）在下注请求中同步调用，结果未知时由对账任务冲正；其余交易由投递任务异步调用并重试
+type WalletTxn struct {
+	TxnID          string          `db:"txn_id"`           // 交易号（发给平台的幂等键）
+	PlatformID     int8            `db:"platform_id"`      // 平台ID
+	PlatformUserID string          `db:"platform_user_id"` // 平台用户ID
+	UserID         int64           `db:"user_id"`          // 内部用户ID
+	Op             int8            `db:"op"`               // 操作
+	BizType        string          `db:"biz_type"`         // bet|settle|refund|bet_cancel|resettle|rollback
+	Amount         decimal.Decimal `db:"amount"`           // 金额（rollback 为原扣款金额）
+	Currency       string          `db:"currency"`         // 币种
+	RefTxnID       string          `db:"ref_txn_id"`       // 关联的下注扣款交易号
+	GameRoundID    string          `db:"game_round_id"`    // 局号
+	BillNos        string          `db:"bill_nos"`         // 关联注单号（逗号分隔）
+	Status         int8            `db:"status"`           // 状态
+	Attempts       int             `db:"attempts"`         // 已调用次数
+	NextRetryAt    int64           `db:"next_retry_at"`    // 下次调用时间（13位毫秒时间戳）
+	LastError      string          `db:"last_error"`       // 最后一次错误
+	Balance        decimal.Decimal `db:"balance"`          // 平台返回的处理后余额
+	TraceID        string          `db:"trace_id"`         // 链路追踪ID
+	CreatedAt      int64           `db:"created_at"`       // 创建时间（13位毫秒时间戳）
+	UpdatedAt      int64           `db:"updated_at"`       // 更新时间（13位毫秒时间戳）
+}
+
+const (
+	WalletTxnOpDebit    int8 = 1
+	WalletTxnOpCredit   int8 = 2
+	WalletTxnOpRollback int8 = 3
+
+	WalletTxnPending int8 = 1
+	WalletTxnSuccess int8 = 2
+	WalletTxnFailed  int8 = 3
+	WalletTxnUnknown int8 = 4
+)
+
+const walletTxnColumns = `txn_id, platform_id, platform_user_id, user_id, op, biz_type, amount, currency, ref_txn_id,
+	game_round_id, bill_nos, status, attempts, next_retry_at, last_error, balance, trace_id, created_at, updated_at`
+
+// BillNoList 关联注单号列表
+func (t *WalletTxn) BillNoList() []string {
+	if t.BillNos == "" {
+		return nil
+	}
+	return strings.Split(t.BillNos, ",")
+}
+
+// Insert 登记一笔待调用的钱包交易（status=1，立即可调用）
+func (t *WalletTxn) Insert(ctx context.Context, exec sqlx.ExtContext) error {
+	now := time.Now().UnixMilli()
+	if t.Status == 0 {
+		t.Status = WalletTxnPending
+	}
+	t.NextRetryAt, t.CreatedAt, t.UpdatedAt = now, now, now
+	sqlStr := `INSERT INTO wallet_transactions (` + walletTxnColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 0, ?, '', 0, ?, ?, ?)`
+	_, err := exec.ExecContext(ctx, sqlStr, t.TxnID, t.PlatformID, t.PlatformUserID, t.UserID, t.Op, t.BizType, t.Amount, t.Currency,
+		t.RefTxnID, t.GameRoundID, t.BillNos, t.Status, t.NextRetryAt, t.TraceID, t.CreatedAt, t.UpdatedAt)
+	return err
+}
+
+// GetWalletTxn 查询钱包交易
+func GetWalletTxn(ctx context.Context, exec sqlx.QueryerContext, txnID string) (*WalletTxn, error) {
+	var t WalletTxn
+	if err := sqlx.GetContext(ctx, exec, &t, "SELECT "+walletTxnColumns+" FROM wallet_transactions WHERE txn_id = ?", txnID); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// GetWalletTxnForUpdate 锁定钱包交易，需要在事务中调用
+func GetWalletTxnForUpdate(ctx context.Context, exec sqlx.QueryerContext, txnID string) (*WalletTxn, error) {
+	var t WalletTxn
+	if err := sqlx.GetContext(ctx, exec, &t, "SELECT "+walletTxnColumns+" FROM wallet_transactions WHERE txn_id = ? FOR UPDATE", txnID); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ListDueWalletTxns 查询到期待调用的异步交易（入账、撤销与更正扣回；不含下注扣款）
+func ListDueWalletTxns(ctx context.Context, exec sqlx.QueryerContext, now int64, limit int) ([]WalletTxn, error) {
+	out := []WalletTxn{}
+	err := sqlx.SelectContext(ctx, exec, &out, "SELECT "+walletTxnColumns+` FROM wallet_transactions
+		WHERE status IN (1, 4) AND next_retry_at <= ? AND NOT (op = 1 AND biz_type = 'bet')
+		ORDER BY next_retry_at LIMIT ?`, now, limit)
+	return out, err
+}
+
+// ListStaleBetDebits 查询创建早于 before 且仍未确认的下注扣款（待调用或结果未知），用于对账冲正
+func ListStaleBetDebits(ctx context.Context, exec sqlx.QueryerContext, before int64, limit int) ([]WalletTxn, error) {
+	out := []WalletTxn{}
+	err := sqlx.SelectContext(ctx, exec, &out, "SELECT "+walletTxnColumns+` FROM wallet_transactions
+		WHERE op = 1 AND biz_type = 'bet' AND status IN (1, 4) AND created_at < ?
+		ORDER BY created_at LIMIT ?`, before, limit)
+	return out, err
+}
+
+// ClaimWalletTxn 抢占一笔到期交易（多实例时只有一个实例调用）：推迟下次调用时间并累加调用次数
+// 返回 false 表示已被其他实例抢占或状态已变化
+func ClaimWalletTxn(ctx context.Context, exec sqlx.ExtContext, txnID string, prevRetryAt, leaseUntil int64) (bool, error) {
+	res, err := exec.ExecContext(ctx, `UPDATE wallet_transactions SET attempts = attempts + 1, next_retry_at = ?, updated_at = ?
+		WHERE txn_id = ? AND status IN (1, 4) AND next_retry_at = ?`,
+		leaseUntil, time.Now().UnixMilli(), txnID, prevRetryAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// MarkWalletTxn 更新交易状态；status 为 1/4 时 nextRetryAt 为下次调用时间
+func MarkWalletTxn(ctx context.Context, exec sqlx.ExtContext, txnID string, status int8, balance decimal.Decimal, lastError string, nextRetryAt int64) error {
+	if len(lastError) > 255 {
+		lastError = lastError[:255]
+	}
+	_, err := exec.ExecContext(ctx, `UPDATE wallet_transactions SET status = ?, balance = ?, last_error = ?, next_retry_at = ?, updated_at = ?
+		WHERE txn_id = ?`, status, balance, lastError, nextRetryAt, time.Now().UnixMilli(), txnID)
+	return err
+}
@@ -0,0 +1,291 @@
+// Package replay 由 game_event_audit（及 settlement_log）回放重建牌局
+//
+// 审计事件按写入顺序折叠为牌局的期望状态（Projection），与 game_round_info 逐字段比对找出偏差，
+// 并可用于重写损坏的行。只比对审计中能确定的字段：早期审计的 game_start/game_stop 未记录下注窗口，
+// 此时下注时间视为未知，不参与比对，重建时保留原值。
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"dt-server/internal/model"
+	"dt-server/internal/state"
+)
+
+// 可比对的字段（game_round_info 列名）
+const (
+	FieldGameID        = "game_id"
+	FieldRoomID        = "room_id"
+	FieldGameStatus    = "game_status"
+	FieldBetStartTime  = "bet_start_time"
+	FieldBetStopTime   = "bet_stop_time"
+	FieldCardList      = "card_list"
+	FieldShoeNo        = "shoe_no"
+	FieldSeedHash      = "seed_hash"
+	FieldSeedNonce     = "seed_nonce"
+	FieldGameResult    = "game_result"
+	FieldGameResultStr = "game_result_str"
+	FieldIsSettled     = "is_settled"
+)
+
+// Projection 回放得到的牌局期望状态
+type Projection struct {
+	GameRoundID   string          `json:"game_round_id"`
+	GameID        string          `json:"game_id"`
+	RoomID        string          `json:"room_id"`
+	State         string          `json:"state"`
+	GameStatus    int8            `json:"game_status"`
+	BetStartTime  int64           `json:"bet_start_time"`
+	BetStopTime   int64           `json:"bet_stop_time"`
+	GameDrawTime  int64           `json:"game_draw_time"` // 取开奖审计时间（近似值，不参与比对）
+	CardList      string          `json:"card_list"`
+	ShoeNo        string          `json:"shoe_no"`
+	SeedHash      string          `json:"seed_hash"`
+	SeedNonce     string          `json:"seed_nonce"`
+	GameResult    int8            `json:"game_result"`
+	GameResultStr string          `json:"game_result_str"`
+	IsSettled     int8            `json:"is_settled"`
+	CreatedAt     int64           `json:"created_at"` // 首个事件时间
+	UpdatedAt     int64           `json:"updated_at"` // 最后一个事件时间
+	Events        int             `json:"events"`
+	Anomalies     []string        `json:"anomalies"` // 审计流本身的问题（状态断档、非法跳转等）
+	Known         map[string]bool `json:"-"`         // 可由审计确定的字段
+}
+
+// FieldDiff 期望值与 game_round_info 实际值的差异
+type FieldDiff struct {
+	Field    string `json:"field"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// Fold 将一局的审计事件（按 id 正序）与结算日志（可为 nil）折叠为期望状态
+func Fold(roundID string, events []model.GameEventAudit, settle *model.SettlementLog) *Projection {
+	m := state.Default
+	p := &Projection{
+		GameRoundID: roundID,
+		State:       m.Initial(),
+		GameStatus:  m.StateCode(m.Initial()),
+		Anomalies:   []string{},
+		Known:       map[string]bool{},
+	}
+	var lastSeq int64
+
+	for i := range events {
+		e := &events[i]
+		p.Events++
+		if p.CreatedAt == 0 {
+			p.CreatedAt = e.CreatedAt
+		}
+		p.UpdatedAt = e.CreatedAt
+		if e.GameID != "" {
+			p.GameID = e.GameID
+			p.Known[FieldGameID] = true
+		}
+		if e.RoomID != "" {
+			p.RoomID = e.RoomID
+			p.Known[FieldRoomID] = true
+		}
+
+		if e.EventType == model.AuditEventResultCorrection {
+			p.correct(e)
+			continue
+		}
+
+		// 开奖结算的审计复用 game_draw 事件码（drawn -> settled），按目标状态识别
+		evt, ok := m.EventByCode(e.EventType)
+		if e.NextState == state.StateSettled {
+			evt, ok = state.EvtDrawResult, true
+		}
+		if !ok {
+			p.anomaly(e, "unknown event_type %d", e.EventType)
+		}
+		if e.Seq > 0 {
+			if e.Seq != lastSeq+1 {
+				p.anomaly(e, "seq %d, expected %d", e.Seq, lastSeq+1)
+			}
+			lastSeq = e.Seq
+		}
+		if e.PrevState != p.State {
+			p.anomaly(e, "prev_state %s, expected %s", e.PrevState, p.State)
+		}
+		if ok {
+			snap := state.Snapshot{IsSettled: p.IsSettled == 1, HasResult: p.GameResultStr != ""}
+			if next, err := m.Fire(e.PrevState, evt, snap); err != nil {
+				p.anomaly(e, "illegal transition %s --%s-->: %v", e.PrevState, evt, err)
+			} else if next != e.NextState {
+				p.anomaly(e, "next_state %s, state machine gives %s", e.NextState, next)
+			}
+		}
+
+		payload := map[string]any{}
+		_ = json.Unmarshal([]byte(e.Payload), &payload)
+		switch evt {
+		case state.EvtGameStart:
+			p.BetStartTime = e.CreatedAt
+			if v, ok := int64Of(payload["bet_start_time"]); ok {
+				p.BetStartTime = v
+				p.Known[FieldBetStartTime] = true
+			}
+			if v, ok := int64Of(payload["bet_stop_time"]); ok {
+				p.BetStopTime = v
+				p.Known[FieldBetStopTime] = true
+			}
+			p.SeedHash, _ = payload["seed_hash"].(string)
+			p.SeedNonce, _ = payload["seed_nonce"].(string)
+			p.Known[FieldSeedHash] = p.Known[FieldBetStartTime]
+			p.Known[FieldSeedNonce] = p.Known[FieldBetStartTime]
+		case state.EvtGameStop:
+			if v, ok := int64Of(payload["bet_stop_time"]); ok {
+				p.BetStopTime = v
+				p.Known[FieldBetStopTime] = true
+			} else {
+				p.BetStopTime = e.CreatedAt
+				p.Known[FieldBetStopTime] = false
+			}
+		case state.EvtNewCard:
+			if v, ok := payload["card_list"].(string); ok {
+				p.CardList = v
+				p.ShoeNo, _ = payload["shoe_no"].(string)
+				p.Known[FieldCardList] = true
+				p.Known[FieldShoeNo] = true
+			}
+		case state.EvtDrawResult:
+			p.GameDrawTime = e.CreatedAt
+			if v, ok := payload["card_list"].(string); ok {
+				p.CardList = v
+				p.Known[FieldCardList] = true
+			}
+			if v, ok := payload["result"].(string); ok {
+				p.GameResultStr = v
+			}
+			p.IsSettled = 1
+		}
+
+		if e.NextState != "" {
+			p.State = e.NextState
+			p.GameStatus = m.StateCode(e.NextState)
+		}
+	}
+	if p.Events > 0 {
+		p.Known[FieldGameStatus] = true
+		p.Known[FieldIsSettled] = true
+	}
+
+	// 结算日志为开奖结果的权威来源
+	if settle != nil {
+		p.CardList = settle.CardList
+		p.GameResultStr = settle.Result
+		p.IsSettled = 1
+		p.Known[FieldCardList] = true
+		p.Known[FieldIsSettled] = true
+		if p.GameDrawTime == 0 {
+			p.GameDrawTime = settle.CreatedAt
+		}
+		if p.State != state.StateSettled && p.State != state.StateFinished {
+			p.Anomalies = append(p.Anomalies, fmt.Sprintf("settlement_log exists but audit ends in %s", p.State))
+		}
+	}
+	p.GameResult = model.PlayTypeCode(p.GameResultStr)
+	if p.GameResultStr != "" {
+		p.Known[FieldGameResult] = true
+		p.Known[FieldGameResultStr] = true
+	}
+	return p
+}
+
+// Diff 比对期望状态与实际行（actual 为 nil 表示行缺失），只比对可确定的字段
+func (p *Projection) Diff(actual *model.GameRoundInfo) []FieldDiff {
+	diffs := []FieldDiff{}
+	if actual == nil {
+		return append(diffs, FieldDiff{Field: "row", Expected: "present", Actual: "missing"})
+	}
+	cmp := func(field, expected, got string) {
+		if p.Known[field] && expected != got {
+			diffs = append(diffs, FieldDiff{Field: field, Expected: expected, Actual: got})
+		}
+	}
+	itoa := func(v int64) string { return strconv.FormatInt(v, 10) }
+	cmp(FieldGameID, p.GameID, actual.GameID)
+	cmp(FieldRoomID, p.RoomID, actual.RoomID)
+	cmp(FieldGameStatus, itoa(int64(p.GameStatus)), itoa(int64(actual.GameStatus)))
+	cmp(FieldBetStartTime, itoa(p.BetStartTime), itoa(actual.BetStartTime))
+	cmp(FieldBetStopTime, itoa(p.BetStopTime), itoa(actual.BetStopTime))
+	cmp(FieldCardList, p.CardList, actual.CardList)
+	cmp(FieldShoeNo, p.ShoeNo, actual.ShoeNo)
+	cmp(FieldSeedHash, p.SeedHash, actual.SeedHash)
+	cmp(FieldSeedNonce, p.SeedNonce, actual.SeedNonce)
+	cmp(FieldGameResult, itoa(int64(p.GameResult)), itoa(int64(actual.GameResult)))
+	cmp(FieldGameResultStr, p.GameResultStr, actual.GameResultStr)
+	cmp(FieldIsSettled, itoa(int64(p.IsSettled)), itoa(int64(actual.IsSettled)))
+	return diffs
+}
+
+// Apply 生成重写后的行：可确定的字段取期望值，其余保留实际值（actual 为 nil 时取回放近似值）
+func (p *Projection) Apply(actual *model.GameRoundInfo) *model.GameRoundInfo {
+	out := &model.GameRoundInfo{
+		GameRoundID:  p.GameRoundID,
+		BetStartTime: p.BetStartTime,
+		BetStopTime:  p.BetStopTime,
+		GameDrawTime: p.GameDrawTime,
+		CreatedAt:    p.CreatedAt,
+	}
+	if actual != nil {
+		*out = *actual
+	}
+	set := func(field string, apply func()) {
+		if p.Known[field] || actual == nil {
+			apply()
+		}
+	}
+	set(FieldGameID, func() { out.GameID = p.GameID })
+	set(FieldRoomID, func() { out.RoomID = p.RoomID })
+	set(FieldGameStatus, func() { out.GameStatus = p.GameStatus })
+	set(FieldBetStartTime, func() { out.BetStartTime = p.BetStartTime })
+	set(FieldBetStopTime, func() { out.BetStopTime = p.BetStopTime })
+	set(FieldCardList, func() { out.CardList = p.CardList })
+	set(FieldShoeNo, func() { out.ShoeNo = p.ShoeNo })
+	set(FieldSeedHash, func() { out.SeedHash = p.SeedHash })
+	set(FieldSeedNonce, func() { out.SeedNonce = p.SeedNonce })
+	set(FieldGameResult, func() { out.GameResult = p.GameResult })
+	set(FieldGameResultStr, func() { out.GameResultStr = p.GameResultStr })
+	set(FieldIsSettled, func() { out.IsSettled = p.IsSettled })
+	if out.GameDrawTime == 0 {
+		out.GameDrawTime = p.GameDrawTime
+	}
+	return out
+}
+
+// correct 开奖结果更正：状态不变，替换牌面与结果
+func (p *Projection) correct(e *model.GameEventAudit) {
+	if e.PrevState != p.State || e.NextState != e.PrevState {
+		p.anomaly(e, "correction %s -> %s in state %s", e.PrevState, e.NextState, p.State)
+	}
+	if p.IsSettled != 1 {
+		p.anomaly(e, "correction before settlement")
+	}
+	payload := map[string]any{}
+	_ = json.Unmarshal([]byte(e.Payload), &payload)
+	if v, ok := payload["card_list"].(string); ok {
+		p.CardList = v
+		p.Known[FieldCardList] = true
+	}
+	if v, ok := payload["result"].(string); ok {
+		p.GameResultStr = v
+	}
+}
+
+func (p *Projection) anomaly(e *model.GameEventAudit, format string, args ...any) {
+	p.Anomalies = append(p.Anomalies, fmt.Sprintf("audit#%d: ", e.ID)+fmt.Sprintf(format, args...))
+}
+
+// int64Of 审计 payload 中的数值（JSON 解码为 float64）
+func int64Of(v any) (int64, bool) {
+	f, ok := v.(float64)
+	if !ok {
+		return 0, false
+	}
+	return int64(f), true
+}
@@ -0,0 +1,119 @@
+// Package road 路单（珠盘路、大路及大眼仔、小路、曱甴路三条下三路）
+//
+// 路单只依赖每局的主结果，与具体游戏无关：龙虎为 dragon/tiger/tie，百家乐为 player/banker/tie。
+// 非和局结果各自成列，和局不占格，记在大路上一格的 Ties 上。
+// 返回的是逻辑列（大路超过 6 行的"长龙拐弯"由前端排版），珠盘路按发生顺序给出，前端每 6 个一列。
+package road
+
+// Tie 和局结果
+const Tie = "tie"
+
+// 下三路颜色
+const (
+	Red  = "red"
+	Blue = "blue"
+)
+
+// Cell 大路单元格
+type Cell struct {
+	Result string `json:"result"`
+	Ties   int    `json:"ties,omitempty"` // 该格之后连续出现的和局数
+}
+
+// Board 一靴的全部路单
+type Board struct {
+	Bead        []string       `json:"bead_road"`      // 珠盘路：按顺序的结果（含和局）
+	BigRoad     [][]Cell       `json:"big_road"`       // 大路：同一结果连续成列
+	BigEye      [][]string     `json:"big_eye_road"`   // 大眼仔（与左 1 列比较）
+	Small       [][]string     `json:"small_road"`     // 小路（与左 2 列比较）
+	Cockroach   [][]string     `json:"cockroach_road"` // 曱甴路（与左 3 列比较）
+	LeadingTies int            `json:"leading_ties"`   // 大路第一格出现前的和局数
+	Counts      map[string]int `json:"counts"`         // 各结果局数
+}
+
+// New 空路单
+func New() *Board {
+	return &Board{
+		Bead:      []string{},
+		BigRoad:   [][]Cell{},
+		BigEye:    [][]string{},
+		Small:     [][]string{},
+		Cockroach: [][]string{},
+		Counts:    map[string]int{},
+	}
+}
+
+// Build 由按时间顺序的结果序列重建路单
+func Build(results []string) *Board {
+	b := New()
+	for _, r := range results {
+		b.Add(r)
+	}
+	return b
+}
+
+// Len 已记录的局数
+func (b *Board) Len() int { return len(b.Bead) }
+
+// Add 追加一局结果（增量更新各路）
+func (b *Board) Add(result string) {
+	b.Bead = append(b.Bead, result)
+	b.Counts[result]++
+
+	if result == Tie {
+		if n := len(b.BigRoad); n > 0 {
+			col := b.BigRoad[n-1]
+			col[len(col)-1].Ties++
+		} else {
+			b.LeadingTies++
+		}
+		return
+	}
+
+	n := len(b.BigRoad)
+	if n > 0 && b.BigRoad[n-1][0].Result == result {
+		b.BigRoad[n-1] = append(b.BigRoad[n-1], Cell{Result: result})
+	} else {
+		b.BigRoad = append(b.BigRoad, []Cell{{Result: result}})
+	}
+
+	c := len(b.BigRoad) - 1
+	r := len(b.BigRoad[c]) - 1
+	b.BigEye = appendDerived(b.BigEye, b.derived(c, r, 1))
+	b.Small = appendDerived(b.Small, b.derived(c, r, 2))
+	b.Cockroach = appendDerived(b.Cockroach, b.derived(c, r, 3))
+}
+
+// derived 大路 (c, r) 新增一格时，与左 k 列比较得到的下三路颜色，未到起点返回空串
+//   - 新起一列（r=0）：比较左 1 列与左 1+k 列的长度，相同为红，否则为蓝
+//   - 列内延续（r>0）：左 k 列长度恰好为 r（"直落"到此为止）为蓝，否则为红
+func (b *Board) derived(c, r, k int) string {
+	if r == 0 {
+		if c-1-k < 0 {
+			return ""
+		}
+		if len(b.BigRoad[c-1]) == len(b.BigRoad[c-1-k]) {
+			return Red
+		}
+		return Blue
+	}
+	if c-k < 0 {
+		return ""
+	}
+	if len(b.BigRoad[c-k]) == r {
+		return Blue
+	}
+	return Red
+}
+
+// appendDerived 下三路按颜色连续成列
+func appendDerived(road [][]string, color string) [][]string {
+	if color == "" {
+		return road
+	}
+	if n := len(road); n > 0 && road[n-1][0] == color {
+		road[n-1] = append(road[n-1], color)
+		return road
+	}
+	return append(road, []string{color})
+}
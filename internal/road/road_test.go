@@ -0,0 +1,170 @@
+package road
+
+import (
+	"reflect"
+	"testing"
+)
+
+// seq 简写序列转结果：B=banker P=player T=tie
+func seq(s string) []string {
+	names := map[rune]string{'B': "banker", 'P': "player", 'T': Tie}
+	out := make([]string, 0, len(s))
+	for _, c := range s {
+		out = append(out, names[c])
+	}
+	return out
+}
+
+// cols 简写列转下三路：每个参数为一列，R=red B=blue
+func cols(s ...string) [][]string {
+	out := [][]string{}
+	for _, col := range s {
+		var c []string
+		for _, ch := range col {
+			if ch == 'R' {
+				c = append(c, Red)
+			} else {
+				c = append(c, Blue)
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// marks 下三路的总格数
+func marks(road [][]string) int {
+	n := 0
+	for _, col := range road {
+		n += len(col)
+	}
+	return n
+}
+
+func TestBoardKnownSequence(t *testing.T) {
+	// 大路：
+	//   B P B P B P
+	//   B P B   B P
+	//     P
+	// 第三列第一格后有一局和
+	b := Build(seq("BBPPPBTBPBBPP"))
+
+	bankers := func(n, ties int) []Cell {
+		c := make([]Cell, n)
+		for i := range c {
+			c[i] = Cell{Result: "banker"}
+		}
+		c[0].Ties = ties
+		return c
+	}
+	players := func(n int) []Cell {
+		c := make([]Cell, n)
+		for i := range c {
+			c[i] = Cell{Result: "player"}
+		}
+		return c
+	}
+	wantBig := [][]Cell{bankers(2, 0), players(3), bankers(2, 1), players(1), bankers(2, 0), players(2)}
+	if !reflect.DeepEqual(b.BigRoad, wantBig) {
+		t.Fatalf("big road=%v, want %v", b.BigRoad, wantBig)
+	}
+	if want := cols("R", "BB", "R", "BBBB", "R"); !reflect.DeepEqual(b.BigEye, want) {
+		t.Fatalf("big eye=%v, want %v", b.BigEye, want)
+	}
+	if want := cols("RR", "B", "RR", "B"); !reflect.DeepEqual(b.Small, want) {
+		t.Fatalf("small=%v, want %v", b.Small, want)
+	}
+	if want := cols("B", "R", "B", "R"); !reflect.DeepEqual(b.Cockroach, want) {
+		t.Fatalf("cockroach=%v, want %v", b.Cockroach, want)
+	}
+	if b.Len() != 13 || b.LeadingTies != 0 {
+		t.Fatalf("len=%d leading_ties=%d, want 13/0", b.Len(), b.LeadingTies)
+	}
+	if want := map[string]int{"banker": 6, "player": 6, Tie: 1}; !reflect.DeepEqual(b.Counts, want) {
+		t.Fatalf("counts=%v, want %v", b.Counts, want)
+	}
+}
+
+func TestDerivedStartCells(t *testing.T) {
+	// 大眼仔从 (1,1) 或 (2,0) 起，小路从 (2,1) 或 (3,0) 起，曱甴路从 (3,1) 或 (4,0) 起
+	cases := []struct {
+		seq                  string
+		bigEye, small, roach int
+	}{
+		{"B", 0, 0, 0},
+		{"BB", 0, 0, 0},
+		{"BP", 0, 0, 0},
+		{"BPP", 1, 0, 0},     // (1,1)
+		{"BPB", 1, 0, 0},     // (2,0)
+		{"BPBB", 2, 1, 0},    // (2,1)
+		{"BPBP", 2, 1, 0},    // (3,0)
+		{"BPBPP", 3, 2, 1},   // (3,1)
+		{"BPBPB", 3, 2, 1},   // (4,0)
+		{"BBBBBBP", 0, 0, 0}, // 长龙后新起第二列仍未到起点
+		{"TTBTPTT", 0, 0, 0}, // 和局不占格
+		{"BPBPTTB", 3, 2, 1}, // 和局不影响起点
+		{"BPBPPPP", 5, 4, 3}, // 列内延续逐格比较
+		{"BPBPBPB", 5, 4, 3}, // 单跳
+	}
+	for _, c := range cases {
+		b := Build(seq(c.seq))
+		if got := [3]int{marks(b.BigEye), marks(b.Small), marks(b.Cockroach)}; got != [3]int{c.bigEye, c.small, c.roach} {
+			t.Fatalf("%s: marks=%v, want %v", c.seq, got, [3]int{c.bigEye, c.small, c.roach})
+		}
+	}
+}
+
+func TestDerivedColors(t *testing.T) {
+	cases := []struct {
+		seq                      string
+		bigEye, small, cockroach [][]string
+	}{
+		// 单跳：新列比较的两列长度相同，全红
+		{"BPBPBPB", cols("RRRRR"), cols("RRRR"), cols("RRR")},
+		// 直落：左 1 列长度恰好等于当前行时为蓝，再往下延续为红
+		{"BBPPP", cols("R", "B"), cols(), cols()},
+		{"BBPPPP", cols("R", "B", "R"), cols(), cols()},
+		// 新列：左 1 列与左 2 列长度不同为蓝
+		{"BBPPPB", cols("R", "BB"), cols(), cols()},
+		// 左 2 列只有 1 格：第三列第二格小路为蓝
+		{"BPPBB", cols("BB", "R"), cols("B"), cols()},
+		// 左 3 列只有 1 格：第四列第二格曱甴路为蓝
+		{"BPPBPP", cols("BBBB"), cols("RR"), cols("B")},
+	}
+	for _, c := range cases {
+		b := Build(seq(c.seq))
+		if !reflect.DeepEqual(b.BigEye, c.bigEye) || !reflect.DeepEqual(b.Small, c.small) || !reflect.DeepEqual(b.Cockroach, c.cockroach) {
+			t.Fatalf("%s: big eye=%v small=%v cockroach=%v, want %v %v %v",
+				c.seq, b.BigEye, b.Small, b.Cockroach, c.bigEye, c.small, c.cockroach)
+		}
+	}
+}
+
+func TestTies(t *testing.T) {
+	b := Build(seq("TTBTTPT"))
+	if b.LeadingTies != 2 {
+		t.Fatalf("leading ties=%d, want 2", b.LeadingTies)
+	}
+	want := [][]Cell{{{Result: "banker", Ties: 2}}, {{Result: "player", Ties: 1}}}
+	if !reflect.DeepEqual(b.BigRoad, want) {
+		t.Fatalf("big road=%v, want %v", b.BigRoad, want)
+	}
+	if want := seq("TTBTTPT"); !reflect.DeepEqual(b.Bead, want) {
+		t.Fatalf("bead=%v, want %v", b.Bead, want)
+	}
+	if b.Counts[Tie] != 5 || b.Counts["banker"] != 1 || b.Counts["player"] != 1 {
+		t.Fatalf("counts=%v", b.Counts)
+	}
+
+	// 和局记在大路最后一格上，不新起格
+	b = Build(seq("BBTT"))
+	if want := [][]Cell{{{Result: "banker"}, {Result: "banker", Ties: 2}}}; !reflect.DeepEqual(b.BigRoad, want) {
+		t.Fatalf("big road=%v, want %v", b.BigRoad, want)
+	}
+
+	// 全是和局
+	b = Build(seq("TTT"))
+	if b.LeadingTies != 3 || len(b.BigRoad) != 0 || b.Len() != 3 {
+		t.Fatalf("leading ties=%d big road=%v len=%d, want 3/[]/3", b.LeadingTies, b.BigRoad, b.Len())
+	}
+}
@@ -0,0 +1,105 @@
+package seamless
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	chelper "dt-server/common/helper"
+	"dt-server/internal/auth"
+
+	"github.com/google/uuid"
+)
+
+// DefaultTimeout 单次调用缺省超时
+const DefaultTimeout = 3 * time.Second
+
+// ErrUnknown 调用结果未知（超时、网络错误、5xx 或响应无法解析），平台可能已处理也可能未处理
+var ErrUnknown = errors.New("seamless wallet: outcome unknown")
+
+// RejectedError 平台明确拒绝（响应 code 非 0），交易未生效
+type RejectedError struct {
+	Code    int
+	Message string
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("seamless wallet rejected: code=%d, message=%s", e.Code, e.Message)
+}
+
+// Client 平台钱包客户端
+type Client struct {
+	BaseURL   string
+	AppKey    string
+	AppSecret string
+	Timeout   time.Duration
+}
+
+// Debit 扣款
+func (c *Client) Debit(ctx context.Context, req Request) (*Response, error) {
+	return c.Call(ctx, PathDebit, req)
+}
+
+// Credit 入账
+func (c *Client) Credit(ctx context.Context, req Request) (*Response, error) {
+	return c.Call(ctx, PathCredit, req)
+}
+
+// Rollback 撤销扣款
+func (c *Client) Rollback(ctx context.Context, req Request) (*Response, error) {
+	return c.Call(ctx, PathRollback, req)
+}
+
+// Call 签名并发送请求；返回 ErrUnknown（包装原因）或 *RejectedError
+func (c *Client) Call(ctx context.Context, path string, req Request) (*Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	if dl, ok := ctx.Deadline(); ok {
+		if left := time.Until(dl); left < timeout {
+			timeout = left
+		}
+	}
+	if timeout <= 0 {
+		return nil, fmt.Errorf("%w: %v", ErrUnknown, context.DeadlineExceeded)
+	}
+
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce := uuid.NewString()
+	headers := map[string]string{
+		"Content-Type":   "application/json",
+		"X-Platform-Key": c.AppKey,
+		"X-Timestamp":    ts,
+		"X-Nonce":        nonce,
+		"X-Signature":    auth.SignRequest(c.AppKey, ts, nonce, string(body), c.AppSecret),
+	}
+	url := strings.TrimRight(c.BaseURL, "/") + path
+	respBody, status, err := chelper.HttpDoTimeoutForThirdPay(body, "POST", url, headers, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnknown, err)
+	}
+	if status >= 500 || status == 0 {
+		return nil, fmt.Errorf("%w: http status %d", ErrUnknown, status)
+	}
+
+	var resp Response
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("%w: http status %d, decode response: %v", ErrUnknown, status, err)
+	}
+	if resp.Code != CodeOK {
+		return nil, &RejectedError{Code: resp.Code, Message: resp.Message}
+	}
+	if status != 200 {
+		return nil, fmt.Errorf("%w: http status %d", ErrUnknown, status)
+	}
+	return &resp, nil
+}
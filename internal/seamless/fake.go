@@ -0,0 +1,187 @@
+package seamless
+
+import (
+	"crypto/hmac"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"dt-server/internal/auth"
+
+	decimal "github.com/shopspring/decimal"
+)
+
+// FakeWallet 本地模拟的平台钱包（内存实现），用于离线联调 seamless 模式，见 cmd/fakewallet
+//
+// 实现与平台侧约定一致：校验签名、txn_id 幂等、rollback 找不到扣款时记录并拒绝迟到的扣款。
+// 首次出现的用户按 InitialBalance 开户。可注入故障模拟结果未知：
+//   - FailRate：处理前直接返回 500（平台未处理）
+//   - LostRate：处理后返回 500（平台已处理，响应丢失）
+//   - Delay：每个请求的处理延迟（超过调用方超时即为结果未知）
+type FakeWallet struct {
+	AppKey         string
+	AppSecret      string // 为空时不校验签名
+	InitialBalance decimal.Decimal
+	FailRate       float64
+	LostRate       float64
+	Delay          time.Duration
+
+	mu         sync.Mutex
+	balances   map[string]decimal.Decimal // user_id|currency -> 余额
+	txns       map[string]fakeTxn         // txn_id -> 首次处理结果
+	rolledBack map[string]bool            // 已回滚（或回滚先于扣款到达）的扣款 txn_id
+}
+
+type fakeTxn struct {
+	path   string
+	req    Request
+	resp   Response
+	status int
+}
+
+// NewFakeWallet 创建模拟钱包
+func NewFakeWallet(appKey, appSecret string, initialBalance decimal.Decimal) *FakeWallet {
+	return &FakeWallet{
+		AppKey:         appKey,
+		AppSecret:      appSecret,
+		InitialBalance: initialBalance,
+		balances:       make(map[string]decimal.Decimal),
+		txns:           make(map[string]fakeTxn),
+		rolledBack:     make(map[string]bool),
+	}
+}
+
+// ServeHTTP 处理 /debit /credit /rollback（POST）与 /balance?user_id=&currency=（GET），路径前缀不限
+func (w *FakeWallet) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if w.Delay > 0 {
+		time.Sleep(w.Delay)
+	}
+	path := r.URL.Path
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		path = path[i:]
+	}
+	if r.Method == http.MethodGet && path == "/balance" {
+		w.mu.Lock()
+		bal := w.balanceLocked(r.URL.Query().Get("user_id"), r.URL.Query().Get("currency"))
+		w.mu.Unlock()
+		writeFake(rw, http.StatusOK, Response{Code: CodeOK, Balance: bal.StringFixed(2)})
+		return
+	}
+	if r.Method != http.MethodPost || (path != PathDebit && path != PathCredit && path != PathRollback) {
+		writeFake(rw, http.StatusNotFound, Response{Code: CodeInvalidRequest, Message: "not found"})
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeFake(rw, http.StatusBadRequest, Response{Code: CodeInvalidRequest, Message: "read body failed"})
+		return
+	}
+	if w.AppSecret != "" {
+		sig := auth.SignRequest(r.Header.Get("X-Platform-Key"), r.Header.Get("X-Timestamp"), r.Header.Get("X-Nonce"), string(body), w.AppSecret)
+		if r.Header.Get("X-Platform-Key") != w.AppKey || !hmac.Equal([]byte(sig), []byte(r.Header.Get("X-Signature"))) {
+			writeFake(rw, http.StatusUnauthorized, Response{Code: CodeInvalidSignature, Message: "invalid signature"})
+			return
+		}
+	}
+	var req Request
+	if err := json.Unmarshal(body, &req); err != nil || req.TxnID == "" || req.UserID == "" {
+		writeFake(rw, http.StatusOK, Response{Code: CodeInvalidRequest, Message: "invalid request"})
+		return
+	}
+	if w.FailRate > 0 && rand.Float64() < w.FailRate {
+		writeFake(rw, http.StatusInternalServerError, Response{Code: -1, Message: "injected failure"})
+		return
+	}
+
+	status, resp := w.handle(path, req)
+	if w.LostRate > 0 && rand.Float64() < w.LostRate {
+		writeFake(rw, http.StatusInternalServerError, Response{Code: -1, Message: "injected lost response"})
+		return
+	}
+	writeFake(rw, status, resp)
+}
+
+// Balance 查询余额（首次查询即开户）
+func (w *FakeWallet) Balance(userID, currency string) decimal.Decimal {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.balanceLocked(userID, currency)
+}
+
+func (w *FakeWallet) handle(path string, req Request) (int, Response) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if t, ok := w.txns[req.TxnID]; ok {
+		if t.path != path {
+			return http.StatusOK, Response{Code: CodeInvalidRequest, Message: "txn_id reused"}
+		}
+		return t.status, t.resp
+	}
+
+	key := req.UserID + "|" + req.Currency
+	bal := w.balanceLocked(req.UserID, req.Currency)
+	var resp Response
+	switch path {
+	case PathDebit:
+		amt, err := decimal.NewFromString(req.Amount)
+		switch {
+		case err != nil || amt.IsNegative():
+			resp = Response{Code: CodeInvalidRequest, Message: "invalid amount"}
+		case w.rolledBack[req.TxnID]:
+			resp = Response{Code: CodeTxnRolledBack, Message: "debit already rolled back"}
+		case bal.LessThan(amt):
+			resp = Response{Code: CodeInsufficientBalance, Message: "insufficient balance"}
+		default:
+			bal = bal.Sub(amt)
+			w.balances[key] = bal
+			resp = Response{Code: CodeOK}
+		}
+	case PathCredit:
+		amt, err := decimal.NewFromString(req.Amount)
+		if err != nil || amt.IsNegative() {
+			resp = Response{Code: CodeInvalidRequest, Message: "invalid amount"}
+			break
+		}
+		bal = bal.Add(amt)
+		w.balances[key] = bal
+		resp = Response{Code: CodeOK}
+	case PathRollback:
+		if !w.rolledBack[req.RefTxnID] {
+			w.rolledBack[req.RefTxnID] = true
+			if d, ok := w.txns[req.RefTxnID]; ok && d.path == PathDebit && d.resp.Code == CodeOK {
+				amt, _ := decimal.NewFromString(d.req.Amount)
+				dk := d.req.UserID + "|" + d.req.Currency
+				w.balances[dk] = w.balances[dk].Add(amt)
+				if dk == key {
+					bal = w.balances[dk]
+				}
+			}
+		}
+		resp = Response{Code: CodeOK}
+	}
+	resp.Balance = bal.StringFixed(2)
+	w.txns[req.TxnID] = fakeTxn{path: path, req: req, resp: resp, status: http.StatusOK}
+	return http.StatusOK, resp
+}
+
+func (w *FakeWallet) balanceLocked(userID, currency string) decimal.Decimal {
+	key := userID + "|" + currency
+	bal, ok := w.balances[key]
+	if !ok {
+		bal = w.InitialBalance
+		w.balances[key] = bal
+	}
+	return bal
+}
+
+func writeFake(rw http.ResponseWriter, status int, resp Response) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	_ = json.NewEncoder(rw).Encode(resp)
+}
@@ -0,0 +1,64 @@
+// Package seamless 单一钱包（seamless wallet）协议与客户端
+//
+// 平台配置 wallet_mode=seamless 时，本服务不维护玩家余额，而是调用平台钱包接口：
+//
+//	POST {wallet_url}/debit     下注扣款
+//	POST {wallet_url}/credit    结算派彩、作废退款、撤单退款、开奖更正补派
+//	POST {wallet_url}/rollback  撤销一笔扣款（扣款结果未知、或扣款成功但注单未能确认时）
+//
+// 请求以平台的 app_key/app_secret 签名，算法与平台调用本服务一致（见 auth.SignRequest）：
+//
+//	X-Platform-Key: app_key
+//	X-Timestamp:    秒级时间戳
+//	X-Nonce:        随机串
+//	X-Signature:    HMAC-SHA256(app_key + timestamp + nonce + body, app_secret)
+//
+// 平台侧约定：
+//   - 同一 txn_id 重复调用须幂等，返回首次处理的结果
+//   - rollback 找不到 ref_txn_id 对应的扣款时返回成功，并拒绝之后迟到的该笔扣款（CodeTxnRolledBack）
+//   - 业务拒绝（余额不足等）返回 HTTP 200 与非 0 code；超时、5xx 或无法解析的响应视为结果未知，由本服务重试或冲正
+package seamless
+
+// 接口路径
+const (
+	PathDebit    = "/debit"
+	PathCredit   = "/credit"
+	PathRollback = "/rollback"
+)
+
+// 响应码
+const (
+	CodeOK                  = 0
+	CodeInsufficientBalance = 1001 // 余额不足
+	CodeUserNotFound        = 1002 // 平台用户不存在
+	CodeInvalidRequest      = 1003 // 参数错误
+	CodeInvalidSignature    = 1004 // 签名错误
+	CodeTxnRolledBack       = 1005 // 该扣款已被回滚，拒绝迟到的扣款
+)
+
+// 入账原因（credit.reason）
+const (
+	ReasonSettle    = "settle"     // 结算派彩（输的注单金额为 0，用于平台关闭注单）
+	ReasonRefund    = "refund"     // 牌局作废退款
+	ReasonBetCancel = "bet_cancel" // 玩家撤单退款
+	ReasonResettle  = "resettle"   // 开奖结果更正：补派差额（差额为负时以 debit 扣回）
+)
+
+// Request 钱包请求
+type Request struct {
+	TxnID    string   `json:"txn_id"`               // 交易号（全局唯一，幂等键）
+	UserID   string   `json:"user_id"`              // 平台用户ID
+	Currency string   `json:"currency"`             // 币种
+	Amount   string   `json:"amount,omitempty"`     // 金额（两位小数字符串）；rollback 不传
+	RefTxnID string   `json:"ref_txn_id,omitempty"` // credit/rollback 关联的下注扣款交易号
+	RoundID  string   `json:"round_id"`             // 局号
+	BillNos  []string `json:"bill_nos,omitempty"`   // 关联注单号
+	Reason   string   `json:"reason,omitempty"`     // 入账/扣款原因：settle|refund|bet_cancel|resettle；下注扣款为空
+}
+
+// Response 钱包响应
+type Response struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Balance string `json:"balance"` // 处理后的余额
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	chelper "dt-server/common/helper"
+	"dt-server/internal/engine"
 	infmysql "dt-server/internal/infra/mysql"
 	infrds "dt-server/internal/infra/redis"
 	"dt-server/internal/metrics"
@@ -38,18 +39,24 @@ type BetInput struct {
 	PlatformUserID   string // 平台用户ID
 	PlatformUserName string // 平台用户名（可选）
 	BetAmount        string
-	PlayType         int // 1dragon|2tiger|3tie（API层为int）
+	PlayType         int    // 1dragon|2tiger|3tie|4~16边注（API层为int，编码表见 model.PlayTypeName）
+	Currency         string // 平台传入的玩家币种（可选），须与房间币种一致
 	IdempotencyKey   string
 	TraceID          string
 }
 
 type BetOutput struct {
 	BillNo       string
-	RemainAmount string // 剩余金额
+	RemainAmount string // 剩余金额（下注币种钱包）
+	Currency     string // 下注币种
 }
 
 type BetService interface {
 	PlaceBet(ctx context.Context, in BetInput) (*BetOutput, error)
+	// PlaceBets 批量投注：同一幂等键下的多个玩法在一个事务内整体成功或失败
+	PlaceBets(ctx context.Context, in BatchBetInput) (*BatchBetOutput, error)
+	// CancelBet 撤单：下注中且未过房间撤单截止时间时，撤销本人待结算注单并全额退款
+	CancelBet(ctx context.Context, in CancelBetInput) (*CancelBetOutput, error)
 }
 
 type betService struct{}
@@ -73,7 +80,9 @@ var (
 	ErrInvalidStateBet      = errors.New("bet not allowed in current state")
 	ErrBetWindowNotStart    = errors.New("bet window not started")
 	ErrBetWindowClosed      = errors.New("bet window closed")
-	ErrConflictingPlayTypes = errors.New("cannot bet on both dragon and tiger in the same round")
+	ErrConflictingPlayTypes = errors.New("cannot bet on both sides (dragon/tiger, player/banker) in the same round")
+	ErrPlayTypeNotOffered   = errors.New("play type not offered in this room")
+	ErrRoundRoomMismatch    = errors.New("game round does not belong to this room")
 )
 
 // PlaceBet 处理下注主流程：
@@ -86,8 +95,8 @@ func (s *betService) PlaceBet(ctx context.Context, in BetInput) (*BetOutput, err
 	// ========== 投注金额解析和验证==========
 	// 1. 解析金额字符串
 	// 2. 验证金额为正数
-	// 3. 验证最小投注限制
-	// 4. 验证最大投注限制
+	// 3. 读取房间配置：房间须为开放状态，且提供该玩法
+	// 4. 按房间该玩法的限红验证最小/最大投注
 	// ================================================
 
 	// 解析投注金额
@@ -105,24 +114,65 @@ func (s *betService) PlaceBet(ctx context.Context, in BetInput) (*BetOutput, err
 		return nil, errors.New("bet amount must be positive")
 	}
 
-	// 验证最小投注限制（0.01）
-	minBet := decimal.NewFromFloat(0.01)
-	if amtDec.LessThan(minBet) {
-		fmt.Printf("[Bet]  投注金额低于最小限制: bet_amount=%s, min=%s, trace_id=%s\n",
-			in.BetAmount, minBet.String(), in.TraceID)
-		return nil, fmt.Errorf("bet amount below minimum limit: %s", minBet.String())
+	ptStr := model.PlayTypeName(int8(in.PlayType))
+
+	// 房间配置（注册表缓存）
+	room, err := LookupRoom(ctx, in.RoomID)
+	if err != nil {
+		fmt.Printf("[Bet]  读取房间配置失败: room_id=%s, error=%v, trace_id=%s\n",
+			in.RoomID, err, in.TraceID)
+		return nil, err
+	}
+	if !room.acceptsGame(in.GameID) {
+		fmt.Printf("[Bet]  game_id 与房间不一致: room_id=%s, game_id=%s, room_game_id=%s, trace_id=%s\n",
+			in.RoomID, in.GameID, room.GameID, in.TraceID)
+		return nil, ErrRoomGameMismatch
+	}
+	if room.Status != RoomStatusOpen {
+		fmt.Printf("[Bet]  房间未开放: room_id=%s, status=%d, trace_id=%s\n",
+			in.RoomID, room.Status, in.TraceID)
+		return nil, ErrRoomNotOpen
+	}
+	limit, okLimit := room.LimitFor(ptStr)
+	oddsDec, okOdds := room.OddsFor(ptStr)
+	if !okLimit || !okOdds {
+		fmt.Printf("[Bet]  房间未开放该玩法: room_id=%s, play_type=%s, trace_id=%s\n",
+			in.RoomID, ptStr, in.TraceID)
+		return nil, ErrPlayTypeNotOffered
+	}
+	// 赔率表覆盖房间默认赔率，注单记录所用版本便于追溯
+	oddsDec, oddsVersion := resolveOdds(ctx, room, in.PlatformID, ptStr, oddsDec, time.Now())
+
+	// 验证最小投注限制（房间该玩法的 min）
+	if amtDec.LessThan(limit.Min) {
+		fmt.Printf("[Bet]  投注金额低于最小限制: bet_amount=%s, min=%s, room_id=%s, trace_id=%s\n",
+			in.BetAmount, limit.Min.String(), in.RoomID, in.TraceID)
+		return nil, fmt.Errorf("bet amount below minimum limit: %s", limit.Min.String())
 	}
 
-	// 验证最大投注限制（1,000,000）
-	maxBet := decimal.NewFromInt(1000000)
-	if amtDec.GreaterThan(maxBet) {
-		fmt.Printf("[Bet]  投注金额超过最大限制: bet_amount=%s, max=%s, trace_id=%s\n",
-			in.BetAmount, maxBet.String(), in.TraceID)
-		return nil, fmt.Errorf("bet amount exceeds maximum limit: %s", maxBet.String())
+	// 验证最大投注限制（房间该玩法的 max）
+	if amtDec.GreaterThan(limit.Max) {
+		fmt.Printf("[Bet]  投注金额超过最大限制: bet_amount=%s, max=%s, room_id=%s, trace_id=%s\n",
+			in.BetAmount, limit.Max.String(), in.RoomID, in.TraceID)
+		return nil, fmt.Errorf("bet amount exceeds maximum limit: %s", limit.Max.String())
 	}
 
-	ptMap := map[int]string{1: "dragon", 2: "tiger", 3: "tie"}
-	ptStr := ptMap[in.PlayType]
+	// 币种：注单按房间币种计价，须平台允许；金额精度与币种单注限额
+	cur, err := resolveBetCurrency(room, in.PlatformID, in.Currency)
+	if err != nil {
+		fmt.Printf("[Bet]  币种不可用: room_id=%s, room_currency=%s, currency=%s, platform_id=%d, error=%v, trace_id=%s\n",
+			in.RoomID, room.Currency, in.Currency, in.PlatformID, err, in.TraceID)
+		return nil, err
+	}
+	if err := cur.checkAmount(amtDec); err != nil {
+		fmt.Printf("[Bet]  投注金额不符合币种规则: bet_amount=%s, currency=%s, error=%v, trace_id=%s\n",
+			in.BetAmount, cur.Code, err, in.TraceID)
+		return nil, err
+	}
+
+	// 平台钱包模式：seamless 由平台钱包扣款，本服务不维护余额
+	sp := seamlessPlatform(in.PlatformID)
+
 	defer func() { metrics.RecordBet(result, ptStr, start) }()
 
 	// 打印接收到的投注请求
@@ -168,26 +218,15 @@ func (s *betService) PlaceBet(ctx context.Context, in BetInput) (*BetOutput, err
 		}
 
 		// 使用 Lua 脚本原子释放锁（仅当锁值匹配时删除）
-		defer func() {
-			// Lua 脚本：只有当锁的值等于我们设置的值时才删除
-			script := `
-				if redis.call("get", KEYS[1]) == ARGV[1] then
-					return redis.call("del", KEYS[1])
-				else
-					return 0
-				end
-			`
-			result, err := r.Eval(ctx, script, []string{lockKey}, lockValue).Result()
-			if err != nil {
-				fmt.Printf("[Bet] 释放分布式锁失败: idem_key=%s, error=%v, trace_id=%s\n",
-					in.IdempotencyKey, err, in.TraceID)
-				// TODO: 记录指标用于监控 metrics.RecordLockReleaseFailure("bet", "redis_error")
-			} else if result == int64(0) {
-				fmt.Printf("[Bet] 分布式锁已被其他请求释放或过期: idem_key=%s, trace_id=%s\n",
-					in.IdempotencyKey, in.TraceID)
-				// TODO: 记录指标用于监控 metrics.RecordLockReleaseFailure("bet", "lock_mismatch")
-			}
-		}()
+		defer releaseIdemLock(ctx, lockKey, lockValue, in.IdempotencyKey, in.TraceID)
+	}
+
+	// 回合状态缓存显示已封盘/不在下注窗口时直接拒绝，不开事务（封盘前后的请求高峰不再打到数据库）
+	cachedRound, err := precheckRoundState(ctx, in.GameRoundID)
+	if err != nil {
+		fmt.Printf("[Bet]  回合状态缓存显示不可下注: error=%v, game_status=%d, bet_stop=%d, round_id=%s, trace_id=%s\n",
+			err, cachedRound.GameStatus, cachedRound.BetStopTime, in.GameRoundID, in.TraceID)
+		return nil, err
 	}
 
 	// ========== 生产环境审计：交易超时 ==========
@@ -213,29 +252,34 @@ func (s *betService) PlaceBet(ctx context.Context, in BetInput) (*BetOutput, err
 	}
 	defer func() { _ = tx.Rollback() }()
 
-	// 获取或创建用户（自动注册）
-	user, err := getOrCreateUserInTx(txCtx, tx, in.PlatformID, in.PlatformUserID, in.PlatformUserName)
+	// 获取或创建用户（自动注册）；互斥主注需串行做冲突检查，才锁定用户行
+	_, opposing := opposingPlayTypes[in.PlayType]
+	user, err := getOrCreateUserInTx(txCtx, tx, in.PlatformID, in.PlatformUserID, in.PlatformUserName, opposing)
 	if err != nil {
 		fmt.Printf("[Bet] 获取或创建用户失败: error=%v, platform_id=%d, platform_user_id=%s, trace_id=%s\n",
 			err, in.PlatformID, in.PlatformUserID, in.TraceID)
 		return nil, fmt.Errorf("failed to get or create user: %w", err)
 	}
 
-	// 获取当前赔率
-	odds := calcOdds(ptStr)
 	// 生成订单号（使用可读格式，使用内部用户ID）
 	billNo := generateBillNo(user.ID)
 
-	// 获取回合信息并锁定
-	round, err := model.GetRoundForUpdate(txCtx, tx, in.GameRoundID)
+	// 获取回合状态（共享锁：同一局的下注互不阻塞，封盘须等本事务提交）
+	serial := roundBetNeedsSerial(room)
+	round, err := lockRoundForBet(txCtx, tx, in.GameRoundID, serial, cachedRound)
 	if err != nil {
 		fmt.Printf("[Bet]  查询游戏回合失败: error=%v, round_id=%s, trace_id=%s\n",
 			err, in.GameRoundID, in.TraceID)
 		return nil, fmt.Errorf("failed to get round info: %w", err)
 	}
+	if err := checkRoundRoom(round, room); err != nil {
+		fmt.Printf("[Bet] 回合不属于该房间: room_id=%s, game_id=%s, round_room_id=%s, round_game_id=%s, round_id=%s, trace_id=%s\n",
+			in.RoomID, room.GameID, round.RoomID, round.GameID, in.GameRoundID, in.TraceID)
+		return nil, err
+	}
 
 	// 校验回合状态：仅在 betting 状态允许下注
-	currentState := codeToState(round.GameStatus)
+	currentState := state.Default.StateName(round.GameStatus)
 	if currentState != state.StateBetting {
 		fmt.Printf("[Bet]  游戏状态不允许投注: current_state=%s(%d), expected=betting(2), round_id=%s, trace_id=%s\n",
 			currentState, round.GameStatus, in.GameRoundID, in.TraceID)
@@ -255,7 +299,7 @@ func (s *betService) PlaceBet(ctx context.Context, in BetInput) (*BetOutput, err
 		return nil, ErrBetWindowClosed
 	}
 
-	// 检查是否存在冲突的投注（同一局不能同时投注龙和虎）
+	// 检查是否存在冲突的投注（同一局不能同时投注龙和虎、闲和庄）
 	hasConflict, err := checkConflictingBets(txCtx, tx, in.GameRoundID, in.PlatformID, in.PlatformUserID, in.PlayType)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check conflicting bets: %w", err)
@@ -284,15 +328,23 @@ func (s *betService) PlaceBet(ctx context.Context, in BetInput) (*BetOutput, err
 					}
 				}
 			}
-			// DB 回源：根据幂等键查 bill_no，再查用户余额
+			// DB 回源：根据幂等键查 bill_no，再查该币种钱包余额（seamless 模式为扣款后的平台余额）
 			ref, e1 := model.SelectRefByIdemKey(txCtx, infmysql.SQLX(), in.IdempotencyKey)
 			if e1 == nil && ref != "" {
-				// 查询用户余额
-				u, e2 := model.GetUserByPlatformUser(txCtx, infmysql.SQLX(), in.PlatformID, in.PlatformUserID)
+				var bal decimal.Decimal
+				var e2 error
+				if sp != nil {
+					bal, e2 = seamlessBetResult(txCtx, ref)
+					if errors.Is(e2, ErrDuplicateInFlight) || errors.Is(e2, ErrWalletRejected) {
+						return nil, e2
+					}
+				} else {
+					bal, e2 = walletBalance(txCtx, in.PlatformID, in.PlatformUserID, room.Currency)
+				}
 				if e2 == nil {
 					fmt.Printf("[Bet]  从数据库返回上次结果: bill_no=%s, trace_id=%s\n",
 						ref, in.TraceID)
-					return &BetOutput{BillNo: ref, RemainAmount: chelper.TrimDecimal(decimal.NewFromFloat(u.Balance))}, nil
+					return &BetOutput{BillNo: ref, RemainAmount: chelper.TrimDecimal(bal), Currency: room.Currency}, nil
 				}
 			}
 		}
@@ -301,48 +353,56 @@ func (s *betService) PlaceBet(ctx context.Context, in BetInput) (*BetOutput, err
 		return nil, fmt.Errorf("idempotency conflict or insert failed: %w", err)
 	}
 
-	// 校验用户状态（user 已经在事务中加锁）
+	// 校验用户状态
 	if user.Status != 1 {
 		fmt.Printf("[Bet]  用户状态异常: user_id=%d, status=%d, trace_id=%s\n",
 			user.ID, user.Status, in.TraceID)
 		return nil, errors.New("user disabled")
 	}
-	// 校验余额（decimal 比较）
-	if decimal.NewFromFloat(user.Balance).Cmp(amtDec) < 0 {
-		return nil, errors.New("insufficient balance")
-	}
-
-	beforeDec := decimal.NewFromFloat(user.Balance)
-	afterDec := beforeDec.Sub(amtDec)
-
-	// 更新余额（两位小数）
-	if err := model.UpdateUserBalance(txCtx, tx, user.ID, afterDec.Round(2).InexactFloat64()); err != nil {
-		return nil, err
-	}
-
-	// 写账本，此处为扣款
-	ledger := &model.WalletLedger{
-		UserID:       user.ID,
-		BizType:      BIZ_TYPE_BET, //1
-		BizTypeStr:   "bet",        // 冗余
-		Amount:       amtDec.Round(2).InexactFloat64(),
-		BeforeAmount: beforeDec.Round(2).InexactFloat64(),
-		AfterAmount:  afterDec.Round(2).InexactFloat64(),
-		Currency:     "CNY",
-		BillNo:       billNo,
-		GameRoundID:  in.GameRoundID,
-		GameID:       in.GameID,
-		RoomID:       in.RoomID,
-		Remark:       "bet deduct",
-		TraceID:      in.TraceID,
-	}
-	if err := ledger.Insert(txCtx, tx); err != nil {
-		fmt.Printf("[Bet]  写入账本失败: error=%v, bill_no=%s, trace_id=%s\n",
-			err, billNo, in.TraceID)
-		return nil, err
+	afterDec := decimal.Zero
+	var debit *model.WalletTxn
+	if sp != nil {
+		// seamless：登记平台扣款交易，注单以 bet_status=1 落库，提交后调用平台扣款再确认
+		debit = newBetDebit(user, room.Currency, in.GameRoundID, []string{billNo}, amtDec, in.TraceID)
+		if err := debit.Insert(txCtx, tx); err != nil {
+			return nil, err
+		}
+	} else {
+		// 原子扣款：单条条件语句（余额足够且版本未变）写入，返回扣款前后余额（两位小数）
+		beforeDec, after, err := mutateWallet(txCtx, tx, user.ID, room.Currency, amtDec.Neg(), false)
+		if err != nil {
+			if !errors.Is(err, ErrInsufficientBalance) {
+				fmt.Printf("[Bet] 扣款失败: error=%v, user_id=%d, currency=%s, trace_id=%s\n",
+					err, user.ID, room.Currency, in.TraceID)
+			}
+			return nil, err
+		}
+		afterDec = after
+
+		// 写账本，此处为扣款
+		ledger := &model.WalletLedger{
+			UserID:       user.ID,
+			BizType:      BIZ_TYPE_BET, //1
+			BizTypeStr:   "bet",        // 冗余
+			Amount:       amtDec.Round(2),
+			BeforeAmount: beforeDec,
+			AfterAmount:  afterDec,
+			Currency:     room.Currency,
+			BillNo:       billNo,
+			GameRoundID:  in.GameRoundID,
+			GameID:       in.GameID,
+			RoomID:       in.RoomID,
+			Remark:       "bet deduct",
+			TraceID:      in.TraceID,
+		}
+		if err := ledger.Insert(txCtx, tx); err != nil {
+			fmt.Printf("[Bet]  写入账本失败: error=%v, bill_no=%s, trace_id=%s\n",
+				err, billNo, in.TraceID)
+			return nil, err
+		}
 	}
 
-	// 落注单（bet_status:2成功, bill_status:1待结算）
+	// 落注单（bet_status:2成功, bill_status:1待结算；seamless 扣款确认前 bet_status=1）
 	ord := &model.Order{
 		BillNo:         billNo,
 		RoomID:         in.RoomID,
@@ -352,33 +412,51 @@ func (s *betService) PlaceBet(ctx context.Context, in BetInput) (*BetOutput, err
 		PlatformID:     in.PlatformID,
 		PlatformUserID: in.PlatformUserID,
 		UserName:       user.Username,
-		BetAmount:      amtDec.Round(2).InexactFloat64(),
+		BetAmount:      amtDec.Round(2),
 		PlayType:       ptStr,
 		BetStatus:      2,
 		BillStatus:     1,
-		WinAmount:      0,
-		BetOdds:        odds,
-		Currency:       "CNY",
+		WinAmount:      decimal.Zero,
+		BetOdds:        oddsDec, // 下注时锁定当前赔率，结算按注单上的赔率派彩
+		OddsVersion:    oddsVersion,
+		Currency:       room.Currency,
 		IdempotencyKey: in.IdempotencyKey,
 		TraceID:        in.TraceID,
 	}
+	if debit != nil {
+		ord.BetStatus = 1
+		ord.WalletTxnID = debit.TxnID
+	}
 	if err := ord.Insert(txCtx, tx); err != nil {
 		fmt.Printf("[Bet]  创建订单失败: error=%v, bill_no=%s, trace_id=%s\n",
 			err, billNo, in.TraceID)
 		return nil, err
 	}
 
-	// Outbox 消息（异步）
+	// Outbox 消息（异步）；seamless 模式在平台扣款确认后写入
 	payload := map[string]any{
 		"event":            "bet_placed",
 		"bill_no":          billNo,
 		"user_id":          user.ID,
 		"platform_id":      in.PlatformID,
 		"platform_user_id": in.PlatformUserID,
+		"currency":         room.Currency,
 	}
-	if err := model.CreateOutbox(txCtx, tx, "bet_placed", billNo, payload); err != nil {
-		fmt.Printf("[Bet]  写入 Outbox 失败: error=%v, bill_no=%s, trace_id=%s\n",
-			err, billNo, in.TraceID)
+	if debit == nil {
+		if err := model.CreateOutbox(txCtx, tx, "bet_placed", billNo, payload); err != nil {
+			fmt.Printf("[Bet]  写入 Outbox 失败: error=%v, bill_no=%s, trace_id=%s\n",
+				err, billNo, in.TraceID)
+			return nil, err
+		}
+	}
+
+	// 赔付敞口：校验并累加本局各结果的净赔付，超过房间上限则拒绝（注单写入后、提交前）
+	eng, err := engine.For(room.GameID)
+	if err != nil {
+		return nil, err
+	}
+	leg := exposureLeg{playType: ptStr, amount: amtDec.Round(2), odds: oddsDec}
+	if err := reserveExposure(txCtx, tx, eng, room, in.GameRoundID, []exposureLeg{leg}, serial, in.TraceID); err != nil {
 		return nil, err
 	}
 
@@ -388,8 +466,15 @@ func (s *betService) PlaceBet(ctx context.Context, in BetInput) (*BetOutput, err
 		return nil, err
 	}
 
+	// seamless：调用平台扣款并确认注单，余额为平台返回的扣款后余额
+	if debit != nil {
+		if afterDec, err = completeSeamlessBet(ctx, sp, debit, eng, []exposureLeg{leg}, in.TraceID); err != nil {
+			return nil, err
+		}
+	}
+
 	result = "success"
-	out := &BetOutput{BillNo: billNo, RemainAmount: chelper.TrimDecimal(afterDec)}
+	out := &BetOutput{BillNo: billNo, RemainAmount: chelper.TrimDecimal(afterDec), Currency: room.Currency}
 
 	// 写入 Redis 结果缓存（降级容错）
 	if r := infrds.Client(); r != nil {
@@ -401,19 +486,31 @@ func (s *betService) PlaceBet(ctx context.Context, in BetInput) (*BetOutput, err
 	return out, nil
 }
 
-// 赔率
-// 这里只是方便演示，暂时硬编码
-func calcOdds(pt string) float64 {
-
-	switch strings.ToLower(pt) {
-	case "dragon":
-		return 0.97
-	case "tiger":
-		return 0.97
-	case "tie":
-		return 8.0
+// releaseLockScript 只有当锁的值等于我们设置的值时才删除
+const releaseLockScript = `
+	if redis.call("get", KEYS[1]) == ARGV[1] then
+		return redis.call("del", KEYS[1])
+	else
+		return 0
+	end
+`
+
+// releaseIdemLock 原子释放幂等进行中锁，防止误删其他请求的锁
+func releaseIdemLock(ctx context.Context, lockKey, lockValue, idemKey, traceID string) {
+	r := infrds.Client()
+	if r == nil {
+		return
+	}
+	result, err := r.Eval(ctx, releaseLockScript, []string{lockKey}, lockValue).Result()
+	if err != nil {
+		fmt.Printf("[Bet] 释放分布式锁失败: idem_key=%s, error=%v, trace_id=%s\n",
+			idemKey, err, traceID)
+		// TODO: 记录指标用于监控 metrics.RecordLockReleaseFailure("bet", "redis_error")
+	} else if result == int64(0) {
+		fmt.Printf("[Bet] 分布式锁已被其他请求释放或过期: idem_key=%s, trace_id=%s\n",
+			idemKey, traceID)
+		// TODO: 记录指标用于监控 metrics.RecordLockReleaseFailure("bet", "lock_mismatch")
 	}
-	return 0.0
 }
 
 // generateBillNo 生成可读的订单号
@@ -438,49 +535,48 @@ func generateBillNo(userID int64) string {
 	return fmt.Sprintf("DT%s%s%s", dateTime, userSuffix, randomHex)
 }
 
+// opposingPlayTypes 互斥的主注：龙(1)/虎(2)、闲(17)/庄(18)
+var opposingPlayTypes = map[int]int{1: 2, 2: 1, 17: 18, 18: 17}
+
 // checkConflictingBets 检查用户在当前游戏轮次是否已投注冲突的玩法
-// 规则：同一局游戏，一个用户不可以同时投注龙和虎
+// 规则：同一局游戏，一个用户不可以同时投注龙和虎、闲和庄（仅限主注；边注如龙大、虎大可以同时投注）
 // 返回：true 表示有冲突，false 表示无冲突
 func checkConflictingBets(ctx context.Context, tx *sqlx.Tx, gameRoundID string, platformID int8, platformUserID string, playType int) (bool, error) {
-	// 只检查 Dragon(1) 和 Tiger(2) 的冲突
-	// Tie(3) 可以与 Dragon/Tiger 共存
-	if playType != 1 && playType != 2 {
-		return false, nil // Tie 不检查冲突
+	// Tie(3) 与边注(4~16) 可以与任意玩法共存
+	opposite, ok := opposingPlayTypes[playType]
+	if !ok {
+		return false, nil // Tie/边注 不检查冲突
 	}
 
-	// 查询该用户在当前游戏轮次的所有投注
 	query := `
-		SELECT play_type
+		SELECT COUNT(*)
 		FROM orders
-		WHERE game_round_id = ? AND platform_id = ? AND platform_user_id = ? AND bill_status IN (1, 2)
+		WHERE game_round_id = ? AND platform_id = ? AND platform_user_id = ? AND bill_status IN (1, 2) AND play_type = ?
 	`
 
-	var existingPlayTypes []int
-	err := tx.SelectContext(ctx, &existingPlayTypes, query, gameRoundID, platformID, platformUserID)
-	if err != nil {
+	var n int
+	if err := tx.GetContext(ctx, &n, query, gameRoundID, platformID, platformUserID, opposite); err != nil {
 		return false, fmt.Errorf("failed to check existing bets: %w", err)
 	}
-
-	// 检查是否有冲突
-	for _, existingType := range existingPlayTypes {
-		// 如果当前要投注 Dragon(1)，但已经投注了 Tiger(2)
-		if playType == 1 && existingType == 2 {
-			return true, nil
-		}
-		// 如果当前要投注 Tiger(2)，但已经投注了 Dragon(1)
-		if playType == 2 && existingType == 1 {
-			return true, nil
-		}
-	}
-
-	return false, nil
+	return n > 0, nil
 }
 
-// getOrCreateUserInTx 在事务中获取或创建用户
-// 如果用户不存在，自动创建；如果存在，返回现有用户并加锁
-func getOrCreateUserInTx(ctx context.Context, tx *sqlx.Tx, platformID int8, platformUserID, username string) (*model.Customers, error) {
-	// 1. 先尝试加锁查询
-	user, err := model.GetUserByPlatformUserForUpdate(ctx, tx, platformID, platformUserID)
+// getOrCreateUserInTx 在事务中获取或创建用户，不存在时自动创建
+// lock 时对 customers 行加排他锁并持有到事务结束，使同一用户的事务串行：
+//   - 下注含互斥主注（龙/虎、闲/庄）时，checkConflictingBets 先查后写，不串行则两笔并发的龙、虎注单都能通过检查
+//   - 转账按 transfer_id 先查后写做幂等
+//
+// 其余下注（和、边注）不需要串行，只取共享锁，避免同一用户的并发下注在整笔事务期间互相等待。
+// 不用普通读取：普通读取会在此时建立一致性读快照，其后（如敞口降级时持排他锁按注单校验）读不到其他事务已提交的注单
+func getOrCreateUserInTx(ctx context.Context, tx *sqlx.Tx, platformID int8, platformUserID, username string, lock bool) (*model.Customers, error) {
+	// 1. 先加锁查询
+	var user *model.Customers
+	var err error
+	if lock {
+		user, err = model.GetUserByPlatformUserForUpdate(ctx, tx, platformID, platformUserID)
+	} else {
+		user, err = model.GetUserByPlatformUserShared(ctx, tx, platformID, platformUserID)
+	}
 	if err == nil {
 		return user, nil // 用户已存在
 	}
@@ -492,8 +588,8 @@ func getOrCreateUserInTx(ctx context.Context, tx *sqlx.Tx, platformID int8, plat
 			PlatformID:     platformID,
 			PlatformUserID: platformUserID,
 			Username:       username,
-			Balance:        0.00, // 初始余额
-			Status:         1,    // 正常状态
+			Balance:        decimal.Zero, // 初始余额
+			Status:         1,            // 正常状态
 			CreatedAt:      now,
 			UpdatedAt:      now,
 		}
@@ -506,7 +602,7 @@ func getOrCreateUserInTx(ctx context.Context, tx *sqlx.Tx, platformID int8, plat
 		if err != nil {
 			// 处理并发创建的情况（唯一索引冲突）
 			if me, ok := err.(*mysqlerr.MySQLError); ok && me.Number == 1062 {
-				// 重新查询并加锁
+				// 重新查询：须用加锁读取，事务内的普通读取沿用首次读取时的快照，看不到并发事务刚创建的用户
 				return model.GetUserByPlatformUserForUpdate(ctx, tx, platformID, platformUserID)
 			}
 			return nil, err
@@ -0,0 +1,458 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	chelper "dt-server/common/helper"
+	"dt-server/internal/engine"
+	infmysql "dt-server/internal/infra/mysql"
+	infrds "dt-server/internal/infra/redis"
+	"dt-server/internal/metrics"
+	"dt-server/internal/model"
+	"dt-server/internal/state"
+
+	mysqlerr "github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+	decimal "github.com/shopspring/decimal"
+)
+
+// maxBatchBets 单次批量投注最多包含的玩法数
+const maxBatchBets = 16
+
+// BetItem 批量投注中的单个玩法
+type BetItem struct {
+	PlayType  int
+	BetAmount string
+}
+
+// BatchBetInput 批量投注参数：同一局、同一幂等键下的多个玩法
+type BatchBetInput struct {
+	GameID           string
+	RoomID           string
+	GameRoundID      string
+	PlatformID       int8
+	PlatformUserID   string
+	PlatformUserName string
+	Items            []BetItem
+	Currency         string // 平台传入的玩家币种（可选），须与房间币种一致
+	IdempotencyKey   string
+	TraceID          string
+}
+
+// BatchBetOrder 批量投注生成的注单
+type BatchBetOrder struct {
+	BillNo    string `json:"bill_no"`
+	PlayType  int    `json:"play_type"`
+	BetAmount string `json:"bet_amount"`
+}
+
+type BatchBetOutput struct {
+	Orders       []BatchBetOrder `json:"orders"`
+	RemainAmount string          `json:"remain_amount"` // 剩余金额（下注币种钱包）
+	Currency     string          `json:"currency"`      // 下注币种
+}
+
+var ErrDuplicatePlayType = errors.New("duplicate play type in batch")
+
+// batchLeg 已校验的单个玩法
+type batchLeg struct {
+	playType int
+	ptStr    string
+	amount   decimal.Decimal
+	odds     decimal.Decimal
+	// oddsVersion 赔率表版本（0 表示房间默认赔率）
+	oddsVersion int64
+}
+
+// PlaceBets 批量投注：一次校验全部玩法的限红与互斥规则，锁一次用户（牌局取共享锁），原子扣一次总额，每笔注单写一条账本，整体成功或失败
+func (s *betService) PlaceBets(ctx context.Context, in BatchBetInput) (*BatchBetOutput, error) {
+	start := time.Now()
+	result := "fail"
+
+	if len(in.Items) == 0 || len(in.Items) > maxBatchBets {
+		return nil, ErrBadRequest
+	}
+
+	// 房间配置（注册表缓存）
+	room, err := LookupRoom(ctx, in.RoomID)
+	if err != nil {
+		fmt.Printf("[BetBatch]  读取房间配置失败: room_id=%s, error=%v, trace_id=%s\n",
+			in.RoomID, err, in.TraceID)
+		return nil, err
+	}
+	if !room.acceptsGame(in.GameID) {
+		return nil, ErrRoomGameMismatch
+	}
+	if room.Status != RoomStatusOpen {
+		return nil, ErrRoomNotOpen
+	}
+	cur, err := resolveBetCurrency(room, in.PlatformID, in.Currency)
+	if err != nil {
+		fmt.Printf("[BetBatch]  币种不可用: room_id=%s, room_currency=%s, currency=%s, platform_id=%d, error=%v, trace_id=%s\n",
+			in.RoomID, room.Currency, in.Currency, in.PlatformID, err, in.TraceID)
+		return nil, err
+	}
+
+	// ========== 逐个玩法校验金额与限红，并校验批次内的互斥玩法 ==========
+	legs := make([]batchLeg, 0, len(in.Items))
+	seen := make(map[int]bool, len(in.Items))
+	total := decimal.Zero
+	oddsAt := time.Now() // 批次内全部玩法按同一时刻选择赔率版本
+	for _, it := range in.Items {
+		if seen[it.PlayType] {
+			return nil, ErrDuplicatePlayType
+		}
+		seen[it.PlayType] = true
+		if opposite, ok := opposingPlayTypes[it.PlayType]; ok && seen[opposite] {
+			fmt.Printf("[BetBatch] 批次内存在冲突投注: round_id=%s, platform_user_id=%s, play_types=%d/%d, trace_id=%s\n",
+				in.GameRoundID, in.PlatformUserID, it.PlayType, opposite, in.TraceID)
+			return nil, ErrConflictingPlayTypes
+		}
+
+		amt, err := decimal.NewFromString(strings.TrimSpace(it.BetAmount))
+		if err != nil {
+			return nil, errors.New("invalid bet amount format")
+		}
+		if amt.LessThanOrEqual(decimal.Zero) {
+			return nil, errors.New("bet amount must be positive")
+		}
+		ptStr := model.PlayTypeName(int8(it.PlayType))
+		limit, okLimit := room.LimitFor(ptStr)
+		odds, okOdds := room.OddsFor(ptStr)
+		if !okLimit || !okOdds {
+			fmt.Printf("[BetBatch]  房间未开放该玩法: room_id=%s, play_type=%s, trace_id=%s\n",
+				in.RoomID, ptStr, in.TraceID)
+			return nil, ErrPlayTypeNotOffered
+		}
+		if amt.LessThan(limit.Min) {
+			return nil, fmt.Errorf("bet amount below minimum limit: %s (%s)", limit.Min.String(), ptStr)
+		}
+		if amt.GreaterThan(limit.Max) {
+			return nil, fmt.Errorf("bet amount exceeds maximum limit: %s (%s)", limit.Max.String(), ptStr)
+		}
+		if err := cur.checkAmount(amt); err != nil {
+			return nil, fmt.Errorf("%w (%s)", err, ptStr)
+		}
+		odds, oddsVersion := resolveOdds(ctx, room, in.PlatformID, ptStr, odds, oddsAt)
+		legs = append(legs, batchLeg{playType: it.PlayType, ptStr: ptStr, amount: amt.Round(2), odds: odds, oddsVersion: oddsVersion})
+		total = total.Add(amt.Round(2))
+	}
+
+	// 平台钱包模式：seamless 整批一笔平台扣款
+	sp := seamlessPlatform(in.PlatformID)
+
+	defer func() {
+		for _, l := range legs {
+			metrics.RecordBet(result, l.ptStr, start)
+		}
+	}()
+
+	fmt.Printf("[BetBatch]  收到批量投注请求: round_id=%s, platform_id=%d, platform_user_id=%s, bets=%d, total=%s, idem_key=%s, trace_id=%s\n",
+		in.GameRoundID, in.PlatformID, in.PlatformUserID, len(legs), total.String(), in.IdempotencyKey, in.TraceID)
+
+	// Redis 快路径与进行中锁（与单笔投注使用独立的 Key，结果结构不同）
+	if r := infrds.Client(); r != nil {
+		if out := cachedBatchResult(ctx, in.IdempotencyKey); out != nil {
+			fmt.Printf("[BetBatch]  Redis 缓存命中: idem_key=%s, trace_id=%s\n", in.IdempotencyKey, in.TraceID)
+			return out, nil
+		}
+		lockValue := uuid.New().String()
+		lockKey := infrds.BatchIdemLockKey(in.IdempotencyKey)
+		ok, _ := r.SetNX(ctx, lockKey, lockValue, idemLockTTL).Result()
+		if !ok {
+			if out := cachedBatchResult(ctx, in.IdempotencyKey); out != nil {
+				return out, nil
+			}
+			fmt.Printf("[BetBatch]  重复请求进行中: idem_key=%s, trace_id=%s\n", in.IdempotencyKey, in.TraceID)
+			return nil, ErrDuplicateInFlight
+		}
+		defer releaseIdemLock(ctx, lockKey, lockValue, in.IdempotencyKey, in.TraceID)
+	}
+
+	// 回合状态缓存显示不可下注时直接拒绝，不开事务
+	cachedRound, err := precheckRoundState(ctx, in.GameRoundID)
+	if err != nil {
+		fmt.Printf("[BetBatch]  回合状态缓存显示不可下注: error=%v, game_status=%d, round_id=%s, trace_id=%s\n",
+			err, cachedRound.GameStatus, in.GameRoundID, in.TraceID)
+		return nil, err
+	}
+
+	txCtx := ctx
+	if _, has := ctx.Deadline(); !has {
+		c, cancel := context.WithTimeout(ctx, defaultTxTimeout)
+		txCtx = c
+		defer cancel()
+	}
+	tx, err := infmysql.SQLX().BeginTxx(txCtx, nil)
+	if err != nil {
+		fmt.Printf("[BetBatch] 开启事务失败: error=%v, round_id=%s, trace_id=%s\n",
+			err, in.GameRoundID, in.TraceID)
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// 获取或创建用户（自动注册）；含互斥主注时锁定用户行，与该用户的其他下注串行做冲突检查
+	opposing := false
+	for _, l := range legs {
+		if _, ok := opposingPlayTypes[l.playType]; ok {
+			opposing = true
+		}
+	}
+	user, err := getOrCreateUserInTx(txCtx, tx, in.PlatformID, in.PlatformUserID, in.PlatformUserName, opposing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get or create user: %w", err)
+	}
+
+	// 获取回合状态（共享锁：同一局的下注互不阻塞，封盘须等本事务提交）
+	serial := roundBetNeedsSerial(room)
+	round, err := lockRoundForBet(txCtx, tx, in.GameRoundID, serial, cachedRound)
+	if err != nil {
+		fmt.Printf("[BetBatch]  查询游戏回合失败: error=%v, round_id=%s, trace_id=%s\n",
+			err, in.GameRoundID, in.TraceID)
+		return nil, fmt.Errorf("failed to get round info: %w", err)
+	}
+	if err := checkRoundRoom(round, room); err != nil {
+		fmt.Printf("[BetBatch] 回合不属于该房间: room_id=%s, round_room_id=%s, round_game_id=%s, round_id=%s, trace_id=%s\n",
+			in.RoomID, round.RoomID, round.GameID, in.GameRoundID, in.TraceID)
+		return nil, err
+	}
+	if state.Default.StateName(round.GameStatus) != state.StateBetting {
+		return nil, ErrInvalidStateBet
+	}
+	now := time.Now().UnixMilli()
+	if now < round.BetStartTime {
+		return nil, ErrBetWindowNotStart
+	}
+	if now > round.BetStopTime {
+		return nil, ErrBetWindowClosed
+	}
+
+	// 与本局已有注单的互斥检查
+	for _, l := range legs {
+		hasConflict, err := checkConflictingBets(txCtx, tx, in.GameRoundID, in.PlatformID, in.PlatformUserID, l.playType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check conflicting bets: %w", err)
+		}
+		if hasConflict {
+			fmt.Printf("[BetBatch] 存在冲突投注: round_id=%s, platform_user_id=%s, play_type=%d, trace_id=%s\n",
+				in.GameRoundID, in.PlatformUserID, l.playType, in.TraceID)
+			return nil, ErrConflictingPlayTypes
+		}
+	}
+
+	// 同一批次内订单号不能重复（随机部分只有 3 位十六进制）
+	billNos := make([]string, len(legs))
+	used := make(map[string]bool, len(legs))
+	for i := range legs {
+		for {
+			b := generateBillNo(user.ID)
+			if !used[b] {
+				used[b] = true
+				billNos[i] = b
+				break
+			}
+		}
+	}
+
+	// 幂等：先占幂等键，ref 记录第一笔注单号（全部注单的 idempotency_key 相同）
+	if err := (&model.IdempotencyKey{IdempotencyKey: in.IdempotencyKey, Purpose: "bet_batch", Ref: billNos[0]}).Insert(txCtx, tx); err != nil {
+		if me, ok := err.(*mysqlerr.MySQLError); ok && me.Number == 1062 {
+			fmt.Printf("[BetBatch]  幂等键冲突，尝试返回上次结果: idem_key=%s, trace_id=%s\n",
+				in.IdempotencyKey, in.TraceID)
+			_ = tx.Rollback()
+			if out := cachedBatchResult(ctx, in.IdempotencyKey); out != nil {
+				return out, nil
+			}
+			if out, err := previousBatchResult(ctx, in, sp != nil); out != nil || err != nil {
+				return out, err
+			}
+		}
+		fmt.Printf("[BetBatch]  插入幂等键失败: error=%v, idem_key=%s, trace_id=%s\n",
+			err, in.IdempotencyKey, in.TraceID)
+		return nil, fmt.Errorf("idempotency conflict or insert failed: %w", err)
+	}
+
+	if user.Status != 1 {
+		return nil, errors.New("user disabled")
+	}
+	// 账本余额快照逐笔递减
+	ledgers := make([]*model.WalletLedger, 0, len(legs))
+	for i, l := range legs {
+		ledgers = append(ledgers, &model.WalletLedger{
+			UserID:      user.ID,
+			BizType:     BIZ_TYPE_BET,
+			BizTypeStr:  "bet",
+			Amount:      l.amount,
+			Currency:    room.Currency,
+			BillNo:      billNos[i],
+			GameRoundID: in.GameRoundID,
+			GameID:      in.GameID,
+			RoomID:      in.RoomID,
+			Remark:      "bet deduct (batch)",
+			TraceID:     in.TraceID,
+		})
+	}
+	afterDec := decimal.Zero
+	var debit *model.WalletTxn
+	if sp != nil {
+		// seamless：整批登记一笔平台扣款，注单以 bet_status=1 落库，提交后调用平台扣款再确认
+		debit = newBetDebit(user, room.Currency, in.GameRoundID, billNos, total, in.TraceID)
+		if err := debit.Insert(txCtx, tx); err != nil {
+			return nil, err
+		}
+	} else {
+		// 一次原子扣除总额（余额足够且版本未变才生效）
+		beforeDec, _, err := mutateWallet(txCtx, tx, user.ID, room.Currency, total.Neg(), false)
+		if err != nil {
+			return nil, err
+		}
+		afterDec = postLedgers(beforeDec, true, ledgers)
+	}
+
+	// 每笔注单一条账本、一张注单、一条 Outbox；seamless 模式只落注单
+	out := &BatchBetOutput{Orders: make([]BatchBetOrder, 0, len(legs)), RemainAmount: chelper.TrimDecimal(afterDec), Currency: room.Currency}
+	for i, l := range legs {
+		billNo := billNos[i]
+		if debit == nil {
+			if err := ledgers[i].Insert(txCtx, tx); err != nil {
+				fmt.Printf("[BetBatch]  写入账本失败: error=%v, bill_no=%s, trace_id=%s\n", err, billNo, in.TraceID)
+				return nil, err
+			}
+		}
+
+		ord := &model.Order{
+			BillNo:         billNo,
+			RoomID:         in.RoomID,
+			GameRoundID:    in.GameRoundID,
+			GameID:         in.GameID,
+			UserID:         user.ID,
+			PlatformID:     in.PlatformID,
+			PlatformUserID: in.PlatformUserID,
+			UserName:       user.Username,
+			BetAmount:      l.amount,
+			PlayType:       l.ptStr,
+			BetStatus:      2,
+			BetTime:        now,
+			BillStatus:     1,
+			BetOdds:        l.odds,
+			OddsVersion:    l.oddsVersion,
+			Currency:       room.Currency,
+			IdempotencyKey: in.IdempotencyKey,
+			TraceID:        in.TraceID,
+		}
+		if debit != nil {
+			ord.BetStatus = 1
+			ord.WalletTxnID = debit.TxnID
+		}
+		if err := ord.Insert(txCtx, tx); err != nil {
+			fmt.Printf("[BetBatch]  创建订单失败: error=%v, bill_no=%s, trace_id=%s\n", err, billNo, in.TraceID)
+			return nil, err
+		}
+
+		if debit == nil {
+			if err := model.CreateOutbox(txCtx, tx, "bet_placed", billNo, map[string]any{
+				"event":            "bet_placed",
+				"bill_no":          billNo,
+				"user_id":          user.ID,
+				"platform_id":      in.PlatformID,
+				"platform_user_id": in.PlatformUserID,
+				"currency":         room.Currency,
+			}); err != nil {
+				return nil, err
+			}
+		}
+		out.Orders = append(out.Orders, BatchBetOrder{BillNo: billNo, PlayType: l.playType, BetAmount: chelper.TrimDecimal(l.amount)})
+	}
+
+	// 赔付敞口：整批一次校验并累加（对冲的玩法在同一批内相互抵消）
+	eng, err := engine.For(room.GameID)
+	if err != nil {
+		return nil, err
+	}
+	exLegs := make([]exposureLeg, 0, len(legs))
+	for _, l := range legs {
+		exLegs = append(exLegs, exposureLeg{playType: l.ptStr, amount: l.amount, odds: l.odds})
+	}
+	if err := reserveExposure(txCtx, tx, eng, room, in.GameRoundID, exLegs, serial, in.TraceID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		fmt.Printf("[BetBatch]  提交事务失败: error=%v, round_id=%s, trace_id=%s\n", err, in.GameRoundID, in.TraceID)
+		return nil, err
+	}
+
+	// seamless：调用平台扣款并确认整批注单
+	if debit != nil {
+		bal, err := completeSeamlessBet(ctx, sp, debit, eng, exLegs, in.TraceID)
+		if err != nil {
+			return nil, err
+		}
+		out.RemainAmount = chelper.TrimDecimal(bal)
+	}
+
+	result = "success"
+	fmt.Printf("[BetBatch]  批量投注成功: round_id=%s, user_id=%d, bets=%d, total=%s, remain=%s, trace_id=%s\n",
+		in.GameRoundID, user.ID, len(out.Orders), total.String(), out.RemainAmount, in.TraceID)
+
+	if r := infrds.Client(); r != nil {
+		if b, e := json.Marshal(out); e == nil {
+			_ = r.Set(ctx, infrds.BatchIdemResultKey(in.IdempotencyKey), b, idemResultTTL).Err()
+		}
+	}
+	return out, nil
+}
+
+// cachedBatchResult 读取批量投注的幂等结果缓存，未命中返回 nil
+func cachedBatchResult(ctx context.Context, idemKey string) *BatchBetOutput {
+	r := infrds.Client()
+	if r == nil {
+		return nil
+	}
+	bs, _ := r.Get(ctx, infrds.BatchIdemResultKey(idemKey)).Bytes()
+	if len(bs) == 0 {
+		return nil
+	}
+	var out BatchBetOutput
+	if json.Unmarshal(bs, &out) != nil {
+		return nil
+	}
+	return &out
+}
+
+// previousBatchResult DB 回源：按幂等键查询该用户已生成的注单与注单币种的当前余额，查询失败返回 nil
+// seamless 模式返回扣款后的平台余额；扣款尚未确认或已失败时返回对应错误
+func previousBatchResult(ctx context.Context, in BatchBetInput, seamlessMode bool) (*BatchBetOutput, error) {
+	orders, err := model.ListByIdemKey(ctx, infmysql.SQLX(), in.IdempotencyKey, in.PlatformID, in.PlatformUserID)
+	if err != nil || len(orders) == 0 {
+		return nil, nil
+	}
+	currency := orders[0].Currency
+	var bal decimal.Decimal
+	if seamlessMode {
+		bal, err = seamlessBetResult(ctx, orders[0].BillNo)
+		if errors.Is(err, ErrDuplicateInFlight) || errors.Is(err, ErrWalletRejected) {
+			return nil, err
+		}
+	} else {
+		bal, err = walletBalance(ctx, in.PlatformID, in.PlatformUserID, currency)
+	}
+	if err != nil {
+		return nil, nil
+	}
+	out := &BatchBetOutput{Orders: make([]BatchBetOrder, 0, len(orders)), RemainAmount: chelper.TrimDecimal(bal), Currency: currency}
+	for _, o := range orders {
+		out.Orders = append(out.Orders, BatchBetOrder{
+			BillNo:    o.BillNo,
+			PlayType:  int(model.PlayTypeCode(o.PlayType)),
+			BetAmount: chelper.TrimDecimal(o.BetAmount),
+		})
+	}
+	fmt.Printf("[BetBatch]  从数据库返回上次结果: idem_key=%s, orders=%d, trace_id=%s\n", in.IdempotencyKey, len(orders), in.TraceID)
+	return out, nil
+}
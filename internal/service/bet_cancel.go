@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	chelper "dt-server/common/helper"
+	"dt-server/internal/engine"
+	infmysql "dt-server/internal/infra/mysql"
+	infrds "dt-server/internal/infra/redis"
+	"dt-server/internal/metrics"
+	"dt-server/internal/model"
+	"dt-server/internal/seamless"
+	"dt-server/internal/state"
+
+	decimal "github.com/shopspring/decimal"
+)
+
+// BIZ_TYPE_BET_CANCEL 玩家撤单退款（wallet_ledger.biz_type=6）
+const BIZ_TYPE_BET_CANCEL = 6
+
+// CancelBetInput 撤单参数：只能撤销本人的注单
+type CancelBetInput struct {
+	BillNo         string
+	PlatformID     int8
+	PlatformUserID string
+	TraceID        string
+}
+
+type CancelBetOutput struct {
+	BillNo       string `json:"bill_no"`
+	RefundAmount string `json:"refund_amount"` // 退回金额
+	RemainAmount string `json:"remain_amount"` // 剩余金额（注单币种钱包）；seamless 钱包模式以平台为准，为空
+	Currency     string `json:"currency"`      // 注单币种
+}
+
+var (
+	ErrBetNotFound       = errors.New("bet not found")
+	ErrBetCancelDisabled = errors.New("bet cancellation not allowed in this room")
+	ErrBetCancelClosed   = errors.New("bet cancellation window closed")
+	ErrBetNotCancellable = errors.New("bet already settled or cancelled")
+)
+
+// CancelBet 撤单：仅在牌局 betting 状态、且早于 bet_stop_time 减去房间撤单截止秒数时允许
+// 1. 注单须属于当前用户且待结算（bill_status=1），房间须开启撤单
+// 2. 加锁顺序：用户 → 牌局（共享锁）→ 注单，钱包余额以条件语句原子更新
+// 3. 注单置为已取消（bill_status=3），全额退回本金并写 bet_cancel 账本，写 bet_cancelled Outbox 消息
+// 4. 提交后删除原幂等键的 Redis 结果缓存，避免重复请求返回已撤销的下注结果
+func (s *betService) CancelBet(ctx context.Context, in CancelBetInput) (*CancelBetOutput, error) {
+	result := "fail"
+	defer func() { metrics.RecordBetCancel(result) }()
+
+	fmt.Printf("[BetCancel] 收到撤单请求: bill_no=%s, platform_id=%d, platform_user_id=%s, trace_id=%s\n",
+		in.BillNo, in.PlatformID, in.PlatformUserID, in.TraceID)
+
+	// 先无锁读取注单，确定所属牌局与房间
+	ord, err := model.GetOrder(ctx, infmysql.SQLX(), in.BillNo)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrBetNotFound
+		}
+		return nil, err
+	}
+	if ord.PlatformID != in.PlatformID || ord.PlatformUserID != in.PlatformUserID {
+		// 不暴露他人注单是否存在
+		fmt.Printf("[BetCancel] 注单不属于当前用户: bill_no=%s, platform_user_id=%s, trace_id=%s\n",
+			in.BillNo, in.PlatformUserID, in.TraceID)
+		return nil, ErrBetNotFound
+	}
+
+	room, err := LookupRoom(ctx, ord.RoomID)
+	if err != nil {
+		fmt.Printf("[BetCancel] 读取房间配置失败: room_id=%s, error=%v, trace_id=%s\n",
+			ord.RoomID, err, in.TraceID)
+		return nil, err
+	}
+	if !room.CancelAllowed {
+		fmt.Printf("[BetCancel] 房间不允许撤单: room_id=%s, bill_no=%s, trace_id=%s\n",
+			ord.RoomID, in.BillNo, in.TraceID)
+		return nil, ErrBetCancelDisabled
+	}
+
+	txCtx := ctx
+	if _, has := ctx.Deadline(); !has {
+		c, cancel := context.WithTimeout(ctx, defaultTxTimeout)
+		txCtx = c
+		defer cancel()
+	}
+	tx, err := infmysql.SQLX().BeginTxx(txCtx, nil)
+	if err != nil {
+		fmt.Printf("[BetCancel] 开启事务失败: error=%v, bill_no=%s, trace_id=%s\n",
+			err, in.BillNo, in.TraceID)
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	user, err := model.GetUserByIDForUpdate(txCtx, tx, ord.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock user: %w", err)
+	}
+	// seamless 钱包模式的注单（wallet_txn_id 非空）余额在平台侧
+	seamlessOrder := ord.WalletTxnID != ""
+
+	// 共享锁读取回合状态：与下注一致，封盘须等本事务提交
+	round, err := lockRoundForBet(txCtx, tx, ord.GameRoundID, false, nil)
+	if err != nil {
+		fmt.Printf("[BetCancel] 查询游戏回合失败: error=%v, round_id=%s, trace_id=%s\n",
+			err, ord.GameRoundID, in.TraceID)
+		return nil, fmt.Errorf("failed to get round info: %w", err)
+	}
+
+	// 锁定注单后再次校验状态（并发撤单/作废时以锁内状态为准）
+	ord, err = model.GetOrderForUpdate(txCtx, tx, in.BillNo)
+	if err != nil {
+		return nil, err
+	}
+	if ord.BillStatus != 1 || ord.BetStatus != 2 {
+		fmt.Printf("[BetCancel] 注单不可撤销: bill_no=%s, bill_status=%d, bet_status=%d, trace_id=%s\n",
+			in.BillNo, ord.BillStatus, ord.BetStatus, in.TraceID)
+		return nil, ErrBetNotCancellable
+	}
+
+	currentState := state.Default.StateName(round.GameStatus)
+	if currentState != state.StateBetting {
+		fmt.Printf("[BetCancel] 游戏状态不允许撤单: current_state=%s(%d), round_id=%s, trace_id=%s\n",
+			currentState, round.GameStatus, ord.GameRoundID, in.TraceID)
+		return nil, ErrBetCancelClosed
+	}
+	now := time.Now().UnixMilli()
+	cutoff := round.BetStopTime - int64(room.CancelCutoffSec)*1000
+	if now > cutoff {
+		fmt.Printf("[BetCancel] 已过撤单截止时间: now=%d, cutoff=%d, bet_stop=%d, round_id=%s, trace_id=%s\n",
+			now, cutoff, round.BetStopTime, ord.GameRoundID, in.TraceID)
+		return nil, ErrBetCancelClosed
+	}
+
+	if err := model.CancelOrder(txCtx, tx, ord.BillNo); err != nil {
+		return nil, err
+	}
+
+	amtDec := ord.BetAmount
+	afterDec := decimal.Zero
+	if seamlessOrder {
+		// 登记平台入账退回本金，由投递任务异步调用
+		if err := queueSeamlessTxn(txCtx, tx, *ord, model.WalletTxnOpCredit, seamless.ReasonBetCancel, "BC"+ord.BillNo, amtDec, in.TraceID); err != nil {
+			return nil, err
+		}
+	} else {
+		// 原子退回本金（单条条件语句）
+		beforeDec, after, err := mutateWallet(txCtx, tx, user.ID, ord.Currency, amtDec, true)
+		if err != nil {
+			return nil, err
+		}
+		afterDec = after
+
+		ledger := &model.WalletLedger{
+			UserID:       user.ID,
+			BizType:      BIZ_TYPE_BET_CANCEL,
+			BizTypeStr:   "bet_cancel",
+			Amount:       amtDec.Round(2),
+			BeforeAmount: beforeDec,
+			AfterAmount:  afterDec,
+			Currency:     ord.Currency,
+			BillNo:       ord.BillNo,
+			GameRoundID:  ord.GameRoundID,
+			GameID:       ord.GameID,
+			RoomID:       ord.RoomID,
+			Remark:       "bet cancel",
+			TraceID:      in.TraceID,
+		}
+		if err := ledger.Insert(txCtx, tx); err != nil {
+			fmt.Printf("[BetCancel] 写入账本失败: error=%v, bill_no=%s, trace_id=%s\n",
+				err, ord.BillNo, in.TraceID)
+			return nil, err
+		}
+	}
+
+	if err := model.CreateOutbox(txCtx, tx, "bet_cancelled", ord.BillNo, map[string]any{
+		"event":            "bet_cancelled",
+		"bill_no":          ord.BillNo,
+		"user_id":          user.ID,
+		"platform_id":      ord.PlatformID,
+		"platform_user_id": ord.PlatformUserID,
+		"game_id":          ord.GameID,
+		"room_id":          ord.RoomID,
+		"game_round_id":    ord.GameRoundID,
+		"play_type":        ord.PlayType,
+		"refund":           ord.BetAmount,
+		"currency":         ord.Currency,
+		"trace_id":         in.TraceID,
+	}); err != nil {
+		fmt.Printf("[BetCancel] 写入 Outbox 失败: error=%v, bill_no=%s, trace_id=%s\n",
+			err, ord.BillNo, in.TraceID)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		fmt.Printf("[BetCancel] 提交事务失败: error=%v, bill_no=%s, trace_id=%s\n",
+			err, ord.BillNo, in.TraceID)
+		return nil, err
+	}
+	result = "success"
+
+	// 扣减本局赔付敞口
+	if eng, err := engine.For(ord.GameID); err == nil {
+		releaseExposure(ctx, eng, ord.GameRoundID, []exposureLeg{{
+			playType: ord.PlayType,
+			amount:   amtDec,
+			odds:     ord.BetOdds,
+		}}, in.TraceID)
+	}
+
+	// 失效原下注的幂等结果缓存（单笔与批量共用同一幂等键空间）；删除失败时缓存按 TTL 自然过期
+	if r := infrds.Client(); r != nil && ord.IdempotencyKey != "" {
+		if err := r.Del(ctx, infrds.IdemResultKey(ord.IdempotencyKey), infrds.BatchIdemResultKey(ord.IdempotencyKey)).Err(); err != nil {
+			fmt.Printf("[BetCancel] 删除幂等结果缓存失败: idem_key=%s, error=%v, trace_id=%s\n",
+				ord.IdempotencyKey, err, in.TraceID)
+		}
+	}
+
+	fmt.Printf("[BetCancel] 撤单成功: bill_no=%s, refund=%s, remain=%s, trace_id=%s\n",
+		ord.BillNo, amtDec.String(), afterDec.String(), in.TraceID)
+
+	out := &CancelBetOutput{
+		BillNo:       ord.BillNo,
+		RefundAmount: chelper.TrimDecimal(amtDec),
+		Currency:     ord.Currency,
+	}
+	if !seamlessOrder {
+		out.RemainAmount = chelper.TrimDecimal(afterDec)
+	}
+	return out, nil
+}
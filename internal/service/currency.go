@@ -0,0 +1,163 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"dt-server/internal/config"
+	"dt-server/internal/model"
+
+	decimal "github.com/shopspring/decimal"
+)
+
+// defaultMoneyPrecision 未配置币种时的金额精度（与 DECIMAL(18,2) 存储一致）
+const defaultMoneyPrecision = 2
+
+var (
+	ErrCurrencyNotSupported = errors.New("currency not supported")
+	ErrCurrencyNotAllowed   = errors.New("currency not allowed for this platform")
+	ErrCurrencyMismatch     = errors.New("currency does not match room")
+)
+
+// currencyRule 币种规则（来自配置 currencies，支持 Nacos 热更新）
+type currencyRule struct {
+	Code      string
+	Precision int32
+	MinBet    decimal.Decimal // 0 表示不限
+	MaxBet    decimal.Decimal // 0 表示不限
+}
+
+// lookupCurrency 查询币种规则；未配置任何币种时不限制币种，按 2 位精度、不限单注金额
+func lookupCurrency(code string) (currencyRule, error) {
+	cfg := config.Get()
+	if cfg == nil || len(cfg.Currencies) == 0 {
+		return currencyRule{Code: code, Precision: defaultMoneyPrecision}, nil
+	}
+	for _, c := range cfg.Currencies {
+		if !strings.EqualFold(c.Code, code) {
+			continue
+		}
+		p := int32(c.Precision)
+		if p < 0 || p > defaultMoneyPrecision {
+			p = defaultMoneyPrecision
+		}
+		return currencyRule{
+			Code:      code,
+			Precision: p,
+			MinBet:    decimal.NewFromFloat(c.MinBet),
+			MaxBet:    decimal.NewFromFloat(c.MaxBet),
+		}, nil
+	}
+	return currencyRule{}, ErrCurrencyNotSupported
+}
+
+// platformAllowsCurrency 平台是否允许其玩家使用该币种；平台未配置 currencies（或未登记，如演示平台）时不限制
+func platformAllowsCurrency(platformID int8, code string) bool {
+	cfg := config.Get()
+	if cfg == nil {
+		return true
+	}
+	for _, p := range cfg.Auth.Platforms {
+		if p.PlatformID != platformID {
+			continue
+		}
+		if len(p.Currencies) == 0 {
+			return true
+		}
+		for _, c := range p.Currencies {
+			if strings.EqualFold(c, code) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// resolveBetCurrency 确定下注币种：注单按房间币种计价，平台传入的币种须与房间一致；
+// 币种须已配置且平台允许。返回币种规则
+func resolveBetCurrency(room *RoomConfig, platformID int8, requested string) (currencyRule, error) {
+	if requested != "" && !strings.EqualFold(requested, room.Currency) {
+		return currencyRule{}, ErrCurrencyMismatch
+	}
+	rule, err := lookupCurrency(room.Currency)
+	if err != nil {
+		return currencyRule{}, err
+	}
+	if !platformAllowsCurrency(platformID, room.Currency) {
+		return currencyRule{}, ErrCurrencyNotAllowed
+	}
+	return rule, nil
+}
+
+// checkAmount 校验金额精度与币种单注限额
+func (r currencyRule) checkAmount(amt decimal.Decimal) error {
+	if !amt.Equal(amt.Truncate(r.Precision)) {
+		return fmt.Errorf("invalid bet amount: %s allows %d decimal places", r.Code, r.Precision)
+	}
+	if r.MinBet.IsPositive() && amt.LessThan(r.MinBet) {
+		return fmt.Errorf("bet amount below minimum limit: %s %s", r.MinBet.String(), r.Code)
+	}
+	if r.MaxBet.IsPositive() && amt.GreaterThan(r.MaxBet) {
+		return fmt.Errorf("bet amount exceeds maximum limit: %s %s", r.MaxBet.String(), r.Code)
+	}
+	return nil
+}
+
+// round 按币种精度舍入（派彩）
+func (r currencyRule) round(amt decimal.Decimal) decimal.Decimal {
+	return amt.Round(r.Precision)
+}
+
+// roundMoney 按币种精度舍入；币种已不在配置中（历史注单）时按默认精度
+func roundMoney(currency string, amt decimal.Decimal) decimal.Decimal {
+	rule, err := lookupCurrency(currency)
+	if err != nil {
+		return amt.Round(defaultMoneyPrecision)
+	}
+	return rule.round(amt)
+}
+
+// currencyTotals 按币种累计金额（结算/退款统计按币种分组，不同币种金额不能直接相加）
+type currencyTotals map[string]decimal.Decimal
+
+func (t currencyTotals) add(currency string, amt decimal.Decimal) {
+	t[currency] = t[currency].Add(amt)
+}
+
+// rounded 转为 币种 -> 金额（两位小数），用于审计与 Outbox 消息
+func (t currencyTotals) rounded() map[string]decimal.Decimal {
+	out := make(map[string]decimal.Decimal, len(t))
+	for c, v := range t {
+		out[c] = v.Round(2)
+	}
+	return out
+}
+
+// currencyStat 单个币种的结算统计（settlement_log.currency_stats）
+type currencyStat struct {
+	Orders    int             `json:"orders"`
+	BetAmount decimal.Decimal `json:"bet_amount"`
+	Payout    decimal.Decimal `json:"payout"`
+}
+
+// settlementCurrencyStats 按注单币种统计注单数、下注额与派彩
+func settlementCurrencyStats(orders []model.Order, payouts map[string]decimal.Decimal) map[string]currencyStat {
+	bets, pays := currencyTotals{}, currencyTotals{}
+	counts := map[string]int{}
+	for _, o := range orders {
+		counts[o.Currency]++
+		bets.add(o.Currency, o.BetAmount)
+		pays.add(o.Currency, payouts[o.BillNo])
+	}
+	out := make(map[string]currencyStat, len(counts))
+	for c, n := range counts {
+		out[c] = currencyStat{
+			Orders:    n,
+			BetAmount: bets[c].Round(2),
+			Payout:    pays[c].Round(2),
+		}
+	}
+	return out
+}
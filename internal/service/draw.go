@@ -5,14 +5,15 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"strconv"
 	"strings"
 	"time"
 
+	"dt-server/internal/engine"
 	infmysql "dt-server/internal/infra/mysql"
 	infrds "dt-server/internal/infra/redis"
 	"dt-server/internal/metrics"
 	"dt-server/internal/model"
+	"dt-server/internal/seamless"
 	"dt-server/internal/state"
 
 	decimal "github.com/shopspring/decimal"
@@ -22,52 +23,78 @@ type DrawInput struct {
 	GameID      string
 	RoomID      string
 	GameRoundID string
-	CardList    string
+	CardList    string // 人工录入牌面；服务端牌靴模式下为空，或配合 Override 使用
+	Override    bool   // 人工覆盖模式：以 CardList 替代服务端牌面（审计记录操作人与原牌面）
+	Operator    string // 请求中填写的操作人（人工覆盖时必填）；仅在未认证时用于审计，不作为复核身份
+	Source      string // 结果来源：manual（默认）或牌面扫描设备标识；开奖复核时须与认证的扫描设备一致
+	Submitter   string // 已认证的身份（管理员名、scan:<设备标识> 或 MQ 命令的 mq），作为提交人与审计操作人
 	TraceID     string
 }
 
+// 开奖提交结果（DrawSubmission.Status）
+const (
+	DrawStatusSettled  = "settled"  // 已结算
+	DrawStatusPending  = "pending"  // 房间开启开奖复核，等待另一名管理员复核或另一路扫描
+	DrawStatusMismatch = "mismatch" // 两路扫描不一致，已阻断结算
+)
+
+// DrawSubmission 开奖提交结果
+type DrawSubmission struct {
+	Status    string `json:"status"`
+	PendingID int64  `json:"pending_id,omitempty"` // 待复核记录ID（pending / mismatch）
+	Result    string `json:"result,omitempty"`
+}
+
 type DrawService interface {
-	SubmitDrawResult(ctx context.Context, in DrawInput) error
+	// SubmitDrawResult 提交开奖结果：房间未开启开奖复核时立即结算，否则写入待复核记录
+	SubmitDrawResult(ctx context.Context, in DrawInput) (*DrawSubmission, error)
+	// ApproveDrawResult 复核通过待复核的开奖结果并结算，复核人必须与提交人不同
+	ApproveDrawResult(ctx context.Context, in DrawReviewInput) (*DrawSubmission, error)
+	// RejectDrawResult 驳回待复核（或扫描不一致）的开奖结果，驳回后可重新提交
+	RejectDrawResult(ctx context.Context, in DrawReviewInput) error
+	// ListPendingDraws 查询开奖复核记录：指定局时返回该局全部提交，否则返回全部未结案的提交
+	ListPendingDraws(ctx context.Context, roundID string) ([]model.PendingDrawResult, error)
+	// CorrectDrawResult 更正已结算牌局的开奖牌面：冲正原派彩并按新牌面重新结算
+	CorrectDrawResult(ctx context.Context, in CorrectionInput) (*CorrectionResult, error)
 }
 
 type drawService struct{}
 
 func NewDrawService() DrawService { return &drawService{} }
 
-// SubmitDrawResult: 计算牌型结果，更新回合，结算所有订单（账本与订单），记录审计
-func (s *drawService) SubmitDrawResult(ctx context.Context, in DrawInput) error {
-	if in.GameRoundID == "" || len(in.CardList) == 0 {
-		fmt.Printf("[DrawResult]  参数校验失败: round_id=%s, card_list=%s, trace_id=%s\n",
-			in.GameRoundID, in.CardList, in.TraceID)
-		return ErrBadRequest
+// SubmitDrawResult: 开启开奖复核的房间写入待复核记录；否则直接结算
+func (s *drawService) SubmitDrawResult(ctx context.Context, in DrawInput) (*DrawSubmission, error) {
+	if in.GameRoundID == "" || (in.Override && (len(in.CardList) == 0 || in.Operator == "")) {
+		fmt.Printf("[DrawResult]  参数校验失败: round_id=%s, card_list=%s, override=%v, operator=%s, trace_id=%s\n",
+			in.GameRoundID, in.CardList, in.Override, in.Operator, in.TraceID)
+		return nil, ErrBadRequest
 	}
 
-	fmt.Printf("[DrawResult] 收到开奖请求: round_id=%s, card_list=%s, game_id=%s, room_id=%s, trace_id=%s\n",
-		in.GameRoundID, in.CardList, in.GameID, in.RoomID, in.TraceID)
+	fmt.Printf("[DrawResult] 收到开奖请求: round_id=%s, card_list=%s, game_id=%s, room_id=%s, source=%s, trace_id=%s\n",
+		in.GameRoundID, in.CardList, in.GameID, in.RoomID, in.Source, in.TraceID)
+
+	approval, err := drawApprovalRequired(ctx, in.GameRoundID)
+	if err != nil {
+		return nil, err
+	}
+	if approval {
+		return s.submitForApproval(ctx, in)
+	}
+	if err := s.settle(ctx, in, nil); err != nil {
+		return nil, err
+	}
+	return &DrawSubmission{Status: DrawStatusSettled}, nil
+}
 
+// settle: 计算牌型结果，更新回合，结算所有订单（账本与订单），记录审计
+// appr 非空表示经复核的结算：同一事务内将待复核记录置为已通过，牌局已结算时不再视为幂等成功
+func (s *drawService) settle(ctx context.Context, in DrawInput, appr *drawApproval) error {
 	// 指标：在输入校验通过后开始计时
 	start := time.Now()
 	resultLabel := "fail"
 	outcomeLabel := "unknown"
 	defer func() { metrics.RecordDraw(resultLabel, outcomeLabel, start) }()
 
-	// 计算游戏结果并验证
-	// 1. 计算游戏结果
-	// 2. 验证结果不为空
-	// 3. 验证结果为有效值（dragon/tiger/tie）
-	res := decideResult(in.CardList)
-	outcomeLabel = res
-
-	// 验证结果不为空
-	if res == "" {
-		return errors.New("invalid card list format: unable to determine result")
-	}
-
-	// 验证结果为有效值
-	if res != "dragon" && res != "tiger" && res != "tie" {
-		return fmt.Errorf("invalid game result: %s", res)
-	}
-
 	tx, err := infmysql.SQLX().BeginTxx(ctx, nil)
 	if err != nil {
 		return err
@@ -84,11 +111,14 @@ func (s *drawService) SubmitDrawResult(ctx context.Context, in DrawInput) error
 		return err
 	}
 
-	currentState := codeToState(statusCode)
+	currentState := state.Default.StateName(statusCode)
 	fmt.Printf("[DrawResult]  当前状态: state=%s(%d), is_settled=%d, round_id=%s, trace_id=%s\n",
 		currentState, statusCode, isSettled, in.GameRoundID, in.TraceID)
 
-	// 如果已经结算过，直接返回成功（幂等）
+	// 如果已经结算过，直接返回成功（幂等）；复核结算时说明已由其他途径结算，待复核记录不能再通过
+	if isSettled == 1 && appr != nil {
+		return ErrInvalidStateDraw
+	}
 	if isSettled == 1 {
 		fmt.Printf("[DrawResult] 该回合已结算，跳过重复结算: round_id=%s, trace_id=%s\n",
 			in.GameRoundID, in.TraceID)
@@ -96,28 +126,74 @@ func (s *drawService) SubmitDrawResult(ctx context.Context, in DrawInput) error
 		return nil
 	}
 
-	// 校验当前回合状态：仅允许在 drawn(已触发game_draw事件) 状态执行开奖结算
-	if currentState != state.StateDrawn {
+	// 校验当前回合状态：仅允许在 drawn(已触发game_draw事件) 状态执行开奖结算（drawn --draw_result--> settled）
+	nextState, err := state.Default.Fire(currentState, state.EvtDrawResult, state.Snapshot{IsSettled: isSettled == 1})
+	if err != nil {
+		fmt.Printf("[DrawResult] 状态转换失败: round_id=%s, error=%v, trace_id=%s\n",
+			in.GameRoundID, err, in.TraceID)
 		return ErrInvalidStateDraw
 	}
 
+	round, err := model.GetRoundInfo(ctx, tx, in.GameRoundID)
+	if err != nil {
+		return err
+	}
+	d, err := resolveDraw(round, in)
+	if err != nil {
+		return err
+	}
+	eng, mode, cardList, outcome := d.eng, d.mode, d.cardList, d.outcome
+	res := outcome.Result
+	outcomeLabel = res
+
+	operator := drawMaker(in)
+	if appr != nil {
+		operator = appr.maker
+	}
+	if mode == "manual_override" {
+		fmt.Printf("[DrawResult] 人工覆盖牌面: round_id=%s, operator=%s, server=%s, override=%s, result=%s, trace_id=%s\n",
+			in.GameRoundID, operator, round.CardList, cardList, res, in.TraceID)
+	}
+
 	// 更新回合信息的开奖结果（状态保持为 drawn(5)）。card_list 按原始字符串入库。
-	if err := model.UpdateDraw(ctx, tx, in.GameRoundID, in.CardList, res, 5); err != nil {
+	if err := model.UpdateDraw(ctx, tx, in.GameRoundID, cardList, res, 5); err != nil {
 		return err
 	}
 
 	// 发送玩法开奖事件到 Outbox（事务内写入，确保与数据库状态一致）
 	fmt.Printf("[DrawResult] 写入 Outbox: topic=game_drawn, round_id=%s, trace_id=%s\n",
 		in.GameRoundID, in.TraceID)
-	if err := model.CreateOutbox(ctx, tx, "game_drawn", in.GameRoundID, map[string]any{
+	drawnPayload := map[string]any{
 		"event":         "game_drawn",
 		"game_id":       in.GameID,
 		"room_id":       in.RoomID,
 		"game_round_id": in.GameRoundID,
-		"card_list":     in.CardList,
+		"card_list":     cardList,
 		"result":        res,
+		"points":        outcome.Points,
 		"trace_id":      in.TraceID,
-	}); err != nil {
+	}
+	for k, v := range eng.Details(outcome) {
+		drawnPayload[k] = v
+	}
+	// 路单：计入本局后的当前牌靴路单，前端无需自行计算；路单异常不影响结算
+	roads, err := nextRoads(ctx, tx, round, res)
+	if err != nil {
+		fmt.Printf("[DrawResult] 计算路单失败，game_drawn 不附带路单: round_id=%s, room_id=%s, error=%v, trace_id=%s\n",
+			in.GameRoundID, round.RoomID, err, in.TraceID)
+	} else {
+		drawnPayload["roads"] = roads
+	}
+	if round.SeedHash != "" {
+		// 可验证公平：靴种子在换靴时随 shoe_changed 公布（提前公布会泄露本靴后续牌面）；旧版按局种子结算即公布
+		drawnPayload["seed_hash"] = round.SeedHash
+		drawnPayload["seed_nonce"] = round.SeedNonce
+		drawnPayload["shoe_position"] = round.ShoePosition
+		if round.ServerSeed != "" {
+			drawnPayload["server_seed"] = round.ServerSeed
+		}
+	}
+	if err := model.CreateOutbox(ctx, tx, "game_drawn", in.GameRoundID, drawnPayload); err != nil {
 		fmt.Printf("[DrawResult]  写入 Outbox 失败: round_id=%s, error=%v, trace_id=%s\n",
 			in.GameRoundID, err, in.TraceID)
 		return err
@@ -125,19 +201,22 @@ func (s *drawService) SubmitDrawResult(ctx context.Context, in DrawInput) error
 
 	// ========== 幂等性保护 #2: 创建结算日志 ==========
 	// 利用唯一索引防止重复结算（双重保护）
-	totalPayout := 0.0
+	totalPayout := decimal.Zero
 	settlementLog := &model.SettlementLog{
 		GameRoundID: in.GameRoundID,
-		CardList:    in.CardList,
+		CardList:    cardList,
 		Result:      res,
-		TotalOrders: 0, // 稍后更新
-		TotalPayout: 0, // 稍后更新
-		Operator:    "admin",
+		TotalOrders: 0,            // 稍后更新
+		TotalPayout: decimal.Zero, // 稍后更新
+		Operator:    operator,
 		TraceID:     in.TraceID,
 	}
 
 	if err := model.CreateSettlementLog(ctx, tx, settlementLog); err != nil {
 		// 如果是唯一键冲突，说明已经结算过（双重保护）
+		if isMySQLDuplicateKeyError(err) && appr != nil {
+			return ErrInvalidStateDraw
+		}
 		if isMySQLDuplicateKeyError(err) {
 			fmt.Printf("[DrawResult] 结算日志已存在，跳过重复结算: round_id=%s, trace_id=%s\n",
 				in.GameRoundID, in.TraceID)
@@ -158,89 +237,96 @@ func (s *drawService) SubmitDrawResult(ctx context.Context, in DrawInput) error
 	fmt.Printf("[DrawResult]  找到 %d 个待结算订单: round_id=%s, trace_id=%s\n",
 		len(orders), in.GameRoundID, in.TraceID)
 
-	// 将游戏结果字符串转换为数值枚举
-	gameResultCode := resultToCode(res)
+	// 派彩按下注时锁定在注单上的赔率；未记录赔率的历史注单回落到房间当前赔率
+	applyRoomOdds(ctx, orders, round.RoomID, in.TraceID)
+
+	// 按引擎计算每笔注单派彩；牌面不足以结算某玩法（如红黑边注缺少花色）时整体回滚，需按 D13h,T13h 格式重新录入
+	payouts, err := computePayouts(eng, orders, outcome, in.GameRoundID, cardList, in.TraceID)
+	if err != nil {
+		return err
+	}
+
+	// 将游戏结果字符串转换为数值枚举（与玩法编码一致）
+	gameResultCode := model.PlayTypeCode(res)
 
 	// 第一步：更新所有订单的结算状态和游戏结果，并计算总派彩
-	totalPayout = 0.0
+	totalPayout = decimal.Zero
 	for i := range orders {
 		o := orders[i]
-		payout := settlePayout(o, res)
-		totalPayout += payout
+		payout := payouts[o.BillNo]
+		totalPayout = totalPayout.Add(payout)
 		billStatus := int8(2) // 2=已结算
 		if err := model.UpdateSettlement(ctx, tx, o.BillNo, payout, billStatus, gameResultCode); err != nil {
 			return err
 		}
 	}
 
-	// 第二步：按用户分组，批量处理余额更新（避免同一用户多次锁定）
+	// 第二步：按用户与币种分组，批量处理余额更新（避免同一钱包多次锁定；查询已按 user_id, currency 排序，加锁顺序固定）
 	type userSettlement struct {
 		userID        int64
-		totalPayout   float64
+		currency      string
 		orders        []model.Order
-		payoutAmounts []float64
+		payoutAmounts []decimal.Decimal
 	}
 
-	userMap := make(map[int64]*userSettlement)
+	userMap := make(map[walletKey]*userSettlement)
+	userOrder := make([]walletKey, 0)
 	for i := range orders {
 		o := orders[i]
-		payout := settlePayout(o, res)
+		payout := payouts[o.BillNo]
+
+		// seamless 钱包模式的注单：登记平台入账（输的注单入账 0，供平台关闭注单），由投递任务异步调用
+		if o.WalletTxnID != "" {
+			if err := queueSeamlessTxn(ctx, tx, o, model.WalletTxnOpCredit, seamless.ReasonSettle, "ST"+o.BillNo,
+				payout, in.TraceID); err != nil {
+				return err
+			}
+			continue
+		}
 
-		if payout > 0 {
-			if _, exists := userMap[o.UserID]; !exists {
-				userMap[o.UserID] = &userSettlement{
+		if payout.IsPositive() {
+			k := walletKey{userID: o.UserID, currency: o.Currency}
+			if _, exists := userMap[k]; !exists {
+				userMap[k] = &userSettlement{
 					userID:        o.UserID,
-					totalPayout:   0,
+					currency:      o.Currency,
 					orders:        []model.Order{},
-					payoutAmounts: []float64{},
+					payoutAmounts: []decimal.Decimal{},
 				}
+				userOrder = append(userOrder, k)
 			}
-			userMap[o.UserID].totalPayout += payout
-			userMap[o.UserID].orders = append(userMap[o.UserID].orders, o)
-			userMap[o.UserID].payoutAmounts = append(userMap[o.UserID].payoutAmounts, payout)
+			userMap[k].orders = append(userMap[k].orders, o)
+			userMap[k].payoutAmounts = append(userMap[k].payoutAmounts, payout)
 		}
 	}
 
-	// 第三步：每个用户只锁定一次，批量更新余额和账本
-	for _, us := range userMap {
-		// 锁定用户
-		user, err := model.GetUserByIDForUpdate(ctx, tx, us.userID)
-		if err != nil {
-			return err
-		}
+	// 第三步：每个钱包原子入账一次（不锁用户行，单条条件语句），批量写账本
+	for _, k := range userOrder {
+		us := userMap[k]
 
-		// 使用 decimal 进行精确计算
-		beforeDec := decimal.NewFromFloat(user.Balance)
-		totalPayoutDec := decimal.NewFromFloat(us.totalPayout)
-		afterDec := beforeDec.Add(totalPayoutDec).Round(2)
-
-		// 更新余额
-		if err := model.UpdateUserBalance(ctx, tx, us.userID, afterDec.InexactFloat64()); err != nil {
+		// 每笔订单一条账本，余额快照逐笔递增
+		ledgers := make([]*model.WalletLedger, 0, len(us.orders))
+		for idx, o := range us.orders {
+			ledgers = append(ledgers, &model.WalletLedger{
+				UserID:      o.UserID,
+				BizType:     2,
+				BizTypeStr:  "settle",
+				Amount:      us.payoutAmounts[idx],
+				Currency:    o.Currency,
+				BillNo:      o.BillNo,
+				GameRoundID: in.GameRoundID,
+				GameID:      in.GameID,
+				RoomID:      in.RoomID,
+				Remark:      "bet payout",
+				TraceID:     in.TraceID,
+			})
+		}
+		beforeDec, _, err := mutateWallet(ctx, tx, us.userID, us.currency, ledgerTotal(ledgers), true)
+		if err != nil {
 			return err
 		}
-
-		// 为每笔订单创建账本记录
-		// 使用 decimal 累计计算，确保精度
-		currentBalanceDec := beforeDec
-		for idx, o := range us.orders {
-			payoutDec := decimal.NewFromFloat(us.payoutAmounts[idx])
-			currentBalanceDec = currentBalanceDec.Add(payoutDec).Round(2)
-
-			ledger := &model.WalletLedger{
-				UserID:       o.UserID,
-				BizType:      2,
-				BizTypeStr:   "settle",
-				Amount:       payoutDec.InexactFloat64(),
-				BeforeAmount: currentBalanceDec.Sub(payoutDec).Round(2).InexactFloat64(),
-				AfterAmount:  currentBalanceDec.InexactFloat64(),
-				Currency:     o.Currency,
-				BillNo:       o.BillNo,
-				GameRoundID:  in.GameRoundID,
-				GameID:       in.GameID,
-				RoomID:       in.RoomID,
-				Remark:       "bet payout",
-				TraceID:      in.TraceID,
-			}
+		postLedgers(beforeDec, false, ledgers)
+		for _, ledger := range ledgers {
 			if err := ledger.Insert(ctx, tx); err != nil {
 				return err
 			}
@@ -250,7 +336,7 @@ func (s *drawService) SubmitDrawResult(ctx context.Context, in DrawInput) error
 	// 第四步：为所有订单创建 Outbox 消息
 	for i := range orders {
 		o := orders[i]
-		payout := settlePayout(o, res)
+		payout := payouts[o.BillNo]
 
 		if err := model.CreateOutbox(ctx, tx, "order_settled", o.BillNo, map[string]any{
 			"event":         "order_settled",
@@ -261,6 +347,7 @@ func (s *drawService) SubmitDrawResult(ctx context.Context, in DrawInput) error
 			"game_round_id": in.GameRoundID,
 			"play_type":     o.PlayType,
 			"payout":        payout,
+			"currency":      o.Currency,
 			"result":        res,
 			"trace_id":      in.TraceID,
 		}); err != nil {
@@ -269,15 +356,16 @@ func (s *drawService) SubmitDrawResult(ctx context.Context, in DrawInput) error
 	}
 
 	// 明确输出到控制台
-	fmt.Printf("drawresult: round=%s result=%s cards=%v\n", in.GameRoundID, res, in.CardList)
+	fmt.Printf("drawresult: round=%s result=%s cards=%v\n", in.GameRoundID, res, cardList)
 
 	// ========== 幂等性保护 #3: 标记为已结算 ==========
-	if err := model.MarkAsSettled(ctx, tx, in.GameRoundID); err != nil {
+	if err := model.MarkAsSettled(ctx, tx, in.GameRoundID, state.Default.StateCode(nextState)); err != nil {
 		return err
 	}
 
-	// 更新结算日志的统计信息（写入数据库）
-	if err := model.UpdateSettlementStats(ctx, tx, in.GameRoundID, len(orders), totalPayout); err != nil {
+	// 更新结算日志的统计信息（写入数据库，按币种分组）
+	currencyStats := settlementCurrencyStats(orders, payouts)
+	if err := model.UpdateSettlementStats(ctx, tx, in.GameRoundID, settlementLog.Version, len(orders), totalPayout, toJSON(currencyStats)); err != nil {
 		fmt.Printf("[DrawResult] 更新结算日志统计失败: round_id=%s, error=%v, trace_id=%s\n",
 			in.GameRoundID, err, in.TraceID)
 		return err
@@ -285,20 +373,34 @@ func (s *drawService) SubmitDrawResult(ctx context.Context, in DrawInput) error
 
 	// 审计事件 - draw_result（开奖结算）
 	auditPayload := map[string]any{
-		"card_list":    in.CardList,
+		"mode":         mode,
+		"operator":     operator,
+		"card_list":    cardList,
 		"result":       res,
 		"total_orders": len(orders),
 		"total_payout": totalPayout,
+		"by_currency":  currencyStats,
+	}
+	if mode == "manual_override" {
+		// 覆盖模式留存服务端原牌面与靴号，便于稽核
+		auditPayload["server_card_list"] = round.CardList
+		auditPayload["shoe_no"] = round.ShoeNo
+	}
+	if appr != nil {
+		// 开奖复核：留存提交人、复核人与对应的待复核记录
+		auditPayload["maker"] = appr.maker
+		auditPayload["checker"] = appr.checker
+		auditPayload["pending_ids"] = appr.ids
 	}
-	// 事件类型 4 = game_draw（这里记录的是开奖结算操作）
+	// 事件类型 4 = game_draw（这里记录的是开奖结算操作，draw_result 为内部事件没有独立事件码）
 	aud := &model.GameEventAudit{
 		GameID:      in.GameID,
 		RoomID:      in.RoomID,
 		GameRoundID: in.GameRoundID,
-		EventType:   4,
-		PrevState:   "drawn",
-		NextState:   "settled",
-		Operator:    "system",
+		EventType:   state.Default.EventCode(state.EvtGameDraw),
+		PrevState:   currentState,
+		NextState:   nextState,
+		Operator:    operator,
 		Source:      "api",
 		Payload:     toJSON(auditPayload),
 		TraceID:     in.TraceID,
@@ -306,6 +408,11 @@ func (s *drawService) SubmitDrawResult(ctx context.Context, in DrawInput) error
 	if err := aud.Insert(ctx, tx); err != nil {
 		return err
 	}
+	if appr != nil {
+		if err := appr.close(ctx, tx, in.GameRoundID); err != nil {
+			return err
+		}
+	}
 
 	if err := tx.Commit(); err != nil {
 		fmt.Printf("[DrawResult] 提交事务失败: round_id=%s, error=%v, trace_id=%s\n",
@@ -313,18 +420,24 @@ func (s *drawService) SubmitDrawResult(ctx context.Context, in DrawInput) error
 		return err
 	}
 
+	// 事务提交后更新路单缓存
+	if roads != nil {
+		storeRoads(ctx, roads, true)
+	}
+
 	// 将开奖结果写入 Redis，便于后续查询/回放
 	if r := infrds.Client(); r != nil {
 		val := map[string]any{
 			"game_id":       in.GameID,
 			"room_id":       in.RoomID,
 			"game_round_id": in.GameRoundID,
-			"card_list":     in.CardList,
+			"card_list":     cardList,
 			"result":        res,
 			"game_status":   6, // settled
 			"is_settled":    1,
 			"total_orders":  len(orders),
 			"total_payout":  totalPayout,
+			"by_currency":   currencyStats,
 		}
 		if b, e := json.Marshal(val); e == nil {
 			fmt.Printf("[DrawResult]  写入 Redis 缓存: key=%s, ttl=2m, round_id=%s, trace_id=%s\n",
@@ -334,146 +447,106 @@ func (s *drawService) SubmitDrawResult(ctx context.Context, in DrawInput) error
 	}
 
 	resultLabel = "success"
-	fmt.Printf("[DrawResult] 开奖处理完成: round_id=%s, result=%s, current_state=settled(6), total_orders=%d, total_payout=%.2f, trace_id=%s\n",
+	fmt.Printf("[DrawResult] 开奖处理完成: round_id=%s, result=%s, current_state=settled(6), total_orders=%d, total_payout=%s, trace_id=%s\n",
 		in.GameRoundID, res, len(orders), totalPayout, in.TraceID)
 	fmt.Printf("[DrawResult] 提示: 请手动调用 /api/game_event (event_type=5) 来结束游戏\n")
 	return nil
 }
 
-// decideResult 解析开奖结果
-// 标准格式：D<数字>,T<数字>,R<结果>
-// 示例：
-//   - "D9,T8,Rd"     -> Dragon 9点，Tiger 8点，Dragon 赢
-//   - "D1,T2,Rt"     -> Dragon 1点，Tiger 2点，Tiger 赢
-//   - "D9,T9,Rtie"   -> Dragon 9点，Tiger 9点，和局
-//   - "D13,T10,Rd"   -> Dragon K，Tiger 10，Dragon 赢
-func decideResult(cardListRaw string) string {
-	input := strings.TrimSpace(cardListRaw)
-	if input == "" {
-		return ""
-	}
-
-	// 解析标准格式：D<数字>,T<数字>,R<结果>
-	tokens := strings.Split(input, ",")
-	if len(tokens) != 3 {
-		return ""
-	}
-
-	var dragonCard, tigerCard int
-	var result string
+// resolvedDraw 确定的开奖牌面与结果
+type resolvedDraw struct {
+	eng      engine.GameEngine
+	mode     string
+	cardList string
+	outcome  *engine.Outcome
+}
 
-	for _, token := range tokens {
-		tok := strings.ToLower(strings.TrimSpace(token))
-		if tok == "" {
-			continue
+// resolveDraw 确定牌面来源并计算结果：
+// - server：服务端牌靴在 new_card 时已发牌，结果只按点数计算（忽略 R 标记）
+// - manual_override：操作人显式覆盖，允许 R 标记，审计记录原服务端牌面
+// - manual：未启用服务端牌靴时的人工录入（兼容旧流程）
+func resolveDraw(round *model.GameRoundInfo, in DrawInput) (*resolvedDraw, error) {
+	// 按 game_id 选择游戏引擎（解析牌面、判定结果、计算派彩）
+	eng, err := engine.For(round.GameID)
+	if err != nil {
+		fmt.Printf("[DrawResult] 未知游戏: round_id=%s, game_id=%s, trace_id=%s\n",
+			in.GameRoundID, round.GameID, in.TraceID)
+		return nil, err
+	}
+	d := &resolvedDraw{eng: eng}
+	switch {
+	case in.Override:
+		d.mode = "manual_override"
+		d.cardList = in.CardList
+	case round.CardList != "":
+		if in.CardList != "" && in.CardList != round.CardList {
+			fmt.Printf("[DrawResult] 拒绝人工牌面: 本局已由服务端发牌, round_id=%s, server=%s, input=%s, trace_id=%s\n",
+				in.GameRoundID, round.CardList, in.CardList, in.TraceID)
+			return nil, ErrManualCardsNotAllowed
 		}
-
-		switch tok[0] {
-		case 'd':
-			// 解析 Dragon 牌点：D<数字>
-			if val, err := strconv.Atoi(tok[1:]); err == nil && val >= 1 && val <= 13 {
-				dragonCard = val
-			}
-		case 't':
-			// 解析 Tiger 牌点：T<数字>
-			if val, err := strconv.Atoi(tok[1:]); err == nil && val >= 1 && val <= 13 {
-				tigerCard = val
-			}
-		case 'r':
-			// 解析结果标记：R<结果>
-			result = parseResultToken(tok[1:])
+		d.mode = "server"
+		d.cardList = round.CardList
+	case shoeEnabled():
+		// 已启用服务端牌靴但本局没有服务端牌面（例如开启前已发牌），只能走覆盖模式
+		return nil, ErrManualCardsNotAllowed
+	default:
+		if in.CardList == "" {
+			return nil, ErrBadRequest
 		}
+		d.mode = "manual"
+		d.cardList = in.CardList
 	}
 
-	// 验证必须有 Dragon 和 Tiger 牌点
-	if dragonCard == 0 || tigerCard == 0 {
-		return "" // 格式错误，返回默认值
+	hand, err := eng.ParseCards(d.cardList)
+	if err != nil {
+		return nil, fmt.Errorf("invalid card list format: %w", err)
 	}
-
-	// 优先使用显式指定的结果
-	if result != "" {
-		return result
+	if d.mode == "server" {
+		hand.Forced = "" // 服务端牌面只按牌面计算
 	}
-
-	// 根据牌点自动计算结果
-	return calculateResult(dragonCard, tigerCard)
-}
-
-// parseResultToken 解析结果标记
-// 支持：d, dragon, -dragon, t, tiger, -tiger, tie
-func parseResultToken(token string) string {
-	// 转换为小写进行匹配
-	tok := strings.ToLower(token)
-	switch tok {
-	case "d", "dragon", "-dragon":
-		return "dragon"
-	case "t", "tiger", "-tiger":
-		return "tiger"
-	case "tie":
-		return "tie"
-	default:
-		return ""
+	if d.outcome, err = eng.DecideResult(hand); err != nil {
+		return nil, fmt.Errorf("invalid card list format: %w", err)
 	}
+	return d, nil
 }
 
-// calculateResult 根据牌点计算结果
-func calculateResult(dragonCard, tigerCard int) string {
-	if dragonCard > tigerCard {
-		return "dragon"
-	} else if dragonCard < tigerCard {
-		return "tiger"
+// computePayouts 按引擎计算每笔注单派彩（bill_no -> 派彩），任一注单无法结算时返回错误
+func computePayouts(eng engine.GameEngine, orders []model.Order, outcome *engine.Outcome, roundID, cardList, traceID string) (map[string]decimal.Decimal, error) {
+	payouts := make(map[string]decimal.Decimal, len(orders))
+	for _, o := range orders {
+		p, err := eng.Payout(o.PlayType, o.BetAmount, o.BetOdds, outcome)
+		if err != nil {
+			fmt.Printf("[DrawResult] 注单无法结算: round_id=%s, bill_no=%s, play_type=%s, card_list=%s, error=%v, trace_id=%s\n",
+				roundID, o.BillNo, o.PlayType, cardList, err, traceID)
+			return nil, err
+		}
+		// 派彩按注单币种精度舍入
+		payouts[o.BillNo] = roundMoney(o.Currency, p)
 	}
-	return "tie"
-}
-
-// 计算派彩金额
-// 重要：下注时已经扣款，派彩金额是用户最终收到的金额
-// 规则：
-// 1. 投注龙/虎，结果匹配 -> 派彩 = 本金 + 本金 × 赔率 = 本金 × (1 + 赔率)
-// 2. 投注龙/虎，结果是和 -> 派彩 = 本金（退还本金）
-// 3. 投注龙/虎，结果不匹配 -> 派彩 = 0（输掉本金）
-// 4. 投注和，结果是和 -> 派彩 = 本金 + 本金 × 赔率 = 本金 × (1 + 赔率)
-// 5. 投注和，结果不是和 -> 派彩 = 0（输掉本金）
-//
-// 示例：
-// - 投注龙 100，赔率 0.95，龙赢 -> 派彩 = 100 + 100×0.95 = 195（用户余额变化：-100+195=+95）
-// - 投注和 50，赔率 8.00，和局 -> 派彩 = 50 + 50×8 = 450（用户余额变化：-50+450=+400）
-// - 投注龙 100，和局 -> 派彩 = 100（用户余额变化：-100+100=0，不输不赢）
-func settlePayout(o model.Order, result string) float64 {
-	pt := strings.ToLower(o.PlayType)
-	betAmt := decimal.NewFromFloat(o.BetAmount)
-
-	// 情况1: 投注类型与结果匹配（赢）-> 返还本金 + 赢的钱
-	if pt == result {
-		// 使用 decimal 进行精确计算，避免浮点数精度问题
-		odds := decimal.NewFromFloat(o.BetOdds)
-		// 派彩 = 本金 + 本金 × 赔率 = 本金 × (1 + 赔率)
-		one := decimal.NewFromInt(1)
-		payout := betAmt.Mul(one.Add(odds)).Round(2)
-		return payout.InexactFloat64()
-	}
-
-	// 情况2: 投注龙或虎，结果是和局 -> 退还本金
-	if (pt == "dragon" || pt == "tiger") && result == "tie" {
-		return betAmt.InexactFloat64()
-	}
-
-	// 情况3: 其他情况（输）
-	return 0
+	return payouts, nil
 }
 
-// resultToCode 将游戏结果字符串转换为数值枚举
-// dragon -> 1, tiger -> 2, tie -> 3, 其他 -> 0
-func resultToCode(result string) int8 {
-	switch strings.ToLower(result) {
-	case "dragon":
-		return 1
-	case "tiger":
-		return 2
-	case "tie":
-		return 3
-	default:
-		return 0
+// applyRoomOdds 为 bet_odds 缺失（<=0）的注单补上房间配置中的赔率
+func applyRoomOdds(ctx context.Context, orders []model.Order, roomID, traceID string) {
+	var room *RoomConfig
+	for i := range orders {
+		if orders[i].BetOdds.IsPositive() {
+			continue
+		}
+		if room == nil {
+			r, err := LookupRoom(ctx, roomID)
+			if err != nil {
+				fmt.Printf("[DrawResult] 读取房间赔率失败，缺失赔率的注单按 0 派彩: room_id=%s, error=%v, trace_id=%s\n",
+					roomID, err, traceID)
+				return
+			}
+			room = r
+		}
+		if odds, ok := room.OddsFor(orders[i].PlayType); ok {
+			orders[i].BetOdds = odds
+			fmt.Printf("[DrawResult] 注单缺失赔率，使用房间赔率: bill_no=%s, room_id=%s, odds=%s, trace_id=%s\n",
+				orders[i].BillNo, roomID, odds.String(), traceID)
+		}
 	}
 }
 
@@ -498,4 +571,7 @@ var (
 	ErrBadRequest        = errors.New("bad request")
 	ErrInvalidStateDraw  = errors.New("draw not allowed in current state")
 	ErrGameRoundNotFound = errors.New("game round not found")
+
+	ErrManualCardsNotAllowed = errors.New("manual card list requires override when server shoe is enabled")
+	ErrCardSuitRequired      = engine.ErrCardSuitRequired
 )
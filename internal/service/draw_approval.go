@@ -0,0 +1,367 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	infmysql "dt-server/internal/infra/mysql"
+	"dt-server/internal/metrics"
+	"dt-server/internal/model"
+	"dt-server/internal/state"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// pendingDrawListLimit 复核列表单次最多返回的记录数
+const pendingDrawListLimit = 200
+
+// DrawReviewInput 开奖复核（通过/驳回）参数
+type DrawReviewInput struct {
+	PendingID int64
+	Checker   string // 复核人（已认证的管理员身份）
+	Reason    string // 驳回原因
+	TraceID   string
+}
+
+var (
+	ErrPendingDrawNotFound = errors.New("pending draw result not found")
+	ErrPendingDrawDecided  = errors.New("pending draw result already decided")
+	ErrPendingDrawExists   = errors.New("source already has a pending draw result for this round")
+	ErrSameApprover        = errors.New("approver must be a different admin from the submitter")
+	ErrDrawResultMismatch  = errors.New("card scans disagree, settlement blocked")
+)
+
+// drawApproval 经复核的结算
+type drawApproval struct {
+	ids     []int64 // 随本次结算通过的待复核记录
+	maker   string
+	checker string
+}
+
+// close 在结算事务内将待复核记录置为已通过，并驳回该局其余待复核的提交
+// 调用方已锁定牌局行；记录已被其他请求结案或该局存在扫描不一致时中止结算
+func (a *drawApproval) close(ctx context.Context, tx *sqlx.Tx, roundID string) error {
+	open, err := model.ListOpenPendingDraws(ctx, tx, roundID)
+	if err != nil {
+		return err
+	}
+	for i := range open {
+		if open[i].Status == model.PendingDrawMismatch {
+			return ErrDrawResultMismatch
+		}
+	}
+	for _, id := range a.ids {
+		n, err := model.DecidePendingDraw(ctx, tx, id, model.PendingDrawPending, model.PendingDrawApproved, a.checker, "")
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return ErrPendingDrawDecided
+		}
+	}
+	_, err = model.SupersedePendingDraws(ctx, tx, roundID, a.checker, fmt.Sprintf("superseded by #%d", a.ids[0]))
+	return err
+}
+
+// drawApprovalRequired 牌局所在房间是否开启开奖复核；未登记的房间（历史牌局）按直接结算处理
+func drawApprovalRequired(ctx context.Context, roundID string) (bool, error) {
+	round, err := model.GetRoundInfo(ctx, infmysql.SQLX(), roundID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, ErrGameRoundNotFound
+	}
+	if err != nil {
+		return false, err
+	}
+	room, err := LookupRoom(ctx, round.RoomID)
+	if errors.Is(err, ErrRoomNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return room.DrawApproval, nil
+}
+
+// submitForApproval 写入待复核记录；房间白名单内的另一台扫描设备已提交时自动比对：一致则直接结算，不一致则阻断并告警
+func (s *drawService) submitForApproval(ctx context.Context, in DrawInput) (*DrawSubmission, error) {
+	source, err := drawSource(in)
+	if err != nil {
+		fmt.Printf("[DrawApproval] 拒绝提交: round_id=%s, source=%s, submitter=%s, error=%v, trace_id=%s\n",
+			in.GameRoundID, in.Source, in.Submitter, err, in.TraceID)
+		return nil, err
+	}
+	maker := drawMaker(in)
+
+	tx, err := infmysql.SQLX().BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// 锁定牌局行，与结算、复核串行
+	statusCode, isSettled, err := model.GetSettlementStatusForUpdate(ctx, tx, in.GameRoundID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrGameRoundNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if isSettled == 1 {
+		fmt.Printf("[DrawApproval] 该回合已结算，忽略提交: round_id=%s, source=%s, trace_id=%s\n",
+			in.GameRoundID, source, in.TraceID)
+		return &DrawSubmission{Status: DrawStatusSettled}, nil
+	}
+	currentState := state.Default.StateName(statusCode)
+	if _, err := state.Default.Fire(currentState, state.EvtDrawResult, state.Snapshot{}); err != nil {
+		return nil, ErrInvalidStateDraw
+	}
+
+	round, err := model.GetRoundInfo(ctx, tx, in.GameRoundID)
+	if err != nil {
+		return nil, err
+	}
+	d, err := resolveDraw(round, in)
+	if err != nil {
+		return nil, err
+	}
+	scanRoom, err := autoCompareRoom(ctx, round.RoomID, source, maker)
+	if err != nil {
+		return nil, err
+	}
+
+	open, err := model.ListOpenPendingDraws(ctx, tx, in.GameRoundID)
+	if err != nil {
+		return nil, err
+	}
+	var other *model.PendingDrawResult // 白名单内另一台扫描设备的待复核提交
+	for i := range open {
+		p := &open[i]
+		switch {
+		case p.Status == model.PendingDrawMismatch:
+			// 扫描不一致须先驳回，才能重新提交
+			return &DrawSubmission{Status: DrawStatusMismatch, PendingID: p.ID, Result: p.Result}, ErrDrawResultMismatch
+		case p.Source == source && sameCardList(p.CardList, d.cardList):
+			// 重复提交
+			return &DrawSubmission{Status: DrawStatusPending, PendingID: p.ID, Result: p.Result}, nil
+		case p.Source == source:
+			return nil, ErrPendingDrawExists
+		case scanRoom != nil && other == nil && p.Maker != maker &&
+			p.Maker == model.ScannerMakerPrefix+p.Source && scanRoom.ScannerAllowed(p.Source):
+			other = p
+		}
+	}
+
+	pd := &model.PendingDrawResult{
+		GameID:      round.GameID,
+		RoomID:      round.RoomID,
+		GameRoundID: in.GameRoundID,
+		Source:      source,
+		Mode:        d.mode,
+		CardList:    d.cardList,
+		Result:      d.outcome.Result,
+		Maker:       maker,
+		TraceID:     in.TraceID,
+	}
+	if err := pd.Insert(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	if other != nil && !sameCardList(other.CardList, pd.CardList) {
+		return s.blockMismatch(ctx, tx, other, pd, in.TraceID)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	fmt.Printf("[DrawApproval] 开奖结果待复核: round_id=%s, pending_id=%d, source=%s, maker=%s, card_list=%s, result=%s, trace_id=%s\n",
+		in.GameRoundID, pd.ID, source, pd.Maker, pd.CardList, pd.Result, in.TraceID)
+	metrics.RecordDrawApproval("submitted")
+	if other == nil {
+		return &DrawSubmission{Status: DrawStatusPending, PendingID: pd.ID, Result: pd.Result}, nil
+	}
+
+	// 两路扫描一致：视为互相复核，直接结算
+	fmt.Printf("[DrawApproval] 双扫描一致，自动结算: round_id=%s, pending_ids=%d,%d, sources=%s,%s, trace_id=%s\n",
+		in.GameRoundID, other.ID, pd.ID, other.Source, source, in.TraceID)
+	if err := s.settle(ctx, pendingDrawInput(pd, other.Maker, in.TraceID), &drawApproval{
+		ids:     []int64{other.ID, pd.ID},
+		maker:   other.Maker,
+		checker: maker,
+	}); err != nil {
+		return nil, err
+	}
+	metrics.RecordDrawApproval("auto_approved")
+	return &DrawSubmission{Status: DrawStatusSettled, PendingID: pd.ID, Result: pd.Result}, nil
+}
+
+// blockMismatch 两路扫描不一致：双方置为不一致并写入 draw_result_mismatch 告警，阻断结算
+func (s *drawService) blockMismatch(ctx context.Context, tx *sqlx.Tx, a, b *model.PendingDrawResult, traceID string) (*DrawSubmission, error) {
+	reason := fmt.Sprintf("%s=%s vs %s=%s", a.Source, a.CardList, b.Source, b.CardList)
+	for _, id := range []int64{a.ID, b.ID} {
+		if _, err := model.DecidePendingDraw(ctx, tx, id, model.PendingDrawPending, model.PendingDrawMismatch, "", reason); err != nil {
+			return nil, err
+		}
+	}
+	if err := model.CreateOutbox(ctx, tx, "draw_result_mismatch", b.GameRoundID, map[string]any{
+		"event":         "draw_result_mismatch",
+		"game_id":       b.GameID,
+		"room_id":       b.RoomID,
+		"game_round_id": b.GameRoundID,
+		"pending_ids":   []int64{a.ID, b.ID},
+		"sources":       []string{a.Source, b.Source},
+		"card_lists":    []string{a.CardList, b.CardList},
+		"results":       []string{a.Result, b.Result},
+		"trace_id":      traceID,
+	}); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	metrics.RecordDrawApproval("mismatch")
+	fmt.Printf("[DrawApproval] 双扫描不一致，已阻断结算: round_id=%s, pending_ids=%d,%d, %s, trace_id=%s\n",
+		b.GameRoundID, a.ID, b.ID, reason, traceID)
+	return &DrawSubmission{Status: DrawStatusMismatch, PendingID: b.ID, Result: b.Result}, ErrDrawResultMismatch
+}
+
+func (s *drawService) ApproveDrawResult(ctx context.Context, in DrawReviewInput) (*DrawSubmission, error) {
+	if in.PendingID <= 0 || strings.TrimSpace(in.Checker) == "" {
+		return nil, ErrBadRequest
+	}
+	p, err := model.GetPendingDraw(ctx, infmysql.SQLX(), in.PendingID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPendingDrawNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch p.Status {
+	case model.PendingDrawPending:
+	case model.PendingDrawMismatch:
+		return nil, ErrDrawResultMismatch
+	default:
+		return nil, ErrPendingDrawDecided
+	}
+	if strings.EqualFold(strings.TrimSpace(p.Maker), strings.TrimSpace(in.Checker)) {
+		fmt.Printf("[DrawApproval] 拒绝复核: 复核人与提交人相同, pending_id=%d, round_id=%s, admin=%s, trace_id=%s\n",
+			p.ID, p.GameRoundID, in.Checker, in.TraceID)
+		return nil, ErrSameApprover
+	}
+
+	if err := s.settle(ctx, pendingDrawInput(p, p.Maker, in.TraceID), &drawApproval{
+		ids:     []int64{p.ID},
+		maker:   p.Maker,
+		checker: in.Checker,
+	}); err != nil {
+		fmt.Printf("[DrawApproval] 复核结算失败: pending_id=%d, round_id=%s, checker=%s, error=%v, trace_id=%s\n",
+			p.ID, p.GameRoundID, in.Checker, err, in.TraceID)
+		return nil, err
+	}
+	metrics.RecordDrawApproval("approved")
+	fmt.Printf("[DrawApproval] 复核通过并结算: pending_id=%d, round_id=%s, maker=%s, checker=%s, result=%s, trace_id=%s\n",
+		p.ID, p.GameRoundID, p.Maker, in.Checker, p.Result, in.TraceID)
+	return &DrawSubmission{Status: DrawStatusSettled, PendingID: p.ID, Result: p.Result}, nil
+}
+
+func (s *drawService) RejectDrawResult(ctx context.Context, in DrawReviewInput) error {
+	if in.PendingID <= 0 || strings.TrimSpace(in.Checker) == "" {
+		return ErrBadRequest
+	}
+	p, err := model.GetPendingDraw(ctx, infmysql.SQLX(), in.PendingID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrPendingDrawNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if p.Status != model.PendingDrawPending && p.Status != model.PendingDrawMismatch {
+		return ErrPendingDrawDecided
+	}
+	n, err := model.DecidePendingDraw(ctx, infmysql.SQLX(), p.ID, p.Status, model.PendingDrawRejected, in.Checker, in.Reason)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrPendingDrawDecided
+	}
+	metrics.RecordDrawApproval("rejected")
+	fmt.Printf("[DrawApproval] 驳回开奖结果: pending_id=%d, round_id=%s, source=%s, checker=%s, reason=%s, trace_id=%s\n",
+		p.ID, p.GameRoundID, p.Source, in.Checker, in.Reason, in.TraceID)
+	return nil
+}
+
+func (s *drawService) ListPendingDraws(ctx context.Context, roundID string) ([]model.PendingDrawResult, error) {
+	return model.ListPendingDraws(ctx, infmysql.SQLX(), roundID, pendingDrawListLimit)
+}
+
+// drawMaker 提交人：已认证的身份；未启用管理员认证时取请求中的操作人，缺省为 admin
+func drawMaker(in DrawInput) string {
+	switch {
+	case in.Submitter != "":
+		return in.Submitter
+	case in.Operator != "":
+		return in.Operator
+	}
+	return "admin"
+}
+
+// drawSource 结果来源取自认证身份：扫描设备固定为其设备标识（请求中的 source 须为空或一致），
+// 其余身份（管理员、MQ 命令）只能人工录入，避免同一人以不同 source 冒充两路扫描互相复核
+func drawSource(in DrawInput) (string, error) {
+	claimed := strings.ToLower(strings.TrimSpace(in.Source))
+	if strings.HasPrefix(in.Submitter, model.ScannerMakerPrefix) {
+		scanner := strings.TrimPrefix(in.Submitter, model.ScannerMakerPrefix)
+		if claimed != "" && claimed != scanner {
+			return "", fmt.Errorf("%w: source %s does not match scanner %s", ErrBadRequest, claimed, scanner)
+		}
+		return scanner, nil
+	}
+	if claimed != "" && claimed != model.PendingDrawSourceManual {
+		return "", fmt.Errorf("%w: source %s requires scanner credentials", ErrBadRequest, claimed)
+	}
+	return model.PendingDrawSourceManual, nil
+}
+
+// autoCompareRoom 提交参与双扫描自动比对时返回房间配置，否则返回 nil（须人工复核）：
+// 提交人须为已认证的扫描设备，且在牌局所在房间的 draw_scanners 白名单中
+func autoCompareRoom(ctx context.Context, roomID, source, maker string) (*RoomConfig, error) {
+	if maker != model.ScannerMakerPrefix+source {
+		return nil, nil
+	}
+	room, err := LookupRoom(ctx, roomID)
+	if errors.Is(err, ErrRoomNotFound) {
+		return nil, nil
+	}
+	if err != nil || !room.ScannerAllowed(source) {
+		return nil, err
+	}
+	return room, nil
+}
+
+// pendingDrawInput 由待复核记录还原结算参数（牌面已在提交时确定）
+func pendingDrawInput(p *model.PendingDrawResult, maker, traceID string) DrawInput {
+	return DrawInput{
+		GameID:      p.GameID,
+		RoomID:      p.RoomID,
+		GameRoundID: p.GameRoundID,
+		CardList:    p.CardList,
+		Override:    p.Mode == "manual_override",
+		Operator:    maker,
+		Source:      p.Source,
+		TraceID:     traceID,
+	}
+}
+
+// sameCardList 比对两路牌面（忽略空白与大小写，顺序敏感）
+func sameCardList(a, b string) bool {
+	norm := func(s string) string {
+		tokens := strings.Split(s, ",")
+		for i := range tokens {
+			tokens[i] = strings.ToLower(strings.TrimSpace(tokens[i]))
+		}
+		return strings.Join(tokens, ",")
+	}
+	return norm(a) == norm(b)
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDrawSourceFromAuth(t *testing.T) {
+	cases := []struct {
+		submitter, source string
+		want              string
+		ok                bool
+	}{
+		{"scan:scanner_a", "", "scanner_a", true},
+		{"scan:scanner_a", "Scanner_A", "scanner_a", true},
+		{"scan:scanner_a", "scanner_b", "", false}, // 扫描设备不能冒用其他设备的 source
+		{"dealer_ops", "", "manual", true},
+		{"dealer_ops", "manual", "manual", true},
+		{"dealer_ops", "scanner_a", "", false}, // 管理员不能以扫描源提交
+		{"mq", "scanner_a", "", false},         // MQ 命令同上
+		{"", "scanner_a", "", false},
+	}
+	for _, c := range cases {
+		got, err := drawSource(DrawInput{Submitter: c.submitter, Source: c.source})
+		if !c.ok {
+			if !errors.Is(err, ErrBadRequest) {
+				t.Fatalf("submitter=%s source=%s: want ErrBadRequest, got %v", c.submitter, c.source, err)
+			}
+			continue
+		}
+		if err != nil || got != c.want {
+			t.Fatalf("submitter=%s source=%s: got %q, %v; want %q", c.submitter, c.source, got, err, c.want)
+		}
+	}
+}
+
+func TestDrawMakerIgnoresOperator(t *testing.T) {
+	// 已认证时以认证身份为准，请求中的 operator 不作为提交人
+	if got := drawMaker(DrawInput{Submitter: "dealer_ops", Operator: "pit_boss"}); got != "dealer_ops" {
+		t.Fatalf("maker=%s, want dealer_ops", got)
+	}
+	if got := drawMaker(DrawInput{Submitter: "mq", Operator: "pit_boss"}); got != "mq" {
+		t.Fatalf("maker=%s, want mq", got)
+	}
+	if got := drawMaker(DrawInput{}); got != "admin" {
+		t.Fatalf("maker=%s, want admin", got)
+	}
+}
+
+func TestScannerAllowed(t *testing.T) {
+	room := &RoomConfig{DrawScanners: []string{"scanner_a", "scanner_b"}}
+	if !room.ScannerAllowed("scanner_a") || room.ScannerAllowed("scanner_c") || room.ScannerAllowed("manual") {
+		t.Fatalf("allow-list mismatch: %v", room.DrawScanners)
+	}
+	if (&RoomConfig{}).ScannerAllowed("scanner_a") {
+		t.Fatalf("empty allow-list allowed a scanner")
+	}
+}
@@ -0,0 +1,349 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"dt-server/internal/config"
+	"dt-server/internal/engine"
+	infmysql "dt-server/internal/infra/mysql"
+	infrds "dt-server/internal/infra/redis"
+	"dt-server/internal/model"
+	"dt-server/internal/seamless"
+	"dt-server/internal/state"
+
+	"github.com/shopspring/decimal"
+)
+
+// CorrectionInput 开奖结果更正入参
+type CorrectionInput struct {
+	GameRoundID string
+	CardList    string // 更正后的牌面
+	Operator    string // 操作人（必填）
+	Reason      string // 更正原因（必填）
+	TraceID     string
+}
+
+// CorrectionResult 更正结果
+type CorrectionResult struct {
+	GameRoundID   string          `json:"game_round_id"`
+	Version       int             `json:"version"` // 新的结算版本
+	OldCardList   string          `json:"old_card_list"`
+	CardList      string          `json:"card_list"`
+	OldResult     string          `json:"old_result"`
+	Result        string          `json:"result"`
+	TotalOrders   int             `json:"total_orders"`
+	OldPayout     decimal.Decimal `json:"old_payout"`     // 冲正的原派彩合计
+	TotalPayout   decimal.Decimal `json:"total_payout"`   // 重新结算的派彩合计
+	ChangedOrders int             `json:"changed_orders"` // 派彩发生变化的注单数
+	NegativeUsers int             `json:"negative_users"` // 更正后余额为负的用户数（仅 allow 策略下可能非 0）
+}
+
+// CorrectDrawResult 更正已结算（settled/finished）牌局的开奖牌面，在一个事务内：
+//  1. 按新牌面重新计算每笔已结算注单的派彩，更新注单
+//  2. 每个用户先入账新派彩（settle），再扣回原派彩（settle_reverse），过程余额不低于最终余额；
+//     最终余额为负时按 correction.negative_balance 策略拒绝（默认）或允许
+//  3. 写入新版本 settlement_log，更新 game_round_info 牌面与结果（状态不变）
+//  4. 每笔注单写 order_resettled、整局写 round_resettled Outbox，审计 event_type=7 记录操作人与原因
+func (s *drawService) CorrectDrawResult(ctx context.Context, in CorrectionInput) (*CorrectionResult, error) {
+	if in.GameRoundID == "" || in.CardList == "" || strings.TrimSpace(in.Operator) == "" || strings.TrimSpace(in.Reason) == "" {
+		return nil, ErrBadRequest
+	}
+	fmt.Printf("[DrawCorrect] 收到开奖更正: round_id=%s, card_list=%s, operator=%s, reason=%s, trace_id=%s\n",
+		in.GameRoundID, in.CardList, in.Operator, in.Reason, in.TraceID)
+
+	tx, err := infmysql.SQLX().BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	round, err := model.GetRoundForUpdate(ctx, tx, in.GameRoundID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrGameRoundNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	cur := state.Default.StateName(round.GameStatus)
+	if round.IsSettled != 1 || (cur != state.StateSettled && cur != state.StateFinished) {
+		fmt.Printf("[DrawCorrect] 牌局未结算或已作废，拒绝更正: round_id=%s, state=%s, is_settled=%d, trace_id=%s\n",
+			in.GameRoundID, cur, round.IsSettled, in.TraceID)
+		return nil, ErrCorrectionNotAllowed
+	}
+	prevLog, err := model.GetSettlementLog(ctx, tx, in.GameRoundID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCorrectionNotAllowed
+	}
+	if err != nil {
+		return nil, err
+	}
+	if in.CardList == round.CardList {
+		return nil, ErrCorrectionUnchanged
+	}
+
+	eng, err := engine.For(round.GameID)
+	if err != nil {
+		return nil, err
+	}
+	hand, err := eng.ParseCards(in.CardList)
+	if err != nil {
+		return nil, fmt.Errorf("invalid card list format: %w", err)
+	}
+	outcome, err := eng.DecideResult(hand)
+	if err != nil {
+		return nil, fmt.Errorf("invalid card list format: %w", err)
+	}
+	res := outcome.Result
+	version := prevLog.Version + 1
+
+	orders, err := model.ListSettledByRoundForUpdate(ctx, tx, in.GameRoundID)
+	if err != nil {
+		return nil, err
+	}
+	applyRoomOdds(ctx, orders, round.RoomID, in.TraceID)
+	payouts, err := computePayouts(eng, orders, outcome, in.GameRoundID, in.CardList, in.TraceID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &CorrectionResult{
+		GameRoundID: in.GameRoundID,
+		Version:     version,
+		OldCardList: round.CardList,
+		CardList:    in.CardList,
+		OldResult:   round.GameResultStr,
+		Result:      res,
+		TotalOrders: len(orders),
+	}
+
+	// 第一步：更新注单派彩与结果，按用户与币种分组（查询已按 user_id, currency 排序，加锁顺序固定）
+	type userResettle struct {
+		userID   int64
+		currency string
+		credit   decimal.Decimal
+		debit    decimal.Decimal
+		orders   []model.Order
+	}
+	gameResultCode := model.PlayTypeCode(res)
+	oldTotal, newTotal := decimal.Zero, decimal.Zero
+	users := make([]*userResettle, 0)
+	for _, o := range orders {
+		payout := payouts[o.BillNo]
+		if err := model.UpdateSettlement(ctx, tx, o.BillNo, payout, 2, gameResultCode); err != nil {
+			return nil, err
+		}
+		oldDec, newDec := o.WinAmount, payout
+		oldTotal, newTotal = oldTotal.Add(oldDec), newTotal.Add(newDec)
+		if !oldDec.Equal(newDec) {
+			out.ChangedOrders++
+		}
+		// seamless 钱包模式的注单：按差额登记平台补派（credit）或扣回（debit），由投递任务异步调用；
+		// 扣回被平台拒绝（如余额不足）时交易置为失败并告警，由运营线下追缴
+		if o.WalletTxnID != "" {
+			if delta := newDec.Sub(oldDec); !delta.IsZero() {
+				op := model.WalletTxnOpCredit
+				if delta.IsNegative() {
+					op = model.WalletTxnOpDebit
+				}
+				txnID := fmt.Sprintf("RS%s-%d", o.BillNo, version)
+				if err := queueSeamlessTxn(ctx, tx, o, op, seamless.ReasonResettle, txnID, delta.Abs(), in.TraceID); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		if n := len(users); n == 0 || users[n-1].userID != o.UserID || users[n-1].currency != o.Currency {
+			users = append(users, &userResettle{userID: o.UserID, currency: o.Currency})
+		}
+		u := users[len(users)-1]
+		u.credit = u.credit.Add(newDec)
+		u.debit = u.debit.Add(oldDec)
+		u.orders = append(u.orders, o)
+	}
+	out.OldPayout = oldTotal.Round(2)
+	out.TotalPayout = newTotal.Round(2)
+
+	// 第二步：每个钱包原子变更一次（单条条件语句），先入账新派彩再扣回原派彩
+	allowNegative := negativeBalancePolicy() == config.NegativeBalanceAllow
+	for _, u := range users {
+		beforeDec, afterDec, err := mutateWallet(ctx, tx, u.userID, u.currency, u.credit.Sub(u.debit), allowNegative)
+		if errors.Is(err, ErrInsufficientBalance) {
+			fmt.Printf("[DrawCorrect] 扣回原派彩后余额为负，拒绝更正: round_id=%s, user_id=%d, currency=%s, balance=%s, credit=%s, debit=%s, trace_id=%s\n",
+				in.GameRoundID, u.userID, u.currency, beforeDec.String(), u.credit.String(), u.debit.String(), in.TraceID)
+			return nil, ErrCorrectionNegativeBalance
+		}
+		if err != nil {
+			return nil, err
+		}
+		if afterDec.IsNegative() {
+			out.NegativeUsers++
+			fmt.Printf("[DrawCorrect] 警告: 更正后用户余额为负（allow 策略）: round_id=%s, user_id=%d, after=%s, trace_id=%s\n",
+				in.GameRoundID, u.userID, afterDec.String(), in.TraceID)
+		}
+
+		currentBalanceDec := beforeDec
+		writeLedger := func(o model.Order, bizType int, amount decimal.Decimal, remark string) error {
+			before := currentBalanceDec
+			if bizType == 5 {
+				currentBalanceDec = currentBalanceDec.Sub(amount).Round(2)
+			} else {
+				currentBalanceDec = currentBalanceDec.Add(amount).Round(2)
+			}
+			ledger := &model.WalletLedger{
+				UserID:       o.UserID,
+				BizType:      bizType,
+				Amount:       amount,
+				BeforeAmount: before,
+				AfterAmount:  currentBalanceDec,
+				Currency:     o.Currency,
+				BillNo:       o.BillNo,
+				GameRoundID:  in.GameRoundID,
+				GameID:       round.GameID,
+				RoomID:       round.RoomID,
+				Remark:       remark,
+				TraceID:      in.TraceID,
+			}
+			return ledger.Insert(ctx, tx)
+		}
+		for _, o := range u.orders {
+			if p := payouts[o.BillNo]; p.IsPositive() {
+				if err := writeLedger(o, 2, p, fmt.Sprintf("bet payout (resettle v%d)", version)); err != nil {
+					return nil, err
+				}
+			}
+		}
+		for _, o := range u.orders {
+			if o.WinAmount.IsPositive() {
+				if err := writeLedger(o, 5, o.WinAmount, fmt.Sprintf("payout reversal (v%d)", prevLog.Version)); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	// 第三步：牌局结果与新版本结算日志
+	if err := model.UpdateResult(ctx, tx, in.GameRoundID, in.CardList, res); err != nil {
+		return nil, err
+	}
+	if err := model.CreateSettlementLog(ctx, tx, &model.SettlementLog{
+		GameRoundID:   in.GameRoundID,
+		Version:       version,
+		CardList:      in.CardList,
+		Result:        res,
+		TotalOrders:   len(orders),
+		TotalPayout:   out.TotalPayout,
+		CurrencyStats: toJSON(settlementCurrencyStats(orders, payouts)),
+		Operator:      in.Operator,
+		Reason:        in.Reason,
+		TraceID:       in.TraceID,
+	}); err != nil {
+		return nil, err
+	}
+
+	// 第四步：Outbox
+	for _, o := range orders {
+		if err := model.CreateOutbox(ctx, tx, "order_resettled", o.BillNo, map[string]any{
+			"event":              "order_resettled",
+			"bill_no":            o.BillNo,
+			"user_id":            o.UserID,
+			"game_id":            round.GameID,
+			"room_id":            round.RoomID,
+			"game_round_id":      in.GameRoundID,
+			"play_type":          o.PlayType,
+			"old_payout":         o.WinAmount,
+			"payout":             payouts[o.BillNo],
+			"currency":           o.Currency,
+			"old_result":         round.GameResultStr,
+			"result":             res,
+			"settlement_version": version,
+			"reason":             in.Reason,
+			"trace_id":           in.TraceID,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	roundPayload := map[string]any{
+		"event":              "round_resettled",
+		"game_id":            round.GameID,
+		"room_id":            round.RoomID,
+		"game_round_id":      in.GameRoundID,
+		"settlement_version": version,
+		"old_card_list":      round.CardList,
+		"card_list":          in.CardList,
+		"old_result":         round.GameResultStr,
+		"result":             res,
+		"points":             outcome.Points,
+		"total_orders":       len(orders),
+		"old_payout":         out.OldPayout,
+		"total_payout":       out.TotalPayout,
+		"operator":           in.Operator,
+		"reason":             in.Reason,
+		"trace_id":           in.TraceID,
+	}
+	for k, v := range eng.Details(outcome) {
+		roundPayload[k] = v
+	}
+	if err := model.CreateOutbox(ctx, tx, "round_resettled", in.GameRoundID, roundPayload); err != nil {
+		return nil, err
+	}
+
+	// 审计：结果更正不改变状态
+	aud := &model.GameEventAudit{
+		GameID:      round.GameID,
+		RoomID:      round.RoomID,
+		GameRoundID: in.GameRoundID,
+		EventType:   model.AuditEventResultCorrection,
+		PrevState:   cur,
+		NextState:   cur,
+		Operator:    in.Operator,
+		Source:      "api",
+		Payload: toJSON(map[string]any{
+			"version":        version,
+			"reason":         in.Reason,
+			"old_card_list":  round.CardList,
+			"card_list":      in.CardList,
+			"old_result":     round.GameResultStr,
+			"result":         res,
+			"total_orders":   len(orders),
+			"old_payout":     out.OldPayout,
+			"total_payout":   out.TotalPayout,
+			"changed_orders": out.ChangedOrders,
+		}),
+		TraceID: in.TraceID,
+	}
+	if err := aud.Insert(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		fmt.Printf("[DrawCorrect] 提交事务失败: round_id=%s, error=%v, trace_id=%s\n", in.GameRoundID, err, in.TraceID)
+		return nil, err
+	}
+
+	// 结果缓存与路单缓存失效，下次读取时按新结果重建
+	if r := infrds.Client(); r != nil {
+		_ = r.Del(ctx, infrds.RoundResultKey(in.GameRoundID), infrds.RoomRoadsKey(round.RoomID)).Err()
+	}
+
+	fmt.Printf("[DrawCorrect] 开奖更正完成: round_id=%s, version=%d, result=%s->%s, orders=%d, changed=%d, payout=%s->%s, operator=%s, trace_id=%s\n",
+		in.GameRoundID, version, round.GameResultStr, res, len(orders), out.ChangedOrders, out.OldPayout, out.TotalPayout, in.Operator, in.TraceID)
+	return out, nil
+}
+
+// negativeBalancePolicy 当前负余额策略，未配置时为 reject
+func negativeBalancePolicy() string {
+	if cfg := config.Get(); cfg != nil && cfg.Correction.NegativeBalance == config.NegativeBalanceAllow {
+		return config.NegativeBalanceAllow
+	}
+	return config.NegativeBalanceReject
+}
+
+var (
+	ErrCorrectionNotAllowed      = errors.New("draw correction not allowed: round not settled")
+	ErrCorrectionUnchanged       = errors.New("draw correction: card list unchanged")
+	ErrCorrectionNegativeBalance = errors.New("draw correction rejected: balance would become negative")
+)
@@ -0,0 +1,430 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	chelper "dt-server/common/helper"
+	"dt-server/internal/engine"
+	infmysql "dt-server/internal/infra/mysql"
+	infrds "dt-server/internal/infra/redis"
+	"dt-server/internal/model"
+
+	"github.com/jmoiron/sqlx"
+	goredis "github.com/redis/go-redis/v9"
+	decimal "github.com/shopspring/decimal"
+)
+
+// 单局赔付敞口（庄家风险）
+//
+// 每局在 Redis Hash 中按分累计：
+//   - stake:<玩法>  该玩法累计下注额
+//   - payout:<玩法> 该玩法全部中奖时的派彩（含本金）
+//   - net:<结果>    该主结果开出时庄家净赔付 = 全部派彩 - 全部下注额
+//
+// 主注按引擎规则逐个结果计算派彩（押龙/虎遇和退本金）；边注与主结果无关，按最坏情况计入每个结果。
+// 房间配置了 liability_cap 时，下注使任一结果的净赔付增加并超过上限即被拒绝；减少敞口的下注（对冲）不受限制。
+// 下注在提交前原子地校验并累加敞口；Hash 缺失（过期、Redis 重启）时由 orders 重建，Redis 不可用时直接按 orders 校验。
+
+// exposureTTL 敞口缓存过期时间，覆盖一局从下注到结算的时长
+const exposureTTL = 2 * time.Hour
+
+const (
+	exposureStakePrefix  = "stake:"
+	exposurePayoutPrefix = "payout:"
+	exposureNetPrefix    = "net:"
+)
+
+var ErrExposureLimit = errors.New("bet would exceed table liability cap")
+
+// ErrExposureUnavailable Redis 敞口校验失败且下注只持有牌局共享锁，无法按注单校验，拒绝本次下注（客户端可重试）
+var ErrExposureUnavailable = errors.New("liability check unavailable, retry later")
+
+// exposureLeg 一笔注单对敞口的贡献：玩法、金额、下注时锁定的赔率
+type exposureLeg struct {
+	playType string
+	amount   decimal.Decimal
+	odds     decimal.Decimal
+}
+
+// exposureBook 敞口字段 -> 金额（分）
+type exposureBook map[string]int64
+
+func toCents(d decimal.Decimal) int64 { return d.Mul(decimal.NewFromInt(100)).Round(0).IntPart() }
+
+func fromCents(c int64) decimal.Decimal { return decimal.New(c, -2) }
+
+// legBook 计算一组注单的敞口（净赔付字段覆盖引擎的全部主结果）
+func legBook(eng engine.GameEngine, legs []exposureLeg) (exposureBook, error) {
+	results := eng.Results()
+	main := make(map[string]bool, len(results))
+	for _, r := range results {
+		main[r] = true
+	}
+	book := make(exposureBook, len(results)+2*len(legs))
+	for _, r := range results {
+		book[exposureNetPrefix+r] = 0
+	}
+	for _, l := range legs {
+		stake := toCents(l.amount)
+		win := toCents(l.amount.Mul(decimal.NewFromInt(1).Add(l.odds)).Round(2))
+		book[exposureStakePrefix+l.playType] += stake
+		book[exposurePayoutPrefix+l.playType] += win
+		for _, r := range results {
+			if !main[l.playType] {
+				// 边注：最坏情况按中奖计入
+				book[exposureNetPrefix+r] += win - stake
+				continue
+			}
+			p, err := eng.Payout(l.playType, l.amount, l.odds, &engine.Outcome{Result: r})
+			if err != nil {
+				return nil, err
+			}
+			book[exposureNetPrefix+r] += toCents(p) - stake
+		}
+	}
+	return book, nil
+}
+
+// bookFromOrders 按 orders 中本局待结算注单重建敞口（在事务中调用时包含本事务写入的注单）
+func bookFromOrders(ctx context.Context, exec sqlx.QueryerContext, eng engine.GameEngine, roundID string) (exposureBook, error) {
+	orders, err := model.ListOpenByRound(ctx, exec, roundID)
+	if err != nil {
+		return nil, err
+	}
+	legs := make([]exposureLeg, 0, len(orders))
+	for _, o := range orders {
+		legs = append(legs, exposureLeg{
+			playType: o.PlayType,
+			amount:   o.BetAmount,
+			odds:     o.BetOdds,
+		})
+	}
+	return legBook(eng, legs)
+}
+
+// reserveExposureScript 原子校验并累加敞口
+// KEYS[1] 敞口 Hash；ARGV[1] 上限（分，0=不限）；ARGV[2] TTL（秒）；ARGV[3] 是否携带基线（0/1）
+// 其后每 3 个参数为：字段、增量、基线。Hash 不存在且未携带基线时返回 {-1}，由调用方按 orders 计算基线后重试
+// 返回 {1} 成功；{0, 字段} 该结果净赔付将超过上限
+const reserveExposureScript = `
+local seeded = redis.call("exists", KEYS[1]) == 1
+if not seeded and ARGV[3] == "0" then
+	return {-1, ""}
+end
+local cap = tonumber(ARGV[1])
+for i = 4, #ARGV, 3 do
+	local f, d = ARGV[i], tonumber(ARGV[i + 1])
+	if cap > 0 and d > 0 and string.sub(f, 1, 4) == "net:" then
+		local cur = tonumber(ARGV[i + 2])
+		if seeded then
+			cur = tonumber(redis.call("hget", KEYS[1], f) or "0")
+		end
+		if cur + d > cap then
+			return {0, f}
+		end
+	end
+end
+for i = 4, #ARGV, 3 do
+	if seeded then
+		redis.call("hincrby", KEYS[1], ARGV[i], ARGV[i + 1])
+	else
+		redis.call("hset", KEYS[1], ARGV[i], tonumber(ARGV[i + 1]) + tonumber(ARGV[i + 2]))
+	end
+end
+redis.call("expire", KEYS[1], ARGV[2])
+return {1, ""}
+`
+
+// releaseExposureScript 扣减敞口（撤单）；Hash 不存在时忽略，下次下注按 orders 重建
+const releaseExposureScript = `
+if redis.call("exists", KEYS[1]) == 0 then
+	return 0
+end
+for i = 1, #ARGV, 2 do
+	redis.call("hincrby", KEYS[1], ARGV[i], ARGV[i + 1])
+end
+return 1
+`
+
+// reserveExposure 下注提交前校验并累加本局敞口，超过房间上限返回 ErrExposureLimit
+// 需在写入注单之后、提交之前于同一事务中调用（orders 重建时包含本事务的注单）
+// 提交失败时不回滚 Redis 中的累加：多计只会更保守，由 orders 重建或运营对账修正
+// serial 为事务是否已持有牌局排他锁（lockRoundForBet 按 roundBetNeedsSerial 在加锁前决定，事务内不升级）
+func reserveExposure(ctx context.Context, tx *sqlx.Tx, eng engine.GameEngine, room *RoomConfig, roundID string, legs []exposureLeg, serial bool, traceID string) error {
+	delta, err := legBook(eng, legs)
+	if err != nil {
+		return err
+	}
+	capCents := toCents(room.LiabilityCap)
+
+	if r := infrds.Client(); r != nil {
+		key := infrds.RoundExposureKey(roundID)
+		code, field, err := evalReserveExposure(ctx, r, key, capCents, delta, nil)
+		if err == nil && code == exposureNeedBase {
+			// Hash 缺失：基线 = 库中注单（含本事务）- 本次增量
+			var total exposureBook
+			if total, err = bookFromOrders(ctx, tx, eng, roundID); err == nil {
+				base := make(exposureBook, len(total))
+				for f, v := range total {
+					base[f] = v - delta[f]
+				}
+				code, field, err = evalReserveExposure(ctx, r, key, capCents, delta, base)
+			}
+		}
+		if err == nil {
+			if code != exposureReserved {
+				fmt.Printf("[Exposure] 超出单局赔付上限: round_id=%s, room_id=%s, field=%s, cap=%s, trace_id=%s\n",
+					roundID, room.RoomID, field, room.LiabilityCap.String(), traceID)
+				return ErrExposureLimit
+			}
+			return nil
+		}
+		if capCents > 0 && !serial {
+			// 只持有共享锁时并发下注互不可见，按注单校验会漏算；共享锁升级为排他锁又会与其他下注互相等待而死锁
+			fmt.Printf("[Exposure] Redis 敞口校验失败，拒绝下注: round_id=%s, error=%v, trace_id=%s\n",
+				roundID, err, traceID)
+			return ErrExposureUnavailable
+		}
+		fmt.Printf("[Exposure] Redis 敞口校验失败，降级按注单校验: round_id=%s, error=%v, trace_id=%s\n",
+			roundID, err, traceID)
+	}
+
+	// 降级：按库中注单（含本事务）校验，此时事务已持有牌局排他锁，同一局的下注串行（Redis 未配置时见 roundBetNeedsSerial）
+	if capCents <= 0 {
+		return nil
+	}
+	total, err := bookFromOrders(ctx, tx, eng, roundID)
+	if err != nil {
+		return err
+	}
+	for f, d := range delta {
+		if strings.HasPrefix(f, exposureNetPrefix) && d > 0 && total[f] > capCents {
+			fmt.Printf("[Exposure] 超出单局赔付上限: round_id=%s, room_id=%s, field=%s, net=%d, cap=%s, trace_id=%s\n",
+				roundID, room.RoomID, f, total[f], room.LiabilityCap.String(), traceID)
+			return ErrExposureLimit
+		}
+	}
+	return nil
+}
+
+// reserveExposureScript 返回码
+const (
+	exposureNeedBase int64 = -1 // Hash 缺失，需携带基线重试
+	exposureOverCap  int64 = 0  // 超过上限
+	exposureReserved int64 = 1  // 已累加
+)
+
+// evalReserveExposure 执行 reserveExposureScript，返回 (返回码, 超限字段, error)
+func evalReserveExposure(ctx context.Context, r *goredis.Client, key string, capCents int64, delta, base exposureBook) (int64, string, error) {
+	withBase := "0"
+	if base != nil {
+		withBase = "1"
+	}
+	args := []interface{}{capCents, int64(exposureTTL / time.Second), withBase}
+	for _, f := range unionFields(delta, base) {
+		args = append(args, f, delta[f], base[f])
+	}
+	res, err := r.Eval(ctx, reserveExposureScript, []string{key}, args...).Slice()
+	if err != nil {
+		return 0, "", err
+	}
+	if len(res) != 2 {
+		return 0, "", fmt.Errorf("unexpected exposure script result: %v", res)
+	}
+	code, ok := res[0].(int64)
+	if !ok {
+		return 0, "", fmt.Errorf("unexpected exposure script result: %v", res)
+	}
+	field, _ := res[1].(string)
+	return code, field, nil
+}
+
+// releaseExposure 撤单后扣减本局敞口（提交之后调用，失败只记录日志）
+func releaseExposure(ctx context.Context, eng engine.GameEngine, roundID string, legs []exposureLeg, traceID string) {
+	r := infrds.Client()
+	if r == nil {
+		return
+	}
+	delta, err := legBook(eng, legs)
+	if err != nil {
+		return
+	}
+	args := make([]interface{}, 0, 2*len(delta))
+	for _, f := range unionFields(delta, nil) {
+		args = append(args, f, -delta[f])
+	}
+	if err := r.Eval(ctx, releaseExposureScript, []string{infrds.RoundExposureKey(roundID)}, args...).Err(); err != nil {
+		fmt.Printf("[Exposure] 扣减敞口失败: round_id=%s, error=%v, trace_id=%s\n", roundID, err, traceID)
+	}
+}
+
+// unionFields 两个敞口的字段并集（排序，保证脚本参数稳定）
+func unionFields(a, b exposureBook) []string {
+	fields := make([]string, 0, len(a)+len(b))
+	for f := range a {
+		fields = append(fields, f)
+	}
+	for f := range b {
+		if _, ok := a[f]; !ok {
+			fields = append(fields, f)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// ========== 运营查询 ==========
+
+// PlayTypeExposure 单个玩法的累计下注与潜在派彩
+type PlayTypeExposure struct {
+	PlayType        string `json:"play_type"`
+	Stake           string `json:"stake"`            // 累计下注额
+	PotentialPayout string `json:"potential_payout"` // 该玩法全部中奖时的派彩（含本金）
+}
+
+// OutcomeExposure 某主结果开出时庄家的净赔付
+type OutcomeExposure struct {
+	Result       string `json:"result"`
+	NetLiability string `json:"net_liability"`      // 净赔付（负数表示庄家盈利）
+	Headroom     string `json:"headroom,omitempty"` // 距上限的余量（未设上限时为空）
+}
+
+// RoundExposure 单局赔付敞口
+type RoundExposure struct {
+	GameRoundID  string             `json:"game_round_id"`
+	GameID       string             `json:"game_id"`
+	RoomID       string             `json:"room_id"`
+	Currency     string             `json:"currency"`
+	LiabilityCap string             `json:"liability_cap"` // 0 表示不限
+	Source       string             `json:"source"`        // redis | orders
+	Drift        bool               `json:"drift"`         // 对账时 Redis 与 orders 是否不一致
+	PlayTypes    []PlayTypeExposure `json:"play_types"`
+	Outcomes     []OutcomeExposure  `json:"outcomes"`
+}
+
+type ExposureService interface {
+	// GetRoundExposure 查询单局敞口：优先读 Redis，缺失时按 orders 计算
+	// reconcile 为 true 时按 orders 重算并覆盖 Redis，Drift 表示覆盖前是否不一致
+	GetRoundExposure(ctx context.Context, roundID string, reconcile bool, traceID string) (*RoundExposure, error)
+}
+
+type exposureService struct{}
+
+func NewExposureService() ExposureService { return &exposureService{} }
+
+func (s *exposureService) GetRoundExposure(ctx context.Context, roundID string, reconcile bool, traceID string) (*RoundExposure, error) {
+	round, err := model.GetRoundInfo(ctx, infmysql.SQLX(), roundID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrGameRoundNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	eng, err := engine.For(round.GameID)
+	if err != nil {
+		return nil, err
+	}
+	out := &RoundExposure{GameRoundID: roundID, GameID: round.GameID, RoomID: round.RoomID, LiabilityCap: "0"}
+	var capDec decimal.Decimal
+	if room, err := LookupRoom(ctx, round.RoomID); err == nil {
+		capDec = room.LiabilityCap
+		out.Currency = room.Currency
+		out.LiabilityCap = chelper.TrimDecimal(room.LiabilityCap)
+	}
+
+	r := infrds.Client()
+	key := infrds.RoundExposureKey(roundID)
+	var cached exposureBook
+	if r != nil {
+		if m, err := r.HGetAll(ctx, key).Result(); err == nil && len(m) > 0 {
+			cached = make(exposureBook, len(m))
+			for f, v := range m {
+				var n int64
+				if _, err := fmt.Sscan(v, &n); err == nil {
+					cached[f] = n
+				}
+			}
+		}
+	}
+
+	book := cached
+	out.Source = "redis"
+	if cached == nil || reconcile {
+		fresh, err := bookFromOrders(ctx, infmysql.SQLX(), eng, roundID)
+		if err != nil {
+			return nil, err
+		}
+		if reconcile {
+			out.Drift = !sameBook(cached, fresh)
+			if r != nil {
+				if err := writeExposure(ctx, r, key, fresh); err != nil {
+					fmt.Printf("[Exposure] 对账写入 Redis 失败: round_id=%s, error=%v, trace_id=%s\n", roundID, err, traceID)
+				}
+			}
+			fmt.Printf("[Exposure] 敞口对账: round_id=%s, drift=%v, trace_id=%s\n", roundID, out.Drift, traceID)
+		}
+		book = fresh
+		out.Source = "orders"
+	}
+
+	playTypes := make([]string, 0)
+	for f := range book {
+		if pt, ok := strings.CutPrefix(f, exposureStakePrefix); ok {
+			playTypes = append(playTypes, pt)
+		}
+	}
+	sort.Slice(playTypes, func(i, j int) bool {
+		return model.PlayTypeCode(playTypes[i]) < model.PlayTypeCode(playTypes[j])
+	})
+	out.PlayTypes = make([]PlayTypeExposure, 0, len(playTypes))
+	for _, pt := range playTypes {
+		out.PlayTypes = append(out.PlayTypes, PlayTypeExposure{
+			PlayType:        pt,
+			Stake:           chelper.TrimDecimal(fromCents(book[exposureStakePrefix+pt])),
+			PotentialPayout: chelper.TrimDecimal(fromCents(book[exposurePayoutPrefix+pt])),
+		})
+	}
+	out.Outcomes = make([]OutcomeExposure, 0, len(eng.Results()))
+	for _, res := range eng.Results() {
+		net := fromCents(book[exposureNetPrefix+res])
+		oe := OutcomeExposure{Result: res, NetLiability: chelper.TrimDecimal(net)}
+		if capDec.IsPositive() {
+			oe.Headroom = chelper.TrimDecimal(capDec.Sub(net))
+		}
+		out.Outcomes = append(out.Outcomes, oe)
+	}
+	return out, nil
+}
+
+// writeExposure 用 orders 重算的敞口整体覆盖 Redis
+func writeExposure(ctx context.Context, r *goredis.Client, key string, book exposureBook) error {
+	_, err := r.TxPipelined(ctx, func(p goredis.Pipeliner) error {
+		p.Del(ctx, key)
+		if len(book) > 0 {
+			vals := make([]interface{}, 0, 2*len(book))
+			for f, v := range book {
+				vals = append(vals, f, v)
+			}
+			p.HSet(ctx, key, vals...)
+			p.Expire(ctx, key, exposureTTL)
+		}
+		return nil
+	})
+	return err
+}
+
+// sameBook 比较两份敞口（缺失字段按 0）
+func sameBook(a, b exposureBook) bool {
+	for _, f := range unionFields(a, b) {
+		if a[f] != b[f] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"dt-server/internal/engine"
+	"dt-server/internal/fair"
+	infmysql "dt-server/internal/infra/mysql"
+	"dt-server/internal/model"
+	"dt-server/internal/shoe"
+)
+
+// FairVerifyResult 可验证公平核对结果
+// 靴种子在换靴后公布（旧版按局种子在结算/结束/作废后公布）；公布前只返回承诺
+type FairVerifyResult struct {
+	GameRoundID     string `json:"game_round_id"`
+	SeedHash        string `json:"seed_hash"`
+	SeedNonce       string `json:"seed_nonce"`
+	Mode            string `json:"mode"`          // shoe=靴种子 round=旧版按局种子
+	ShoePosition    int    `json:"shoe_position"` // 本局第一张牌在牌靴中的下标（靴种子模式）
+	Revealed        bool   `json:"revealed"`
+	ServerSeed      string `json:"server_seed,omitempty"`
+	Decks           int    `json:"decks"`
+	HashValid       bool   `json:"hash_valid"`        // SHA256(server_seed) == seed_hash
+	CardList        string `json:"card_list"`         // 本局实际牌面
+	DerivedCardList string `json:"derived_card_list"` // 由种子复算的牌面
+	CardsMatch      bool   `json:"cards_match"`       // 实际牌面与复算一致（人工覆盖开奖时为 false）
+}
+
+type FairService interface {
+	Verify(ctx context.Context, roundID string) (*FairVerifyResult, error)
+}
+
+type fairService struct{}
+
+func NewFairService() FairService { return &fairService{} }
+
+func (s *fairService) Verify(ctx context.Context, roundID string) (*FairVerifyResult, error) {
+	if roundID == "" {
+		return nil, ErrBadRequest
+	}
+	round, err := model.GetRoundInfo(ctx, infmysql.SQLX(), roundID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrGameRoundNotFound
+		}
+		return nil, err
+	}
+	if round.SeedHash == "" {
+		return nil, ErrRoundNotProvablyFair
+	}
+	eng, err := engine.For(round.GameID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &FairVerifyResult{
+		GameRoundID: round.GameRoundID,
+		SeedHash:    round.SeedHash,
+		SeedNonce:   round.SeedNonce,
+	}
+
+	var cards []shoe.Card
+	pos := 0
+	if round.ServerSeed != "" {
+		// 旧版按局种子：以局号为 nonce 从虚拟 8 副牌派生前若干张
+		out.Mode, out.Decks = "round", fairDecks
+		// game_status: 6=已结算 7=已结束 8=已取消
+		if round.IsSettled != 1 && round.GameStatus < 6 {
+			return out, nil
+		}
+		out.ServerSeed = round.ServerSeed
+		cards = fair.DealCards(round.ServerSeed, round.SeedNonce, fairDecks, eng.MaxCards())
+	} else {
+		// 靴种子：seed_nonce 为靴号，牌靴换下后才公布种子
+		out.Mode, out.ShoePosition = "shoe", round.ShoePosition
+		sh, err := model.GetShoeByNo(ctx, infmysql.SQLX(), round.SeedNonce)
+		if err != nil {
+			return nil, err
+		}
+		out.Decks = sh.Decks
+		if round.CardList == "" || sh.Status != 2 {
+			return out, nil
+		}
+		out.ServerSeed = sh.ServerSeed
+		cards, pos = fair.ShuffleShoe(sh.ServerSeed, sh.ShoeNo, sh.Decks), round.ShoePosition
+	}
+
+	out.Revealed = true
+	out.HashValid = fair.Verify(out.ServerSeed, round.SeedHash)
+	out.CardList = round.CardList
+	out.DerivedCardList, err = eng.Deal(cardSource(cards, &pos))
+	if err != nil {
+		return nil, err
+	}
+	out.CardsMatch = strings.EqualFold(strings.TrimSpace(out.CardList), out.DerivedCardList)
+	return out, nil
+}
+
+var ErrRoundNotProvablyFair = errors.New("round has no provably-fair commitment")
@@ -2,23 +2,31 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
 
+	"dt-server/internal/engine"
 	infmysql "dt-server/internal/infra/mysql"
 	infrds "dt-server/internal/infra/redis"
 	"dt-server/internal/metrics"
 	"dt-server/internal/model"
 	"dt-server/internal/state"
+
+	"github.com/jmoiron/sqlx"
 )
 
 type GameEventInput struct {
 	GameID      string
 	RoomID      string
 	GameRoundID string //局ID
-	EventType   int8   // 1=game_start 2=game_stop 3=new_card 4=game_draw 5=game_end
+	EventType   int8   // 1=game_start 2=game_stop 3=new_card 4=game_draw 5=game_end 6=game_cancel
+	EventID     string // 事件ID（可选，发送方生成）：重复投递按成功处理
+	Seq         int64  // 局内事件序号（可选，从 1 开始连续递增）：重复按成功处理，超前拒绝
+	Source      string // 事件来源: api|mq|task，空值视为 api
+	Reason      string // 作废原因（仅 game_cancel 使用）
 	TraceID     string
 }
 
@@ -31,14 +39,14 @@ type gameEventService struct{}
 func NewGameEventService() GameEventService { return &gameEventService{} }
 
 const (
-	betWindowSeconds = 45               // 下注窗口 45s
-	roundInfoTTL     = 60 * time.Second // 回合信息缓存 60s（应大于下注窗口）
+	roundInfoTTL = 60 * time.Second // 回合信息缓存 60s（下注窗口更长时按 roundInfoCacheTTL 延长）
 )
 
 func (s *gameEventService) Handle(ctx context.Context, in GameEventInput) error {
 
-	// 基本校验：必须有回合ID，且事件类型为 1..5
-	if in.GameRoundID == "" || in.EventType < 1 || in.EventType > 5 {
+	// 基本校验：必须有回合ID，且事件类型为状态机定义的外部事件
+	evtStr, ok := state.Default.EventByCode(in.EventType)
+	if in.GameRoundID == "" || !ok {
 		fmt.Printf("[GameEvent]  参数校验失败: round_id=%s, event_type=%d, trace_id=%s\n",
 			in.GameRoundID, in.EventType, in.TraceID)
 		return ErrBadRequest
@@ -47,12 +55,45 @@ func (s *gameEventService) Handle(ctx context.Context, in GameEventInput) error
 	// 指标：仅在输入校验通过后开始计时
 	start := time.Now()
 	resultLabel := "fail"
-	// 将事件代码转换为状态机事件名（用于内部状态机与指标标签）
-	evtStr := eventCodeToString(in.EventType)
 	defer func() { metrics.RecordGameEvent(resultLabel, evtStr, start) }()
 
-	fmt.Printf("[GameEvent] 收到事件: event=%s(%d), round_id=%s, game_id=%s, room_id=%s, trace_id=%s\n",
-		evtStr, in.EventType, in.GameRoundID, in.GameID, in.RoomID, in.TraceID)
+	fmt.Printf("[GameEvent] 收到事件: event=%s(%d), event_id=%s, seq=%d, round_id=%s, game_id=%s, room_id=%s, trace_id=%s\n",
+		evtStr, in.EventType, in.EventID, in.Seq, in.GameRoundID, in.GameID, in.RoomID, in.TraceID)
+
+	// 重复投递快速返回（不加锁预检；并发到达的重复事件由加锁后的复检识别）
+	if in.EventID != "" {
+		dup, err := checkEventID(ctx, infmysql.SQLX(), in)
+		if err != nil {
+			return err
+		}
+		if dup {
+			resultLabel = "duplicate"
+			return nil
+		}
+	}
+
+	// game_start 读取房间配置：房间须已登记且为开放状态，下注窗口按房间配置
+	var room *RoomConfig
+	if evtStr == state.EvtGameStart {
+		r, err := LookupRoom(ctx, in.RoomID)
+		if err != nil {
+			fmt.Printf("[GameEvent] 读取房间配置失败: room_id=%s, error=%v, trace_id=%s\n",
+				in.RoomID, err, in.TraceID)
+			return err
+		}
+		if !r.acceptsGame(in.GameID) {
+			fmt.Printf("[GameEvent] game_id 与房间不一致: room_id=%s, game_id=%s, room_game_id=%s, trace_id=%s\n",
+				in.RoomID, in.GameID, r.GameID, in.TraceID)
+			return ErrRoomGameMismatch
+		}
+		if r.Status != RoomStatusOpen {
+			fmt.Printf("[GameEvent] 房间未开放，拒绝开局: room_id=%s, status=%d, trace_id=%s\n",
+				in.RoomID, r.Status, in.TraceID)
+			return ErrRoomNotOpen
+		}
+		room = r
+		in.GameID = r.GameID
+	}
 
 	// ========== 生产审计：事务管理 ==========
 	//  高优先级：未设置事务超时
@@ -79,52 +120,111 @@ func (s *gameEventService) Handle(ctx context.Context, in GameEventInput) error
 		}
 	}
 
-	prevStatus, err := model.GetStatusForUpdate(ctx, tx, in.GameRoundID)
+	round, err := model.GetRoundForUpdate(ctx, tx, in.GameRoundID)
 	if err != nil {
 		fmt.Printf("[GameEvent] 获取回合状态失败: round_id=%s, error=%v, trace_id=%s\n",
 			in.GameRoundID, err, in.TraceID)
 		return err
 	}
-	prev := codeToState(prevStatus)
+	prevStatus := round.GameStatus
+	prev := state.Default.StateName(prevStatus)
+
+	// 持有回合行锁后复检幂等与顺序：已处理过的事件不再走状态机，避免重试被判为非法跳转
+	if dup, err := checkEventOrder(ctx, tx, in, prev); err != nil || dup {
+		if dup {
+			resultLabel = "duplicate"
+		}
+		return err
+	}
 
 	fmt.Printf("[GameEvent] 当前状态: state=%s(%d), round_id=%s, trace_id=%s\n",
 		prev, prevStatus, in.GameRoundID, in.TraceID)
 
-	// 计算目标状态（不处理 draw）
-	nextStr, err := state.NextState(prev, evtStr)
+	// 计算目标状态（守卫：结束需已有开奖结果，作废需未结算）
+	nextStr, err := state.Default.Fire(prev, evtStr, roundSnapshot(round))
 	if err != nil {
-		fmt.Printf("[GameEvent] 状态转换失败: %s --%s--> ?, round_id=%s, trace_id=%s\n",
-			prev, evtStr, in.GameRoundID, in.TraceID)
+		fmt.Printf("[GameEvent] 状态转换失败: %s --%s--> ?, round_id=%s, error=%v, trace_id=%s\n",
+			prev, evtStr, in.GameRoundID, err, in.TraceID)
+		switch {
+		case errors.Is(err, state.ErrNoDrawResult):
+			return ErrGameEndWithoutDrawResult
+		case errors.Is(err, state.ErrAlreadySettled):
+			return ErrCancelSettledRound
+		}
 		return err
 	}
-	nextCode := stateToCode(nextStr)
+	nextCode := state.Default.StateCode(nextStr)
 
 	var (
-		betStartMs int64
-		betStopMs  int64
+		betStartMs   int64
+		betStopMs    int64
+		refund       *roundRefund
+		auditPayload = "{}"
+		seedHash     string
+		seedNonce    string
+		revealSeed   string
 	)
 	// 根据事件设置相应时间戳
 	switch evtStr {
 	case state.EvtGameStart:
 		betStartMs = time.Now().UnixMilli()
-		betStopMs = betStartMs + int64(betWindowSeconds*1000)
-		fmt.Printf("[GameEvent] game_start: 设置投注时间窗口, bet_start=%d, bet_stop=%d, window=%ds, round_id=%s, trace_id=%s\n",
-			betStartMs, betStopMs, betWindowSeconds, in.GameRoundID, in.TraceID)
+		betStopMs = betStartMs + room.BetWindow().Milliseconds()
+		fmt.Printf("[GameEvent] game_start: 设置投注时间窗口, bet_start=%d, bet_stop=%d, window=%ds, room_id=%s, round_id=%s, trace_id=%s\n",
+			betStartMs, betStopMs, room.BetWindowSec, in.RoomID, in.GameRoundID, in.TraceID)
 		// 同时设置 bet_start_time 和 bet_stop_time
 		if err := model.SetBetTimes(ctx, tx, in.GameRoundID, betStartMs, betStopMs); err != nil {
 			return err
 		}
+		// 可验证公平：开局即承诺本局所用牌靴的种子（需要时先换靴），new_card 从该靴发牌，换靴后公布种子
+		if fairEnabled() {
+			eng, err := engine.For(round.GameID)
+			if err != nil {
+				return err
+			}
+			sh, err := dealableShoe(ctx, tx, round.GameID, round.RoomID, eng, in.TraceID)
+			if err != nil {
+				return err
+			}
+			seedHash, seedNonce = sh.SeedHash, sh.ShoeNo
+			if err := model.SetSeedCommit(ctx, tx, in.GameRoundID, "", seedHash, seedNonce); err != nil {
+				return err
+			}
+		}
+		// 审计记录下注窗口与种子承诺，供回放重建牌局
+		startAudit := map[string]any{"bet_start_time": betStartMs, "bet_stop_time": betStopMs}
+		if seedHash != "" {
+			startAudit["seed_hash"] = seedHash
+			startAudit["seed_nonce"] = seedNonce
+		}
+		auditPayload = toJSON(startAudit)
 	case state.EvtGameStop:
 		fmt.Printf("[GameEvent] game_stop: 封盘, round_id=%s, trace_id=%s\n",
 			in.GameRoundID, in.TraceID)
-		if err := model.SetBetStopNow(ctx, tx, in.GameRoundID); err != nil {
+		betStopMs = time.Now().UnixMilli()
+		if err := model.SetBetStop(ctx, tx, in.GameRoundID, betStopMs); err != nil {
 			fmt.Printf("[GameEvent] 设置封盘时间失败: round_id=%s, error=%v, trace_id=%s\n",
 				in.GameRoundID, err, in.TraceID)
 			return err
 		}
+		auditPayload = toJSON(map[string]any{"bet_stop_time": betStopMs})
 	case state.EvtNewCard:
 		fmt.Printf("[GameEvent] new_card: 发牌, round_id=%s, trace_id=%s\n",
 			in.GameRoundID, in.TraceID)
+		// 服务端牌靴模式：由服务端从房间牌靴发牌，开奖时直接使用
+		if shoeEnabled() {
+			dealt, err := dealRoundCards(ctx, tx, in)
+			if err != nil {
+				fmt.Printf("[GameEvent] 服务端发牌失败: round_id=%s, error=%v, trace_id=%s\n",
+					in.GameRoundID, err, in.TraceID)
+				return err
+			}
+			auditPayload = toJSON(map[string]any{
+				"shoe_no":        dealt.ShoeNo,
+				"card_list":      dealt.CardList,
+				"start_position": dealt.Start,
+				"position":       dealt.Position,
+			})
+		}
 	case state.EvtGameDraw:
 		fmt.Printf("[GameEvent] game_draw: 准备开奖, round_id=%s, trace_id=%s\n",
 			in.GameRoundID, in.TraceID)
@@ -133,27 +233,32 @@ func (s *gameEventService) Handle(ctx context.Context, in GameEventInput) error
 		fmt.Printf("[GameEvent] game_end: 游戏结束, round_id=%s, trace_id=%s\n",
 			in.GameRoundID, in.TraceID)
 
-		// 校验：必须确保已经调用过开奖接口（即已经有开奖结果）
-		round, err := model.GetRoundForUpdate(ctx, tx, in.GameRoundID)
-		if err != nil {
-			fmt.Printf("[GameEvent] 获取回合信息失败: round_id=%s, error=%v, trace_id=%s\n",
-				in.GameRoundID, err, in.TraceID)
-			return err
-		}
-
-		// 检查是否已经有开奖结果
-		if round.GameResult == 0 || round.CardList == "" {
-			fmt.Printf("[GameEvent] 游戏结束失败: 当前牌局尚未开奖，不能触发游戏结束事件, round_id=%s, game_result=%d, card_list=%s, trace_id=%s\n",
-				in.GameRoundID, round.GameResult, round.CardList, in.TraceID)
-			return ErrGameEndWithoutDrawResult
-		}
-
+		// 开奖结果校验已由状态机守卫完成；这里仅提示未结算的旧数据
 		// 检查是否已经结算
 		if round.IsSettled == 0 {
 			fmt.Printf("[GameEvent] 警告: 当前牌局尚未结算，建议先调用 /api/drawresult 进行结算, round_id=%s, trace_id=%s\n",
 				in.GameRoundID, in.TraceID)
 		}
+	case state.EvtGameCancel:
+		fmt.Printf("[GameEvent] game_cancel: 作废牌局并退款, round_id=%s, reason=%s, trace_id=%s\n",
+			in.GameRoundID, in.Reason, in.TraceID)
+
+		// 已结算的牌局不能作废（状态机守卫 notSettled 已校验 is_settled）
+		// 旧版按局种子：作废后同样公布种子，便于核对作废前已发出的牌（靴种子在换靴时公布）
+		revealSeed = round.ServerSeed
 
+		refund, err = refundRoundOrders(ctx, tx, in)
+		if err != nil {
+			fmt.Printf("[GameEvent] 作废退款失败: round_id=%s, error=%v, trace_id=%s\n",
+				in.GameRoundID, err, in.TraceID)
+			return err
+		}
+		auditPayload = toJSON(map[string]any{
+			"reason":       in.Reason,
+			"total_orders": refund.TotalOrders,
+			"total_refund": refund.TotalRefund,
+			"by_currency":  refund.RefundByCurrency,
+		})
 	}
 
 	if err := model.UpdateState(ctx, tx, in.GameRoundID, nextCode); err != nil {
@@ -171,6 +276,10 @@ func (s *gameEventService) Handle(ctx context.Context, in GameEventInput) error
 			"bet_stop_time":  betStopMs,
 			"trace_id":       in.TraceID,
 		}
+		if seedHash != "" {
+			payload["seed_hash"] = seedHash
+			payload["seed_nonce"] = seedNonce
+		}
 		if err := model.CreateOutbox(ctx, tx, "game_started", in.GameRoundID, payload); err != nil {
 			return err
 		}
@@ -204,21 +313,54 @@ func (s *gameEventService) Handle(ctx context.Context, in GameEventInput) error
 			return err
 		}
 	}
+	if evtStr == state.EvtGameCancel {
+		payload := map[string]any{
+			"event":         "round_cancelled",
+			"game_id":       in.GameID,
+			"room_id":       in.RoomID,
+			"game_round_id": in.GameRoundID,
+			"reason":        in.Reason,
+			"total_orders":  refund.TotalOrders,
+			"total_refund":  refund.TotalRefund,
+			"by_currency":   refund.RefundByCurrency,
+			"trace_id":      in.TraceID,
+		}
+		if revealSeed != "" {
+			payload["server_seed"] = revealSeed
+		}
+		fmt.Printf("[GameEvent] 写入 Outbox: topic=round_cancelled, round_id=%s, trace_id=%s\n",
+			in.GameRoundID, in.TraceID)
+		if err := model.CreateOutbox(ctx, tx, "round_cancelled", in.GameRoundID, payload); err != nil {
+			return err
+		}
+	}
 
 	// 审计（补充 game_id/room_id 入库，满足非空约束）
+	source := in.Source
+	if source == "" {
+		source = "api"
+	}
 	aud := &model.GameEventAudit{
 		GameID:      in.GameID,
 		RoomID:      in.RoomID,
 		GameRoundID: in.GameRoundID,
 		EventType:   in.EventType,
+		EventID:     in.EventID,
+		Seq:         in.Seq,
 		PrevState:   prev,
 		NextState:   nextStr,
 		Operator:    "system",
-		Source:      "api",
-		Payload:     "{}",
+		Source:      source,
+		Payload:     auditPayload,
 		TraceID:     in.TraceID,
 	}
 	if err := aud.Insert(ctx, tx); err != nil {
+		if isMySQLDuplicateKeyError(err) {
+			// 行锁已串行化同一局的事件，到这里说明 event_id 被其他局的事件占用
+			fmt.Printf("[GameEvent] 事件ID或序号冲突: event_id=%s, seq=%d, round_id=%s, trace_id=%s\n",
+				in.EventID, in.Seq, in.GameRoundID, in.TraceID)
+			return ErrEventConflict
+		}
 		fmt.Printf("[GameEvent]  写入审计日志失败: round_id=%s, error=%v, trace_id=%s\n",
 			in.GameRoundID, err, in.TraceID)
 		return err
@@ -231,6 +373,20 @@ func (s *gameEventService) Handle(ctx context.Context, in GameEventInput) error
 	}
 
 	// 事务提交后：写/删 Redis（避免未提交数据被读取）
+	// 回合下注状态缓存：状态版本与 UpdateState 的 +1 一致，乱序写入由版本防护丢弃
+	betState := &model.RoundBetState{
+		GameStatus:   nextCode,
+		BetStartTime: round.BetStartTime,
+		BetStopTime:  round.BetStopTime,
+		StateVersion: round.StateVersion + 1,
+	}
+	if betStartMs > 0 {
+		betState.BetStartTime = betStartMs
+	}
+	if betStopMs > 0 {
+		betState.BetStopTime = betStopMs
+	}
+	cacheRoundState(ctx, in.GameRoundID, betState)
 	if r := infrds.Client(); r != nil {
 		switch evtStr {
 		case state.EvtGameStart:
@@ -242,12 +398,17 @@ func (s *gameEventService) Handle(ctx context.Context, in GameEventInput) error
 				"bet_stop_time":  betStopMs,
 				"game_status":    2, // betting
 			}
+			if seedHash != "" {
+				val["seed_hash"] = seedHash
+				val["seed_nonce"] = seedNonce
+			}
 			if b, e := json.Marshal(val); e == nil {
+				ttl := roundInfoCacheTTL(room)
 				fmt.Printf("[GameEvent] 写入 Redis 缓存: key=%s, ttl=%v, round_id=%s, trace_id=%s\n",
-					infrds.RoundInfoKey(in.GameRoundID), roundInfoTTL, in.GameRoundID, in.TraceID)
-				_ = r.Set(ctx, infrds.RoundInfoKey(in.GameRoundID), b, roundInfoTTL).Err()
+					infrds.RoundInfoKey(in.GameRoundID), ttl, in.GameRoundID, in.TraceID)
+				_ = r.Set(ctx, infrds.RoundInfoKey(in.GameRoundID), b, ttl).Err()
 			}
-		case state.EvtGameEnd:
+		case state.EvtGameEnd, state.EvtGameCancel:
 			fmt.Printf("[GameEvent] 删除 Redis 缓存: key=%s, round_id=%s, trace_id=%s\n",
 				infrds.RoundInfoKey(in.GameRoundID), in.GameRoundID, in.TraceID)
 			_ = r.Del(ctx, infrds.RoundInfoKey(in.GameRoundID)).Err()
@@ -260,68 +421,109 @@ func (s *gameEventService) Handle(ctx context.Context, in GameEventInput) error
 	return nil
 }
 
-// 约定的状态码映射：1=init, 2=betting, 3=sealed, 4=dealt, 5=drawn, 6=settled, 7=finished
-func codeToState(c int8) string {
-	switch c {
-	case 1:
-		return state.StateInit
-	case 2:
-		return state.StateBetting
-	case 3:
-		return state.StateSealed
-	case 4:
-		return state.StateDealt
-	case 5:
-		return state.StateDrawn
-	case 6:
-		return state.StateSettled
-	case 7:
-		return state.StateFinished
-	default:
-		return state.StateInit
+// checkEventID 按事件ID识别重复投递：同一局同一事件返回 dup=true，ID 被其他事件占用返回 ErrEventConflict
+func checkEventID(ctx context.Context, exec sqlx.QueryerContext, in GameEventInput) (bool, error) {
+	prior, err := model.GetAuditByEventID(ctx, exec, in.EventID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if prior.GameRoundID != in.GameRoundID || prior.EventType != in.EventType || (in.Seq > 0 && prior.Seq != in.Seq) {
+		fmt.Printf("[GameEvent] 事件ID已被占用: event_id=%s, round_id=%s, event_type=%d, prior_round_id=%s, prior_event_type=%d, trace_id=%s\n",
+			in.EventID, in.GameRoundID, in.EventType, prior.GameRoundID, prior.EventType, in.TraceID)
+		return false, ErrEventConflict
+	}
+	fmt.Printf("[GameEvent] 重复投递，按成功处理: event_id=%s, round_id=%s, audit_id=%d, trace_id=%s\n",
+		in.EventID, in.GameRoundID, prior.ID, in.TraceID)
+	return true, nil
+}
+
+// checkEventOrder 在持有回合行锁时校验事件ID与局内序号，cur 为回合当前状态
+//   - 事件ID或序号已入库且为同一事件：重复投递，返回 dup=true
+//   - 序号已被其他事件占用：ErrEventConflict
+//   - 序号超前（前序事件未到）：ErrEventOutOfOrder，发送方补发前序事件后重试
+//   - 未带事件ID与序号：回合已处于该事件的目标状态且最近一条审计即为同类事件时，视为普通重试，返回 dup=true
+func checkEventOrder(ctx context.Context, tx *sqlx.Tx, in GameEventInput, cur string) (bool, error) {
+	if in.EventID == "" && in.Seq <= 0 {
+		return checkPlainRetry(ctx, tx, in, cur)
+	}
+	if in.EventID != "" {
+		if dup, err := checkEventID(ctx, tx, in); err != nil || dup {
+			return dup, err
+		}
+	}
+	if in.Seq <= 0 {
+		return false, nil
+	}
+	last, err := model.MaxAuditSeq(ctx, tx, in.GameRoundID)
+	if err != nil {
+		return false, err
 	}
+	switch {
+	case in.Seq == last+1:
+		return false, nil
+	case in.Seq > last+1:
+		fmt.Printf("[GameEvent] 事件序号超前: seq=%d, expected=%d, round_id=%s, trace_id=%s\n",
+			in.Seq, last+1, in.GameRoundID, in.TraceID)
+		return false, ErrEventOutOfOrder
+	}
+	prior, err := model.GetAuditBySeq(ctx, tx, in.GameRoundID, in.Seq)
+	if errors.Is(err, sql.ErrNoRows) {
+		// 序号小于已入库最大值但缺失：之前的事件未带序号到达，已不能按序号补入
+		return false, ErrEventConflict
+	}
+	if err != nil {
+		return false, err
+	}
+	if prior.EventType != in.EventType || (in.EventID != "" && prior.EventID != "" && prior.EventID != in.EventID) {
+		fmt.Printf("[GameEvent] 事件序号已被占用: seq=%d, event_type=%d, prior_event_type=%d, round_id=%s, trace_id=%s\n",
+			in.Seq, in.EventType, prior.EventType, in.GameRoundID, in.TraceID)
+		return false, ErrEventConflict
+	}
+	fmt.Printf("[GameEvent] 重复投递（序号已处理），按成功处理: seq=%d, round_id=%s, audit_id=%d, trace_id=%s\n",
+		in.Seq, in.GameRoundID, prior.ID, in.TraceID)
+	return true, nil
 }
 
-func stateToCode(s string) int8 {
-	switch s {
-	case state.StateInit:
-		return 1
-	case state.StateBetting:
-		return 2
-	case state.StateSealed:
-		return 3
-	case state.StateDealt:
-		return 4
-	case state.StateDrawn:
-		return 5
-	case state.StateSettled:
-		return 6
-	case state.StateFinished:
-		return 7
-	default:
-		return 1
+// checkPlainRetry 未带事件ID/序号的重试（如 HTTP 超时重发 game_stop）：
+// 最近一条审计为同类事件且其目标状态即回合当前状态，说明该事件已生效，按成功处理而不是判为非法跳转
+func checkPlainRetry(ctx context.Context, tx *sqlx.Tx, in GameEventInput, cur string) (bool, error) {
+	last, err := model.GetLastAudit(ctx, tx, in.GameRoundID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
 	}
+	if err != nil {
+		return false, err
+	}
+	if last.EventType != in.EventType || last.NextState != cur {
+		return false, nil
+	}
+	fmt.Printf("[GameEvent] 重复请求（回合已处于目标状态），按成功处理: event_type=%d, state=%s, round_id=%s, audit_id=%d, trace_id=%s\n",
+		in.EventType, cur, in.GameRoundID, last.ID, in.TraceID)
+	return true, nil
+}
 
+// roundSnapshot 由加锁读取的回合构造状态机守卫快照
+func roundSnapshot(r *model.GameRoundInfo) state.Snapshot {
+	return state.Snapshot{
+		IsSettled: r.IsSettled == 1,
+		HasResult: r.GameResult != 0 && r.CardList != "",
+	}
 }
 
-// eventCodeToString: 将数值事件代码映射为状态机事件名
-func eventCodeToString(c int8) string {
-	switch c {
-	case 1:
-		return state.EvtGameStart
-	case 2:
-		return state.EvtGameStop
-	case 3:
-		return state.EvtNewCard
-	case 4:
-		return state.EvtGameDraw
-	case 5:
-		return state.EvtGameEnd
-	default:
-		return ""
+// roundInfoCacheTTL 回合信息缓存需覆盖整个下注窗口
+func roundInfoCacheTTL(room *RoomConfig) time.Duration {
+	if room != nil && room.BetWindow()+15*time.Second > roundInfoTTL {
+		return room.BetWindow() + 15*time.Second
 	}
+	return roundInfoTTL
 }
 
 var (
 	ErrGameEndWithoutDrawResult = errors.New("game end not allowed: draw result not found")
+	ErrCancelSettledRound       = errors.New("game cancel not allowed: round already settled")
+	ErrEventOutOfOrder          = errors.New("game event out of order: preceding events not received")
+	ErrEventConflict            = errors.New("game event id or seq already used by another event")
 )
@@ -0,0 +1,279 @@
+package service
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"dt-server/internal/engine"
+	infmysql "dt-server/internal/infra/mysql"
+	"dt-server/internal/model"
+	"dt-server/internal/shoe"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	decimal "github.com/shopspring/decimal"
+)
+
+// 性质：任意下注/结算/退款序列经 mutateWallet 写入钱包之后，钱包余额 == 初始余额 + Σ(账本入账) − Σ(账本扣款) + 其他事务的变更，
+// 每条账本的变动前余额为变更生效时的余额，金额始终为两位小数；
+// 余额不足只在钱包实际余额不足时返回（同一事务内多次扣款、并发事务改动余额都不会误拒），钱包不存在时自动创建。
+//
+// 钱包行由 walletSim 模拟，mutateWallet 的每条 SQL 经 go-sqlmock 按模拟的行状态应答并校验参数。
+func TestLedgerBalanceProperty(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+	infmysql.UseDB(db)
+	if infmysql.SQLX() == nil {
+		t.Fatalf("sqlx handle not initialized")
+	}
+
+	eng, err := engine.For("dt")
+	if err != nil {
+		t.Fatalf("engine: %v", err)
+	}
+	playTypes := []string{"dragon", "tiger", "tie", "dragon_big", "tiger_odd", "dragon_red", "suited_tie"}
+	odds := map[string]decimal.Decimal{
+		"dragon": decimal.RequireFromString("0.97"), "tiger": decimal.RequireFromString("0.97"),
+		"tie": decimal.NewFromInt(8), "suited_tie": decimal.NewFromInt(50),
+		"dragon_big": decimal.RequireFromString("0.95"), "tiger_odd": decimal.RequireFromString("0.75"),
+		"dragon_red": decimal.RequireFromString("0.9"),
+	}
+
+	var missReads, created int
+	for seed := int64(1); seed <= 40; seed++ {
+		rnd := rand.New(rand.NewSource(seed))
+		initial := randMoney(rnd, 2000)
+		w := &walletSim{t: t, mock: mock, rnd: rnd, userID: seed, currency: "CNY", balance: initial, exists: true}
+		if seed%2 == 1 {
+			// 钱包尚不存在：首笔上分时创建
+			w.balance, w.exists = decimal.Zero, false
+			tx := w.begin()
+			if before, after, err := w.mutate(tx, initial, true); err != nil || !before.IsZero() || !after.Equal(initial) {
+				t.Fatalf("seed=%d: first deposit: before=%s after=%s err=%v", seed, before, after, err)
+			}
+			w.commit(tx)
+		}
+		var journal []*model.WalletLedger
+
+		for round := 0; round < 20; round++ {
+			// 下注：同一事务内逐注扣款，余额不足的注被拒绝
+			tx := w.begin()
+			var orders []model.Order
+			for n := 1 + rnd.Intn(5); n > 0; n-- {
+				pt := playTypes[rnd.Intn(len(playTypes))]
+				amt := randMoney(rnd, 800)
+				before, _, err := w.mutate(tx, amt.Neg(), false)
+				if errors.Is(err, ErrInsufficientBalance) {
+					continue
+				}
+				if err != nil {
+					t.Fatalf("seed=%d round=%d: bet: %v", seed, round, err)
+				}
+				o := model.Order{BillNo: fmt.Sprintf("B%d-%d", round, n), PlayType: pt,
+					BetAmount: amt, BetOdds: odds[pt], Currency: "CNY"}
+				orders = append(orders, o)
+				l := &model.WalletLedger{BizType: BIZ_TYPE_BET, Amount: amt, BillNo: o.BillNo}
+				postLedgers(before, true, []*model.WalletLedger{l})
+				journal = append(journal, l)
+			}
+			w.commit(tx)
+
+			// 约 1/10 的牌局作废：全额退款；否则按引擎派彩，派彩为正的注单入账（均为一次变更，允许为负）
+			var credits []*model.WalletLedger
+			if rnd.Intn(10) == 0 {
+				for _, o := range orders {
+					credits = append(credits, &model.WalletLedger{BizType: 3, Amount: o.BetAmount, BillNo: o.BillNo})
+				}
+			} else {
+				payouts, err := computePayouts(eng, orders, randOutcome(t, eng, rnd), "R", "", "")
+				if err != nil {
+					t.Fatalf("seed=%d round=%d: payout: %v", seed, round, err)
+				}
+				for _, o := range orders {
+					if p := payouts[o.BillNo]; p.IsPositive() {
+						credits = append(credits, &model.WalletLedger{BizType: 2, Amount: p, BillNo: o.BillNo})
+					}
+				}
+			}
+			if len(credits) == 0 {
+				continue
+			}
+			tx = w.begin()
+			before, after, err := w.mutate(tx, ledgerTotal(credits), true)
+			if err != nil {
+				t.Fatalf("seed=%d round=%d: settle: %v", seed, round, err)
+			}
+			if got := postLedgers(before, false, credits); !got.Equal(after) {
+				t.Fatalf("seed=%d round=%d: ledger after=%s, wallet after=%s", seed, round, got, after)
+			}
+			journal = append(journal, credits...)
+			w.commit(tx)
+		}
+
+		// 钱包余额 == 初始余额 + 账本净额 + 其他事务的变更
+		net := decimal.Zero
+		for i, l := range journal {
+			delta := l.Amount
+			if l.BizType == BIZ_TYPE_BET {
+				delta = delta.Neg()
+			}
+			if !l.BeforeAmount.Add(delta).Equal(l.AfterAmount) {
+				t.Fatalf("seed=%d entry=%d: %s + %s != %s", seed, i, l.BeforeAmount, delta, l.AfterAmount)
+			}
+			if l.AfterAmount.Exponent() < -2 || l.Amount.Exponent() < -2 {
+				t.Fatalf("seed=%d entry=%d: more than 2 decimal places: amount=%s after=%s", seed, i, l.Amount, l.AfterAmount)
+			}
+			net = net.Add(delta)
+		}
+		if !w.balance.Equal(initial.Add(net).Add(w.external)) {
+			t.Fatalf("seed=%d: balance=%s, initial=%s, ledger net=%s, external=%s", seed, w.balance, initial, net, w.external)
+		}
+		if w.balance.IsNegative() {
+			t.Fatalf("seed=%d: negative balance %s", seed, w.balance)
+		}
+		missReads += w.missReads
+		created += w.created
+	}
+	if missReads == 0 || created == 0 {
+		t.Fatalf("fallback paths not exercised: miss=%d created=%d", missReads, created)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+}
+
+// walletSim 模拟 wallets 表中的一行，为 mutateWallet 的每条 SQL 登记 sqlmock 应答
+type walletSim struct {
+	t        *testing.T
+	mock     sqlmock.Sqlmock
+	rnd      *rand.Rand
+	userID   int64
+	currency string
+
+	balance decimal.Decimal // 行的当前值（本事务可见）
+	exists  bool
+	locked  bool // 本事务已持有行锁（已写入）
+
+	external  decimal.Decimal // 其他事务对余额的变更合计
+	missReads int             // 条件更新未命中后的加锁读取次数
+	created   int             // 新建钱包次数
+}
+
+func (w *walletSim) begin() *sqlx.Tx {
+	w.mock.ExpectBegin()
+	tx, err := infmysql.SQLX().BeginTxx(context.Background(), nil)
+	if err != nil {
+		w.t.Fatalf("begin: %v", err)
+	}
+	w.locked = false
+	return tx
+}
+
+func (w *walletSim) commit(tx *sqlx.Tx) {
+	w.mock.ExpectCommit()
+	if err := tx.Commit(); err != nil {
+		w.t.Fatalf("commit: %v", err)
+	}
+}
+
+// mutate 登记本次变更预期的 SQL 后调用 mutateWallet，并按模拟的行状态校验返回值
+func (w *walletSim) mutate(tx *sqlx.Tx, delta decimal.Decimal, allowNegative bool) (decimal.Decimal, decimal.Decimal, error) {
+	delta = delta.Round(2)
+
+	// 本事务尚未持有行锁时，其他事务可能先提交对余额的改动
+	if w.exists && !w.locked && w.rnd.Intn(4) == 0 {
+		d := randMoney(w.rnd, 300)
+		if w.rnd.Intn(2) == 0 && w.balance.GreaterThanOrEqual(d) {
+			d = d.Neg()
+		}
+		w.balance = w.balance.Add(d)
+		w.external = w.external.Add(d)
+	}
+
+	covered := allowNegative || !w.balance.Add(delta).IsNegative()
+	hit := w.exists && covered
+	w.expectUpdate(delta, allowNegative, hit)
+	wantBefore, wantErr := w.balance, error(nil)
+	if hit {
+		// 命中后经同一事务加锁读回变更后余额
+		w.mock.ExpectQuery(`^SELECT balance FROM wallets WHERE user_id = \? AND currency = \? FOR UPDATE$`).
+			WithArgs(w.userID, w.currency).
+			WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(w.balance.Add(delta).String()))
+	} else {
+		// 未命中：创建缺失的钱包并加锁读取，按读到的余额判断是否不足
+		w.missReads++
+		var inserted int64
+		if !w.exists {
+			w.exists, w.balance, inserted = true, decimal.Zero, 1
+			w.created++
+		}
+		w.mock.ExpectExec(`INSERT IGNORE INTO wallets`).WillReturnResult(sqlmock.NewResult(0, inserted))
+		w.mock.ExpectQuery(`^SELECT user_id, currency, balance, version, created_at, updated_at FROM wallets WHERE user_id = \? AND currency = \? FOR UPDATE$`).
+			WithArgs(w.userID, w.currency).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "currency", "balance", "version", "created_at", "updated_at"}).
+				AddRow(w.userID, w.currency, w.balance.String(), 0, 0, 0))
+		wantBefore = w.balance
+		if allowNegative || !w.balance.Add(delta).IsNegative() {
+			w.expectUpdate(delta, allowNegative, true)
+		} else {
+			wantErr = ErrInsufficientBalance
+		}
+	}
+	if wantErr == nil {
+		w.balance, w.locked = w.balance.Add(delta), true
+	}
+
+	before, after, err := mutateWallet(context.Background(), tx, w.userID, w.currency, delta, allowNegative)
+	if (err == nil) != (wantErr == nil) || (wantErr != nil && !errors.Is(err, wantErr)) {
+		w.t.Fatalf("mutate %s (allow_negative=%v): err=%v, want %v", delta, allowNegative, err, wantErr)
+	}
+	if err == nil && (!before.Equal(wantBefore) || !after.Equal(wantBefore.Add(delta))) {
+		w.t.Fatalf("mutate %s: before=%s after=%s, want before=%s", delta, before, after, wantBefore)
+	}
+	return before, after, err
+}
+
+// expectUpdate 登记 AddWalletBalance 的条件更新；hit 为是否命中（影响 1 行）
+func (w *walletSim) expectUpdate(delta decimal.Decimal, allowNegative, hit bool) {
+	args := []driver.Value{delta.String(), sqlmock.AnyArg(), w.userID, w.currency}
+	if !allowNegative {
+		args = append(args, delta.Neg().String())
+	}
+	var affected int64
+	if hit {
+		affected = 1
+	}
+	w.mock.ExpectExec(`^UPDATE wallets SET balance = balance \+ \?, version = version \+ 1, updated_at = \? WHERE user_id = \? AND currency = \?( AND balance >= \?)?$`).
+		WithArgs(args...).
+		WillReturnResult(sqlmock.NewResult(0, affected))
+}
+
+// randMoney 随机金额 (0, max]，两位小数
+func randMoney(rnd *rand.Rand, max int64) decimal.Decimal {
+	return decimal.New(rnd.Int63n(max*100)+1, -2)
+}
+
+// randOutcome 从一副牌随机发牌并由引擎判定结果（带花色，可结算全部边注）
+func randOutcome(t *testing.T, eng engine.GameEngine, rnd *rand.Rand) *engine.Outcome {
+	deck := shoe.NewDeck(1)
+	cardList, err := eng.Deal(func() (shoe.Card, error) { return deck[rnd.Intn(len(deck))], nil })
+	if err != nil {
+		t.Fatalf("deal: %v", err)
+	}
+	h, err := eng.ParseCards(cardList)
+	if err != nil {
+		t.Fatalf("parse %q: %v", cardList, err)
+	}
+	out, err := eng.DecideResult(h)
+	if err != nil {
+		t.Fatalf("decide %q: %v", cardList, err)
+	}
+	return out
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"dt-server/internal/engine"
+	infmysql "dt-server/internal/infra/mysql"
+	"dt-server/internal/model"
+
+	"github.com/jmoiron/sqlx"
+	decimal "github.com/shopspring/decimal"
+)
+
+// OddsChangedTopic 赔率表变更广播（Outbox 投递，各实例收到后失效本地缓存）
+const OddsChangedTopic = "odds_changed"
+
+// oddsBackdateTolerance 允许的生效时间回拨（客户端时钟误差）；更早的生效时间视为回溯修改，拒绝
+const oddsBackdateTolerance = 5 * time.Second
+
+// OddsScheduleInput 新增赔率版本参数
+type OddsScheduleInput struct {
+	GameID        string
+	RoomID        string // 空表示该游戏全部房间
+	PlatformID    int8   // -1 表示全部平台
+	Odds          map[string]decimal.Decimal
+	EffectiveFrom int64 // 0 表示立即生效
+	Remark        string
+	Operator      string
+	TraceID       string
+}
+
+type OddsService interface {
+	// CreateSchedule 新增赔率版本（不修改已有版本，已下注单按原版本追溯）
+	CreateSchedule(ctx context.Context, in OddsScheduleInput) (*OddsSchedule, error)
+	// RevokeSchedule 撤销赔率版本，之后的下注回退到次优版本或房间默认赔率；重复撤销直接返回
+	RevokeSchedule(ctx context.Context, version int64, operator, traceID string) (*OddsSchedule, error)
+	// ListSchedules 按游戏（可选房间）查询赔率版本（含已撤销，直接读库）
+	ListSchedules(ctx context.Context, gameID, roomID string, limit int) ([]*OddsSchedule, error)
+}
+
+type oddsService struct{}
+
+func NewOddsService() OddsService { return &oddsService{} }
+
+var ErrOddsScheduleNotFound = errors.New("odds schedule not found")
+
+func (s *oddsService) CreateSchedule(ctx context.Context, in OddsScheduleInput) (*OddsSchedule, error) {
+	now := time.Now().UnixMilli()
+	if in.EffectiveFrom == 0 {
+		in.EffectiveFrom = now
+	}
+	if err := validateOddsSchedule(ctx, &in, now); err != nil {
+		return nil, err
+	}
+
+	m := &model.OddsSchedule{
+		GameID:        in.GameID,
+		RoomID:        in.RoomID,
+		PlatformID:    in.PlatformID,
+		Odds:          toJSON(in.Odds),
+		EffectiveFrom: in.EffectiveFrom,
+		Operator:      in.Operator,
+		Remark:        in.Remark,
+		TraceID:       in.TraceID,
+	}
+	tx, err := infmysql.SQLX().BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if err := m.Insert(ctx, tx); err != nil {
+		fmt.Printf("[Odds] 新增赔率版本失败: game_id=%s, room_id=%s, error=%v, trace_id=%s\n",
+			in.GameID, in.RoomID, err, in.TraceID)
+		return nil, err
+	}
+	if err := createOddsChangedOutbox(ctx, tx, m, "created"); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("[Odds] 新增赔率版本: version=%d, game_id=%s, room_id=%s, platform_id=%d, effective_from=%d, operator=%s, trace_id=%s\n",
+		m.Version, m.GameID, m.RoomID, m.PlatformID, m.EffectiveFrom, in.Operator, in.TraceID)
+	s.reload(ctx, in.TraceID)
+	return oddsScheduleFromModel(m)
+}
+
+func (s *oddsService) RevokeSchedule(ctx context.Context, version int64, operator, traceID string) (*OddsSchedule, error) {
+	tx, err := infmysql.SQLX().BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	n, err := model.RevokeOddsSchedule(ctx, tx, version, operator, traceID)
+	if err != nil {
+		return nil, err
+	}
+	m, err := model.GetOddsSchedule(ctx, tx, version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrOddsScheduleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if n > 0 {
+		if err := createOddsChangedOutbox(ctx, tx, m, "revoked"); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	if n > 0 {
+		fmt.Printf("[Odds] 撤销赔率版本: version=%d, game_id=%s, room_id=%s, operator=%s, trace_id=%s\n",
+			version, m.GameID, m.RoomID, operator, traceID)
+		s.reload(ctx, traceID)
+	}
+	return oddsScheduleFromModel(m)
+}
+
+func (s *oddsService) ListSchedules(ctx context.Context, gameID, roomID string, limit int) ([]*OddsSchedule, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	rows, err := model.ListOddsSchedules(ctx, infmysql.SQLX(), gameID, roomID, limit)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*OddsSchedule, 0, len(rows))
+	for i := range rows {
+		sch, err := oddsScheduleFromModel(&rows[i])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, sch)
+	}
+	return out, nil
+}
+
+// reload 写入后立即重载本实例缓存，其他实例由 odds_changed 广播失效
+func (s *oddsService) reload(ctx context.Context, traceID string) {
+	if err := ReloadOdds(ctx); err != nil {
+		fmt.Printf("[Odds] 重载赔率缓存失败: error=%v, trace_id=%s\n", err, traceID)
+	}
+}
+
+// validateOddsSchedule 校验作用范围、玩法与赔率、生效时间
+func validateOddsSchedule(ctx context.Context, in *OddsScheduleInput, now int64) error {
+	eng, err := engine.For(in.GameID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBadRequest, err)
+	}
+	if in.RoomID != "" {
+		room, err := LookupRoom(ctx, in.RoomID)
+		if err != nil {
+			return err
+		}
+		if room.GameID != in.GameID {
+			return ErrRoomGameMismatch
+		}
+	}
+	if len(in.Odds) == 0 {
+		return fmt.Errorf("%w: odds required", ErrBadRequest)
+	}
+	for pt, o := range in.Odds {
+		if !eng.ValidPlayType(pt) {
+			return fmt.Errorf("%w: play type %s not supported by %s", ErrBadRequest, pt, eng.Name())
+		}
+		if !o.IsPositive() {
+			return fmt.Errorf("%w: odds of %s must be positive", ErrBadRequest, pt)
+		}
+	}
+	if in.EffectiveFrom < now-oddsBackdateTolerance.Milliseconds() {
+		return fmt.Errorf("%w: effective_from must not be in the past", ErrBadRequest)
+	}
+	return nil
+}
+
+func createOddsChangedOutbox(ctx context.Context, tx sqlx.ExtContext, m *model.OddsSchedule, action string) error {
+	return model.CreateOutbox(ctx, tx, OddsChangedTopic, fmt.Sprintf("odds:%d", m.Version), map[string]any{
+		"event":          OddsChangedTopic,
+		"action":         action,
+		"version":        m.Version,
+		"game_id":        m.GameID,
+		"room_id":        m.RoomID,
+		"platform_id":    m.PlatformID,
+		"effective_from": m.EffectiveFrom,
+		"trace_id":       m.TraceID,
+	})
+}
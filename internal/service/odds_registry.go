@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	infmysql "dt-server/internal/infra/mysql"
+	"dt-server/internal/model"
+
+	decimal "github.com/shopspring/decimal"
+)
+
+// oddsReloadInterval 赔率表变更检测间隔（兜底；管理接口写入与 MQ 广播会立即失效缓存）
+const oddsReloadInterval = 30 * time.Second
+
+// OddsSchedule 赔率版本（odds_schedules 行的解析结果，只读）
+type OddsSchedule struct {
+	Version       int64                      `json:"version"`
+	GameID        string                     `json:"game_id"`
+	RoomID        string                     `json:"room_id"`     // 空表示该游戏的全部房间
+	PlatformID    int8                       `json:"platform_id"` // -1 表示全部平台
+	Odds          map[string]decimal.Decimal `json:"odds"`
+	EffectiveFrom int64                      `json:"effective_from"`
+	Status        int8                       `json:"status"`
+	Operator      string                     `json:"operator"`
+	Remark        string                     `json:"remark"`
+	CreatedAt     int64                      `json:"created_at"`
+	UpdatedAt     int64                      `json:"updated_at"`
+}
+
+// specificity 作用范围的精确程度：房间+平台 > 房间 > 平台 > 游戏
+func (s *OddsSchedule) specificity() int {
+	n := 0
+	if s.RoomID != "" {
+		n += 2
+	}
+	if s.PlatformID != model.OddsAnyPlatform {
+		n++
+	}
+	return n
+}
+
+// oddsRegistry 有效赔率版本的进程内缓存
+// 读取时若距上次检测超过 oddsReloadInterval，则比对表指纹后按需整表重载；
+// 本实例的管理接口写入后立即失效，其他实例通过 odds_changed 广播失效（见 worker.StartOddsBroadcastConsumer）。
+// 缓存包含尚未生效的版本，按下注时刻选择，定时生效不依赖重载。
+type oddsRegistry struct {
+	mu        sync.RWMutex
+	schedules []*OddsSchedule
+	loaded    bool
+	count     int64
+	maxUpdAt  int64
+	checkedAt time.Time
+
+	reloadMu sync.Mutex
+}
+
+var oddsTable = &oddsRegistry{}
+
+// InvalidateOdds 失效赔率缓存，下一次读取时重载（MQ 广播与配置变更回调调用）
+func InvalidateOdds() {
+	oddsTable.mu.Lock()
+	oddsTable.checkedAt = time.Time{}
+	oddsTable.count = -1
+	oddsTable.mu.Unlock()
+}
+
+// ReloadOdds 强制重载赔率缓存
+func ReloadOdds(ctx context.Context) error {
+	return oddsTable.refresh(ctx, true)
+}
+
+// resolveOdds 按房间、平台与下注时刻选择玩法赔率
+// 取对该玩法生效（effective_from <= now）的版本中作用范围最精确者，同级取生效时间最晚、版本号最大者；
+// 没有匹配的版本时使用房间默认赔率 roomOdds，版本为 0。缓存不可用时同样降级为房间默认赔率。
+func resolveOdds(ctx context.Context, room *RoomConfig, platformID int8, playType string, roomOdds decimal.Decimal, now time.Time) (decimal.Decimal, int64) {
+	if err := oddsTable.refresh(ctx, false); err != nil {
+		fmt.Printf("[Odds] 读取赔率表失败，使用房间默认赔率: room_id=%s, error=%v\n", room.RoomID, err)
+		return roomOdds, 0
+	}
+	nowMs := now.UnixMilli()
+	pt := strings.ToLower(playType)
+
+	oddsTable.mu.RLock()
+	defer oddsTable.mu.RUnlock()
+	var best *OddsSchedule
+	for _, s := range oddsTable.schedules {
+		if s.GameID != room.GameID || s.EffectiveFrom > nowMs {
+			continue
+		}
+		if s.RoomID != "" && s.RoomID != room.RoomID {
+			continue
+		}
+		if s.PlatformID != model.OddsAnyPlatform && s.PlatformID != platformID {
+			continue
+		}
+		if _, ok := s.Odds[pt]; !ok {
+			continue
+		}
+		if best == nil || betterSchedule(s, best) {
+			best = s
+		}
+	}
+	if best == nil {
+		return roomOdds, 0
+	}
+	return best.Odds[pt], best.Version
+}
+
+func betterSchedule(a, b *OddsSchedule) bool {
+	if sa, sb := a.specificity(), b.specificity(); sa != sb {
+		return sa > sb
+	}
+	if a.EffectiveFrom != b.EffectiveFrom {
+		return a.EffectiveFrom > b.EffectiveFrom
+	}
+	return a.Version > b.Version
+}
+
+func (r *oddsRegistry) stale() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return !r.loaded || time.Since(r.checkedAt) >= oddsReloadInterval
+}
+
+// refresh 检测并按需重载；已加载过的缓存在数据库异常时继续使用旧快照，只有首次加载失败才返回错误
+func (r *oddsRegistry) refresh(ctx context.Context, force bool) error {
+	if !force && !r.stale() {
+		return nil
+	}
+	r.reloadMu.Lock()
+	defer r.reloadMu.Unlock()
+	if !force && !r.stale() {
+		return nil
+	}
+
+	db := infmysql.SQLX()
+	count, maxUpdAt, err := model.OddsSchedulesFingerprint(ctx, db)
+	if err != nil {
+		return r.degrade(err)
+	}
+	r.mu.RLock()
+	unchanged := r.loaded && count == r.count && maxUpdAt == r.maxUpdAt
+	r.mu.RUnlock()
+	if unchanged && !force {
+		r.mu.Lock()
+		r.checkedAt = time.Now()
+		r.mu.Unlock()
+		return nil
+	}
+
+	rows, err := model.ListActiveOddsSchedules(ctx, db)
+	if err != nil {
+		return r.degrade(err)
+	}
+	next := make([]*OddsSchedule, 0, len(rows))
+	for i := range rows {
+		s, err := oddsScheduleFromModel(&rows[i])
+		if err != nil {
+			fmt.Printf("[Odds] 赔率版本解析失败，已跳过: version=%d, error=%v\n", rows[i].Version, err)
+			continue
+		}
+		next = append(next, s)
+	}
+
+	r.mu.Lock()
+	r.schedules = next
+	r.loaded = true
+	r.count = count
+	r.maxUpdAt = maxUpdAt
+	r.checkedAt = time.Now()
+	r.mu.Unlock()
+
+	fmt.Printf("[Odds] 赔率缓存已重载: schedules=%d, max_updated_at=%d\n", len(next), maxUpdAt)
+	return nil
+}
+
+func (r *oddsRegistry) degrade(err error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.loaded {
+		return fmt.Errorf("load odds schedules: %w", err)
+	}
+	r.checkedAt = time.Now()
+	fmt.Printf("[Odds] 赔率缓存刷新失败，继续使用旧版本: error=%v\n", err)
+	return nil
+}
+
+// oddsScheduleFromModel 解析 odds_schedules 行
+func oddsScheduleFromModel(m *model.OddsSchedule) (*OddsSchedule, error) {
+	s := &OddsSchedule{
+		Version:       m.Version,
+		GameID:        m.GameID,
+		RoomID:        m.RoomID,
+		PlatformID:    m.PlatformID,
+		EffectiveFrom: m.EffectiveFrom,
+		Status:        m.Status,
+		Operator:      m.Operator,
+		Remark:        m.Remark,
+		CreatedAt:     m.CreatedAt,
+		UpdatedAt:     m.UpdatedAt,
+	}
+	if err := json.Unmarshal([]byte(m.Odds), &s.Odds); err != nil {
+		return nil, fmt.Errorf("odds: %w", err)
+	}
+	return s, nil
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	infmysql "dt-server/internal/infra/mysql"
+	infrds "dt-server/internal/infra/redis"
+	"dt-server/internal/model"
+	"dt-server/internal/replay"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// replayMaxRounds 按时间范围回放时单次最多处理的局数
+const replayMaxRounds = 500
+
+type ReplayInput struct {
+	GameRoundID string // 指定单局；为空时按 [From, To] 时间范围（毫秒，按审计事件时间）
+	From        int64
+	To          int64
+	Rebuild     bool   // 有差异时用回放结果重写 game_round_info
+	Operator    string // 操作人（重建时记录日志）
+	TraceID     string
+}
+
+// ReplayReport 单局回放结果
+type ReplayReport struct {
+	GameRoundID string             `json:"game_round_id"`
+	Expected    *replay.Projection `json:"expected"`
+	RowExists   bool               `json:"row_exists"`
+	Diffs       []replay.FieldDiff `json:"diffs"`
+	Consistent  bool               `json:"consistent"` // 无差异且审计流无异常
+	Rebuilt     bool               `json:"rebuilt"`
+	Skipped     string             `json:"skipped,omitempty"` // 未重建的原因
+}
+
+type ReplayService interface {
+	// Replay 回放单局或时间范围内的牌局，报告与 game_round_info 的差异，可选重建
+	Replay(ctx context.Context, in ReplayInput) ([]*ReplayReport, error)
+}
+
+type replayService struct{}
+
+func NewReplayService() ReplayService { return &replayService{} }
+
+func (s *replayService) Replay(ctx context.Context, in ReplayInput) ([]*ReplayReport, error) {
+	ids := []string{in.GameRoundID}
+	if in.GameRoundID == "" {
+		if in.From <= 0 || in.To < in.From {
+			return nil, ErrBadRequest
+		}
+		var err error
+		ids, err = model.ListAuditRoundIDs(ctx, infmysql.SQLX(), in.From, in.To, replayMaxRounds)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	fmt.Printf("[Replay] 开始回放: round_id=%s, from=%d, to=%d, rounds=%d, rebuild=%v, operator=%s, trace_id=%s\n",
+		in.GameRoundID, in.From, in.To, len(ids), in.Rebuild, in.Operator, in.TraceID)
+
+	reports := make([]*ReplayReport, 0, len(ids))
+	inconsistent := 0
+	for _, id := range ids {
+		rep, err := replayRound(ctx, id, in)
+		if err != nil {
+			fmt.Printf("[Replay] 回放失败: round_id=%s, error=%v, trace_id=%s\n", id, err, in.TraceID)
+			return nil, err
+		}
+		if !rep.Consistent {
+			inconsistent++
+		}
+		reports = append(reports, rep)
+	}
+
+	fmt.Printf("[Replay] 回放完成: rounds=%d, inconsistent=%d, trace_id=%s\n", len(reports), inconsistent, in.TraceID)
+	return reports, nil
+}
+
+// replayRound 回放单局；重建时在事务内锁定牌局行后读取审计并重写
+func replayRound(ctx context.Context, roundID string, in ReplayInput) (*ReplayReport, error) {
+	tx, err := infmysql.SQLX().BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var actual *model.GameRoundInfo
+	if in.Rebuild {
+		actual, err = model.GetRoundForUpdate(ctx, tx, roundID)
+	} else {
+		actual, err = model.GetRoundInfo(ctx, tx, roundID)
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		actual = nil
+	}
+
+	events, err := model.ListAuditsByRound(ctx, tx, roundID)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		if actual == nil {
+			return nil, ErrGameRoundNotFound
+		}
+		return &ReplayReport{GameRoundID: roundID, RowExists: true, Diffs: []replay.FieldDiff{}, Skipped: "no audit events"}, nil
+	}
+	settle, err := loadSettlementLog(ctx, tx, roundID)
+	if err != nil {
+		return nil, err
+	}
+
+	p := replay.Fold(roundID, events, settle)
+	rep := &ReplayReport{
+		GameRoundID: roundID,
+		Expected:    p,
+		RowExists:   actual != nil,
+		Diffs:       p.Diff(actual),
+	}
+	rep.Consistent = len(rep.Diffs) == 0 && len(p.Anomalies) == 0
+
+	switch {
+	case !in.Rebuild || len(rep.Diffs) == 0:
+		return rep, nil
+	case len(p.Anomalies) > 0:
+		// 审计流本身不完整时回放结果不可信，需人工核查
+		rep.Skipped = "audit anomalies"
+		return rep, nil
+	}
+
+	if err := model.UpsertRoundProjection(ctx, tx, p.Apply(actual)); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	rep.Rebuilt = true
+	fmt.Printf("[Replay] 已按审计重建牌局: round_id=%s, diffs=%v, operator=%s, trace_id=%s\n",
+		roundID, rep.Diffs, in.Operator, in.TraceID)
+
+	// 重建后清理相关缓存，下次读取时按新数据生成
+	if r := infrds.Client(); r != nil {
+		_ = r.Del(ctx, infrds.RoundInfoKey(roundID), infrds.RoundStateKey(roundID), infrds.RoundResultKey(roundID),
+			infrds.RoomRoadsKey(p.RoomID)).Err()
+	}
+	return rep, nil
+}
+
+// loadSettlementLog 查询结算日志，未结算返回 nil
+func loadSettlementLog(ctx context.Context, exec sqlx.QueryerContext, roundID string) (*model.SettlementLog, error) {
+	sl, err := model.GetSettlementLog(ctx, exec, roundID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return sl, err
+}
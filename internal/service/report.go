@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	infmysql "dt-server/internal/infra/mysql"
+	"dt-server/internal/model"
+)
+
+// maxReportRange 报表单次查询的最大时间跨度
+const maxReportRange = 31 * 24 * time.Hour
+
+// CurrencyReport 按币种分组的下注/派彩报表
+type CurrencyReport struct {
+	From  int64                   `json:"from"`
+	To    int64                   `json:"to"`
+	Items []model.CurrencySummary `json:"items"`
+}
+
+type ReportService interface {
+	// CurrencyReport 按币种汇总下注额与派彩；From/To 为 0 时默认最近 24 小时
+	CurrencyReport(ctx context.Context, f model.CurrencySummaryFilter) (*CurrencyReport, error)
+}
+
+type reportService struct{}
+
+func NewReportService() ReportService { return &reportService{} }
+
+func (s *reportService) CurrencyReport(ctx context.Context, f model.CurrencySummaryFilter) (*CurrencyReport, error) {
+	if f.To == 0 {
+		f.To = time.Now().UnixMilli()
+	}
+	if f.From == 0 {
+		f.From = f.To - (24 * time.Hour).Milliseconds()
+	}
+	if f.From >= f.To {
+		return nil, fmt.Errorf("%w: from must be before to", ErrBadRequest)
+	}
+	if f.To-f.From > maxReportRange.Milliseconds() {
+		return nil, fmt.Errorf("%w: time range exceeds 31 days", ErrBadRequest)
+	}
+	items, err := model.SumOrdersByCurrency(ctx, infmysql.SQLX(), f)
+	if err != nil {
+		return nil, err
+	}
+	return &CurrencyReport{From: f.From, To: f.To, Items: items}, nil
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	infmysql "dt-server/internal/infra/mysql"
+	infrds "dt-server/internal/infra/redis"
+	"dt-server/internal/model"
+	"dt-server/internal/road"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// roadMaxRounds 路单最多保留的局数：未启用牌靴或种子发牌（不消耗牌靴）的房间一直不换靴，只保留最近的局
+	roadMaxRounds = 300
+	// roadCacheTTL 路单缓存有效期，过期后下次读取或结算时重建
+	roadCacheTTL = 24 * time.Hour
+)
+
+// RoomRoads 房间当前牌靴的路单
+type RoomRoads struct {
+	RoomID      string `json:"room_id"`
+	GameID      string `json:"game_id"`
+	ShoeNo      string `json:"shoe_no"`       // 当前牌靴靴号（未启用服务端牌靴时为空）
+	LastRoundID string `json:"last_round_id"` // 最后计入路单的局
+	UpdatedAt   int64  `json:"updated_at"`
+	*road.Board
+}
+
+type RoadService interface {
+	// GetRoads 查询房间当前牌靴的路单
+	GetRoads(ctx context.Context, roomID string) (*RoomRoads, error)
+}
+
+type roadService struct{}
+
+func NewRoadService() RoadService { return &roadService{} }
+
+func (s *roadService) GetRoads(ctx context.Context, roomID string) (*RoomRoads, error) {
+	if strings.TrimSpace(roomID) == "" {
+		return nil, ErrBadRequest
+	}
+	room, err := LookupRoom(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	rr, cached, err := loadRoads(ctx, infmysql.SQLX(), room.RoomID, room.GameID)
+	if err != nil {
+		return nil, err
+	}
+	if !cached {
+		// 读路径只在缓存缺失时回填，避免覆盖结算路径刚写入的新路单
+		storeRoads(ctx, rr, false)
+	}
+	return rr, nil
+}
+
+// loadRoads 读取房间路单：缓存命中且仍是当前牌靴时直接返回，否则由 game_round_info 重建
+// 返回值 cached 表示是否来自缓存
+func loadRoads(ctx context.Context, exec sqlx.ExtContext, roomID, gameID string) (*RoomRoads, bool, error) {
+	shoeNo, since, err := currentShoe(ctx, exec, roomID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if r := infrds.Client(); r != nil {
+		if bs, _ := r.Get(ctx, infrds.RoomRoadsKey(roomID)).Bytes(); len(bs) > 0 {
+			var rr RoomRoads
+			if err := json.Unmarshal(bs, &rr); err == nil && rr.Board != nil && rr.ShoeNo == shoeNo {
+				return &rr, true, nil
+			}
+		}
+	}
+
+	rows, err := model.ListSettledResultsByRoom(ctx, exec, roomID, since, roadMaxRounds)
+	if err != nil {
+		return nil, false, err
+	}
+	results := make([]string, 0, len(rows))
+	rr := &RoomRoads{RoomID: roomID, GameID: gameID, ShoeNo: shoeNo, UpdatedAt: time.Now().UnixMilli()}
+	for _, row := range rows {
+		results = append(results, row.GameResultStr)
+		rr.LastRoundID = row.GameRoundID
+	}
+	rr.Board = road.Build(results)
+	fmt.Printf("[Road] 路单重建: room_id=%s, shoe_no=%s, rounds=%d\n", roomID, shoeNo, len(results))
+	return rr, false, nil
+}
+
+// nextRoads 在开奖事务内计算计入本局结果后的路单（本局尚未标记已结算，不会被重建重复计入）
+func nextRoads(ctx context.Context, tx *sqlx.Tx, round *model.GameRoundInfo, result string) (*RoomRoads, error) {
+	rr, _, err := loadRoads(ctx, tx, round.RoomID, round.GameID)
+	if err != nil {
+		return nil, err
+	}
+	if rr.LastRoundID == round.GameRoundID {
+		return rr, nil
+	}
+	if rr.Len() >= roadMaxRounds {
+		// 超出保留局数：丢弃最早一局后重算（下三路依赖整条大路，不能只截掉头部）
+		rr.Board = road.Build(append(rr.Bead[rr.Len()-roadMaxRounds+1:], result))
+	} else {
+		rr.Add(result)
+	}
+	rr.LastRoundID = round.GameRoundID
+	rr.UpdatedAt = time.Now().UnixMilli()
+	return rr, nil
+}
+
+// storeRoads 写入路单缓存；overwrite=false 时仅在缓存不存在时写入
+func storeRoads(ctx context.Context, rr *RoomRoads, overwrite bool) {
+	r := infrds.Client()
+	if r == nil {
+		return
+	}
+	b, err := json.Marshal(rr)
+	if err != nil {
+		return
+	}
+	key := infrds.RoomRoadsKey(rr.RoomID)
+	if overwrite {
+		err = r.Set(ctx, key, b, roadCacheTTL).Err()
+	} else {
+		err = r.SetNX(ctx, key, b, roadCacheTTL).Err()
+	}
+	if err != nil {
+		fmt.Printf("[Road] 写入路单缓存失败: room_id=%s, error=%v\n", rr.RoomID, err)
+	}
+}
+
+// currentShoe 房间使用中的牌靴与开靴时间；房间没有牌靴时返回空靴号，路单取最近 roadMaxRounds 局
+func currentShoe(ctx context.Context, exec sqlx.ExtContext, roomID string) (string, int64, error) {
+	sh, err := model.GetActiveShoe(ctx, exec, roomID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", 0, nil
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	return sh.ShoeNo, sh.CreatedAt, nil
+}
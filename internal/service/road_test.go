@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"dt-server/internal/model"
+	"dt-server/internal/road"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+// 路单超出 roadMaxRounds 局时丢弃最早一局后由珠盘路重建：大路与下三路都按截断后的序列重新推导，
+// 而不是在原路单上追加；未超出时直接追加本局，已计入的局不重复计入。
+func TestNextRoadsRebuild(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+	xdb := sqlx.NewDb(db, "mysql")
+
+	// 第一局庄、第二局和：截掉第一局后和局成为大路前的和局，下三路也随之整体左移
+	pattern := []string{"banker", "banker", "player", "player", "player", "banker", "tie", "banker", "player"}
+	full := []string{"banker", "tie"}
+	for len(full) < roadMaxRounds {
+		full = append(full, pattern[len(full)%len(pattern)])
+	}
+
+	cases := []struct {
+		name    string
+		history []string
+		roundID string
+		want    []string // 计入本局后的珠盘路
+	}{
+		{"truncate", full, "R301", append(append([]string{}, full[1:]...), "player")},
+		{"append", full[:10], "R11", append(append([]string{}, full[:10]...), "player")},
+		{"already counted", full[:10], "R10", full[:10]},
+	}
+	for _, c := range cases {
+		mock.ExpectBegin()
+		tx, err := xdb.BeginTxx(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("%s: begin: %v", c.name, err)
+		}
+		mock.ExpectQuery(`^SELECT id, shoe_no, game_id, room_id, status, created_at\s+FROM shoes WHERE room_id = \? AND status = 1`).
+			WithArgs("room-1").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "shoe_no", "game_id", "room_id", "status", "created_at"}))
+		// 按 id 倒序返回，由 ListSettledResultsByRoom 翻转为时间顺序
+		rows := sqlmock.NewRows([]string{"game_round_id", "game_result_str", "game_draw_time"})
+		for i := len(c.history) - 1; i >= 0; i-- {
+			rows.AddRow(fmt.Sprintf("R%d", i+1), c.history[i], int64(i+1))
+		}
+		mock.ExpectQuery(`^SELECT game_round_id, game_result_str, game_draw_time\s+FROM game_round_info`).
+			WithArgs("room-1", int64(0), roadMaxRounds).
+			WillReturnRows(rows)
+
+		round := &model.GameRoundInfo{RoomID: "room-1", GameID: "dt", GameRoundID: c.roundID}
+		rr, err := nextRoads(context.Background(), tx, round, "player")
+		if err != nil {
+			t.Fatalf("%s: nextRoads: %v", c.name, err)
+		}
+		if rr.Len() != len(c.want) || rr.LastRoundID != c.roundID {
+			t.Fatalf("%s: len=%d last_round_id=%s, want %d/%s", c.name, rr.Len(), rr.LastRoundID, len(c.want), c.roundID)
+		}
+		if want := road.Build(c.want); !reflect.DeepEqual(rr.Board, want) {
+			t.Fatalf("%s: board differs from rebuilding %d rounds", c.name, len(c.want))
+		}
+		mock.ExpectRollback()
+		if err := tx.Rollback(); err != nil {
+			t.Fatalf("%s: rollback: %v", c.name, err)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sql expectations: %v", err)
+	}
+
+	// 截断后的路单与在完整路单上追加的结果不同，确认走的是重建
+	rebuilt := road.Build(append(append([]string{}, full[1:]...), "player"))
+	appended := road.Build(append(append([]string{}, full...), "player"))
+	if rebuilt.LeadingTies != 1 || appended.LeadingTies != 0 {
+		t.Fatalf("leading ties rebuilt=%d appended=%d, want 1/0", rebuilt.LeadingTies, appended.LeadingTies)
+	}
+	if reflect.DeepEqual(rebuilt.BigEye, appended.BigEye) {
+		t.Fatalf("big eye unchanged after dropping the first round")
+	}
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	infmysql "dt-server/internal/infra/mysql"
+	"dt-server/internal/model"
+)
+
+// RoomInput 新建/修改房间参数（修改时整行覆盖）
+type RoomInput struct {
+	Config  RoomConfig
+	TraceID string
+}
+
+type RoomService interface {
+	// CreateRoom 新建房间，room_id 已存在返回 ErrRoomExists
+	CreateRoom(ctx context.Context, in RoomInput) (*RoomConfig, error)
+	// UpdateRoom 修改房间配置（含开放/维护/关闭），房间不存在返回 ErrRoomNotFound
+	UpdateRoom(ctx context.Context, in RoomInput) (*RoomConfig, error)
+	// GetRoom 查询房间（直接读库，不经注册表缓存）
+	GetRoom(ctx context.Context, roomID string) (*RoomConfig, error)
+	// ListRooms 查询全部房间（直接读库，不经注册表缓存）
+	ListRooms(ctx context.Context) ([]*RoomConfig, error)
+}
+
+type roomService struct{}
+
+func NewRoomService() RoomService { return &roomService{} }
+
+var ErrRoomExists = errors.New("room already exists")
+
+func (s *roomService) CreateRoom(ctx context.Context, in RoomInput) (*RoomConfig, error) {
+	m, err := roomModelFromConfig(&in.Config, in.TraceID)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.Insert(ctx, infmysql.SQLX()); err != nil {
+		if isMySQLDuplicateKeyError(err) {
+			return nil, ErrRoomExists
+		}
+		fmt.Printf("[Room] 新建房间失败: room_id=%s, error=%v, trace_id=%s\n", m.RoomID, err, in.TraceID)
+		return nil, err
+	}
+	fmt.Printf("[Room] 新建房间: room_id=%s, game_id=%s, status=%d, trace_id=%s\n",
+		m.RoomID, m.GameID, m.Status, in.TraceID)
+	return s.afterWrite(ctx, m.RoomID, in.TraceID)
+}
+
+func (s *roomService) UpdateRoom(ctx context.Context, in RoomInput) (*RoomConfig, error) {
+	m, err := roomModelFromConfig(&in.Config, in.TraceID)
+	if err != nil {
+		return nil, err
+	}
+	n, err := model.UpdateRoom(ctx, infmysql.SQLX(), m)
+	if err != nil {
+		fmt.Printf("[Room] 修改房间失败: room_id=%s, error=%v, trace_id=%s\n", m.RoomID, err, in.TraceID)
+		return nil, err
+	}
+	if n == 0 {
+		return nil, ErrRoomNotFound
+	}
+	fmt.Printf("[Room] 修改房间: room_id=%s, game_id=%s, status=%d, bet_window_sec=%d, trace_id=%s\n",
+		m.RoomID, m.GameID, m.Status, m.BetWindowSec, in.TraceID)
+	return s.afterWrite(ctx, m.RoomID, in.TraceID)
+}
+
+// afterWrite 写入后立即重载本实例注册表，并返回库中最新配置
+func (s *roomService) afterWrite(ctx context.Context, roomID, traceID string) (*RoomConfig, error) {
+	if err := ReloadRooms(ctx); err != nil {
+		fmt.Printf("[Room] 重载房间注册表失败: room_id=%s, error=%v, trace_id=%s\n", roomID, err, traceID)
+	}
+	return s.GetRoom(ctx, roomID)
+}
+
+func (s *roomService) GetRoom(ctx context.Context, roomID string) (*RoomConfig, error) {
+	m, err := model.GetRoom(ctx, infmysql.SQLX(), roomID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRoomNotFound
+		}
+		return nil, err
+	}
+	return roomConfigFromModel(m)
+}
+
+func (s *roomService) ListRooms(ctx context.Context) ([]*RoomConfig, error) {
+	rows, err := model.ListRooms(ctx, infmysql.SQLX())
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*RoomConfig, 0, len(rows))
+	for i := range rows {
+		c, err := roomConfigFromModel(&rows[i])
+		if err != nil {
+			return nil, fmt.Errorf("room %s: %w", rows[i].RoomID, err)
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+// roomModelFromConfig 校验并转换为 rooms 表行；校验失败返回包装了 ErrBadRequest 的错误
+func roomModelFromConfig(c *RoomConfig, traceID string) (*model.Room, error) {
+	if err := validateRoomConfig(c); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadRequest, err)
+	}
+	// 币种须已配置，限红金额不能超出币种精度（注册表加载时不校验，币种配置调整不会使已有房间失效）
+	rule, err := lookupCurrency(c.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("%w: currency %s not configured", ErrBadRequest, c.Currency)
+	}
+	for pt, l := range c.BetLimits {
+		if !l.Min.Equal(rule.round(l.Min)) || !l.Max.Equal(rule.round(l.Max)) {
+			return nil, fmt.Errorf("%w: bet_limits of %s exceed %s precision (%d decimals)", ErrBadRequest, pt, c.Currency, rule.Precision)
+		}
+	}
+	m := &model.Room{
+		RoomID:       c.RoomID,
+		GameID:       c.GameID,
+		RoomName:     c.RoomName,
+		BetWindowSec: c.BetWindowSec,
+		BetLimits:    toJSON(c.BetLimits),
+		Odds:         toJSON(c.Odds),
+		Currency:     c.Currency,
+		Status:       c.Status,
+		TraceID:      traceID,
+	}
+	if c.IsVIP {
+		m.IsVIP = 1
+	}
+	if c.DrawApproval {
+		m.DrawApproval = 1
+	}
+	if len(c.DrawScanners) > 0 {
+		m.DrawScanners = toJSON(c.DrawScanners)
+	}
+	if c.CancelAllowed {
+		m.CancelAllowed = 1
+	}
+	m.CancelCutoffSec = c.CancelCutoffSec
+	m.LiabilityCap = c.LiabilityCap.Round(2)
+	return m, nil
+}
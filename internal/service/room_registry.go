@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"dt-server/internal/engine"
+	infmysql "dt-server/internal/infra/mysql"
+	"dt-server/internal/model"
+
+	decimal "github.com/shopspring/decimal"
+)
+
+// 房间状态（rooms.status）
+const (
+	RoomStatusOpen        int8 = 1 // 开放：可开局、可下注
+	RoomStatusMaintenance int8 = 2 // 维护：不再开新局、不接受下注，进行中的牌局可以继续推进与结算
+	RoomStatusClosed      int8 = 3 // 关闭
+)
+
+// roomReloadInterval 注册表变更检测间隔：超过该间隔的读取会先比对一次表指纹
+const roomReloadInterval = 5 * time.Second
+
+var (
+	ErrRoomNotFound     = errors.New("room not found")
+	ErrRoomNotOpen      = errors.New("room not open")
+	ErrRoomGameMismatch = errors.New("game_id does not match room")
+)
+
+// BetLimit 单个玩法的限红
+type BetLimit struct {
+	Min decimal.Decimal `json:"min"`
+	Max decimal.Decimal `json:"max"`
+}
+
+// RoomConfig 房间配置（注册表中的只读快照，调用方不得修改）
+type RoomConfig struct {
+	RoomID       string                     `json:"room_id"`
+	GameID       string                     `json:"game_id"`
+	RoomName     string                     `json:"room_name"`
+	BetWindowSec int                        `json:"bet_window_sec"`
+	BetLimits    map[string]BetLimit        `json:"bet_limits"`
+	Odds         map[string]decimal.Decimal `json:"odds"`
+	Currency     string                     `json:"currency"`
+	Status       int8                       `json:"status"`
+	IsVIP        bool                       `json:"is_vip"`
+	DrawApproval bool                       `json:"draw_approval"` // 开奖结果需另一名管理员复核（或双扫描一致）后才结算
+	// DrawScanners 可自动比对的扫描设备标识（auth.admin.scanners 的 id，小写）；两台不同设备的结果一致时免人工复核
+	DrawScanners []string `json:"draw_scanners"`
+	// 撤单：CancelAllowed 为 true 时下注中允许玩家撤单，bet_stop_time 前 CancelCutoffSec 秒起不再接受撤单
+	CancelAllowed   bool `json:"cancel_allowed"`
+	CancelCutoffSec int  `json:"cancel_cutoff_sec"`
+	// LiabilityCap 单局任一结果开出时庄家净赔付上限（房间币种），为 0 表示不限；超出的下注被拒绝
+	LiabilityCap decimal.Decimal `json:"liability_cap"`
+	UpdatedAt    int64           `json:"updated_at"`
+}
+
+// ScannerAllowed 扫描设备是否在本房间的自动比对白名单中
+func (c *RoomConfig) ScannerAllowed(source string) bool {
+	for _, id := range c.DrawScanners {
+		if id == source {
+			return true
+		}
+	}
+	return false
+}
+
+// LimitFor 玩法限红，未配置的玩法返回 false
+func (c *RoomConfig) LimitFor(playType string) (BetLimit, bool) {
+	l, ok := c.BetLimits[strings.ToLower(playType)]
+	return l, ok
+}
+
+// OddsFor 玩法赔率，未配置的玩法返回 false
+func (c *RoomConfig) OddsFor(playType string) (decimal.Decimal, bool) {
+	o, ok := c.Odds[strings.ToLower(playType)]
+	return o, ok
+}
+
+// BetWindow 下注窗口时长
+func (c *RoomConfig) BetWindow() time.Duration {
+	return time.Duration(c.BetWindowSec) * time.Second
+}
+
+// acceptsGame 入参 game_id 为空或与房间一致
+func (c *RoomConfig) acceptsGame(gameID string) bool {
+	return gameID == "" || gameID == c.GameID
+}
+
+// roomRegistry 房间配置内存注册表
+// 读多写少：读取时若距上次检测超过 roomReloadInterval，则查询表指纹 (COUNT(*), MAX(updated_at))，
+// 指纹变化才整表重载；管理接口修改后立即强制重载本实例，其他实例在下一个检测间隔内生效。
+type roomRegistry struct {
+	mu        sync.RWMutex
+	rooms     map[string]*RoomConfig
+	loaded    bool
+	count     int64
+	maxUpdAt  int64
+	checkedAt time.Time
+
+	reloadMu sync.Mutex // 串行化重载，避免并发读取同时打到数据库
+}
+
+var rooms = &roomRegistry{}
+
+// LookupRoom 从注册表读取房间配置，未登记的房间返回 ErrRoomNotFound
+func LookupRoom(ctx context.Context, roomID string) (*RoomConfig, error) {
+	if err := rooms.refresh(ctx, false); err != nil {
+		return nil, err
+	}
+	rooms.mu.RLock()
+	defer rooms.mu.RUnlock()
+	if c, ok := rooms.rooms[roomID]; ok {
+		return c, nil
+	}
+	return nil, ErrRoomNotFound
+}
+
+// ReloadRooms 强制重载房间注册表
+func ReloadRooms(ctx context.Context) error {
+	return rooms.refresh(ctx, true)
+}
+
+func (r *roomRegistry) stale() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return !r.loaded || time.Since(r.checkedAt) >= roomReloadInterval
+}
+
+// refresh 检测并按需重载；已加载过的注册表在数据库异常时继续使用旧快照（降级），只有首次加载失败才返回错误
+func (r *roomRegistry) refresh(ctx context.Context, force bool) error {
+	if !force && !r.stale() {
+		return nil
+	}
+	r.reloadMu.Lock()
+	defer r.reloadMu.Unlock()
+	if !force && !r.stale() {
+		return nil // 等锁期间已被其他请求刷新
+	}
+
+	db := infmysql.SQLX()
+	count, maxUpdAt, err := model.RoomsFingerprint(ctx, db)
+	if err != nil {
+		return r.degrade(err)
+	}
+
+	r.mu.RLock()
+	unchanged := r.loaded && count == r.count && maxUpdAt == r.maxUpdAt
+	r.mu.RUnlock()
+	if unchanged && !force {
+		r.mu.Lock()
+		r.checkedAt = time.Now()
+		r.mu.Unlock()
+		return nil
+	}
+
+	rows, err := model.ListRooms(ctx, db)
+	if err != nil {
+		return r.degrade(err)
+	}
+	next := make(map[string]*RoomConfig, len(rows))
+	for i := range rows {
+		c, err := roomConfigFromModel(&rows[i])
+		if err != nil {
+			// 单个房间配置损坏不影响其他房间，该房间视为未登记
+			fmt.Printf("[Room] 房间配置解析失败，已跳过: room_id=%s, error=%v\n", rows[i].RoomID, err)
+			continue
+		}
+		next[c.RoomID] = c
+	}
+
+	r.mu.Lock()
+	r.rooms = next
+	r.loaded = true
+	r.count = count
+	r.maxUpdAt = maxUpdAt
+	r.checkedAt = time.Now()
+	r.mu.Unlock()
+
+	fmt.Printf("[Room] 房间注册表已重载: rooms=%d, max_updated_at=%d\n", len(next), maxUpdAt)
+	return nil
+}
+
+func (r *roomRegistry) degrade(err error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.loaded {
+		return fmt.Errorf("load rooms: %w", err)
+	}
+	r.checkedAt = time.Now()
+	fmt.Printf("[Room] 房间注册表刷新失败，继续使用旧配置: error=%v\n", err)
+	return nil
+}
+
+// roomConfigFromModel 解析 rooms 表行
+func roomConfigFromModel(m *model.Room) (*RoomConfig, error) {
+	c := &RoomConfig{
+		RoomID:          m.RoomID,
+		GameID:          m.GameID,
+		RoomName:        m.RoomName,
+		BetWindowSec:    m.BetWindowSec,
+		Currency:        m.Currency,
+		Status:          m.Status,
+		IsVIP:           m.IsVIP == 1,
+		DrawApproval:    m.DrawApproval == 1,
+		CancelAllowed:   m.CancelAllowed == 1,
+		CancelCutoffSec: m.CancelCutoffSec,
+		LiabilityCap:    m.LiabilityCap,
+		UpdatedAt:       m.UpdatedAt,
+	}
+	if err := json.Unmarshal([]byte(m.BetLimits), &c.BetLimits); err != nil {
+		return nil, fmt.Errorf("bet_limits: %w", err)
+	}
+	if err := json.Unmarshal([]byte(m.Odds), &c.Odds); err != nil {
+		return nil, fmt.Errorf("odds: %w", err)
+	}
+	if m.DrawScanners != "" {
+		if err := json.Unmarshal([]byte(m.DrawScanners), &c.DrawScanners); err != nil {
+			return nil, fmt.Errorf("draw_scanners: %w", err)
+		}
+	}
+	if err := validateRoomConfig(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// validateRoomConfig 校验房间配置的业务约束（管理接口写入前与注册表加载时共用）
+func validateRoomConfig(c *RoomConfig) error {
+	if strings.TrimSpace(c.RoomID) == "" || strings.TrimSpace(c.GameID) == "" {
+		return errors.New("room_id and game_id are required")
+	}
+	if c.BetWindowSec <= 0 || c.BetWindowSec > 600 {
+		return errors.New("bet_window_sec must be in 1..600")
+	}
+	if c.Status < RoomStatusOpen || c.Status > RoomStatusClosed {
+		return errors.New("status must be 1|2|3")
+	}
+	if c.CancelCutoffSec < 0 || c.CancelCutoffSec > c.BetWindowSec {
+		return errors.New("cancel_cutoff_sec must be in 0..bet_window_sec")
+	}
+	if c.LiabilityCap.IsNegative() {
+		return errors.New("liability_cap must not be negative")
+	}
+	for _, id := range c.DrawScanners {
+		if id == "" || id != strings.ToLower(id) || id == model.PendingDrawSourceManual {
+			return fmt.Errorf("invalid draw_scanners entry %q", id)
+		}
+	}
+	if len(c.Currency) == 0 || len(c.Currency) > 8 {
+		return errors.New("invalid currency")
+	}
+	if len(c.Odds) == 0 {
+		return errors.New("odds required")
+	}
+	eng, err := engine.For(c.GameID)
+	if err != nil {
+		return err
+	}
+	for pt, o := range c.Odds {
+		if !eng.ValidPlayType(pt) {
+			return fmt.Errorf("play type %s not supported by %s", pt, eng.Name())
+		}
+		if !o.IsPositive() {
+			return fmt.Errorf("odds of %s must be positive", pt)
+		}
+		l, ok := c.BetLimits[pt]
+		if !ok {
+			return fmt.Errorf("bet_limits of %s required", pt)
+		}
+		if !l.Min.IsPositive() || l.Max.LessThan(l.Min) {
+			return fmt.Errorf("bet_limits of %s invalid: min must be positive and max >= min", pt)
+		}
+	}
+	for pt := range c.BetLimits {
+		if _, ok := c.Odds[pt]; !ok {
+			return fmt.Errorf("odds of %s required", pt)
+		}
+	}
+	return nil
+}
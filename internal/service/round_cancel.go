@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"dt-server/internal/model"
+	"dt-server/internal/seamless"
+
+	"github.com/jmoiron/sqlx"
+	decimal "github.com/shopspring/decimal"
+)
+
+// roundRefund 作废牌局的退款统计
+type roundRefund struct {
+	TotalOrders      int
+	TotalRefund      decimal.Decimal
+	RefundByCurrency map[string]decimal.Decimal // 按币种的退款合计
+}
+
+// refundRoundOrders 作废牌局时将所有待结算注单全额退回，需要在事务中调用
+// 1. 锁定本局待结算注单并置为已取消（bill_status=3）
+// 2. 按用户与币种分组，每个钱包只锁定一次，退回本金并写入 refund 账本（seamless 钱包模式登记平台入账）
+// 3. 每笔注单写入 order_refunded Outbox 消息
+func refundRoundOrders(ctx context.Context, tx *sqlx.Tx, in GameEventInput) (*roundRefund, error) {
+	orders, err := model.ListByRoundForUpdate(ctx, tx, in.GameRoundID)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("[GameCancel] 找到 %d 个待退款订单: round_id=%s, trace_id=%s\n",
+		len(orders), in.GameRoundID, in.TraceID)
+
+	// 第一步：注单置为已取消，并按用户与币种分组（查询已按 user_id, currency 排序）
+	type userRefund struct {
+		userID   int64
+		currency string
+		orders   []model.Order
+	}
+	userMap := make(map[walletKey]*userRefund)
+	userOrder := make([]walletKey, 0)
+	totalRefundDec := decimal.Zero
+	byCurrency := currencyTotals{}
+	for i := range orders {
+		o := orders[i]
+		if err := model.CancelOrder(ctx, tx, o.BillNo); err != nil {
+			return nil, err
+		}
+		amt := o.BetAmount
+		totalRefundDec = totalRefundDec.Add(amt)
+		byCurrency.add(o.Currency, amt)
+
+		// seamless 钱包模式的注单：登记平台入账退回本金，由投递任务异步调用
+		if o.WalletTxnID != "" {
+			if err := queueSeamlessTxn(ctx, tx, o, model.WalletTxnOpCredit, seamless.ReasonRefund, "RF"+o.BillNo, amt, in.TraceID); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		k := walletKey{userID: o.UserID, currency: o.Currency}
+		ur, exists := userMap[k]
+		if !exists {
+			ur = &userRefund{userID: o.UserID, currency: o.Currency}
+			userMap[k] = ur
+			userOrder = append(userOrder, k)
+		}
+		ur.orders = append(ur.orders, o)
+	}
+
+	// 第二步：每个钱包原子入账一次（单条条件语句），批量写账本
+	for _, k := range userOrder {
+		ur := userMap[k]
+		ledgers := make([]*model.WalletLedger, 0, len(ur.orders))
+		for _, o := range ur.orders {
+			ledgers = append(ledgers, &model.WalletLedger{
+				UserID:      o.UserID,
+				BizType:     3,
+				BizTypeStr:  "refund",
+				Amount:      o.BetAmount,
+				Currency:    o.Currency,
+				BillNo:      o.BillNo,
+				GameRoundID: in.GameRoundID,
+				GameID:      in.GameID,
+				RoomID:      in.RoomID,
+				Remark:      "bet refund",
+				TraceID:     in.TraceID,
+			})
+		}
+		beforeDec, _, err := mutateWallet(ctx, tx, ur.userID, ur.currency, ledgerTotal(ledgers), true)
+		if err != nil {
+			return nil, err
+		}
+		postLedgers(beforeDec, false, ledgers)
+		for _, ledger := range ledgers {
+			if err := ledger.Insert(ctx, tx); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// 第三步：为所有订单创建 Outbox 消息
+	for i := range orders {
+		o := orders[i]
+		if err := model.CreateOutbox(ctx, tx, "order_refunded", o.BillNo, map[string]any{
+			"event":         "order_refunded",
+			"bill_no":       o.BillNo,
+			"user_id":       o.UserID,
+			"game_id":       in.GameID,
+			"room_id":       in.RoomID,
+			"game_round_id": in.GameRoundID,
+			"play_type":     o.PlayType,
+			"refund":        o.BetAmount,
+			"currency":      o.Currency,
+			"reason":        in.Reason,
+			"trace_id":      in.TraceID,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return &roundRefund{
+		TotalOrders:      len(orders),
+		TotalRefund:      totalRefundDec.Round(2),
+		RefundByCurrency: byCurrency.rounded(),
+	}, nil
+}
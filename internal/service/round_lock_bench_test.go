@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	infmysql "dt-server/internal/infra/mysql"
+	"dt-server/internal/model"
+)
+
+// 同一局并发下注对牌局行的加锁开销：改造前每笔下注 FOR UPDATE（同一局串行），改造后共享锁（互不阻塞）
+// 每次操作模拟一笔下注事务：锁定牌局行并校验下注窗口，持锁期间执行 betWork（钱包、账本、注单写入）后提交
+//
+// 需要可写的 MySQL（已执行 db/migrations），通过环境变量指定：
+//
+//	DT_BENCH_MYSQL_DSN='user:pass@tcp(127.0.0.1:3306)/dt?parseTime=true' go test ./internal/service -run '^$' -bench RoundLock
+func BenchmarkRoundLockConcurrentBets(b *testing.B) {
+	dsn := os.Getenv("DT_BENCH_MYSQL_DSN")
+	if dsn == "" {
+		b.Skip("DT_BENCH_MYSQL_DSN not set")
+	}
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		b.Fatal(err)
+	}
+	db.SetMaxOpenConns(64)
+	db.SetMaxIdleConns(64)
+	infmysql.UseDB(db)
+	ctx := context.Background()
+
+	roundID := fmt.Sprintf("bench-%d", time.Now().UnixNano())
+	if err := model.EnsureOnStart(ctx, infmysql.SQLX(), roundID, "dt", "bench", "bench"); err != nil {
+		b.Fatal(err)
+	}
+	defer func() { _, _ = db.Exec("DELETE FROM game_round_info WHERE game_round_id = ?", roundID) }()
+	now := time.Now().UnixMilli()
+	if err := model.SetBetTimes(ctx, infmysql.SQLX(), roundID, now, now+time.Hour.Milliseconds()); err != nil {
+		b.Fatal(err)
+	}
+	if err := model.UpdateState(ctx, infmysql.SQLX(), roundID, 2); err != nil {
+		b.Fatal(err)
+	}
+
+	const betWork = time.Millisecond
+	for _, mode := range []struct {
+		name      string
+		exclusive bool
+	}{{"before_for_update", true}, {"after_shared", false}} {
+		b.Run(mode.name, func(b *testing.B) {
+			b.SetParallelism(8)
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					tx, err := infmysql.SQLX().BeginTxx(ctx, nil)
+					if err != nil {
+						b.Error(err)
+						return
+					}
+					st, err := lockRoundForBet(ctx, tx, roundID, mode.exclusive, nil)
+					if err == nil {
+						err = checkBetWindow(st, time.Now().UnixMilli())
+					}
+					if err != nil {
+						_ = tx.Rollback()
+						b.Error(err)
+						return
+					}
+					time.Sleep(betWork)
+					if err := tx.Commit(); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	infrds "dt-server/internal/infra/redis"
+	"dt-server/internal/model"
+	"dt-server/internal/state"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// 下注侧的回合状态
+//
+// 下注与撤单不再对 game_round_info 加排他锁（否则同一局的所有下注串行在同一行上，封盘前的高峰成为瓶颈）：
+//   - 事务前读 Redis 回合状态缓存，已封盘/窗口外的请求直接拒绝，不开事务；缓存缺失或显示可下注时以数据库为准
+//   - 事务内以共享锁读取回合状态并校验：并发下注互不阻塞；game_stop 等状态推进取排他锁，须等持有共享锁的下注提交，
+//     封盘提交之后不会再有下注提交
+//
+// 缓存以 state_version（每次状态变更 +1）为防护令牌：只接受不低于当前版本的写入，延迟到达的旧状态不会覆盖新状态。
+
+// roundStateTTL 回合状态缓存过期时间；缓存缺失不影响正确性，只是失去事务前的快速拒绝
+const roundStateTTL = 10 * time.Minute
+
+// setRoundStateScript 版本不低于缓存中的版本时写入回合状态
+// KEYS[1]=key ARGV: state_version, game_status, bet_start_time, bet_stop_time, ttl(ms)
+const setRoundStateScript = `
+	local v = tonumber(redis.call("hget", KEYS[1], "state_version") or "-1")
+	if v > tonumber(ARGV[1]) then
+		return 0
+	end
+	redis.call("hset", KEYS[1], "state_version", ARGV[1], "game_status", ARGV[2], "bet_start_time", ARGV[3], "bet_stop_time", ARGV[4])
+	redis.call("pexpire", KEYS[1], ARGV[5])
+	return 1
+`
+
+// cacheRoundState 写回合状态缓存（st 须为已提交的状态），Redis 不可用时忽略
+func cacheRoundState(ctx context.Context, roundID string, st *model.RoundBetState) {
+	r := infrds.Client()
+	if r == nil || st == nil {
+		return
+	}
+	if err := r.Eval(ctx, setRoundStateScript, []string{infrds.RoundStateKey(roundID)},
+		st.StateVersion, st.GameStatus, st.BetStartTime, st.BetStopTime, roundStateTTL.Milliseconds()).Err(); err != nil {
+		fmt.Printf("[RoundState] 写入回合状态缓存失败: round_id=%s, version=%d, error=%v\n", roundID, st.StateVersion, err)
+	}
+}
+
+// cachedRoundState 读取回合状态缓存，缺失或不可用时返回 nil
+func cachedRoundState(ctx context.Context, roundID string) *model.RoundBetState {
+	r := infrds.Client()
+	if r == nil {
+		return nil
+	}
+	m, err := r.HGetAll(ctx, infrds.RoundStateKey(roundID)).Result()
+	if err != nil || len(m) == 0 {
+		return nil
+	}
+	var st model.RoundBetState
+	status, err1 := strconv.ParseInt(m["game_status"], 10, 8)
+	version, err2 := strconv.ParseInt(m["state_version"], 10, 64)
+	start, err3 := strconv.ParseInt(m["bet_start_time"], 10, 64)
+	stop, err4 := strconv.ParseInt(m["bet_stop_time"], 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+		return nil
+	}
+	st.GameStatus, st.StateVersion, st.BetStartTime, st.BetStopTime = int8(status), version, start, stop
+	return &st
+}
+
+// roundBetNeedsSerial 房间设置了赔付上限而 Redis 不可用时，敞口只能按库中注单校验，同一局的下注须串行
+func roundBetNeedsSerial(room *RoomConfig) bool {
+	return room != nil && room.LiabilityCap.IsPositive() && infrds.Client() == nil
+}
+
+// checkRoundRoom 回合须属于下注请求的房间与游戏
+// 否则注单的玩法可能不属于该局的游戏，结算时派彩失败会中止整局结算
+func checkRoundRoom(st *model.RoundBetState, room *RoomConfig) error {
+	if st.RoomID != room.RoomID || st.GameID != room.GameID {
+		return ErrRoundRoomMismatch
+	}
+	return nil
+}
+
+// checkBetWindow 回合须处于下注中且 now（毫秒）在下注窗口内
+func checkBetWindow(st *model.RoundBetState, now int64) error {
+	if state.Default.StateName(st.GameStatus) != state.StateBetting {
+		return ErrInvalidStateBet
+	}
+	if now < st.BetStartTime {
+		return ErrBetWindowNotStart
+	}
+	if now > st.BetStopTime {
+		return ErrBetWindowClosed
+	}
+	return nil
+}
+
+// precheckRoundState 事务前按缓存快速拒绝不可下注的请求，返回读到的缓存（可能为 nil）
+// 缓存缺失或显示可下注时不返回错误，以事务内的校验为准
+func precheckRoundState(ctx context.Context, roundID string) (*model.RoundBetState, error) {
+	st := cachedRoundState(ctx, roundID)
+	if st == nil {
+		return nil, nil
+	}
+	return st, checkBetWindow(st, time.Now().UnixMilli())
+}
+
+// lockRoundForBet 事务内以共享锁读取回合状态（加锁顺序：customers（互斥主注排他锁，其余共享锁）→ game_round_info → wallets（条件更新）→ orders）
+// exclusive 时改取排他锁，同一局的下注串行（见 roundBetNeedsSerial）；锁模式须在加锁前决定，事务内不得再升级为排他锁
+// （两笔持有共享锁的下注同时升级会互相等待而死锁）
+// cached 为事务前读到的缓存；缺失或版本落后时回填：读到的是已提交的状态，版本防护保证不会覆盖更新的状态
+func lockRoundForBet(ctx context.Context, tx *sqlx.Tx, roundID string, exclusive bool, cached *model.RoundBetState) (*model.RoundBetState, error) {
+	var st *model.RoundBetState
+	if exclusive {
+		round, err := model.GetRoundForUpdate(ctx, tx, roundID)
+		if err != nil {
+			return nil, err
+		}
+		st = &model.RoundBetState{RoomID: round.RoomID, GameID: round.GameID, GameStatus: round.GameStatus,
+			BetStartTime: round.BetStartTime, BetStopTime: round.BetStopTime, StateVersion: round.StateVersion}
+	} else {
+		var err error
+		if st, err = model.GetRoundBetStateShared(ctx, tx, roundID); err != nil {
+			return nil, err
+		}
+	}
+	if cached == nil || cached.StateVersion < st.StateVersion {
+		cacheRoundState(ctx, roundID, st)
+	}
+	return st, nil
+}
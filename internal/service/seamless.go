@@ -0,0 +1,468 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"dt-server/internal/config"
+	"dt-server/internal/engine"
+	infmysql "dt-server/internal/infra/mysql"
+	"dt-server/internal/model"
+	"dt-server/internal/seamless"
+	"dt-server/internal/state"
+
+	"github.com/jmoiron/sqlx"
+	decimal "github.com/shopspring/decimal"
+)
+
+// 单一钱包（seamless）模式
+//
+// 平台配置 wallet_mode=seamless 时本服务不维护该平台玩家的余额（不读写 wallets、不写 wallet_ledger），
+// 每次资金变动都调用平台钱包接口，并记录在 wallet_transactions：
+//   - 下注：注单先以 bet_status=1 落库并登记扣款交易，提交后同步调用 debit（结果未知时以同一 txn_id 重试）；
+//     成功且牌局仍在下注中则确认注单（bet_status=2），被拒则注单置失败（bet_status=3）；
+//     扣款成功但牌局已停止下注时注单置失败并登记 rollback。
+//   - 结算、作废退款、撤单、开奖更正：在业务事务中登记 credit（更正扣回为 debit），由投递任务异步调用并按退避重试。
+//   - 对账：超过 seamlessReconcileAfter 仍未确认的下注扣款（结果未知或进程中断），注单置失败并登记 rollback，
+//     平台据此撤销可能已生效的扣款，并拒绝之后迟到的同一笔扣款。
+//
+// 交易号：下注 BT{首个注单号}；结算 ST{注单号}；退款 RF{注单号}；撤单 BC{注单号}；
+// 更正 RS{注单号}-{结算版本}；撤销 RB{扣款交易号}。同一交易号重复调用由平台幂等处理。
+
+var (
+	ErrWalletUnavailable = errors.New("platform wallet unavailable, bet not confirmed")
+	ErrWalletRejected    = errors.New("platform wallet rejected the debit")
+)
+
+const (
+	// seamlessDebitAttempts 下注扣款结果未知时的同步调用次数（同一 txn_id）
+	seamlessDebitAttempts = 3
+	// seamlessReconcileAfter 下注扣款超过该时长仍未确认即冲正，须大于同步调用的最长耗时
+	seamlessReconcileAfter = 30 * time.Second
+	// seamlessMaxBackoff 异步交易重试的最大间隔
+	seamlessMaxBackoff = 10 * time.Minute
+)
+
+// seamlessPlatform 平台为 seamless 钱包模式时返回其配置，否则返回 nil（transfer 模式）
+func seamlessPlatform(platformID int8) *config.PlatformConfig {
+	cfg := config.Get()
+	if cfg == nil {
+		return nil
+	}
+	for i := range cfg.Auth.Platforms {
+		p := &cfg.Auth.Platforms[i]
+		if p.PlatformID == platformID && strings.EqualFold(p.WalletMode, config.WalletModeSeamless) {
+			return p
+		}
+	}
+	return nil
+}
+
+func seamlessClient(p *config.PlatformConfig) *seamless.Client {
+	return &seamless.Client{
+		BaseURL:   p.WalletURL,
+		AppKey:    p.AppKey,
+		AppSecret: p.AppSecret,
+		Timeout:   time.Duration(p.WalletTimeoutMs) * time.Millisecond,
+	}
+}
+
+// seamlessRequest 由钱包交易构造平台请求
+func seamlessRequest(t *model.WalletTxn) seamless.Request {
+	req := seamless.Request{
+		TxnID:    t.TxnID,
+		UserID:   t.PlatformUserID,
+		Currency: t.Currency,
+		RefTxnID: t.RefTxnID,
+		RoundID:  t.GameRoundID,
+		BillNos:  t.BillNoList(),
+	}
+	if t.Op != model.WalletTxnOpRollback {
+		req.Amount = t.Amount.StringFixed(2)
+	}
+	if t.BizType != "bet" && t.Op != model.WalletTxnOpRollback {
+		req.Reason = t.BizType
+	}
+	return req
+}
+
+// newBetDebit 构造下注扣款交易（批量投注多个注单共用一笔扣款）
+func newBetDebit(user *model.Customers, currency, roundID string, billNos []string, amount decimal.Decimal, traceID string) *model.WalletTxn {
+	return &model.WalletTxn{
+		TxnID:          "BT" + billNos[0],
+		PlatformID:     user.PlatformID,
+		PlatformUserID: user.PlatformUserID,
+		UserID:         user.ID,
+		Op:             model.WalletTxnOpDebit,
+		BizType:        "bet",
+		Amount:         amount.Round(2),
+		Currency:       currency,
+		GameRoundID:    roundID,
+		BillNos:        strings.Join(billNos, ","),
+		TraceID:        traceID,
+	}
+}
+
+// queueSeamlessTxn 在业务事务中登记一笔异步调用的平台钱包交易（入账或更正扣回）
+func queueSeamlessTxn(ctx context.Context, exec sqlx.ExtContext, o model.Order, op int8, bizType, txnID string, amount decimal.Decimal, traceID string) error {
+	t := &model.WalletTxn{
+		TxnID:          txnID,
+		PlatformID:     o.PlatformID,
+		PlatformUserID: o.PlatformUserID,
+		UserID:         o.UserID,
+		Op:             op,
+		BizType:        bizType,
+		Amount:         amount.Round(2),
+		Currency:       o.Currency,
+		RefTxnID:       o.WalletTxnID,
+		GameRoundID:    o.GameRoundID,
+		BillNos:        o.BillNo,
+		TraceID:        traceID,
+	}
+	return t.Insert(ctx, exec)
+}
+
+// completeSeamlessBet 注单落库后调用平台扣款，并确认或撤销注单；返回平台余额
+// 调用方须已提交注单与扣款交易；legs 为本次下注的敞口，注单失败时扣减
+func completeSeamlessBet(ctx context.Context, p *config.PlatformConfig, debit *model.WalletTxn, eng engine.GameEngine, legs []exposureLeg, traceID string) (decimal.Decimal, error) {
+	client := seamlessClient(p)
+	req := seamlessRequest(debit)
+
+	var resp *seamless.Response
+	var err error
+	for attempt := 1; attempt <= seamlessDebitAttempts; attempt++ {
+		resp, err = client.Debit(ctx, req)
+		if err == nil || !errors.Is(err, seamless.ErrUnknown) || attempt == seamlessDebitAttempts {
+			break
+		}
+		fmt.Printf("[Seamless] 扣款结果未知，重试: txn_id=%s, attempt=%d, error=%v, trace_id=%s\n",
+			debit.TxnID, attempt, err, traceID)
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Duration(attempt) * 200 * time.Millisecond):
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	// 确认/撤销使用独立的短事务：请求 ctx 可能已接近超时，结果须落库
+	txCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), defaultTxTimeout)
+	defer cancel()
+
+	var rej *seamless.RejectedError
+	switch {
+	case errors.As(err, &rej):
+		fmt.Printf("[Seamless] 平台拒绝扣款: txn_id=%s, code=%d, message=%s, trace_id=%s\n",
+			debit.TxnID, rej.Code, rej.Message, traceID)
+		failed, e := failBetDebit(txCtx, debit.TxnID, model.WalletTxnFailed, rej.Error(), false)
+		if e != nil {
+			return decimal.Zero, e
+		}
+		if failed {
+			releaseExposure(txCtx, eng, debit.GameRoundID, legs, traceID)
+		}
+		if rej.Code == seamless.CodeInsufficientBalance {
+			return decimal.Zero, ErrInsufficientBalance
+		}
+		return decimal.Zero, ErrWalletRejected
+	case err != nil:
+		// 结果未知：注单保持 bet_status=1，由对账任务冲正
+		fmt.Printf("[Seamless] 扣款结果未知，待对账冲正: txn_id=%s, error=%v, trace_id=%s\n",
+			debit.TxnID, err, traceID)
+		_ = model.MarkWalletTxn(txCtx, infmysql.SQLX(), debit.TxnID, model.WalletTxnUnknown, decimal.Zero, err.Error(), 0)
+		return decimal.Zero, ErrWalletUnavailable
+	}
+
+	balance, _ := decimal.NewFromString(resp.Balance)
+	return balance, confirmBetDebit(txCtx, debit, balance, eng, legs, traceID)
+}
+
+// confirmBetDebit 平台扣款成功后确认注单；牌局已停止下注时注单置失败并登记 rollback
+func confirmBetDebit(ctx context.Context, debit *model.WalletTxn, balance decimal.Decimal, eng engine.GameEngine, legs []exposureLeg, traceID string) error {
+	tx, err := infmysql.SQLX().BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// 加锁顺序：wallet_transactions → game_round_info → orders（与对账任务一致）
+	t, err := model.GetWalletTxnForUpdate(ctx, tx, debit.TxnID)
+	if err != nil {
+		return err
+	}
+	if t.Status != model.WalletTxnPending && t.Status != model.WalletTxnUnknown {
+		// 对账任务已冲正
+		fmt.Printf("[Seamless] 扣款已被对账冲正，注单未确认: txn_id=%s, status=%d, trace_id=%s\n",
+			debit.TxnID, t.Status, traceID)
+		return ErrWalletUnavailable
+	}
+	// 与下注一致取共享锁：并发确认互不阻塞，game_stop 的排他锁等待确认提交
+	round, err := lockRoundForBet(ctx, tx, debit.GameRoundID, false, nil)
+	if err != nil {
+		return err
+	}
+	if state.Default.StateName(round.GameStatus) != state.StateBetting || time.Now().UnixMilli() > round.BetStopTime {
+		fmt.Printf("[Seamless] 扣款成功但已停止下注，撤销扣款: txn_id=%s, round_id=%s, game_status=%d, trace_id=%s\n",
+			debit.TxnID, debit.GameRoundID, round.GameStatus, traceID)
+		if err := abortBetDebit(ctx, tx, t, model.WalletTxnSuccess, "bet window closed before confirmation"); err != nil {
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		releaseExposure(ctx, eng, debit.GameRoundID, legs, traceID)
+		return ErrBetWindowClosed
+	}
+
+	if _, err := model.ConfirmWalletTxnOrders(ctx, tx, debit.TxnID); err != nil {
+		return err
+	}
+	if err := model.MarkWalletTxn(ctx, tx, debit.TxnID, model.WalletTxnSuccess, balance.Round(2), "", 0); err != nil {
+		return err
+	}
+	orders, err := model.ListByWalletTxn(ctx, tx, debit.TxnID)
+	if err != nil {
+		return err
+	}
+	for _, o := range orders {
+		if err := model.CreateOutbox(ctx, tx, "bet_placed", o.BillNo, map[string]any{
+			"event":            "bet_placed",
+			"bill_no":          o.BillNo,
+			"user_id":          o.UserID,
+			"platform_id":      o.PlatformID,
+			"platform_user_id": o.PlatformUserID,
+			"currency":         o.Currency,
+		}); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// failBetDebit 下注扣款失败：注单置失败，扣款交易置为 debitStatus；rollback 为 true 时登记撤销交易
+// 返回 false 表示扣款已被确认或已处理（状态不再是待调用/结果未知），未做任何修改
+func failBetDebit(ctx context.Context, txnID string, debitStatus int8, reason string, rollback bool) (bool, error) {
+	tx, err := infmysql.SQLX().BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	t, err := model.GetWalletTxnForUpdate(ctx, tx, txnID)
+	if err != nil {
+		return false, err
+	}
+	if t.Status != model.WalletTxnPending && t.Status != model.WalletTxnUnknown {
+		return false, nil
+	}
+	if rollback {
+		err = abortBetDebit(ctx, tx, t, debitStatus, reason)
+	} else {
+		if _, err = model.FailWalletTxnOrders(ctx, tx, txnID); err == nil {
+			err = model.MarkWalletTxn(ctx, tx, txnID, debitStatus, decimal.Zero, reason, 0)
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// abortBetDebit 事务内：注单置失败，扣款交易置为 debitStatus，并登记撤销该扣款的 rollback 交易
+func abortBetDebit(ctx context.Context, tx *sqlx.Tx, debit *model.WalletTxn, debitStatus int8, reason string) error {
+	if _, err := model.FailWalletTxnOrders(ctx, tx, debit.TxnID); err != nil {
+		return err
+	}
+	if err := model.MarkWalletTxn(ctx, tx, debit.TxnID, debitStatus, debit.Balance, reason, 0); err != nil {
+		return err
+	}
+	rb := &model.WalletTxn{
+		TxnID:          "RB" + debit.TxnID,
+		PlatformID:     debit.PlatformID,
+		PlatformUserID: debit.PlatformUserID,
+		UserID:         debit.UserID,
+		Op:             model.WalletTxnOpRollback,
+		BizType:        "rollback",
+		Amount:         debit.Amount,
+		Currency:       debit.Currency,
+		RefTxnID:       debit.TxnID,
+		GameRoundID:    debit.GameRoundID,
+		BillNos:        debit.BillNos,
+		TraceID:        debit.TraceID,
+	}
+	return rb.Insert(ctx, tx)
+}
+
+// seamlessBetResult 幂等重放时按注单的扣款交易返回结果：已确认返回平台余额，未确认返回 ErrDuplicateInFlight
+func seamlessBetResult(ctx context.Context, billNo string) (decimal.Decimal, error) {
+	db := infmysql.SQLX()
+	o, err := model.GetOrder(ctx, db, billNo)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	switch o.BetStatus {
+	case 1:
+		return decimal.Zero, ErrDuplicateInFlight
+	case 3:
+		return decimal.Zero, ErrWalletRejected
+	}
+	if o.WalletTxnID == "" {
+		return decimal.Zero, nil
+	}
+	t, err := model.GetWalletTxn(ctx, db, o.WalletTxnID)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return t.Balance, nil
+}
+
+// ========== 投递与对账（由 worker 定时调用） ==========
+
+type SeamlessWalletService interface {
+	// DeliverDue 调用到期的异步交易（入账、撤销、更正扣回），返回调用成功的笔数
+	DeliverDue(ctx context.Context, limit int) (int, error)
+	// ReconcileBets 冲正超时仍未确认的下注扣款，返回冲正的笔数
+	ReconcileBets(ctx context.Context, limit int) (int, error)
+}
+
+type seamlessWalletService struct{}
+
+func NewSeamlessWalletService() SeamlessWalletService { return &seamlessWalletService{} }
+
+func (s *seamlessWalletService) DeliverDue(ctx context.Context, limit int) (int, error) {
+	db := infmysql.SQLX()
+	now := time.Now().UnixMilli()
+	txns, err := model.ListDueWalletTxns(ctx, db, now, limit)
+	if err != nil {
+		return 0, err
+	}
+	done := 0
+	for i := range txns {
+		t := &txns[i]
+		p := seamlessPlatform(t.PlatformID)
+		timeout := seamless.DefaultTimeout
+		if p != nil && p.WalletTimeoutMs > 0 {
+			timeout = time.Duration(p.WalletTimeoutMs) * time.Millisecond
+		}
+		// 抢占：调用期间推迟 next_retry_at，避免其他实例重复调用
+		ok, err := model.ClaimWalletTxn(ctx, db, t.TxnID, t.NextRetryAt, now+(timeout+5*time.Second).Milliseconds())
+		if err != nil || !ok {
+			continue
+		}
+		attempts := t.Attempts + 1
+		if p == nil {
+			_ = model.MarkWalletTxn(ctx, db, t.TxnID, model.WalletTxnUnknown, decimal.Zero, "platform not in seamless mode", retryAt(attempts))
+			continue
+		}
+
+		client := seamlessClient(p)
+		req := seamlessRequest(t)
+		var resp *seamless.Response
+		switch t.Op {
+		case model.WalletTxnOpDebit:
+			resp, err = client.Debit(ctx, req)
+		case model.WalletTxnOpCredit:
+			resp, err = client.Credit(ctx, req)
+		default:
+			resp, err = client.Rollback(ctx, req)
+		}
+
+		var rej *seamless.RejectedError
+		switch {
+		case err == nil:
+			balance, _ := decimal.NewFromString(resp.Balance)
+			if e := model.MarkWalletTxn(ctx, db, t.TxnID, model.WalletTxnSuccess, balance.Round(2), "", 0); e != nil {
+				fmt.Printf("[Seamless] 更新交易状态失败: txn_id=%s, error=%v\n", t.TxnID, e)
+				continue
+			}
+			done++
+		case errors.As(err, &rej):
+			// 明确拒绝：不再重试，告警由运营人工处理
+			fmt.Printf("[Seamless] 平台拒绝交易，需人工处理: txn_id=%s, op=%d, biz_type=%s, code=%d, message=%s, trace_id=%s\n",
+				t.TxnID, t.Op, t.BizType, rej.Code, rej.Message, t.TraceID)
+			if e := model.MarkWalletTxn(ctx, db, t.TxnID, model.WalletTxnFailed, decimal.Zero, rej.Error(), 0); e != nil {
+				continue
+			}
+			_ = model.CreateOutbox(ctx, db, "wallet_txn_failed", t.TxnID, map[string]any{
+				"event":            "wallet_txn_failed",
+				"txn_id":           t.TxnID,
+				"platform_id":      t.PlatformID,
+				"platform_user_id": t.PlatformUserID,
+				"op":               t.Op,
+				"biz_type":         t.BizType,
+				"amount":           t.Amount,
+				"currency":         t.Currency,
+				"bill_nos":         t.BillNoList(),
+				"code":             rej.Code,
+				"message":          rej.Message,
+				"trace_id":         t.TraceID,
+			})
+		default:
+			_ = model.MarkWalletTxn(ctx, db, t.TxnID, model.WalletTxnUnknown, decimal.Zero, err.Error(), retryAt(attempts))
+		}
+	}
+	return done, nil
+}
+
+func (s *seamlessWalletService) ReconcileBets(ctx context.Context, limit int) (int, error) {
+	db := infmysql.SQLX()
+	txns, err := model.ListStaleBetDebits(ctx, db, time.Now().Add(-seamlessReconcileAfter).UnixMilli(), limit)
+	if err != nil {
+		return 0, err
+	}
+	done := 0
+	for i := range txns {
+		t := &txns[i]
+		orders, err := model.ListByWalletTxn(ctx, db, t.TxnID)
+		if err != nil {
+			continue
+		}
+		aborted, err := failBetDebit(ctx, t.TxnID, model.WalletTxnFailed, "reconciled: debit outcome unknown, rolled back", true)
+		if err != nil {
+			fmt.Printf("[Seamless] 冲正下注扣款失败: txn_id=%s, error=%v, trace_id=%s\n", t.TxnID, err, t.TraceID)
+			continue
+		}
+		if !aborted {
+			continue
+		}
+		done++
+		fmt.Printf("[Seamless] 下注扣款未确认，已冲正: txn_id=%s, round_id=%s, orders=%d, trace_id=%s\n",
+			t.TxnID, t.GameRoundID, len(orders), t.TraceID)
+		releaseOrdersExposure(ctx, orders, t.TraceID)
+	}
+	return done, nil
+}
+
+// releaseOrdersExposure 扣减一组（同一局）未确认注单的敞口
+func releaseOrdersExposure(ctx context.Context, orders []model.Order, traceID string) {
+	if len(orders) == 0 || orders[0].BetStatus != 1 {
+		return
+	}
+	eng, err := engine.For(orders[0].GameID)
+	if err != nil {
+		return
+	}
+	legs := make([]exposureLeg, 0, len(orders))
+	for _, o := range orders {
+		legs = append(legs, exposureLeg{
+			playType: o.PlayType,
+			amount:   o.BetAmount,
+			odds:     o.BetOdds,
+		})
+	}
+	releaseExposure(ctx, eng, orders[0].GameRoundID, legs, traceID)
+}
+
+// retryAt 异步交易第 attempts 次失败后的下次调用时间：1s、2s、4s …… 最长 seamlessMaxBackoff
+func retryAt(attempts int) int64 {
+	backoff := seamlessMaxBackoff
+	if attempts < 20 {
+		if d := time.Duration(1<<uint(attempts-1)) * time.Second; d < backoff {
+			backoff = d
+		}
+	}
+	return time.Now().Add(backoff).UnixMilli()
+}
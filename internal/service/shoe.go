@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"dt-server/internal/config"
+	"dt-server/internal/engine"
+	"dt-server/internal/fair"
+	infmysql "dt-server/internal/infra/mysql"
+	"dt-server/internal/model"
+	"dt-server/internal/shoe"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type ShoeChangeInput struct {
+	GameID  string
+	RoomID  string
+	Reason  string // 换靴原因（人工换靴时填写）
+	TraceID string
+}
+
+type ShoeService interface {
+	// ChangeShoe 人工换靴：作废房间当前牌靴并重新洗一靴，仅允许在两局之间调用
+	ChangeShoe(ctx context.Context, in ShoeChangeInput) (*model.Shoe, error)
+}
+
+type shoeService struct{}
+
+func NewShoeService() ShoeService { return &shoeService{} }
+
+func (s *shoeService) ChangeShoe(ctx context.Context, in ShoeChangeInput) (*model.Shoe, error) {
+	if strings.TrimSpace(in.RoomID) == "" {
+		return nil, ErrBadRequest
+	}
+
+	fmt.Printf("[Shoe] 收到换靴请求: game_id=%s, room_id=%s, reason=%s, trace_id=%s\n",
+		in.GameID, in.RoomID, in.Reason, in.TraceID)
+
+	tx, err := infmysql.SQLX().BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// 局中（下注中~已结算未结束）不允许换靴，避免已发出的牌与新靴不一致
+	// game_status: 2=下注中 3=封盘 4=已发牌 5=已开奖 6=已结算
+	round, err := model.GetLatestRoundByRoom(ctx, tx, in.RoomID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err == nil && round.GameStatus >= 2 && round.GameStatus <= 6 {
+		fmt.Printf("[Shoe] 换靴失败: 房间存在进行中的牌局, room_id=%s, round_id=%s, status=%d, trace_id=%s\n",
+			in.RoomID, round.GameRoundID, round.GameStatus, in.TraceID)
+		return nil, ErrShoeChangeInRound
+	}
+
+	newShoe, err := replaceShoe(ctx, tx, in.GameID, in.RoomID, shoeDecks(), "manual", in.Reason, in.TraceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		fmt.Printf("[Shoe] 提交事务失败: room_id=%s, error=%v, trace_id=%s\n", in.RoomID, err, in.TraceID)
+		return nil, err
+	}
+
+	fmt.Printf("[Shoe] 换靴完成: room_id=%s, shoe_no=%s, cut_position=%d, trace_id=%s\n",
+		in.RoomID, newShoe.ShoeNo, newShoe.CutPosition, in.TraceID)
+	return newShoe, nil
+}
+
+// dealtCards 服务端发牌结果
+type dealtCards struct {
+	ShoeNo   string
+	CardList string // 由游戏引擎按发牌顺序生成，如龙虎 D13S,T10H
+	Start    int    // 本局第一张牌的牌靴下标
+	Position int    // 发牌后的牌靴下标
+}
+
+// dealRoundCards 从房间牌靴为本局发牌（张数与顺序由游戏引擎决定），需要在事务中调用
+// 可验证公平的局须从 game_start 时承诺的牌靴发牌（局中不允许换靴，开局时已保证余牌够一局）
+func dealRoundCards(ctx context.Context, tx *sqlx.Tx, in GameEventInput) (*dealtCards, error) {
+	round, err := model.GetRoundForUpdate(ctx, tx, in.GameRoundID)
+	if err != nil {
+		return nil, err
+	}
+	if round.CardList != "" {
+		// 已发过牌（不会发生：new_card 只会从 sealed 进入一次），保持幂等
+		return nil, fmt.Errorf("round %s already dealt", in.GameRoundID)
+	}
+	eng, err := engine.For(round.GameID)
+	if err != nil {
+		return nil, err
+	}
+
+	sh, err := dealableShoe(ctx, tx, round.GameID, round.RoomID, eng, in.TraceID)
+	if err != nil {
+		return nil, err
+	}
+	if round.SeedHash != "" && (sh.SeedHash != round.SeedHash || sh.ShoeNo != round.SeedNonce) {
+		return nil, fmt.Errorf("round %s committed to shoe %s, active shoe is %s", in.GameRoundID, round.SeedNonce, sh.ShoeNo)
+	}
+
+	cards, err := shoe.Decode(sh.Cards)
+	if err != nil {
+		return nil, err
+	}
+	pos := sh.Position
+	cardList, err := eng.Deal(cardSource(cards, &pos))
+	if err != nil {
+		return nil, fmt.Errorf("shoe %s: %w", sh.ShoeNo, err)
+	}
+	out := &dealtCards{ShoeNo: sh.ShoeNo, CardList: cardList, Start: sh.Position, Position: pos}
+
+	if err := model.UpdateShoePosition(ctx, tx, sh.ID, out.Position); err != nil {
+		return nil, err
+	}
+	if err := model.SetDealtCards(ctx, tx, in.GameRoundID, out.ShoeNo, out.CardList, out.Start); err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("[Shoe] 发牌: round_id=%s, shoe_no=%s, card_list=%s, position=%d->%d/%d, trace_id=%s\n",
+		in.GameRoundID, out.ShoeNo, out.CardList, out.Start, out.Position, sh.TotalCards, in.TraceID)
+	return out, nil
+}
+
+// dealableShoe 锁定房间可发一局的牌靴，需要在事务中调用
+// 房间尚无牌靴时自动开靴；上一局已发到切牌位置（或剩余不足一局最多用牌数）时先自动换靴；
+// 启用可验证公平后，未带种子的旧靴同样换掉
+func dealableShoe(ctx context.Context, tx *sqlx.Tx, gameID, roomID string, eng engine.GameEngine, traceID string) (*model.Shoe, error) {
+	sh, err := model.GetActiveShoeForUpdate(ctx, tx, roomID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return replaceShoe(ctx, tx, gameID, roomID, shoeDecks(), "auto", "no active shoe", traceID)
+	case sh.Position >= sh.CutPosition || sh.Position+eng.MaxCards() > sh.TotalCards:
+		fmt.Printf("[Shoe] 已到切牌位置，自动换靴: room_id=%s, shoe_no=%s, position=%d, cut_position=%d, trace_id=%s\n",
+			roomID, sh.ShoeNo, sh.Position, sh.CutPosition, traceID)
+		return replaceShoe(ctx, tx, gameID, roomID, shoeDecks(), "auto", "cut card reached", traceID)
+	case fairEnabled() && sh.SeedHash == "":
+		fmt.Printf("[Shoe] 牌靴无种子承诺，自动换靴: room_id=%s, shoe_no=%s, trace_id=%s\n", roomID, sh.ShoeNo, traceID)
+		return replaceShoe(ctx, tx, gameID, roomID, shoeDecks(), "auto", "provably fair shoe required", traceID)
+	}
+	return sh, nil
+}
+
+// cardSource 从 cards[*pos] 起依次取牌并推进 *pos
+func cardSource(cards []shoe.Card, pos *int) func() (shoe.Card, error) {
+	return func() (shoe.Card, error) {
+		if *pos >= len(cards) {
+			return shoe.Card{}, errors.New("shoe exhausted")
+		}
+		c := cards[*pos]
+		*pos++
+		return c, nil
+	}
+}
+
+// replaceShoe 作废房间当前牌靴（如有），洗一副新靴并完成开靴烧牌，写入 shoe_changed Outbox
+// 可验证公平模式下整靴牌序由新生成的靴种子派生；旧靴不再发牌，随 shoe_changed 公布其种子
+// trigger: manual=人工换靴 auto=自动换靴
+func replaceShoe(ctx context.Context, tx *sqlx.Tx, gameID, roomID string, decks int, trigger, reason, traceID string) (*model.Shoe, error) {
+	prevShoeNo, prevSeed := "", ""
+	old, err := model.GetActiveShoeForUpdate(ctx, tx, roomID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err == nil {
+		prevShoeNo, prevSeed = old.ShoeNo, old.ServerSeed
+		if gameID == "" {
+			gameID = old.GameID
+		}
+		if err := model.RetireShoe(ctx, tx, old.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	shoeNo := newShoeNo(roomID)
+	var cards []shoe.Card
+	var serverSeed, seedHash string
+	if fairEnabled() {
+		if serverSeed, err = fair.NewServerSeed(); err != nil {
+			return nil, err
+		}
+		seedHash = fair.Commit(serverSeed)
+		cards = fair.ShuffleShoe(serverSeed, shoeNo, decks)
+	} else {
+		cards = shoe.NewDeck(decks)
+		if err := shoe.Shuffle(cards); err != nil {
+			return nil, err
+		}
+	}
+	cut, err := shoe.CutPosition(len(cards))
+	if err != nil {
+		return nil, err
+	}
+	// 开靴烧牌：翻开第一张，按点数烧掉对应张数
+	burn := shoe.BurnCount(cards[0])
+
+	sh := &model.Shoe{
+		ShoeNo:      shoeNo,
+		GameID:      gameID,
+		RoomID:      roomID,
+		Decks:       len(cards) / 52,
+		Cards:       shoe.Encode(cards),
+		TotalCards:  len(cards),
+		Position:    burn,
+		CutPosition: cut,
+		BurnCards:   shoe.Encode(cards[:burn]),
+		ServerSeed:  serverSeed,
+		SeedHash:    seedHash,
+		Status:      1,
+		TraceID:     traceID,
+	}
+	if err := sh.Insert(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	payload := map[string]any{
+		"event":        "shoe_changed",
+		"game_id":      gameID,
+		"room_id":      roomID,
+		"shoe_no":      sh.ShoeNo,
+		"prev_shoe_no": prevShoeNo,
+		"decks":        sh.Decks,
+		"total_cards":  sh.TotalCards,
+		"cut_position": sh.CutPosition,
+		"burn_cards":   sh.BurnCards,
+		"trigger":      trigger,
+		"reason":       reason,
+		"trace_id":     traceID,
+	}
+	if sh.SeedHash != "" {
+		payload["seed_hash"] = sh.SeedHash
+	}
+	if prevSeed != "" {
+		payload["prev_server_seed"] = prevSeed
+	}
+	if err := model.CreateOutbox(ctx, tx, "shoe_changed", sh.ShoeNo, payload); err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("[Shoe] 新靴: room_id=%s, shoe_no=%s, prev=%s, burn=%s, cut_position=%d, trigger=%s, trace_id=%s\n",
+		roomID, sh.ShoeNo, prevShoeNo, sh.BurnCards, sh.CutPosition, trigger, traceID)
+	return sh, nil
+}
+
+// shoeEnabled 是否启用服务端牌靴发牌
+func shoeEnabled() bool {
+	cfg := config.Get()
+	return cfg != nil && cfg.Shoe.Enabled
+}
+
+// fairDecks 旧版按局种子发牌使用的副数（固定为 8），用于复算历史局
+// 种子发牌为部分 Fisher-Yates，前 n 张只取决于种子与 nonce，与取多少张无关
+const fairDecks = shoe.DefaultDecks
+
+// fairEnabled 是否启用可验证公平（依赖服务端发牌，种子在开靴时承诺）
+func fairEnabled() bool {
+	cfg := config.Get()
+	return cfg != nil && cfg.Shoe.Enabled && cfg.Shoe.ProvablyFair
+}
+
+func shoeDecks() int {
+	if cfg := config.Get(); cfg != nil && cfg.Shoe.Decks > 0 {
+		return cfg.Shoe.Decks
+	}
+	return shoe.DefaultDecks
+}
+
+// newShoeNo 生成靴号
+// 格式：S{room_id}{YYYYMMDDHHmmss}{uuid 前 6 位}
+func newShoeNo(roomID string) string {
+	return fmt.Sprintf("S%s%s%s", roomID, time.Now().Format("20060102150405"),
+		strings.ToUpper(strings.ReplaceAll(uuid.NewString(), "-", "")[:6]))
+}
+
+var ErrShoeChangeInRound = errors.New("shoe change not allowed: round in progress")
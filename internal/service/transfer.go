@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"dt-server/common/constant"
+	chelper "dt-server/common/helper"
+	infmysql "dt-server/internal/infra/mysql"
+	"dt-server/internal/model"
+
+	mysqlerr "github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	decimal "github.com/shopspring/decimal"
+)
+
+// 平台上分/下分（wallet_ledger.biz_type=7/8）
+const (
+	BIZ_TYPE_DEPOSIT  = 7
+	BIZ_TYPE_WITHDRAW = 8
+)
+
+// 失败原因（transfers.reason），重复请求按原因返回相同错误
+const (
+	transferReasonInsufficient = "insufficient balance"
+	transferReasonNotAllowed   = "withdraw not allowed"
+)
+
+var (
+	ErrTransferNotFound     = errors.New("transfer not found")
+	ErrTransferConflict     = errors.New("transfer_id already used with different parameters")
+	ErrTransferNotSupported = errors.New("transfer not supported in seamless wallet mode")
+	ErrWithdrawNotAllowed   = errors.New("withdraw not allowed for this user")
+)
+
+// TransferInput 上分/下分参数
+type TransferInput struct {
+	TransferID       string // 平台转账单号（同一平台内唯一，幂等键）
+	PlatformID       int8
+	PlatformUserID   string
+	PlatformUserName string
+	Amount           string
+	Currency         string
+	Remark           string
+	TraceID          string
+}
+
+type TransferOutput struct {
+	TransferID string `json:"transfer_id"`
+	Direction  string `json:"direction"` // deposit|withdraw
+	Amount     string `json:"amount"`
+	Currency   string `json:"currency"`
+	Balance    string `json:"balance"` // 转账后余额（该币种钱包）
+	CreatedAt  int64  `json:"created_at"`
+}
+
+type TransferService interface {
+	// Deposit 平台上分：转入玩家指定币种钱包
+	Deposit(ctx context.Context, in TransferInput) (*TransferOutput, error)
+	// Withdraw 平台下分：从玩家指定币种钱包转出，余额不足或用户禁止提款时拒绝
+	Withdraw(ctx context.Context, in TransferInput) (*TransferOutput, error)
+	// GetTransfer 查询本平台的转账记录（含被拒绝的转账），用于平台对账
+	GetTransfer(ctx context.Context, platformID int8, transferID string) (*model.Transfer, error)
+}
+
+type transferService struct{}
+
+func NewTransferService() TransferService { return &transferService{} }
+
+func (s *transferService) Deposit(ctx context.Context, in TransferInput) (*TransferOutput, error) {
+	return s.transfer(ctx, model.TransferDeposit, in)
+}
+
+func (s *transferService) Withdraw(ctx context.Context, in TransferInput) (*TransferOutput, error) {
+	return s.transfer(ctx, model.TransferWithdraw, in)
+}
+
+func (s *transferService) GetTransfer(ctx context.Context, platformID int8, transferID string) (*model.Transfer, error) {
+	t, err := model.GetTransfer(ctx, infmysql.SQLX(), platformID, transferID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTransferNotFound
+	}
+	return t, err
+}
+
+// transfer 上分/下分
+// 1. 仅 transfer 钱包模式的平台可用；币种须已配置且平台允许，金额精度按币种规则
+// 2. 锁定用户后按 (platform_id, transfer_id) 查重，参数一致的重复请求返回首次结果
+// 3. 余额以单条条件语句原子变更；下分时用户禁止提款（customers.status=UserNotAllowWithdraw）或余额不足，转账以失败状态落库后返回错误
+// 4. 成功时写 deposit/withdraw 账本与 wallet_transfer Outbox 消息
+func (s *transferService) transfer(ctx context.Context, direction int8, in TransferInput) (*TransferOutput, error) {
+	fmt.Printf("[Transfer] 收到%s请求: transfer_id=%s, platform_id=%d, platform_user_id=%s, amount=%s %s, trace_id=%s\n",
+		transferDirectionName(direction), in.TransferID, in.PlatformID, in.PlatformUserID, in.Amount, in.Currency, in.TraceID)
+
+	if seamlessPlatform(in.PlatformID) != nil {
+		return nil, ErrTransferNotSupported
+	}
+	rule, err := lookupCurrency(in.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if !platformAllowsCurrency(in.PlatformID, in.Currency) {
+		return nil, ErrCurrencyNotAllowed
+	}
+	amtDec, err := decimal.NewFromString(in.Amount)
+	if err != nil || !amtDec.IsPositive() {
+		return nil, fmt.Errorf("%w: amount must be positive", ErrBadRequest)
+	}
+	if !amtDec.Equal(amtDec.Truncate(rule.Precision)) {
+		return nil, fmt.Errorf("%w: %s allows %d decimal places", ErrBadRequest, rule.Code, rule.Precision)
+	}
+
+	txCtx := ctx
+	if _, has := ctx.Deadline(); !has {
+		c, cancel := context.WithTimeout(ctx, defaultTxTimeout)
+		txCtx = c
+		defer cancel()
+	}
+	tx, err := infmysql.SQLX().BeginTxx(txCtx, nil)
+	if err != nil {
+		fmt.Printf("[Transfer] 开启事务失败: error=%v, transfer_id=%s, trace_id=%s\n", err, in.TransferID, in.TraceID)
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	user, err := getOrCreateUserInTx(txCtx, tx, in.PlatformID, in.PlatformUserID, in.PlatformUserName, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get or create user: %w", err)
+	}
+
+	// 幂等：同一用户的转账已被用户锁串行化；不同用户复用同一 transfer_id 由唯一键兜底
+	if prev, err := model.GetTransfer(txCtx, tx, in.PlatformID, in.TransferID); err == nil {
+		return previousTransfer(prev, direction, user.ID, amtDec, in)
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	t := &model.Transfer{
+		TransferID:     in.TransferID,
+		PlatformID:     in.PlatformID,
+		PlatformUserID: in.PlatformUserID,
+		UserID:         user.ID,
+		Direction:      direction,
+		Amount:         amtDec,
+		Currency:       in.Currency,
+		Status:         model.TransferSuccess,
+		Remark:         in.Remark,
+		TraceID:        in.TraceID,
+	}
+
+	// 原子变更余额：下分以条件语句要求余额足够；用户禁止提款时不变更余额
+	bizType, bizStr, delta := BIZ_TYPE_DEPOSIT, "deposit", amtDec
+	if direction == model.TransferWithdraw {
+		bizType, bizStr, delta = BIZ_TYPE_WITHDRAW, "withdraw", amtDec.Neg()
+	}
+	var beforeDec, afterDec decimal.Decimal
+	if direction == model.TransferWithdraw && user.Status == constant.UserNotAllowWithdraw {
+		t.Status, t.Reason = model.TransferFailed, transferReasonNotAllowed
+		if w, err := model.GetWallet(txCtx, tx, user.ID, in.Currency); err == nil {
+			beforeDec = w.Balance
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+	} else {
+		beforeDec, afterDec, err = mutateWallet(txCtx, tx, user.ID, in.Currency, delta, direction == model.TransferDeposit)
+		if errors.Is(err, ErrInsufficientBalance) {
+			t.Status, t.Reason = model.TransferFailed, transferReasonInsufficient
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to update wallet: %w", err)
+		}
+	}
+	t.BeforeAmount = beforeDec
+
+	// 下分被拒绝：转账同样落库（余额不变），平台以同一单号重试或查询时得到相同结果
+	if t.Status == model.TransferFailed {
+		t.AfterAmount = beforeDec
+		if err := insertTransfer(txCtx, tx, t); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		fmt.Printf("[Transfer] 下分被拒绝: transfer_id=%s, reason=%s, balance=%s, trace_id=%s\n",
+			in.TransferID, t.Reason, beforeDec.String(), in.TraceID)
+		return nil, transferFailure(t.Reason)
+	}
+
+	t.AfterAmount = afterDec
+	if err := insertTransfer(txCtx, tx, t); err != nil {
+		return nil, err
+	}
+	ledger := &model.WalletLedger{
+		UserID:       user.ID,
+		BizType:      bizType,
+		BizTypeStr:   bizStr,
+		Amount:       amtDec,
+		BeforeAmount: beforeDec,
+		AfterAmount:  afterDec,
+		Currency:     in.Currency,
+		BillNo:       in.TransferID,
+		Remark:       "platform " + bizStr,
+		TraceID:      in.TraceID,
+	}
+	if err := ledger.Insert(txCtx, tx); err != nil {
+		fmt.Printf("[Transfer] 写入账本失败: error=%v, transfer_id=%s, trace_id=%s\n", err, in.TransferID, in.TraceID)
+		return nil, err
+	}
+	if err := model.CreateOutbox(txCtx, tx, "wallet_transfer", fmt.Sprintf("%d:%s", in.PlatformID, in.TransferID), map[string]any{
+		"event":            "wallet_transfer",
+		"transfer_id":      in.TransferID,
+		"direction":        bizStr,
+		"user_id":          user.ID,
+		"platform_id":      in.PlatformID,
+		"platform_user_id": in.PlatformUserID,
+		"amount":           t.Amount,
+		"currency":         in.Currency,
+		"balance":          t.AfterAmount,
+		"trace_id":         in.TraceID,
+	}); err != nil {
+		fmt.Printf("[Transfer] 写入 Outbox 失败: error=%v, transfer_id=%s, trace_id=%s\n", err, in.TransferID, in.TraceID)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		fmt.Printf("[Transfer] 提交事务失败: error=%v, transfer_id=%s, trace_id=%s\n", err, in.TransferID, in.TraceID)
+		return nil, err
+	}
+	fmt.Printf("[Transfer] %s成功: transfer_id=%s, amount=%s %s, before=%s, after=%s, trace_id=%s\n",
+		bizStr, in.TransferID, amtDec.String(), in.Currency, beforeDec.String(), afterDec.String(), in.TraceID)
+	return transferOutput(t), nil
+}
+
+// insertTransfer 写入转账记录；单号已被其他用户占用时返回 ErrTransferConflict
+func insertTransfer(ctx context.Context, tx *sqlx.Tx, t *model.Transfer) error {
+	err := t.Insert(ctx, tx)
+	if me, ok := err.(*mysqlerr.MySQLError); ok && me.Number == 1062 {
+		return ErrTransferConflict
+	}
+	return err
+}
+
+// previousTransfer 重复请求：参数须与首次一致，返回首次的结果（成功的输出或相同的错误）
+func previousTransfer(prev *model.Transfer, direction int8, userID int64, amt decimal.Decimal, in TransferInput) (*TransferOutput, error) {
+	if prev.Direction != direction || prev.UserID != userID || prev.Currency != in.Currency ||
+		!prev.Amount.Equal(amt) {
+		fmt.Printf("[Transfer] 转账单号参数不一致: transfer_id=%s, trace_id=%s\n", in.TransferID, in.TraceID)
+		return nil, ErrTransferConflict
+	}
+	fmt.Printf("[Transfer] 重复请求，返回首次结果: transfer_id=%s, status=%d, trace_id=%s\n",
+		in.TransferID, prev.Status, in.TraceID)
+	if prev.Status == model.TransferFailed {
+		return nil, transferFailure(prev.Reason)
+	}
+	return transferOutput(prev), nil
+}
+
+func transferFailure(reason string) error {
+	if reason == transferReasonNotAllowed {
+		return ErrWithdrawNotAllowed
+	}
+	return ErrInsufficientBalance
+}
+
+func transferOutput(t *model.Transfer) *TransferOutput {
+	return &TransferOutput{
+		TransferID: t.TransferID,
+		Direction:  transferDirectionName(t.Direction),
+		Amount:     chelper.TrimDecimal(t.Amount),
+		Currency:   t.Currency,
+		Balance:    chelper.TrimDecimal(t.AfterAmount),
+		CreatedAt:  t.CreatedAt,
+	}
+}
+
+func transferDirectionName(direction int8) string {
+	if direction == model.TransferWithdraw {
+		return "withdraw"
+	}
+	return "deposit"
+}
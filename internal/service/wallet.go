@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	infmysql "dt-server/internal/infra/mysql"
+	"dt-server/internal/model"
+
+	"github.com/jmoiron/sqlx"
+	decimal "github.com/shopspring/decimal"
+)
+
+var ErrInsufficientBalance = errors.New("insufficient balance")
+
+// mutateWallet 在事务内原子变更钱包余额（delta 为负为扣款），返回变更前后余额：
+//  1. 单条条件语句 UPDATE wallets SET balance = balance + ? ... [AND balance >= ?] 写入，不先读取、不占用事务外的连接；
+//     语句在行锁下读取最新余额并判断是否足够，并发变更由行锁排队，不存在版本冲突，因此不需要重试
+//  2. 命中后经同一事务加锁读回变更后余额（本事务已持有行锁，不会等待），变更前余额 = 变更后余额 - delta
+//  3. 未命中时加锁读取（钱包不存在时以 INSERT IGNORE 创建余额为 0 的钱包）：余额不足返回 ErrInsufficientBalance，
+//     新建的钱包再执行一次第 1 步。不在每次变更前 INSERT IGNORE：重复键的 INSERT 会对已有行加共享锁，
+//     两个事务随后的 UPDATE 互相等待对方的共享锁而死锁
+func mutateWallet(ctx context.Context, tx *sqlx.Tx, userID int64, currency string, delta decimal.Decimal, allowNegative bool) (decimal.Decimal, decimal.Decimal, error) {
+	delta = delta.Round(2)
+	ok, err := model.AddWalletBalance(ctx, tx, userID, currency, delta, !allowNegative)
+	if err != nil {
+		return decimal.Zero, decimal.Zero, err
+	}
+	if ok {
+		after, err := model.GetWalletBalanceForUpdate(ctx, tx, userID, currency)
+		if err != nil {
+			return decimal.Zero, decimal.Zero, err
+		}
+		return after.Sub(delta), after, nil
+	}
+
+	w, err := model.GetWalletForUpdate(ctx, tx, userID, currency)
+	if err != nil {
+		return decimal.Zero, decimal.Zero, err
+	}
+	if !allowNegative && w.Balance.Add(delta).IsNegative() {
+		return w.Balance, w.Balance, ErrInsufficientBalance
+	}
+	if ok, err = model.AddWalletBalance(ctx, tx, userID, currency, delta, !allowNegative); err != nil {
+		return decimal.Zero, decimal.Zero, err
+	}
+	if !ok {
+		// 行锁下余额不会被其他事务修改，不应走到这里
+		return decimal.Zero, decimal.Zero, fmt.Errorf("wallet update missed under row lock: user_id=%d, currency=%s", userID, currency)
+	}
+	return w.Balance, w.Balance.Add(delta), nil
+}
+
+// walletBalance 非锁查询平台用户指定币种的余额（幂等重放时返回），钱包不存在视为 0
+func walletBalance(ctx context.Context, platformID int8, platformUserID, currency string) (decimal.Decimal, error) {
+	db := infmysql.SQLX()
+	u, err := model.GetUserByPlatformUser(ctx, db, platformID, platformUserID)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	w, err := model.GetWallet(ctx, db, u.ID, currency)
+	if errors.Is(err, sql.ErrNoRows) {
+		return decimal.Zero, nil
+	}
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return w.Balance, nil
+}
+
+// walletKey 结算/退款按用户与币种分组的键
+type walletKey struct {
+	userID   int64
+	currency string
+}
+
+// postLedgers 从 before 余额起依次记入账本（Amount 为非负金额，debit 为扣款），填充每条的变动前/后余额并返回最终余额
+// 账本链式衔接：后一条的变动前余额等于前一条的变动后余额，最终余额 = before ± Σ Amount
+func postLedgers(before decimal.Decimal, debit bool, ledgers []*model.WalletLedger) decimal.Decimal {
+	running := before.Round(2)
+	for _, l := range ledgers {
+		l.BeforeAmount = running
+		if debit {
+			running = running.Sub(l.Amount).Round(2)
+		} else {
+			running = running.Add(l.Amount).Round(2)
+		}
+		l.AfterAmount = running
+	}
+	return running
+}
+
+// ledgerTotal 一组账本的金额合计
+func ledgerTotal(ledgers []*model.WalletLedger) decimal.Decimal {
+	total := decimal.Zero
+	for _, l := range ledgers {
+		total = total.Add(l.Amount)
+	}
+	return total
+}
@@ -0,0 +1,139 @@
+package shoe
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// DefaultDecks 默认牌靴副数（龙虎标准 8 副牌，共 416 张）
+const DefaultDecks = 8
+
+// 花色：S=黑桃 H=红心 D=方块 C=梅花
+var suits = []byte{'S', 'H', 'D', 'C'}
+
+// Card 单张牌：Rank 1..13（A=1, J=11, Q=12, K=13），Suit 为花色字母
+type Card struct {
+	Rank int
+	Suit byte
+}
+
+// String 编码为 "<点数><花色>"，例如 "13S"、"1H"
+func (c Card) String() string {
+	return strconv.Itoa(c.Rank) + string(c.Suit)
+}
+
+// ParseCard 解析 "<点数><花色>" 格式的单张牌
+func ParseCard(s string) (Card, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if len(s) < 2 {
+		return Card{}, fmt.Errorf("invalid card: %q", s)
+	}
+	suit := s[len(s)-1]
+	if !isSuit(suit) {
+		return Card{}, fmt.Errorf("invalid card suit: %q", s)
+	}
+	rank, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || rank < 1 || rank > 13 {
+		return Card{}, fmt.Errorf("invalid card rank: %q", s)
+	}
+	return Card{Rank: rank, Suit: suit}, nil
+}
+
+func isSuit(b byte) bool {
+	for _, s := range suits {
+		if s == b {
+			return true
+		}
+	}
+	return false
+}
+
+// Encode 将牌序列编码为逗号分隔字符串（用于入库）
+func Encode(cards []Card) string {
+	parts := make([]string, len(cards))
+	for i, c := range cards {
+		parts[i] = c.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+// Decode 解析 Encode 生成的字符串
+func Decode(s string) ([]Card, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	parts := strings.Split(s, ",")
+	out := make([]Card, 0, len(parts))
+	for _, p := range parts {
+		c, err := ParseCard(p)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+// NewDeck 按副数生成未洗的牌序
+func NewDeck(decks int) []Card {
+	if decks <= 0 {
+		decks = DefaultDecks
+	}
+	out := make([]Card, 0, decks*52)
+	for d := 0; d < decks; d++ {
+		for _, s := range suits {
+			for r := 1; r <= 13; r++ {
+				out = append(out, Card{Rank: r, Suit: s})
+			}
+		}
+	}
+	return out
+}
+
+// Shuffle 使用 crypto/rand 做 Fisher-Yates 洗牌
+func Shuffle(cards []Card) error {
+	for i := len(cards) - 1; i > 0; i-- {
+		j, err := randInt(i + 1)
+		if err != nil {
+			return err
+		}
+		cards[i], cards[j] = cards[j], cards[i]
+	}
+	return nil
+}
+
+// CutPosition 随机放置切牌位置：距离靴尾 1 副到 1.5 副牌之间
+// 发牌位置到达切牌位置后，当前局发完即需换靴
+func CutPosition(total int) (int, error) {
+	if total < 104 {
+		return 0, errors.New("shoe too small")
+	}
+	off, err := randInt(27)
+	if err != nil {
+		return 0, err
+	}
+	return total - 52 - off, nil
+}
+
+// BurnCount 开靴烧牌张数：翻开第一张牌，按其点数再烧掉相应张数（J/Q/K 计 10）
+// 返回值包含翻开的第一张
+func BurnCount(first Card) int {
+	n := first.Rank
+	if n > 10 {
+		n = 10
+	}
+	return n + 1
+}
+
+func randInt(n int) (int, error) {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(v.Int64()), nil
+}
@@ -0,0 +1,72 @@
+package state
+
+import "fmt"
+
+// Default 龙虎牌局状态机（状态码、事件码、守卫与钩子集中定义于此）
+// 包初始化时完成自检，定义不合法会直接 panic，避免带病启动
+var Default = MustNew(GameRoundDefinition())
+
+// GameRoundDefinition 牌局状态机定义
+//
+// 状态码对应 game_round_info.game_status；事件码对应 /api/game_event 的 event_type 与审计 event_type
+func GameRoundDefinition() Definition {
+	enter := []Hook{logTransition}
+	return Definition{
+		Initial: StateInit,
+		States: []StateDef{
+			{Name: StateInit, Code: 1},
+			{Name: StateBetting, Code: 2, OnEnter: enter},
+			{Name: StateSealed, Code: 3, OnEnter: enter},
+			{Name: StateDealt, Code: 4, OnEnter: enter},
+			{Name: StateDrawn, Code: 5, OnEnter: enter},
+			{Name: StateSettled, Code: 6, OnEnter: enter},
+			{Name: StateFinished, Code: 7, Terminal: true, OnEnter: enter},
+			{Name: StateCancelled, Code: 8, Terminal: true, OnEnter: enter},
+		},
+		Events: []EventDef{
+			{Name: EvtGameStart, Code: 1},
+			{Name: EvtGameStop, Code: 2},
+			{Name: EvtNewCard, Code: 3},
+			{Name: EvtGameDraw, Code: 4},
+			{Name: EvtGameEnd, Code: 5},
+			{Name: EvtGameCancel, Code: 6},
+			{Name: EvtDrawResult, Internal: true},
+		},
+		Transitions: []TransitionDef{
+			{From: StateInit, Event: EvtGameStart, To: StateBetting},
+			{From: StateBetting, Event: EvtGameStop, To: StateSealed},
+			{From: StateSealed, Event: EvtNewCard, To: StateDealt},
+			{From: StateDealt, Event: EvtGameDraw, To: StateDrawn},
+			{From: StateDrawn, Event: EvtDrawResult, To: StateSettled, Guards: []Guard{notSettled}},
+			{From: StateSettled, Event: EvtGameEnd, To: StateFinished, Guards: []Guard{hasResult}},
+			// 兼容：开奖结果已写入但未走结算的旧数据
+			{From: StateDrawn, Event: EvtGameEnd, To: StateFinished, Guards: []Guard{hasResult}},
+			// 结算前的任意进行中状态都可以作废
+			{From: StateBetting, Event: EvtGameCancel, To: StateCancelled, Guards: []Guard{notSettled}},
+			{From: StateSealed, Event: EvtGameCancel, To: StateCancelled, Guards: []Guard{notSettled}},
+			{From: StateDealt, Event: EvtGameCancel, To: StateCancelled, Guards: []Guard{notSettled}},
+			{From: StateDrawn, Event: EvtGameCancel, To: StateCancelled, Guards: []Guard{notSettled}},
+		},
+	}
+}
+
+// notSettled 已结算的牌局不能再结算或作废
+func notSettled(t *Transition) error {
+	if t.Snapshot.IsSettled {
+		return ErrAlreadySettled
+	}
+	return nil
+}
+
+// hasResult 必须已有开奖结果才能结束
+func hasResult(t *Transition) error {
+	if !t.Snapshot.HasResult {
+		return ErrNoDrawResult
+	}
+	return nil
+}
+
+func logTransition(t *Transition) error {
+	fmt.Printf("[StateMachine] %s --%s--> %s\n", t.From, t.Event, t.To)
+	return nil
+}
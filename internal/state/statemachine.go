@@ -1,54 +1,341 @@
 package state
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
 
 // State 游戏状态
 const (
-	StateInit     = "init"     // 初始化/未开始
-	StateBetting  = "betting"  // 下注中(game_start~game_stop)
-	StateSealed   = "sealed"   // 已封盘(game_stop)
-	StateDealt    = "dealt"    // 已发牌(new_card)
-	StateDrawn    = "drawn"    // 已开奖(game_draw)
-	StateSettled  = "settled"  // 已结算(drawresult)
-	StateFinished = "finished" // 已结束(game_end)
+	StateInit      = "init"      // 初始化/未开始
+	StateBetting   = "betting"   // 下注中(game_start~game_stop)
+	StateSealed    = "sealed"    // 已封盘(game_stop)
+	StateDealt     = "dealt"     // 已发牌(new_card)
+	StateDrawn     = "drawn"     // 已开奖(game_draw)
+	StateSettled   = "settled"   // 已结算(drawresult)
+	StateFinished  = "finished"  // 已结束(game_end)
+	StateCancelled = "cancelled" // 已取消(game_cancel，注单全额退款)
 )
 
 // Event 游戏事件
 const (
-	EvtGameStart = "game_start"
-	EvtGameStop  = "game_stop"
-	EvtNewCard   = "new_card"
-	EvtGameDraw  = "game_draw"
-	EvtGameEnd   = "game_end"
+	EvtGameStart  = "game_start"
+	EvtGameStop   = "game_stop"
+	EvtNewCard    = "new_card"
+	EvtGameDraw   = "game_draw"
+	EvtGameEnd    = "game_end"
+	EvtGameCancel = "game_cancel" // 作废本局（误发牌/现场故障），仅结算前可用
+	EvtDrawResult = "draw_result" // 开奖结算（/api/drawresult，内部事件，不接受 event_type 传入）
+)
+
+var (
+	ErrInvalidTransition = errors.New("invalid state transition")
+	ErrNoDrawResult      = errors.New("guard: draw result not found")
+	ErrAlreadySettled    = errors.New("guard: round already settled")
 )
 
-// NextState 根据当前状态与事件计算下一个状态，非法转换报错
-func NextState(cur, evt string) (string, error) {
-	switch cur {
-	case StateInit:
-		if evt == EvtGameStart {
-			return StateBetting, nil
+// Snapshot 守卫判断所需的回合快照（由调用方在事务内加锁读取后填充）
+type Snapshot struct {
+	IsSettled bool // 是否已结算
+	HasResult bool // 是否已有开奖结果（牌面与结果均已写入）
+}
+
+// Transition 一次状态转换的上下文，传给守卫与钩子
+type Transition struct {
+	From     string
+	Event    string
+	To       string
+	Snapshot Snapshot
+}
+
+// Guard 守卫：返回错误则拒绝转换
+type Guard func(t *Transition) error
+
+// Hook 进入/离开状态时执行；在调用方事务内执行，返回错误将中止转换，不应产生外部副作用
+type Hook func(t *Transition) error
+
+// StateDef 状态定义
+type StateDef struct {
+	Name     string
+	Code     int8 // 对应 game_round_info.game_status
+	Terminal bool // 终态：不允许再有出边
+	OnEnter  []Hook
+	OnExit   []Hook
+}
+
+// EventDef 事件定义
+type EventDef struct {
+	Name     string
+	Code     int8 // 对应 /api/game_event 的 event_type 与审计 event_type；0 表示内部事件
+	Internal bool // 内部事件：不能通过 event_type 触发
+}
+
+// TransitionDef 转换定义
+type TransitionDef struct {
+	From   string
+	Event  string
+	To     string
+	Guards []Guard
+}
+
+// Definition 状态机完整定义
+type Definition struct {
+	Initial     string
+	States      []StateDef
+	Events      []EventDef
+	Transitions []TransitionDef
+}
+
+// Machine 表驱动状态机（构建后只读，可并发使用）
+type Machine struct {
+	def         Definition
+	states      map[string]*StateDef
+	stateByCode map[int8]*StateDef
+	events      map[string]*EventDef
+	eventByCode map[int8]*EventDef
+	trans       map[string]*TransitionDef // key: from|event
+}
+
+// New 根据定义构建状态机并校验
+func New(def Definition) (*Machine, error) {
+	m := &Machine{
+		def:         def,
+		states:      make(map[string]*StateDef),
+		stateByCode: make(map[int8]*StateDef),
+		events:      make(map[string]*EventDef),
+		eventByCode: make(map[int8]*EventDef),
+		trans:       make(map[string]*TransitionDef),
+	}
+	for i := range m.def.States {
+		s := &m.def.States[i]
+		if s.Name == "" {
+			return nil, fmt.Errorf("state #%d has empty name", i)
+		}
+		if _, dup := m.states[s.Name]; dup {
+			return nil, fmt.Errorf("duplicate state %q", s.Name)
 		}
-	case StateBetting:
-		if evt == EvtGameStop {
-			return StateSealed, nil
+		if other, dup := m.stateByCode[s.Code]; dup {
+			return nil, fmt.Errorf("state %q reuses code %d of %q", s.Name, s.Code, other.Name)
 		}
-	case StateSealed:
-		if evt == EvtNewCard {
-			return StateDealt, nil
+		m.states[s.Name] = s
+		m.stateByCode[s.Code] = s
+	}
+	for i := range m.def.Events {
+		e := &m.def.Events[i]
+		if e.Name == "" {
+			return nil, fmt.Errorf("event #%d has empty name", i)
+		}
+		if _, dup := m.events[e.Name]; dup {
+			return nil, fmt.Errorf("duplicate event %q", e.Name)
+		}
+		if !e.Internal {
+			if e.Code <= 0 {
+				return nil, fmt.Errorf("external event %q needs a positive code", e.Name)
+			}
+			if other, dup := m.eventByCode[e.Code]; dup {
+				return nil, fmt.Errorf("event %q reuses code %d of %q", e.Name, e.Code, other.Name)
+			}
+			m.eventByCode[e.Code] = e
+		}
+		m.events[e.Name] = e
+	}
+	for i := range m.def.Transitions {
+		t := &m.def.Transitions[i]
+		from, ok := m.states[t.From]
+		if !ok {
+			return nil, fmt.Errorf("transition #%d: unknown from state %q", i, t.From)
+		}
+		if _, ok := m.states[t.To]; !ok {
+			return nil, fmt.Errorf("transition #%d: unknown to state %q", i, t.To)
 		}
-	case StateDealt:
-		if evt == EvtGameDraw {
-			return StateDrawn, nil
+		if _, ok := m.events[t.Event]; !ok {
+			return nil, fmt.Errorf("transition #%d: unknown event %q", i, t.Event)
 		}
-	case StateDrawn:
-		if evt == EvtGameEnd {
-			return StateFinished, nil
+		if from.Terminal {
+			return nil, fmt.Errorf("transition #%d: terminal state %q has outgoing edge", i, t.From)
 		}
-	case StateSettled:
-		if evt == EvtGameEnd {
-			return StateFinished, nil
+		key := t.From + "|" + t.Event
+		if _, dup := m.trans[key]; dup {
+			return nil, fmt.Errorf("duplicate transition %s --%s-->", t.From, t.Event)
+		}
+		m.trans[key] = t
+	}
+	if err := m.validateGraph(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// MustNew 构建失败直接 panic（用于包初始化阶段的启动自检）
+func MustNew(def Definition) *Machine {
+	m, err := New(def)
+	if err != nil {
+		panic("state machine definition invalid: " + err.Error())
+	}
+	return m
+}
+
+// validateGraph 校验：初始状态存在、所有状态从初始状态可达、非终态至少有一条出边、事件均被使用
+func (m *Machine) validateGraph() error {
+	if _, ok := m.states[m.def.Initial]; !ok {
+		return fmt.Errorf("unknown initial state %q", m.def.Initial)
+	}
+	out := make(map[string][]string)
+	usedEvents := make(map[string]bool)
+	for _, t := range m.def.Transitions {
+		out[t.From] = append(out[t.From], t.To)
+		usedEvents[t.Event] = true
+	}
+	seen := map[string]bool{m.def.Initial: true}
+	queue := []string{m.def.Initial}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, nxt := range out[cur] {
+			if !seen[nxt] {
+				seen[nxt] = true
+				queue = append(queue, nxt)
+			}
+		}
+	}
+	for _, s := range m.def.States {
+		if !seen[s.Name] {
+			return fmt.Errorf("state %q unreachable from %q", s.Name, m.def.Initial)
+		}
+		if !s.Terminal && len(out[s.Name]) == 0 {
+			return fmt.Errorf("non-terminal state %q has no outgoing transition", s.Name)
+		}
+	}
+	for _, e := range m.def.Events {
+		if !usedEvents[e.Name] {
+			return fmt.Errorf("event %q is not used by any transition", e.Name)
+		}
+	}
+	return nil
+}
+
+// Fire 计算并校验一次转换：查表 -> 守卫 -> 离开钩子 -> 进入钩子，返回目标状态
+// 非法转换返回包装了 ErrInvalidTransition 的错误；守卫拒绝时返回守卫的错误
+func (m *Machine) Fire(from, evt string, snap Snapshot) (string, error) {
+	t, ok := m.trans[from+"|"+evt]
+	if !ok {
+		return from, fmt.Errorf("%w: %s --%s--> ?", ErrInvalidTransition, from, evt)
+	}
+	tc := &Transition{From: from, Event: evt, To: t.To, Snapshot: snap}
+	for _, g := range t.Guards {
+		if err := g(tc); err != nil {
+			return from, err
+		}
+	}
+	for _, h := range m.states[from].OnExit {
+		if err := h(tc); err != nil {
+			return from, err
+		}
+	}
+	for _, h := range m.states[t.To].OnEnter {
+		if err := h(tc); err != nil {
+			return from, err
+		}
+	}
+	return t.To, nil
+}
+
+// Initial 初始状态名
+func (m *Machine) Initial() string { return m.def.Initial }
+
+// StateName 状态码 -> 状态名，未知状态码返回初始状态
+func (m *Machine) StateName(code int8) string {
+	if s, ok := m.stateByCode[code]; ok {
+		return s.Name
+	}
+	return m.def.Initial
+}
+
+// StateCode 状态名 -> 状态码，未知状态返回初始状态码
+func (m *Machine) StateCode(name string) int8 {
+	if s, ok := m.states[name]; ok {
+		return s.Code
+	}
+	return m.states[m.def.Initial].Code
+}
+
+// EventByCode 按 event_type 查找外部事件（内部事件不可通过数值触发）
+func (m *Machine) EventByCode(code int8) (string, bool) {
+	e, ok := m.eventByCode[code]
+	if !ok {
+		return "", false
+	}
+	return e.Name, true
+}
+
+// EventCode 事件名 -> 事件码（内部事件为 0）
+func (m *Machine) EventCode(name string) int8 {
+	if e, ok := m.events[name]; ok {
+		return e.Code
+	}
+	return 0
+}
+
+// Mermaid 导出 Mermaid stateDiagram-v2
+func (m *Machine) Mermaid() string {
+	var b strings.Builder
+	b.WriteString("stateDiagram-v2\n")
+	fmt.Fprintf(&b, "    [*] --> %s\n", m.def.Initial)
+	for _, t := range m.def.Transitions {
+		fmt.Fprintf(&b, "    %s --> %s: %s%s\n", t.From, t.To, m.edgeLabel(t), guardSuffix(t))
+	}
+	for _, s := range m.sortedTerminals() {
+		fmt.Fprintf(&b, "    %s --> [*]\n", s)
+	}
+	return b.String()
+}
+
+// DOT 导出 Graphviz DOT
+func (m *Machine) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph game_round {\n")
+	b.WriteString("    rankdir=LR;\n")
+	b.WriteString("    node [shape=box, style=rounded];\n")
+	for _, s := range m.def.States {
+		shape := ""
+		if s.Terminal {
+			shape = ", peripheries=2"
+		}
+		fmt.Fprintf(&b, "    %s [label=\"%s (%d)\"%s];\n", s.Name, s.Name, s.Code, shape)
+	}
+	b.WriteString("    __start [shape=point];\n")
+	fmt.Fprintf(&b, "    __start -> %s;\n", m.def.Initial)
+	for _, t := range m.def.Transitions {
+		fmt.Fprintf(&b, "    %s -> %s [label=\"%s%s\"];\n", t.From, t.To, m.edgeLabel(t), guardSuffix(t))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func (m *Machine) edgeLabel(t TransitionDef) string {
+	e := m.events[t.Event]
+	if e.Internal {
+		return e.Name
+	}
+	return fmt.Sprintf("%s(%d)", e.Name, e.Code)
+}
+
+func guardSuffix(t TransitionDef) string {
+	if len(t.Guards) == 0 {
+		return ""
+	}
+	return " / guarded"
+}
+
+func (m *Machine) sortedTerminals() []string {
+	var out []string
+	for _, s := range m.def.States {
+		if s.Terminal {
+			out = append(out, s.Name)
 		}
 	}
-	return cur, fmt.Errorf("invalid transition: %s --%s--> ?", cur, evt)
+	sort.Strings(out)
+	return out
 }
@@ -0,0 +1,265 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// legacyNextState 表驱动前 NextState 允许的全部转换
+var legacyNextState = map[[2]string]string{
+	{StateInit, EvtGameStart}:     StateBetting,
+	{StateBetting, EvtGameStop}:   StateSealed,
+	{StateSealed, EvtNewCard}:     StateDealt,
+	{StateDealt, EvtGameDraw}:     StateDrawn,
+	{StateDrawn, EvtGameEnd}:      StateFinished,
+	{StateSettled, EvtGameEnd}:    StateFinished,
+	{StateBetting, EvtGameCancel}: StateCancelled,
+	{StateSealed, EvtGameCancel}:  StateCancelled,
+	{StateDealt, EvtGameCancel}:   StateCancelled,
+	{StateDrawn, EvtGameCancel}:   StateCancelled,
+}
+
+func TestDefaultKeepsLegacyTransitions(t *testing.T) {
+	if _, err := New(GameRoundDefinition()); err != nil {
+		t.Fatalf("default definition: %v", err)
+	}
+	// 新增的结算转换
+	want := map[[2]string]string{{StateDrawn, EvtDrawResult}: StateSettled}
+	for k, v := range legacyNextState {
+		want[k] = v
+	}
+
+	def := GameRoundDefinition()
+	ok := Snapshot{HasResult: true}
+	for _, s := range def.States {
+		for _, e := range def.Events {
+			to, err := Default.Fire(s.Name, e.Name, ok)
+			w, allowed := want[[2]string{s.Name, e.Name}]
+			if !allowed {
+				if !errors.Is(err, ErrInvalidTransition) || to != s.Name {
+					t.Fatalf("%s --%s-->: to=%s err=%v, want ErrInvalidTransition", s.Name, e.Name, to, err)
+				}
+				continue
+			}
+			if err != nil || to != w {
+				t.Fatalf("%s --%s-->: to=%s err=%v, want %s", s.Name, e.Name, to, err, w)
+			}
+		}
+	}
+
+	// 每个状态都能从初始状态经 Fire 到达
+	seen := map[string]bool{Default.Initial(): true}
+	queue := []string{Default.Initial()}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, e := range def.Events {
+			if to, err := Default.Fire(cur, e.Name, ok); err == nil && !seen[to] {
+				seen[to] = true
+				queue = append(queue, to)
+			}
+		}
+	}
+	for _, s := range def.States {
+		if !seen[s.Name] {
+			t.Fatalf("state %s unreachable", s.Name)
+		}
+	}
+}
+
+func TestDefaultCodes(t *testing.T) {
+	states := map[string]int8{
+		StateInit: 1, StateBetting: 2, StateSealed: 3, StateDealt: 4,
+		StateDrawn: 5, StateSettled: 6, StateFinished: 7, StateCancelled: 8,
+	}
+	for name, code := range states {
+		if got := Default.StateCode(name); got != code {
+			t.Fatalf("StateCode(%s)=%d, want %d", name, got, code)
+		}
+		if got := Default.StateName(code); got != name {
+			t.Fatalf("StateName(%d)=%s, want %s", code, got, name)
+		}
+	}
+	if got := Default.StateName(99); got != StateInit {
+		t.Fatalf("StateName(99)=%s, want %s", got, StateInit)
+	}
+	for code, name := range map[int8]string{1: EvtGameStart, 2: EvtGameStop, 3: EvtNewCard, 4: EvtGameDraw, 5: EvtGameEnd, 6: EvtGameCancel} {
+		if got, ok := Default.EventByCode(code); !ok || got != name {
+			t.Fatalf("EventByCode(%d)=%s,%v, want %s", code, got, ok, name)
+		}
+	}
+	// 内部事件不能通过 event_type 触发
+	if got, ok := Default.EventByCode(0); ok {
+		t.Fatalf("EventByCode(0)=%s, want none", got)
+	}
+	if got := Default.EventCode(EvtDrawResult); got != 0 {
+		t.Fatalf("EventCode(draw_result)=%d, want 0", got)
+	}
+}
+
+// miniDefinition 最小合法定义：a --go--> b（终态）
+func miniDefinition() Definition {
+	return Definition{
+		Initial: "a",
+		States:  []StateDef{{Name: "a", Code: 1}, {Name: "b", Code: 2, Terminal: true}},
+		Events:  []EventDef{{Name: "go", Code: 1}},
+		Transitions: []TransitionDef{
+			{From: "a", Event: "go", To: "b"},
+		},
+	}
+}
+
+func TestNewRejectsInvalidDefinition(t *testing.T) {
+	if _, err := New(miniDefinition()); err != nil {
+		t.Fatalf("mini definition: %v", err)
+	}
+	cases := []struct {
+		name   string
+		mutate func(d *Definition)
+		errMsg string
+	}{
+		{"duplicate state", func(d *Definition) { d.States = append(d.States, StateDef{Name: "a", Code: 3}) }, `duplicate state "a"`},
+		{"duplicate state code", func(d *Definition) { d.States[1].Code = 1 }, "reuses code 1"},
+		{"duplicate event", func(d *Definition) { d.Events = append(d.Events, EventDef{Name: "go", Code: 2}) }, `duplicate event "go"`},
+		{"duplicate event code", func(d *Definition) {
+			d.Events = append(d.Events, EventDef{Name: "back", Code: 1})
+		}, "reuses code 1"},
+		{"external event without code", func(d *Definition) { d.Events[0].Code = 0 }, "needs a positive code"},
+		{"unknown to state", func(d *Definition) { d.Transitions[0].To = "c" }, `unknown to state "c"`},
+		{"unknown from state", func(d *Definition) { d.Transitions[0].From = "c" }, `unknown from state "c"`},
+		{"unknown event", func(d *Definition) { d.Transitions[0].Event = "stop" }, `unknown event "stop"`},
+		{"duplicate transition", func(d *Definition) {
+			d.Transitions = append(d.Transitions, TransitionDef{From: "a", Event: "go", To: "a"})
+		}, "duplicate transition a --go-->"},
+		{"terminal with outgoing edge", func(d *Definition) {
+			d.Transitions = append(d.Transitions, TransitionDef{From: "b", Event: "go", To: "a"})
+		}, `terminal state "b" has outgoing edge`},
+		{"unknown initial", func(d *Definition) { d.Initial = "x" }, `unknown initial state "x"`},
+		{"unreachable state", func(d *Definition) {
+			d.States = append(d.States, StateDef{Name: "c", Code: 3, Terminal: true})
+		}, `state "c" unreachable`},
+		{"dead end", func(d *Definition) { d.States[1].Terminal = false }, `non-terminal state "b" has no outgoing transition`},
+		{"unused event", func(d *Definition) { d.Events = append(d.Events, EventDef{Name: "idle", Internal: true}) }, `event "idle" is not used`},
+	}
+	for _, c := range cases {
+		d := miniDefinition()
+		c.mutate(&d)
+		_, err := New(d)
+		if err == nil || !strings.Contains(err.Error(), c.errMsg) {
+			t.Fatalf("%s: err=%v, want containing %q", c.name, err, c.errMsg)
+		}
+	}
+
+	// MustNew 在定义非法时 panic
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("MustNew did not panic on invalid definition")
+			}
+		}()
+		d := miniDefinition()
+		d.Transitions[0].To = "c"
+		MustNew(d)
+	}()
+}
+
+func TestGuardBlocksHooks(t *testing.T) {
+	var calls []string
+	hook := func(name string) Hook {
+		return func(tr *Transition) error {
+			calls = append(calls, fmt.Sprintf("%s:%s->%s", name, tr.From, tr.To))
+			return nil
+		}
+	}
+	errDenied := errors.New("denied")
+	errHook := errors.New("hook failed")
+	d := miniDefinition()
+	d.States[0].OnExit = []Hook{hook("exit")}
+	d.States[1].OnEnter = []Hook{hook("enter")}
+	d.Transitions[0].Guards = []Guard{func(tr *Transition) error {
+		if !tr.Snapshot.HasResult {
+			return errDenied
+		}
+		return nil
+	}}
+	m := MustNew(d)
+
+	// 守卫拒绝：停留在原状态，钩子不执行
+	to, err := m.Fire("a", "go", Snapshot{})
+	if !errors.Is(err, errDenied) || to != "a" || len(calls) != 0 {
+		t.Fatalf("guard denied: to=%s err=%v calls=%v", to, err, calls)
+	}
+	// 守卫通过：先离开再进入
+	to, err = m.Fire("a", "go", Snapshot{HasResult: true})
+	if err != nil || to != "b" {
+		t.Fatalf("guard passed: to=%s err=%v", to, err)
+	}
+	if want := []string{"exit:a->b", "enter:a->b"}; strings.Join(calls, ",") != strings.Join(want, ",") {
+		t.Fatalf("calls=%v, want %v", calls, want)
+	}
+	// 离开钩子失败：中止转换，进入钩子不执行
+	calls = nil
+	d.States[0].OnExit = []Hook{func(*Transition) error { return errHook }}
+	m = MustNew(d)
+	to, err = m.Fire("a", "go", Snapshot{HasResult: true})
+	if !errors.Is(err, errHook) || to != "a" || len(calls) != 0 {
+		t.Fatalf("exit hook failed: to=%s err=%v calls=%v", to, err, calls)
+	}
+
+	// 默认状态机的守卫
+	cases := []struct {
+		from, evt string
+		snap      Snapshot
+		err       error
+	}{
+		{StateDrawn, EvtDrawResult, Snapshot{IsSettled: true, HasResult: true}, ErrAlreadySettled},
+		{StateDrawn, EvtGameCancel, Snapshot{IsSettled: true}, ErrAlreadySettled},
+		{StateBetting, EvtGameCancel, Snapshot{IsSettled: true}, ErrAlreadySettled},
+		{StateDrawn, EvtGameEnd, Snapshot{}, ErrNoDrawResult},
+		{StateSettled, EvtGameEnd, Snapshot{IsSettled: true}, ErrNoDrawResult},
+		{StateSettled, EvtGameCancel, Snapshot{IsSettled: true, HasResult: true}, ErrInvalidTransition},
+		{StateFinished, EvtGameCancel, Snapshot{}, ErrInvalidTransition},
+		{StateCancelled, EvtGameEnd, Snapshot{HasResult: true}, ErrInvalidTransition},
+	}
+	for _, c := range cases {
+		to, err := Default.Fire(c.from, c.evt, c.snap)
+		if !errors.Is(err, c.err) || to != c.from {
+			t.Fatalf("%s --%s--> %+v: to=%s err=%v, want %v", c.from, c.evt, c.snap, to, err, c.err)
+		}
+	}
+}
+
+func TestExportContainsEveryEdge(t *testing.T) {
+	mermaid, dot := Default.Mermaid(), Default.DOT()
+	for _, tr := range GameRoundDefinition().Transitions {
+		label := tr.Event
+		if code := Default.EventCode(tr.Event); code != 0 {
+			label = fmt.Sprintf("%s(%d)", tr.Event, code)
+		}
+		if len(tr.Guards) > 0 {
+			label += " / guarded"
+		}
+		if edge := fmt.Sprintf("%s --> %s: %s\n", tr.From, tr.To, label); !strings.Contains(mermaid, edge) {
+			t.Fatalf("mermaid missing %q:\n%s", edge, mermaid)
+		}
+		if edge := fmt.Sprintf("%s -> %s [label=\"%s\"];\n", tr.From, tr.To, label); !strings.Contains(dot, edge) {
+			t.Fatalf("dot missing %q:\n%s", edge, dot)
+		}
+	}
+	if edges := strings.Count(mermaid, " --> ") - 1 - 2; edges != len(GameRoundDefinition().Transitions) {
+		t.Fatalf("mermaid has %d edges, want %d", edges, len(GameRoundDefinition().Transitions))
+	}
+	for _, s := range []string{StateFinished, StateCancelled} {
+		if !strings.Contains(mermaid, s+" --> [*]\n") {
+			t.Fatalf("mermaid missing terminal %s", s)
+		}
+		if !strings.Contains(dot, s+" [label=\""+s) || !strings.Contains(dot, "peripheries=2") {
+			t.Fatalf("dot missing terminal %s", s)
+		}
+	}
+	if !strings.Contains(mermaid, "[*] --> "+StateInit+"\n") || !strings.Contains(dot, "__start -> "+StateInit+";") {
+		t.Fatalf("initial state missing from export")
+	}
+}
@@ -0,0 +1,295 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+	beego "github.com/beego/beego/v2/server/web"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"dt-server/common/logger"
+	helper "dt-server/internal/common/helper"
+	infmysql "dt-server/internal/infra/mysql"
+	infmq "dt-server/internal/infra/rocketmq"
+	"dt-server/internal/metrics"
+	"dt-server/internal/model"
+	"dt-server/internal/service"
+)
+
+// 入站命令类型
+const (
+	CommandGameEvent  = "game_event"  // data 同 POST /api/game_event
+	CommandDrawResult = "draw_result" // data 同 POST /api/drawresult
+)
+
+const (
+	defaultCommandMaxAttempts = 5
+	commandMaxBackoff         = 60 * time.Second
+)
+
+// commandSubmitter MQ 命令的提交人身份（开奖复核与审计）
+const commandSubmitter = "mq"
+
+// errMalformedCommand 命令格式或参数错误，重试无意义，直接进入死信
+var errMalformedCommand = errors.New("malformed command")
+
+// command 入站命令消息体
+//
+//	{"command_id":"...","command":"game_event","trace_id":"...","data":{"game_round_id":"...","event_type":2,"seq":2}}
+//
+// command_id 为去重键（缺省取 MQ 消息ID），重试投递时保持不变；game_event 未带 event_id 时以 command_id 作为 event_id。
+type command struct {
+	CommandID string          `json:"command_id"`
+	Command   string          `json:"command"`
+	TraceID   string          `json:"trace_id,omitempty"`
+	Data      json.RawMessage `json:"data"`
+	Error     string          `json:"error,omitempty"` // 投递到死信时附带的失败原因
+}
+
+// StartCommandConsumer 启动入站命令消费者，支持通过 ctx 优雅退出
+// 荷官端/现场系统向命令 topic 发布 game_event 与 draw_result 命令，与 HTTP 接口走同一套 Service：
+//   - 按 command_id 写入 inbox 去重，业务事务提交后才写 processed_at（已处理的重复消息直接确认）
+//   - 可重试的失败（序号超前、状态未就绪、数据库错误等）延时投递到重试 topic，退避 1s,2s,4s... 最长 60s
+//   - 参数错误、冲突等不可重试的失败，或超过最大处理次数，投递到死信 topic
+//
+// 配置项：
+//   - rocketmq_command_topic（为空则不启动）
+//   - rocketmq_command_group（缺省为 rocketmq_consumer_group + "_command"）
+//   - rocketmq_command_retry_topic / rocketmq_command_dlq_topic（缺省为命令 topic 加 _retry / _dlq 后缀）
+//   - rocketmq_command_max_attempts（缺省 5）
+func StartCommandConsumer(ctx context.Context, wg *sync.WaitGroup) {
+	topic, _ := beego.AppConfig.String("rocketmq_command_topic")
+	if topic == "" {
+		return
+	}
+	// 失败命令依赖生产者转投重试/死信 topic，生产者不可用时不消费，避免丢失
+	if !infmq.Enabled() {
+		logger.Warn("[mq] command consumer not started: producer disabled", zap.String("topic", topic))
+		return
+	}
+	group, _ := beego.AppConfig.String("rocketmq_command_group")
+	if group == "" {
+		if g, _ := beego.AppConfig.String("rocketmq_consumer_group"); g != "" {
+			group = g + "_command"
+		}
+	}
+	cc := &commandConsumer{
+		pub:         infmq.PublisherInstance(),
+		retryTopic:  beego.AppConfig.DefaultString("rocketmq_command_retry_topic", topic+"_retry"),
+		dlqTopic:    beego.AppConfig.DefaultString("rocketmq_command_dlq_topic", topic+"_dlq"),
+		maxAttempts: beego.AppConfig.DefaultInt("rocketmq_command_max_attempts", defaultCommandMaxAttempts),
+		events:      service.NewGameEventService(),
+		draws:       service.NewDrawService(),
+	}
+	sc := startSimpleConsumer(group, []string{topic, cc.retryTopic})
+	if sc == nil {
+		return
+	}
+	logger.Info("[mq] command consumer started",
+		zap.String("group", group), zap.String("topic", topic),
+		zap.String("retry_topic", cc.retryTopic), zap.String("dlq_topic", cc.dlqTopic))
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer sc.GracefulStop()
+		for {
+			if ctx.Err() != nil {
+				return
+			}
+			mvs, err := sc.Receive(ctx, 16, 30*time.Second)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				logger.Warn("[mq] command receive error", zap.Error(err))
+				continue
+			}
+			// 按接收顺序逐条处理，同一局的命令不并发
+			for _, mv := range mvs {
+				if !cc.handle(ctx, mv) {
+					continue // 不确认：可见性超时后由 broker 重新投递
+				}
+				if err := sc.Ack(ctx, mv); err != nil {
+					logger.Warn("[mq] command ack failed", zap.String("id", mv.GetMessageId()), zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+type commandConsumer struct {
+	pub         infmq.Publisher
+	retryTopic  string
+	dlqTopic    string
+	maxAttempts int
+	events      service.GameEventService
+	draws       service.DrawService
+}
+
+// handle 处理一条命令，返回是否可以确认消息
+func (cc *commandConsumer) handle(ctx context.Context, mv *rmq.MessageView) bool {
+	body := mv.GetBody()
+	var cmd command
+	if err := json.Unmarshal(body, &cmd); err != nil || cmd.Command == "" || len(cmd.CommandID) > 64 {
+		cmd.CommandID = mv.GetMessageId()
+		return cc.deadLetter(&cmd, body, fmt.Errorf("%w: invalid envelope", errMalformedCommand))
+	}
+	if cmd.CommandID == "" {
+		cmd.CommandID = mv.GetMessageId()
+	}
+	if cmd.TraceID == "" {
+		cmd.TraceID = uuid.NewString()
+	}
+
+	processedAt, attempts, err := model.ClaimInbox(ctx, infmysql.SQLX(), cmd.CommandID, mv.GetTopic(), string(body))
+	if err != nil {
+		logger.Warn("[mq] claim inbox failed", zap.String("command_id", cmd.CommandID), zap.Error(err))
+		return false
+	}
+	if processedAt > 0 {
+		metrics.RecordMQCommand(cmd.Command, "duplicate")
+		logger.Info("[mq] duplicate command skipped",
+			zap.String("command_id", cmd.CommandID), zap.String("command", cmd.Command), zap.String("trace_id", cmd.TraceID))
+		return true
+	}
+
+	err = cc.dispatch(ctx, &cmd)
+	if err == nil {
+		// Service 内部事务已提交；标记失败时不确认，重新投递后由事件ID/结算幂等识别为重复
+		if err := model.MarkInboxProcessed(ctx, infmysql.SQLX(), cmd.CommandID); err != nil {
+			logger.Warn("[mq] mark inbox processed failed", zap.String("command_id", cmd.CommandID), zap.Error(err))
+			return false
+		}
+		metrics.RecordMQCommand(cmd.Command, "success")
+		return true
+	}
+
+	_ = model.MarkInboxError(ctx, infmysql.SQLX(), cmd.CommandID, truncateErr(err))
+	if !retryableCommandErr(err) || attempts >= cc.maxAttempts {
+		return cc.deadLetter(&cmd, body, err)
+	}
+	delay := commandBackoff(attempts)
+	b, _ := json.Marshal(cmd)
+	if err := cc.pub.PublishDelayed(cc.retryTopic, b, delay); err != nil {
+		logger.Warn("[mq] publish command retry failed", zap.String("command_id", cmd.CommandID), zap.Error(err))
+		return false
+	}
+	metrics.RecordMQCommand(cmd.Command, "retry")
+	logger.Warn("[mq] command failed, scheduled retry",
+		zap.String("command_id", cmd.CommandID),
+		zap.String("command", cmd.Command),
+		zap.Int("attempts", attempts),
+		zap.Duration("delay", delay),
+		zap.String("trace_id", cmd.TraceID),
+		zap.Error(err))
+	return true
+}
+
+// dispatch 按命令类型调用对应 Service
+func (cc *commandConsumer) dispatch(ctx context.Context, cmd *command) error {
+	switch cmd.Command {
+	case CommandGameEvent:
+		var p helper.GameEventParsed
+		if err := json.Unmarshal(cmd.Data, &p); err != nil {
+			return fmt.Errorf("%w: %v", errMalformedCommand, err)
+		}
+		if ok, msg := helper.ValidateGameEvent(&p); !ok {
+			return fmt.Errorf("%w: %s", errMalformedCommand, msg)
+		}
+		if p.EventId == "" {
+			p.EventId = cmd.CommandID
+		}
+		return cc.events.Handle(ctx, service.GameEventInput{
+			GameID:      p.GameId,
+			RoomID:      p.RoomId,
+			GameRoundID: p.GameRoundId,
+			EventType:   int8(p.EventType),
+			EventID:     p.EventId,
+			Seq:         p.Seq,
+			Source:      "mq",
+			Reason:      p.Reason,
+			TraceID:     cmd.TraceID,
+		})
+	case CommandDrawResult:
+		var p helper.DrawResultParsed
+		if err := json.Unmarshal(cmd.Data, &p); err != nil {
+			return fmt.Errorf("%w: %v", errMalformedCommand, err)
+		}
+		if ok, msg := helper.ValidateDrawResult(&p); !ok {
+			return fmt.Errorf("%w: %s", errMalformedCommand, msg)
+		}
+		// 开启开奖复核的房间只写入待复核记录，由管理员复核。命令 topic 只经 MQ ACL 认证，
+		// 提交人固定为 mq，不取消息中的 operator；也不能冒充扫描设备参与双扫描自动比对（source 须为空或 manual）
+		_, err := cc.draws.SubmitDrawResult(ctx, service.DrawInput{
+			GameID:      p.GameId,
+			RoomID:      p.RoomId,
+			GameRoundID: p.GameRoundId,
+			CardList:    p.CardList,
+			Override:    p.Override,
+			Operator:    p.Operator,
+			Source:      p.Source,
+			Submitter:   commandSubmitter,
+			TraceID:     cmd.TraceID,
+		})
+		return err
+	}
+	return fmt.Errorf("%w: unknown command %q", errMalformedCommand, cmd.Command)
+}
+
+// deadLetter 投递到死信 topic（附带失败原因），返回是否可以确认原消息
+func (cc *commandConsumer) deadLetter(cmd *command, raw []byte, cause error) bool {
+	cmd.Error = cause.Error()
+	b, err := json.Marshal(cmd)
+	if err != nil || cmd.Data == nil {
+		// 消息体无法解析：原样投递
+		b = raw
+	}
+	if err := cc.pub.Publish(cc.dlqTopic, b); err != nil {
+		logger.Warn("[mq] publish command dead letter failed", zap.String("command_id", cmd.CommandID), zap.Error(err))
+		return false
+	}
+	metrics.RecordMQCommand(cmd.Command, "dead_letter")
+	logger.Error("[mq] command dead-lettered",
+		zap.String("command_id", cmd.CommandID),
+		zap.String("command", cmd.Command),
+		zap.String("trace_id", cmd.TraceID),
+		zap.Error(cause))
+	return true
+}
+
+// retryableCommandErr 参数错误与业务冲突不重试；其余（序号超前、状态未就绪、基础设施错误）可能随时间恢复
+func retryableCommandErr(err error) bool {
+	switch {
+	case errors.Is(err, errMalformedCommand),
+		errors.Is(err, service.ErrBadRequest),
+		errors.Is(err, service.ErrEventConflict),
+		errors.Is(err, service.ErrRoomNotFound),
+		errors.Is(err, service.ErrRoomGameMismatch),
+		errors.Is(err, service.ErrCancelSettledRound),
+		errors.Is(err, service.ErrManualCardsNotAllowed),
+		errors.Is(err, service.ErrDrawResultMismatch),
+		errors.Is(err, service.ErrPendingDrawExists),
+		errors.Is(err, service.ErrCardSuitRequired):
+		return false
+	}
+	return true
+}
+
+// commandBackoff 第 n 次失败后的重试延时：1s, 2s, 4s ... 最长 commandMaxBackoff
+func commandBackoff(attempts int) time.Duration {
+	d := time.Second
+	for i := 1; i < attempts && d < commandMaxBackoff; i++ {
+		d *= 2
+	}
+	if d > commandMaxBackoff {
+		d = commandMaxBackoff
+	}
+	return d
+}
@@ -0,0 +1,55 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"dt-server/common/logger"
+	infrds "dt-server/internal/infra/redis"
+)
+
+// acquireLease 获取或续期 Redis 租约（SETNX + 持有者续期）；Redis 未配置时视为单实例部署直接放行
+func acquireLease(ctx context.Context, key, owner string, ttl time.Duration) bool {
+	r := infrds.Client()
+	if r == nil {
+		return true
+	}
+	ok, err := r.SetNX(ctx, key, owner, ttl).Result()
+	if err != nil {
+		logger.Warn("[lease] acquire lease failed", zap.String("key", key), zap.Error(err))
+		return false
+	}
+	if ok {
+		return true
+	}
+	// 已由本实例持有则续期
+	script := `
+		if redis.call("get", KEYS[1]) == ARGV[1] then
+			return redis.call("pexpire", KEYS[1], ARGV[2])
+		else
+			return 0
+		end
+	`
+	res, err := r.Eval(ctx, script, []string{key}, owner, ttl.Milliseconds()).Result()
+	return err == nil && res == int64(1)
+}
+
+// releaseLease 释放本实例持有的租约，便于其他实例尽快接管
+func releaseLease(key, owner string) {
+	r := infrds.Client()
+	if r == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	script := `
+		if redis.call("get", KEYS[1]) == ARGV[1] then
+			return redis.call("del", KEYS[1])
+		else
+			return 0
+		end
+	`
+	_ = r.Eval(ctx, script, []string{key}, owner).Err()
+}
@@ -0,0 +1,89 @@
+package worker
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	beego "github.com/beego/beego/v2/server/web"
+	"go.uber.org/zap"
+
+	"dt-server/common/logger"
+	"dt-server/internal/service"
+)
+
+// StartOddsBroadcastConsumer 启动赔率表变更广播消费者，支持通过 ctx 优雅退出
+// 管理接口新增/撤销赔率版本时经 Outbox 发布 odds_changed 消息，各实例收到后失效本地赔率缓存，下一次下注时重载。
+// 每个实例使用独立消费组，从而每个实例都能收到全部消息（广播）；未配置 MQ 时仅依赖缓存的定时指纹检测（30s）。
+//
+// 配置项：
+//   - rocketmq_odds_topic（缺省 odds_changed）
+//   - rocketmq_odds_group（缺省为 rocketmq_consumer_group + "_odds_" + 主机名）
+func StartOddsBroadcastConsumer(ctx context.Context, wg *sync.WaitGroup) {
+	topic, _ := beego.AppConfig.String("rocketmq_odds_topic")
+	if topic == "" {
+		topic = service.OddsChangedTopic
+	}
+	group, _ := beego.AppConfig.String("rocketmq_odds_group")
+	if group == "" {
+		if g, _ := beego.AppConfig.String("rocketmq_consumer_group"); g != "" {
+			host, _ := os.Hostname()
+			group = g + "_odds_" + sanitizeGroupSuffix(host)
+		}
+	}
+	sc := startSimpleConsumer(group, []string{topic})
+	if sc == nil {
+		return
+	}
+	logger.Info("[mq] odds broadcast consumer started", zap.String("group", group), zap.String("topic", topic))
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer sc.GracefulStop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+				mvs, err := sc.Receive(ctx, 16, 20*time.Second)
+				if err != nil {
+					if ctx.Err() != nil {
+						return
+					}
+					logger.Warn("[mq] odds receive error", zap.Error(err))
+					continue
+				}
+				if len(mvs) > 0 {
+					// 同一批多条变更只需失效一次
+					service.InvalidateOdds()
+					logger.Info("[mq] odds cache invalidated", zap.Int("messages", len(mvs)))
+				}
+				for _, mv := range mvs {
+					if err := sc.Ack(ctx, mv); err != nil {
+						logger.Warn("[mq] odds ack failed", zap.String("id", mv.GetMessageId()), zap.Error(err))
+					}
+				}
+			}
+		}
+	}()
+}
+
+// sanitizeGroupSuffix 消费组名只保留字母、数字、下划线与中划线
+func sanitizeGroupSuffix(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	if b.Len() == 0 {
+		return "local"
+	}
+	return b.String()
+}
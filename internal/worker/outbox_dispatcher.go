@@ -73,82 +73,17 @@ func truncateErr(err error) string {
 // - rocketmq_consumer_group
 // - rocketmq_consume_topics（可空，默认回退到 rocketmq_producer_topics）
 func StartInboxConsumer(ctx context.Context, wg *sync.WaitGroup) {
-	// Ensure RocketMQ SDK logs go to console instead of /logs
-	rmq.ResetLogger()
-
-	endpoint, _ := beego.AppConfig.String("rocketmq_endpoint")
-	if endpoint == "" {
-		endpoint, _ = beego.AppConfig.String("rocketmq_namesrv")
-	}
-	if endpoint == "" {
-		return
-	}
-	// sanitize endpoint: trim, strip scheme, pick first if contains ',' or ';'
-	endpoint = strings.TrimSpace(endpoint)
-	endpoint = strings.TrimPrefix(strings.TrimPrefix(endpoint, "http://"), "https://")
-	if idx := strings.IndexAny(endpoint, ",;"); idx > 0 {
-		endpoint = strings.TrimSpace(endpoint[:idx])
-	}
-	logger.Info("[mq] consumer endpoint", zap.String("endpoint", endpoint))
-
 	group, _ := beego.AppConfig.String("rocketmq_consumer_group")
-	if group == "" {
-		logger.Warn("[mq] consumer not started: empty rocketmq_consumer_group")
-		return
-	}
 	topicsStr, _ := beego.AppConfig.String("rocketmq_consume_topics")
 	if topicsStr == "" {
 		topicsStr, _ = beego.AppConfig.String("rocketmq_producer_topics")
 	}
-	if topicsStr == "" {
-		logger.Warn("[mq] consumer not started: empty topics")
+	sc := startSimpleConsumer(group, strings.Split(topicsStr, ","))
+	if sc == nil {
 		return
 	}
-	ak, _ := beego.AppConfig.String("rocketmq_access_key")
-	sk, _ := beego.AppConfig.String("rocketmq_secret_key")
-	if strings.TrimSpace(ak) == "" || strings.TrimSpace(sk) == "" {
-		logger.Warn("[mq] consumer not started: missing access/secret key")
-		return
-	}
-	cfg := &rmq.Config{Endpoint: endpoint, ConsumerGroup: group}
-	cfg.Credentials = &credentials.SessionCredentials{AccessKey: ak, AccessSecret: sk}
-
-	// 构造订阅表达式：多个 topic，默认 SUB_ALL
-	subs := map[string]*rmq.FilterExpression{}
-	for _, t := range strings.Split(topicsStr, ",") {
-		t = strings.TrimSpace(strings.ReplaceAll(t, ".", "_"))
-		if t == "" {
-			continue
-		}
-		subs[t] = rmq.SUB_ALL
-	}
-
-	awaitDuration := 5 * time.Second
 	maxMessageNum := int32(16)
 	invisibleDuration := 20 * time.Second
-
-	// 尝试启动 SimpleConsumer（带重试，避免容器刚启动未就绪导致一次性失败）
-	var sc rmq.SimpleConsumer
-	var err error
-	for i := 0; i < 6; i++ { // 最长约 6*3s = 18s
-		sc, err = rmq.NewSimpleConsumer(cfg,
-			rmq.WithSimpleAwaitDuration(awaitDuration),
-			rmq.WithSimpleSubscriptionExpressions(subs),
-		)
-		if err == nil {
-			if e := sc.Start(); e == nil {
-				break
-			} else {
-				err = e
-			}
-		}
-		logger.Warn("[mq] simple consumer start retry", zap.Int("attempt", i+1), zap.Error(err))
-		time.Sleep(3 * time.Second)
-	}
-	if err != nil {
-		logger.Error("[mq] start simple consumer failed", zap.Error(err))
-		return
-	}
 	logger.Info("[mq] inbox consumer started", zap.String("group", group), zap.String("topics", topicsStr))
 
 	wg.Add(1)
@@ -195,3 +130,77 @@ func StartInboxConsumer(ctx context.Context, wg *sync.WaitGroup) {
 		}
 	}()
 }
+
+// startSimpleConsumer 按 rocketmq_* 配置创建并启动 SimpleConsumer（订阅 topics，SUB_ALL）
+// 未配置 endpoint、消费组、topic 或凭证时返回 nil
+func startSimpleConsumer(group string, topics []string) rmq.SimpleConsumer {
+	// Ensure RocketMQ SDK logs go to console instead of /logs
+	rmq.ResetLogger()
+
+	endpoint, _ := beego.AppConfig.String("rocketmq_endpoint")
+	if endpoint == "" {
+		endpoint, _ = beego.AppConfig.String("rocketmq_namesrv")
+	}
+	if endpoint == "" {
+		return nil
+	}
+	// sanitize endpoint: trim, strip scheme, pick first if contains ',' or ';'
+	endpoint = strings.TrimSpace(endpoint)
+	endpoint = strings.TrimPrefix(strings.TrimPrefix(endpoint, "http://"), "https://")
+	if idx := strings.IndexAny(endpoint, ",;"); idx > 0 {
+		endpoint = strings.TrimSpace(endpoint[:idx])
+	}
+	logger.Info("[mq] consumer endpoint", zap.String("endpoint", endpoint), zap.String("group", group))
+
+	if group == "" {
+		logger.Warn("[mq] consumer not started: empty consumer group")
+		return nil
+	}
+	// 构造订阅表达式：多个 topic，默认 SUB_ALL
+	subs := map[string]*rmq.FilterExpression{}
+	for _, t := range topics {
+		t = strings.TrimSpace(strings.ReplaceAll(t, ".", "_"))
+		if t == "" {
+			continue
+		}
+		subs[t] = rmq.SUB_ALL
+	}
+	if len(subs) == 0 {
+		logger.Warn("[mq] consumer not started: empty topics", zap.String("group", group))
+		return nil
+	}
+	ak, _ := beego.AppConfig.String("rocketmq_access_key")
+	sk, _ := beego.AppConfig.String("rocketmq_secret_key")
+	if strings.TrimSpace(ak) == "" || strings.TrimSpace(sk) == "" {
+		logger.Warn("[mq] consumer not started: missing access/secret key")
+		return nil
+	}
+	cfg := &rmq.Config{Endpoint: endpoint, ConsumerGroup: group}
+	cfg.Credentials = &credentials.SessionCredentials{AccessKey: ak, AccessSecret: sk}
+
+	awaitDuration := 5 * time.Second
+
+	// 尝试启动 SimpleConsumer（带重试，避免容器刚启动未就绪导致一次性失败）
+	var sc rmq.SimpleConsumer
+	var err error
+	for i := 0; i < 6; i++ { // 最长约 6*3s = 18s
+		sc, err = rmq.NewSimpleConsumer(cfg,
+			rmq.WithSimpleAwaitDuration(awaitDuration),
+			rmq.WithSimpleSubscriptionExpressions(subs),
+		)
+		if err == nil {
+			if e := sc.Start(); e == nil {
+				break
+			} else {
+				err = e
+			}
+		}
+		logger.Warn("[mq] simple consumer start retry", zap.Int("attempt", i+1), zap.Error(err))
+		time.Sleep(3 * time.Second)
+	}
+	if err != nil {
+		logger.Error("[mq] start simple consumer failed", zap.String("group", group), zap.Error(err))
+		return nil
+	}
+	return sc
+}
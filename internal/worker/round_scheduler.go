@@ -0,0 +1,226 @@
+package worker
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"dt-server/common/logger"
+	"dt-server/internal/config"
+	infmysql "dt-server/internal/infra/mysql"
+	infrds "dt-server/internal/infra/redis"
+	"dt-server/internal/model"
+	"dt-server/internal/service"
+)
+
+// 调度默认节奏（配置为 0 时使用）
+const (
+	defaultSchedulerTick  = 500 * time.Millisecond
+	defaultDealDelay      = 5 * time.Second
+	defaultDrawDelay      = 5 * time.Second
+	defaultEndDelay       = 3 * time.Second
+	defaultNextRoundDelay = 5 * time.Second
+	schedulerLeaseTTL     = 5 * time.Second
+)
+
+// StartRoundScheduler 启动按房间自动推进牌局的调度器，支持通过 ctx 优雅退出
+// 每个房间一个 goroutine，按固定节拍读取房间最近一局的 game_status 决定下一步：
+//   - 无局 / finished(7) / cancelled(8)：生成新局号并触发 game_start
+//   - betting(2)：到达 bet_stop_time 后触发 game_stop
+//   - sealed(3)：停留 deal_delay 后触发 new_card
+//   - dealt(4)：停留 draw_delay 后触发 game_draw
+//   - drawn(5)：等待 /api/drawresult 结算
+//   - settled(6)：停留 end_delay 后触发 game_end
+//
+// 所有事件都经由 GameEventService.Handle，审计与 Outbox 与人工调用完全一致；
+// 进度只来自 game_round_info，进程重启后自然从当前 game_status 续跑。
+func StartRoundScheduler(ctx context.Context, wg *sync.WaitGroup) {
+	cfg := config.Get()
+	if cfg == nil || !cfg.Scheduler.Enabled || len(cfg.Scheduler.Rooms) == 0 {
+		return
+	}
+	tick := defaultSchedulerTick
+	if cfg.Scheduler.TickMs > 0 {
+		tick = time.Duration(cfg.Scheduler.TickMs) * time.Millisecond
+	}
+	owner := uuid.NewString()
+	svc := service.NewGameEventService()
+
+	for _, rc := range cfg.Scheduler.Rooms {
+		if strings.TrimSpace(rc.RoomID) == "" {
+			logger.Warn("[scheduler] skip room with empty room_id", zap.String("game_id", rc.GameID))
+			continue
+		}
+		rs := &roomScheduler{
+			gameID:         rc.GameID,
+			roomID:         rc.RoomID,
+			owner:          owner,
+			svc:            svc,
+			dealDelay:      secondsOr(rc.DealDelaySec, defaultDealDelay),
+			drawDelay:      secondsOr(rc.DrawDelaySec, defaultDrawDelay),
+			endDelay:       secondsOr(rc.EndDelaySec, defaultEndDelay),
+			nextRoundDelay: secondsOr(rc.NextRoundDelaySec, defaultNextRoundDelay),
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rs.run(ctx, tick)
+		}()
+		logger.Info("[scheduler] room scheduler started",
+			zap.String("game_id", rc.GameID), zap.String("room_id", rc.RoomID), zap.Duration("tick", tick))
+	}
+}
+
+// roomScheduler 单个房间的调度状态（不持有牌局进度，进度始终以数据库为准）
+type roomScheduler struct {
+	gameID         string
+	roomID         string
+	owner          string // 租约持有者标识（进程级）
+	svc            service.GameEventService
+	dealDelay      time.Duration
+	drawDelay      time.Duration
+	endDelay       time.Duration
+	nextRoundDelay time.Duration
+}
+
+func (rs *roomScheduler) run(ctx context.Context, tick time.Duration) {
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			rs.releaseLease()
+			return
+		case <-ticker.C:
+			if !rs.acquireLease(ctx) {
+				continue
+			}
+			c, cancel := context.WithTimeout(ctx, 5*time.Second)
+			rs.step(c)
+			cancel()
+		}
+	}
+}
+
+// step 根据房间最近一局的状态推进一步
+func (rs *roomScheduler) step(ctx context.Context) {
+	round, err := model.GetLatestRoundByRoom(ctx, infmysql.SQLX(), rs.roomID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			if gameID, ok := rs.openRoomGame(ctx); ok {
+				rs.fire(ctx, gameID, newRoundID(rs.roomID), 1)
+			}
+			return
+		}
+		logger.Warn("[scheduler] load latest round failed", zap.String("room_id", rs.roomID), zap.Error(err))
+		return
+	}
+
+	now := time.Now().UnixMilli()
+	idle := time.Duration(now-round.UpdatedAt) * time.Millisecond
+	gameID := round.GameID
+	if gameID == "" {
+		gameID = rs.gameID
+	}
+
+	// game_status: 1=初始 2=下注中 3=封盘 4=已发牌 5=已开奖 6=已结算 7=已结束 8=已取消
+	switch round.GameStatus {
+	case 1:
+		rs.fire(ctx, gameID, round.GameRoundID, 1)
+	case 2:
+		if round.BetStopTime > 0 && now >= round.BetStopTime {
+			rs.fire(ctx, gameID, round.GameRoundID, 2)
+		}
+	case 3:
+		if idle >= rs.dealDelay {
+			rs.fire(ctx, gameID, round.GameRoundID, 3)
+		}
+	case 4:
+		if idle >= rs.drawDelay {
+			rs.fire(ctx, gameID, round.GameRoundID, 4)
+		}
+	case 5:
+		// 等待开奖结果录入并结算（/api/drawresult），结算后状态变为 settled(6)
+	case 6:
+		if idle >= rs.endDelay {
+			rs.fire(ctx, gameID, round.GameRoundID, 5)
+		}
+	case 7, 8:
+		if idle >= rs.nextRoundDelay {
+			if gameID, ok := rs.openRoomGame(ctx); ok {
+				rs.fire(ctx, gameID, newRoundID(rs.roomID), 1)
+			}
+		}
+	}
+}
+
+// openRoomGame 房间开放时返回房间配置的 game_id；维护/关闭/未登记的房间不再开新局，进行中的牌局照常推进
+func (rs *roomScheduler) openRoomGame(ctx context.Context) (string, bool) {
+	room, err := service.LookupRoom(ctx, rs.roomID)
+	if err != nil || room.Status != service.RoomStatusOpen {
+		return "", false
+	}
+	return room.GameID, true
+}
+
+// fire 通过 GameEventService 触发事件；非法跳转（例如人工已抢先推进）仅记录日志，下一拍会按新状态重新判断
+func (rs *roomScheduler) fire(ctx context.Context, gameID, roundID string, eventType int8) {
+	traceID := uuid.NewString()
+	err := rs.svc.Handle(ctx, service.GameEventInput{
+		GameID:      gameID,
+		RoomID:      rs.roomID,
+		GameRoundID: roundID,
+		EventType:   eventType,
+		Source:      "task",
+		TraceID:     traceID,
+	})
+	if err != nil {
+		logger.Warn("[scheduler] fire event failed",
+			zap.String("room_id", rs.roomID),
+			zap.String("round_id", roundID),
+			zap.Int8("event_type", eventType),
+			zap.String("trace_id", traceID),
+			zap.Error(err))
+		return
+	}
+	logger.Info("[scheduler] event fired",
+		zap.String("room_id", rs.roomID),
+		zap.String("round_id", roundID),
+		zap.Int8("event_type", eventType),
+		zap.String("trace_id", traceID))
+}
+
+// acquireLease 获取或续期房间租约；Redis 未配置时视为单实例部署直接放行
+func (rs *roomScheduler) acquireLease(ctx context.Context) bool {
+	return acquireLease(ctx, infrds.SchedulerLeaseKey(rs.roomID), rs.owner, schedulerLeaseTTL)
+}
+
+// releaseLease 退出时释放租约，便于其他实例尽快接管
+func (rs *roomScheduler) releaseLease() {
+	releaseLease(infrds.SchedulerLeaseKey(rs.roomID), rs.owner)
+}
+
+// newRoundID 生成局号
+// 格式：{room_id}{YYYYMMDDHHmmss}{随机4位十六进制}
+// 示例：R00120251017143025A3F9
+func newRoundID(roomID string) string {
+	b := make([]byte, 2)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%s%s%s", roomID, time.Now().Format("20060102150405"), strings.ToUpper(hex.EncodeToString(b)))
+}
+
+func secondsOr(sec int, def time.Duration) time.Duration {
+	if sec <= 0 {
+		return def
+	}
+	return time.Duration(sec) * time.Second
+}
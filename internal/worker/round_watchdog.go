@@ -0,0 +1,205 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"dt-server/common/logger"
+	"dt-server/internal/config"
+	infmysql "dt-server/internal/infra/mysql"
+	infrds "dt-server/internal/infra/redis"
+	"dt-server/internal/metrics"
+	"dt-server/internal/model"
+	"dt-server/internal/service"
+	"dt-server/internal/state"
+)
+
+const (
+	defaultWatchdogInterval = 10 * time.Second
+	watchdogScanLimit       = 200 // 每个状态单次最多处理的局数
+)
+
+// stuckRule 单个状态的卡局判定：停留时长超过动态阈值 threshold（秒，缺省 def）视为卡住
+type stuckRule struct {
+	state     string
+	threshold string
+	def       int64
+}
+
+// 下注中按超过 bet_stop_time 的时长计算，其余状态按 updated_at（进入该状态的时间）计算
+var stuckRules = []stuckRule{
+	{state: state.StateBetting, threshold: "round_stuck_betting_sec", def: 30},
+	{state: state.StateSealed, threshold: "round_stuck_sealed_sec", def: 60},
+	{state: state.StateDealt, threshold: "round_stuck_dealt_sec", def: 60},
+	{state: state.StateDrawn, threshold: "round_stuck_drawn_sec", def: 120},
+}
+
+// StartRoundWatchdog 启动卡局巡检，支持通过 ctx 优雅退出
+// 按固定间隔扫描 game_round_info 中停留在 betting/sealed/dealt/drawn 超过阈值的牌局：
+//   - 每局每个状态首次发现时写入 round_stuck Outbox 告警并计数（进程重启后可能重复告警一次）
+//   - auto_seal：下注中超时的牌局自动触发 game_stop
+//   - auto_void_sec：卡住超过该时长的牌局自动触发 game_cancel（作废并退款）
+//
+// 自动处置经由 GameEventService.Handle，审计与 Outbox 与人工调用一致；多实例部署时通过 Redis 租约只由一个实例巡检。
+func StartRoundWatchdog(ctx context.Context, wg *sync.WaitGroup) {
+	cfg := config.Get()
+	if cfg == nil || !cfg.Watchdog.Enabled {
+		return
+	}
+	interval := secondsOr(cfg.Watchdog.IntervalSec, defaultWatchdogInterval)
+	w := &roundWatchdog{
+		owner:    uuid.NewString(),
+		svc:      service.NewGameEventService(),
+		autoSeal: cfg.Watchdog.AutoSeal,
+		autoVoid: time.Duration(cfg.Watchdog.AutoVoidSec) * time.Second,
+		leaseTTL: 3 * interval,
+		alerted:  make(map[string]string),
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w.run(ctx, interval)
+	}()
+	logger.Info("[watchdog] round watchdog started",
+		zap.Duration("interval", interval), zap.Bool("auto_seal", w.autoSeal), zap.Duration("auto_void", w.autoVoid))
+}
+
+type roundWatchdog struct {
+	owner    string
+	svc      service.GameEventService
+	autoSeal bool
+	autoVoid time.Duration // 0 表示不自动作废
+	leaseTTL time.Duration
+	alerted  map[string]string // round_id -> 已告警的状态
+}
+
+func (w *roundWatchdog) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			releaseLease(infrds.WatchdogLeaseKey, w.owner)
+			return
+		case <-ticker.C:
+			if !acquireLease(ctx, infrds.WatchdogLeaseKey, w.owner, w.leaseTTL) {
+				continue
+			}
+			c, cancel := context.WithTimeout(ctx, interval)
+			w.scan(c)
+			cancel()
+		}
+	}
+}
+
+// scan 巡检一轮
+func (w *roundWatchdog) scan(ctx context.Context) {
+	now := time.Now().UnixMilli()
+	seen := make(map[string]bool)
+	for _, rule := range stuckRules {
+		threshold := time.Duration(config.GetThreshold(rule.threshold, rule.def)) * time.Second
+		code := state.Default.StateCode(rule.state)
+		rounds, err := model.ListStaleRounds(ctx, infmysql.SQLX(), code, now-threshold.Milliseconds(), watchdogScanLimit)
+		if err != nil {
+			logger.Warn("[watchdog] list stale rounds failed", zap.String("state", rule.state), zap.Error(err))
+			continue
+		}
+		stuck := 0
+		for i := range rounds {
+			round := &rounds[i]
+			since := round.UpdatedAt
+			if rule.state == state.StateBetting && round.BetStopTime > since {
+				since = round.BetStopTime
+			}
+			stuckFor := time.Duration(now-since) * time.Millisecond
+			if stuckFor < threshold {
+				continue
+			}
+			stuck++
+			seen[round.GameRoundID] = true
+			if w.alerted[round.GameRoundID] != rule.state {
+				w.alert(ctx, round, rule.state, stuckFor, threshold)
+				w.alerted[round.GameRoundID] = rule.state
+			}
+			w.remediate(ctx, round, rule.state, stuckFor)
+		}
+		metrics.SetRoundsStuck(rule.state, stuck)
+	}
+	// 已恢复（或已处置）的牌局不再跟踪
+	for id := range w.alerted {
+		if !seen[id] {
+			delete(w.alerted, id)
+		}
+	}
+}
+
+// alert 写入 round_stuck 告警事件
+func (w *roundWatchdog) alert(ctx context.Context, round *model.GameRoundInfo, stateName string, stuckFor, threshold time.Duration) {
+	traceID := uuid.NewString()
+	metrics.RecordRoundStuck(stateName)
+	logger.Warn("[watchdog] round stuck",
+		zap.String("room_id", round.RoomID),
+		zap.String("round_id", round.GameRoundID),
+		zap.String("state", stateName),
+		zap.Duration("stuck_for", stuckFor),
+		zap.String("trace_id", traceID))
+	if err := model.CreateOutbox(ctx, infmysql.SQLX(), "round_stuck", round.GameRoundID, map[string]any{
+		"event":         "round_stuck",
+		"game_id":       round.GameID,
+		"room_id":       round.RoomID,
+		"game_round_id": round.GameRoundID,
+		"state":         stateName,
+		"game_status":   round.GameStatus,
+		"stuck_sec":     int64(stuckFor / time.Second),
+		"threshold_sec": int64(threshold / time.Second),
+		"bet_stop_time": round.BetStopTime,
+		"updated_at":    round.UpdatedAt,
+		"trace_id":      traceID,
+	}); err != nil {
+		logger.Warn("[watchdog] write alert outbox failed", zap.String("round_id", round.GameRoundID), zap.Error(err))
+	}
+}
+
+// remediate 按配置自动处置：下注中优先自动封盘，其余（或未开启自动封盘）卡住超过 auto_void 后作废
+func (w *roundWatchdog) remediate(ctx context.Context, round *model.GameRoundInfo, stateName string, stuckFor time.Duration) {
+	switch {
+	case stateName == state.StateBetting && w.autoSeal:
+		w.fire(ctx, round, "seal", state.EvtGameStop, "")
+	case w.autoVoid > 0 && stuckFor >= w.autoVoid:
+		w.fire(ctx, round, "void", state.EvtGameCancel,
+			fmt.Sprintf("watchdog: stuck in %s for %ds", stateName, int64(stuckFor/time.Second)))
+	}
+}
+
+func (w *roundWatchdog) fire(ctx context.Context, round *model.GameRoundInfo, action, evt, reason string) {
+	traceID := uuid.NewString()
+	err := w.svc.Handle(ctx, service.GameEventInput{
+		GameID:      round.GameID,
+		RoomID:      round.RoomID,
+		GameRoundID: round.GameRoundID,
+		EventType:   state.Default.EventCode(evt),
+		Source:      "task",
+		Reason:      reason,
+		TraceID:     traceID,
+	})
+	if err != nil {
+		metrics.RecordWatchdogRemediation(action, "fail")
+		logger.Warn("[watchdog] remediation failed",
+			zap.String("round_id", round.GameRoundID),
+			zap.String("action", action),
+			zap.String("trace_id", traceID),
+			zap.Error(err))
+		return
+	}
+	metrics.RecordWatchdogRemediation(action, "success")
+	logger.Info("[watchdog] remediation applied",
+		zap.String("round_id", round.GameRoundID),
+		zap.String("action", action),
+		zap.String("reason", reason),
+		zap.String("trace_id", traceID))
+}
@@ -0,0 +1,94 @@
+package worker
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"dt-server/common/logger"
+	"dt-server/internal/config"
+	infrds "dt-server/internal/infra/redis"
+	"dt-server/internal/service"
+)
+
+const (
+	seamlessWalletInterval = 2 * time.Second
+	seamlessWalletBatch    = 100 // 每轮最多处理的交易数
+)
+
+// StartSeamlessWalletWorker 启动 seamless 钱包投递与对账任务，支持通过 ctx 优雅退出
+// 没有平台配置 wallet_mode=seamless 时不启动。每轮：
+//   - 冲正超时仍未确认的下注扣款（注单置失败并登记 rollback）
+//   - 调用到期的入账、撤销、更正扣回交易，失败按退避重试
+//
+// 多实例部署时通过 Redis 租约只由一个实例调用平台钱包（交易本身也有抢占保护）。
+func StartSeamlessWalletWorker(ctx context.Context, wg *sync.WaitGroup) {
+	cfg := config.Get()
+	if cfg == nil {
+		return
+	}
+	enabled := false
+	for _, p := range cfg.Auth.Platforms {
+		if strings.EqualFold(p.WalletMode, config.WalletModeSeamless) {
+			enabled = true
+			break
+		}
+	}
+	if !enabled {
+		return
+	}
+	w := &seamlessWalletWorker{
+		owner:    uuid.NewString(),
+		svc:      service.NewSeamlessWalletService(),
+		leaseTTL: 5 * seamlessWalletInterval,
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w.run(ctx)
+	}()
+	logger.Info("[seamless] wallet worker started", zap.Duration("interval", seamlessWalletInterval))
+}
+
+type seamlessWalletWorker struct {
+	owner    string
+	svc      service.SeamlessWalletService
+	leaseTTL time.Duration
+}
+
+func (w *seamlessWalletWorker) run(ctx context.Context) {
+	ticker := time.NewTicker(seamlessWalletInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			releaseLease(infrds.SeamlessWalletLeaseKey, w.owner)
+			return
+		case <-ticker.C:
+			if !acquireLease(ctx, infrds.SeamlessWalletLeaseKey, w.owner, w.leaseTTL) {
+				continue
+			}
+			w.tick(ctx)
+		}
+	}
+}
+
+// tick 执行一轮对账与投递；单轮耗时受平台调用超时影响，以租约 TTL 为上限
+func (w *seamlessWalletWorker) tick(ctx context.Context) {
+	c, cancel := context.WithTimeout(ctx, w.leaseTTL)
+	defer cancel()
+	if n, err := w.svc.ReconcileBets(c, seamlessWalletBatch); err != nil {
+		logger.Warn("[seamless] reconcile bet debits failed", zap.Error(err))
+	} else if n > 0 {
+		logger.Info("[seamless] bet debits rolled back", zap.Int("count", n))
+	}
+	if n, err := w.svc.DeliverDue(c, seamlessWalletBatch); err != nil {
+		logger.Warn("[seamless] deliver wallet txns failed", zap.Error(err))
+	} else if n > 0 {
+		logger.Debug("[seamless] wallet txns delivered", zap.Int("count", n))
+	}
+}
@@ -43,14 +43,24 @@ func init() {
 	if cfg != nil && cfg.Auth.DemoMode {
 		// 演示模式：简化认证
 		beego.InsertFilter("/api/bet", beego.BeforeExec, middleware.DemoAuthFilter)
+		beego.InsertFilter("/api/bets/batch", beego.BeforeExec, middleware.DemoAuthFilter)
+		beego.InsertFilter("/api/bets/cancel", beego.BeforeExec, middleware.DemoAuthFilter)
 	} else {
 		// 生产模式：平台签名认证
 		beego.InsertFilter("/api/bet", beego.BeforeExec, middleware.PlatformAuthFilter)
+		beego.InsertFilter("/api/bets/batch", beego.BeforeExec, middleware.PlatformAuthFilter)
+		beego.InsertFilter("/api/bets/cancel", beego.BeforeExec, middleware.PlatformAuthFilter)
 	}
 	if cfg != nil && cfg.RateLimit.Enabled {
 		beego.InsertFilter("/api/bet", beego.BeforeExec, middleware.RateLimitFilter)
+		beego.InsertFilter("/api/bets/batch", beego.BeforeExec, middleware.RateLimitFilter)
+		beego.InsertFilter("/api/bets/cancel", beego.BeforeExec, middleware.RateLimitFilter)
 	}
 	beego.Router("/api/bet", &api.BetController{}, "post:Bet")
+	// 批量投注：多个玩法共用一个幂等键，整体成功或失败（认证与限流同 /api/bet）
+	beego.Router("/api/bets/batch", &api.BetController{}, "post:Batch")
+	// 撤单：下注中撤销本人注单并退款，房间需开启撤单（认证与限流同 /api/bet）
+	beego.Router("/api/bets/cancel", &api.BetController{}, "post:Cancel")
 
 	// 用户查询接口：平台认证（用户只能查询自己的数据）
 	if cfg != nil && cfg.Auth.DemoMode {
@@ -61,6 +71,19 @@ func init() {
 	beego.Router("/api/user/balance", &api.UserController{}, "get:Balance")
 	beego.Router("/api/user/bets", &api.UserController{}, "get:Bets")
 
+	// 平台上分/下分接口：平台认证 + 限流（transfer 钱包模式；transfer_id 幂等，可按单号查询对账）
+	if cfg != nil && cfg.Auth.DemoMode {
+		beego.InsertFilter("/api/wallet/*", beego.BeforeExec, middleware.DemoAuthFilter)
+	} else {
+		beego.InsertFilter("/api/wallet/*", beego.BeforeExec, middleware.PlatformAuthFilter)
+	}
+	if cfg != nil && cfg.RateLimit.Enabled {
+		beego.InsertFilter("/api/wallet/*", beego.BeforeExec, middleware.RateLimitFilter)
+	}
+	beego.Router("/api/wallet/deposit", &api.TransferController{}, "post:Deposit")
+	beego.Router("/api/wallet/withdraw", &api.TransferController{}, "post:Withdraw")
+	beego.Router("/api/wallet/transfers/:transfer_id", &api.TransferController{}, "get:Status")
+
 	// ========== 管理 API（需要管理员认证） ==========
 
 	// 游戏事件接口：管理员认证
@@ -75,6 +98,41 @@ func init() {
 	}
 	beego.Router("/api/drawresult", &api.DrawResultController{}, "post:Drawresult")
 
+	// 换靴接口：管理员认证
+	if cfg != nil && cfg.Auth.Admin.Enabled {
+		beego.InsertFilter("/api/shoe_change", beego.BeforeExec, middleware.AdminAuthFilter)
+	}
+	beego.Router("/api/shoe_change", &api.ShoeController{}, "post:ChangeShoe")
+
+	// 房间配置管理接口：管理员认证
+	if cfg != nil && cfg.Auth.Admin.Enabled {
+		beego.InsertFilter("/api/admin/*", beego.BeforeExec, middleware.AdminAuthFilter)
+	}
+	beego.Router("/api/admin/rooms", &api.RoomController{}, "get:List;post:Create")
+	beego.Router("/api/admin/rooms/:room_id", &api.RoomController{}, "get:Get;put:Update")
+	// 牌局回放：由审计事件重建并比对 game_round_info，可选重写（同样受 /api/admin/* 管理员认证保护）
+	beego.Router("/api/admin/replay", &api.ReplayController{}, "post:Replay")
+	beego.Router("/api/admin/replay/:round_id", &api.ReplayController{}, "get:Inspect")
+	// 开奖结果更正：冲正原派彩并按新牌面重新结算（同样受 /api/admin/* 管理员认证保护）
+	beego.Router("/api/admin/drawresult/correct", &api.DrawResultController{}, "post:Correct")
+	// 开奖复核（maker-checker）：开启 draw_approval 的房间，开奖结果经另一名管理员复核后才结算
+	beego.Router("/api/admin/drawresult/pending", &api.DrawResultController{}, "get:Pending")
+	beego.Router("/api/admin/drawresult/pending/:id/approve", &api.DrawResultController{}, "post:Approve")
+	beego.Router("/api/admin/drawresult/pending/:id/reject", &api.DrawResultController{}, "post:Reject")
+	// 单局赔付敞口：查询与按 orders 对账（同样受 /api/admin/* 管理员认证保护）
+	beego.Router("/api/admin/exposure/:round_id", &api.ExposureController{}, "get:Get;post:Reconcile")
+	// 赔率表：版本化赔率的查询、新增与撤销（同样受 /api/admin/* 管理员认证保护）
+	beego.Router("/api/admin/odds", &api.OddsController{}, "get:List;post:Create")
+	beego.Router("/api/admin/odds/:version/revoke", &api.OddsController{}, "post:Revoke")
+	// 报表：按币种汇总下注额与派彩（同样受 /api/admin/* 管理员认证保护）
+	beego.Router("/api/admin/reports/currency", &api.ReportController{}, "get:Currency")
+
+	// 可验证公平核对接口（公开，无需认证）
+	beego.Router("/api/fair/verify/:round_id", &api.FairController{}, "get:Verify")
+
+	// 路单查询接口（公开，无需认证）
+	beego.Router("/api/roads/:room_id", &api.RoadController{}, "get:Get")
+
 	// 局游戏调试接口：从 Redis 读取局缓存与结果缓存
 	// beego.Router("/api/round/:round_id", &api.RoundController{}, "get:GetRound")
 